@@ -0,0 +1,17 @@
+// Package api embeds the OpenAPI document of the service and the docs page
+// that renders it.
+package api
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed openapi.json
+var Spec []byte
+
+//go:embed docs
+var docs embed.FS
+
+// Docs holds index.html of the docs page and its assets.
+var Docs, _ = fs.Sub(docs, "docs")
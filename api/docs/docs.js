@@ -0,0 +1,152 @@
+// Renders openapi.json without third-party code, so the page can run under
+// a content security policy that only allows this origin.
+"use strict";
+
+const methods = ["get", "post", "put", "patch", "delete"];
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [name, value] of Object.entries(attrs || {})) {
+    node.setAttribute(name, value);
+  }
+  for (const child of children) {
+    if (child === undefined || child === null) {
+      continue;
+    }
+    node.append(child);
+  }
+  return node;
+}
+
+function resolve(spec, object) {
+  if (!object || !object.$ref) {
+    return object;
+  }
+  return object.$ref
+    .replace(/^#\//, "")
+    .split("/")
+    .reduce((node, key) => node[key], spec);
+}
+
+function refName(object) {
+  return object && object.$ref ? object.$ref.split("/").pop() : null;
+}
+
+function typeOf(schema) {
+  if (!schema) {
+    return "";
+  }
+  const name = refName(schema);
+  if (name) {
+    return el("a", { href: "#schema-" + name }, name);
+  }
+  if (schema.type === "array") {
+    const items = typeOf(schema.items);
+    return el("span", {}, "array of ", items);
+  }
+  let type = [].concat(schema.type || "object").join(" | ");
+  if (schema.format) {
+    type += " (" + schema.format + ")";
+  }
+  if (schema.enum) {
+    type += ": " + schema.enum.join(", ");
+  }
+  return type;
+}
+
+function propertiesTable(spec, schema) {
+  schema = resolve(spec, schema);
+  if (!schema || !schema.properties) {
+    return el("p", {}, typeOf(schema));
+  }
+  const required = new Set(schema.required || []);
+  const rows = Object.entries(schema.properties).map(([name, property]) =>
+    el("tr", {},
+      el("td", {}, el("code", {}, name), required.has(name) ? "" : el("span", { class: "muted" }, " optional")),
+      el("td", {}, typeOf(property)),
+      el("td", {}, resolve(spec, property).description || "")));
+  return el("table", {}, el("tr", {}, el("th", {}, "Field"), el("th", {}, "Type"), el("th", {}, "")), ...rows);
+}
+
+function jsonSchema(content) {
+  return content && content["application/json"] ? content["application/json"].schema : null;
+}
+
+function renderOperation(spec, path, method, operation, shared) {
+  const body = el("div", {});
+  if (operation.description) {
+    body.append(el("p", {}, operation.description));
+  }
+  if (operation.security && operation.security.length > 0) {
+    body.append(el("p", { class: "muted" }, "Requires a bearer token."));
+  }
+
+  const parameters = shared.concat(operation.parameters || []).map((p) => resolve(spec, p));
+  if (parameters.length > 0) {
+    body.append(el("h4", {}, "Parameters"));
+    body.append(el("table", {},
+      el("tr", {}, el("th", {}, "Name"), el("th", {}, "In"), el("th", {}, "Type"), el("th", {}, "")),
+      ...parameters.map((p) => el("tr", {},
+        el("td", {}, el("code", {}, p.name), p.required ? "" : el("span", { class: "muted" }, " optional")),
+        el("td", {}, p.in),
+        el("td", {}, typeOf(p.schema)),
+        el("td", {}, p.description || "")))));
+  }
+
+  if (operation.requestBody) {
+    body.append(el("h4", {}, "Request body"));
+    body.append(propertiesTable(spec, jsonSchema(resolve(spec, operation.requestBody).content)));
+  }
+
+  body.append(el("h4", {}, "Responses"));
+  body.append(el("table", {},
+    el("tr", {}, el("th", {}, "Status"), el("th", {}, "Body"), el("th", {}, "")),
+    ...Object.entries(operation.responses).map(([status, response]) => {
+      response = resolve(spec, response);
+      return el("tr", {},
+        el("td", {}, status),
+        el("td", {}, typeOf(jsonSchema(response.content))),
+        el("td", {}, response.description || ""));
+    })));
+
+  return el("details", { id: operation.operationId },
+    el("summary", {},
+      el("span", { class: "method " + method }, method),
+      el("code", {}, path), " ",
+      el("span", { class: "muted" }, operation.summary || "")),
+    body);
+}
+
+function render(spec) {
+  document.title = spec.info.title + " API";
+  document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+  document.getElementById("description").textContent = spec.info.description || "";
+
+  const operations = document.getElementById("operations");
+  for (const tag of spec.tags || []) {
+    operations.append(el("h2", {}, tag.name));
+    for (const [path, item] of Object.entries(spec.paths)) {
+      for (const method of methods) {
+        const operation = item[method];
+        if (operation && (operation.tags || []).includes(tag.name)) {
+          operations.append(renderOperation(spec, path, method, operation, item.parameters || []));
+        }
+      }
+    }
+  }
+
+  const schemas = document.getElementById("schemas");
+  schemas.append(el("h2", {}, "Schemas"));
+  for (const [name, schema] of Object.entries(spec.components.schemas)) {
+    schemas.append(el("details", { id: "schema-" + name },
+      el("summary", {}, el("code", {}, name)),
+      el("div", {}, schema.description ? el("p", {}, schema.description) : null, propertiesTable(spec, schema))));
+  }
+}
+
+fetch("../openapi.json")
+  .then((response) => response.json())
+  .then(render)
+  .catch((err) => {
+    document.getElementById("operations").append(el("p", {}, "Failed to load openapi.json: " + err));
+  });
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// SignUp registers a user and returns its id. It doesn't sign in.
+func (c *Client) SignUp(ctx context.Context, input SignUpInput) (int, error) {
+	var out struct {
+		Id int `json:"id"`
+	}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/auth/sign-up", in: input, out: &out})
+
+	return out.Id, err
+}
+
+// SignIn gets a token for the user and keeps it, along with the credentials
+// to get a new one when it expires.
+func (c *Client) SignIn(ctx context.Context, username, password string) (string, error) {
+	input := struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{username, password}
+	var out struct {
+		Token string `json:"token"`
+	}
+	if err := c.do(ctx, call{method: http.MethodPost, path: "/auth/sign-in", in: input, out: &out}); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.token = out.Token
+	c.username, c.password = username, password
+	c.mu.Unlock()
+
+	return out.Token, nil
+}
@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+const categoriesPath = "/api/v1/categories/"
+
+// CreateCategory creates a category of the user and returns its id.
+func (c *Client) CreateCategory(ctx context.Context, input CategoryInput) (uuid.UUID, error) {
+	var out struct {
+		Id uuid.UUID `json:"uuid"`
+	}
+	err := c.do(ctx, call{method: http.MethodPost, path: categoriesPath, in: input, out: &out, auth: true})
+
+	return out.Id, err
+}
+
+// ListCategories iterates over the categories of the user by name.
+func (c *Client) ListCategories(ctx context.Context, opts ListOptions) iter.Seq2[Category, error] {
+	return list[Category](ctx, c, categoriesPath, nil, opts, true)
+}
+
+// DeleteCategory deletes a category that no subcategory, transaction or
+// annotation refers to.
+func (c *Client) DeleteCategory(ctx context.Context, categoryId uuid.UUID) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: categoriesPath + categoryId.String(), auth: true})
+}
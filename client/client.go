@@ -0,0 +1,341 @@
+// Package client is a Go client for the wallets API.
+//
+// A Client signs in once and keeps the token: when the token expires, the
+// client signs in again with the same credentials and repeats the request.
+// Idempotent calls are retried with exponential backoff when the server is
+// unavailable, and every call is retried when it was rejected by the rate
+// limiter, which happens before the request is processed. Failed calls
+// return an *Error that can be matched with errors.Is against the sentinel
+// errors of this package.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAttempts   = 3
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+	userAgent         = "rest-wallets-client"
+)
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+
+	attempts   int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu       sync.Mutex
+	token    string
+	username string
+	password string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests, http.DefaultClient
+// by default.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetry sets how many times a call is attempted and the bounds of the
+// backoff between attempts. attempts of 1 disables retries.
+func WithRetry(attempts int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.attempts = max(1, attempts)
+		c.minBackoff = minBackoff
+		c.maxBackoff = max(minBackoff, maxBackoff)
+	}
+}
+
+// WithCredentials lets the client sign in on its first authenticated call,
+// without an explicit SignIn.
+func WithCredentials(username, password string) Option {
+	return func(c *Client) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithToken sets a token obtained elsewhere. Without credentials it can't be
+// refreshed once it expires.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// New returns a client for the API at baseURL, e.g. https://wallets.example.com.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base url %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		attempts:   defaultAttempts,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// call describes one API call.
+type call struct {
+	method string
+	path   string
+	query  url.Values
+	in     any
+	out    any
+	// ifVersion is sent as If-Match when set.
+	ifVersion *int64
+	// auth sends the bearer token and refreshes it on 401.
+	auth bool
+}
+
+// idempotent calls can be retried after a failure that may have happened
+// once the server started processing them.
+func (r call) idempotent() bool {
+	return r.method == http.MethodGet || r.method == http.MethodDelete
+}
+
+func (c *Client) do(ctx context.Context, r call) error {
+	var body []byte
+	if r.in != nil {
+		var err error
+		if body, err = json.Marshal(r.in); err != nil {
+			return err
+		}
+	}
+
+	refreshed := false
+	for attempt := 1; ; attempt++ {
+		token, err := c.currentToken(ctx, r.auth)
+		if err != nil {
+			return err
+		}
+
+		resp, err := c.send(ctx, r, body, token)
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil || !r.idempotent() || attempt >= c.attempts {
+				return err
+			}
+			wait = c.backoff(attempt)
+
+		case resp.StatusCode == http.StatusUnauthorized && r.auth && !refreshed && c.canRefresh():
+			// The token expired: sign in again and repeat the call, which
+			// doesn't count as a retry.
+			drain(resp)
+			if err := c.refresh(ctx, token); err != nil {
+				return err
+			}
+			refreshed = true
+			attempt--
+			continue
+
+		case resp.StatusCode < 300:
+			defer resp.Body.Close()
+			if r.out == nil {
+				return nil
+			}
+			if err := json.NewDecoder(resp.Body).Decode(r.out); err != nil {
+				return fmt.Errorf("error decoding response: %w", err)
+			}
+			return nil
+
+		default:
+			apiErr := newError(resp)
+			if !retryable(r, resp.StatusCode) || attempt >= c.attempts {
+				return apiErr
+			}
+			wait = max(c.backoff(attempt), apiErr.RetryAfter)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, r call, body []byte, token string) (*http.Response, error) {
+	u := c.baseURL.JoinPath(r.path)
+	u.RawQuery = r.query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if r.ifVersion != nil {
+		req.Header.Set("If-Match", `"`+strconv.FormatInt(*r.ifVersion, 10)+`"`)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// list iterates over a paginated route until a page comes back short.
+// filter, if set, is sent with every page.
+func list[T any](ctx context.Context, c *Client, path string, filter url.Values, opts ListOptions, auth bool) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		offset := opts.Offset
+		for {
+			query := url.Values{}
+			for key, values := range filter {
+				query[key] = values
+			}
+			if opts.PageSize > 0 {
+				query.Set("limit", strconv.Itoa(opts.PageSize))
+			}
+			if offset > 0 {
+				query.Set("offset", strconv.Itoa(offset))
+			}
+
+			var page listResponse[T]
+			if err := c.do(ctx, call{method: http.MethodGet, path: path, query: query, out: &page, auth: auth}); err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range page.Data {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if len(page.Data) == 0 || len(page.Data) < page.Limit {
+				return
+			}
+			offset += len(page.Data)
+		}
+	}
+}
+
+// retryable reports whether a call that failed with status can be repeated.
+// Rate limited calls were never processed, so they are safe to repeat.
+func retryable(r call, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return r.idempotent()
+	}
+	return false
+}
+
+// backoff returns the wait before the next attempt: exponential with jitter,
+// between half and all of minBackoff * 2^(attempt-1), capped at maxBackoff.
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.minBackoff << (attempt - 1)
+	if wait <= 0 || wait > c.maxBackoff {
+		wait = c.maxBackoff
+	}
+	if wait <= 0 {
+		return 0
+	}
+
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// currentToken returns the token for a call, signing in first when the
+// client has credentials but no token yet.
+func (c *Client) currentToken(ctx context.Context, auth bool) (string, error) {
+	if !auth {
+		return "", nil
+	}
+
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+
+	if token == "" && c.canRefresh() {
+		if err := c.refresh(ctx, ""); err != nil {
+			return "", err
+		}
+		c.mu.Lock()
+		token = c.token
+		c.mu.Unlock()
+	}
+
+	return token, nil
+}
+
+func (c *Client) canRefresh() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.username != ""
+}
+
+// refresh signs in again unless another call already replaced the stale
+// token in the meantime.
+func (c *Client) refresh(ctx context.Context, stale string) error {
+	c.mu.Lock()
+	if c.token != stale {
+		c.mu.Unlock()
+		return nil
+	}
+	username, password := c.username, c.password
+	c.mu.Unlock()
+
+	_, err := c.SignIn(ctx, username, password)
+	return err
+}
+
+// Token returns the current token, empty before the client has signed in.
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.token
+}
+
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
@@ -0,0 +1,532 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/config"
+	"github.com/Yoshisoul/rest-wallets/internal/handler"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/Yoshisoul/rest-wallets/internal/service"
+	mockService "github.com/Yoshisoul/rest-wallets/internal/service/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var (
+	walletId  = uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	createdAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type mocks struct {
+	auth        *mockService.MockAuthorization
+	wallet      *mockService.MockWallet
+	transaction *mockService.MockTransaction
+	balance     *mockService.MockBalance
+	category    *mockService.MockCategory
+}
+
+// newServer serves the real handlers, checked against the OpenAPI spec, on
+// top of mocked services. wrap, if set, sits in front of the handlers.
+func newServer(t *testing.T, wrap func(http.Handler) http.Handler) (*httptest.Server, mocks) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	c := gomock.NewController(t)
+	m := mocks{
+		auth:        mockService.NewMockAuthorization(c),
+		wallet:      mockService.NewMockWallet(c),
+		transaction: mockService.NewMockTransaction(c),
+		balance:     mockService.NewMockBalance(c),
+		category:    mockService.NewMockCategory(c),
+	}
+
+	services := &service.Service{Authorization: m.auth, Wallet: m.wallet, Transaction: m.transaction, Balance: m.balance, Category: m.category}
+	var h http.Handler = handler.NewHandler(services, config.HTTPConfig{MaxBodyBytes: 1 << 20, ValidateOpenAPI: true}, "rest-wallets", nil).InitRoutes()
+	if wrap != nil {
+		h = wrap(h)
+	}
+
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+
+	return server, m
+}
+
+func newClient(t *testing.T, server *httptest.Server, opts ...Option) *Client {
+	t.Helper()
+
+	opts = append([]Option{WithHTTPClient(server.Client()), WithRetry(3, time.Millisecond, 5*time.Millisecond)}, opts...)
+	c, err := New(server.URL, opts...)
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestClient_Wallets(t *testing.T) {
+	server, m := newServer(t, nil)
+	client := newClient(t, server)
+	ctx := context.Background()
+
+	m.auth.EXPECT().GenerateToken(gomock.Any(), "user", "qwerty").Return("token", nil)
+	m.auth.EXPECT().ParseToken("token").Return(1, nil).AnyTimes()
+	m.wallet.EXPECT().Create(gomock.Any(), 1).Return(walletId, nil)
+	m.wallet.EXPECT().GetByIdFromUser(gomock.Any(), 1, walletId).Return(models.Wallet{
+		WalletId:  walletId,
+		UserId:    1,
+		Amount:    100,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		Status:    models.WalletActive,
+		Version:   2,
+	}, nil)
+	m.wallet.EXPECT().Close(gomock.Any(), 1, walletId, models.CloseWalletInput{Reason: "moved"}).Return(models.ErrNonZeroBalance)
+	stale := int64(1)
+	m.wallet.EXPECT().Close(gomock.Any(), 1, walletId, models.CloseWalletInput{ExpectedVersion: &stale}).Return(models.ErrVersionMismatch)
+
+	token, err := client.SignIn(ctx, "user", "qwerty")
+	require.NoError(t, err)
+	assert.Equal(t, "token", token)
+
+	id, err := client.CreateWallet(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, walletId, id)
+
+	wallet, err := client.GetWallet(ctx, walletId)
+	require.NoError(t, err)
+	assert.Equal(t, Wallet{
+		WalletId:  walletId,
+		UserId:    1,
+		Amount:    100,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		Status:    WalletActive,
+		Version:   2,
+	}, wallet)
+
+	err = client.DeleteWallet(ctx, walletId, CloseWalletInput{Reason: "moved"})
+	assert.ErrorIs(t, err, ErrNonZeroBalance)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.NotErrorIs(t, err, ErrWalletFrozen)
+
+	err = client.DeleteWallet(ctx, walletId, CloseWalletInput{IfVersion: &stale})
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+}
+
+func TestClient_Balance(t *testing.T) {
+	server, m := newServer(t, nil)
+	client := newClient(t, server, WithToken("token"))
+	ctx := context.Background()
+
+	from := createdAt
+	to := createdAt.AddDate(0, 0, 2)
+	m.auth.EXPECT().ParseToken("token").Return(1, nil).AnyTimes()
+	m.balance.EXPECT().At(gomock.Any(), 1, walletId, to).Return(int64(40), nil)
+	m.balance.EXPECT().History(gomock.Any(), 1, walletId, models.BalanceHistoryInput{Interval: models.Day, From: from, To: to}).
+		Return([]models.BalancePoint{{Date: from, Balance: 100}, {Date: from.AddDate(0, 0, 1), Balance: 40}}, nil)
+	m.balance.EXPECT().History(gomock.Any(), 1, walletId, gomock.Any()).Return(nil, models.ErrTooManyPoints)
+
+	balance, err := client.GetBalance(ctx, walletId, to)
+	require.NoError(t, err)
+	assert.Equal(t, int64(40), balance)
+
+	points, err := client.GetBalanceHistory(ctx, walletId, BalanceHistoryOptions{From: from, To: to})
+	require.NoError(t, err)
+	assert.Equal(t, []BalancePoint{{Date: from, Balance: 100}, {Date: from.AddDate(0, 0, 1), Balance: 40}}, points)
+
+	_, err = client.GetBalanceHistory(ctx, walletId, BalanceHistoryOptions{Interval: Hour, From: from})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestClient_Errors(t *testing.T) {
+	server, m := newServer(t, nil)
+	client := newClient(t, server)
+	ctx := context.Background()
+
+	m.auth.EXPECT().GenerateToken(gomock.Any(), "user", "wrong").Return("", sql.ErrNoRows)
+	m.transaction.EXPECT().Create(gomock.Any(), gomock.Any()).Return(uuid.Nil, models.ErrInsufficientFunds)
+
+	_, err := client.SignIn(ctx, "user", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.NotEmpty(t, apiErr.RequestId)
+
+	_, err = client.CreateTransaction(ctx, TransactionInput{WalletId: walletId, OperationType: Withdraw, Amount: 100})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// Rejected by the spec before reaching the handler.
+	_, err = client.CreateTransaction(ctx, TransactionInput{WalletId: walletId, OperationType: "REFUND", Amount: 100})
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	// Not signed in and no credentials to sign in with.
+	_, err = client.GetWallet(ctx, walletId)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestClient_TokenRefresh(t *testing.T) {
+	server, m := newServer(t, nil)
+	ctx := context.Background()
+
+	gomock.InOrder(
+		m.auth.EXPECT().GenerateToken(gomock.Any(), "user", "qwerty").Return("expired", nil),
+		m.auth.EXPECT().GenerateToken(gomock.Any(), "user", "qwerty").Return("fresh", nil),
+	)
+	m.auth.EXPECT().ParseToken("expired").Return(0, errors.New("token is expired"))
+	m.auth.EXPECT().ParseToken("fresh").Return(1, nil)
+	m.wallet.EXPECT().Create(gomock.Any(), 1).Return(walletId, nil)
+
+	// Credentials alone are enough: the client signs in on the first call.
+	client := newClient(t, server, WithCredentials("user", "qwerty"))
+
+	id, err := client.CreateWallet(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, walletId, id)
+	assert.Equal(t, "fresh", client.Token())
+}
+
+func TestClient_ListWallets(t *testing.T) {
+	server, m := newServer(t, nil)
+	client := newClient(t, server, WithToken("token"))
+	ctx := context.Background()
+
+	wallets := make([]models.Wallet, 5)
+	for i := range wallets {
+		wallets[i] = models.Wallet{
+			WalletId:  uuid.New(),
+			UserId:    1,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+			Status:    models.WalletActive,
+			Version:   1,
+		}
+	}
+
+	m.auth.EXPECT().ParseToken("token").Return(1, nil).AnyTimes()
+	gomock.InOrder(
+		m.wallet.EXPECT().GetAllFromUser(gomock.Any(), 1, models.WalletFilter{}, models.Page{Limit: 2, Offset: 1}).Return(wallets[1:3], nil),
+		m.wallet.EXPECT().GetAllFromUser(gomock.Any(), 1, models.WalletFilter{}, models.Page{Limit: 2, Offset: 3}).Return(wallets[3:5], nil),
+		m.wallet.EXPECT().GetAllFromUser(gomock.Any(), 1, models.WalletFilter{}, models.Page{Limit: 2, Offset: 5}).Return(nil, nil),
+	)
+
+	var got []uuid.UUID
+	for wallet, err := range client.ListWallets(ctx, ListOptions{PageSize: 2, Offset: 1}) {
+		require.NoError(t, err)
+		got = append(got, wallet.WalletId)
+	}
+	assert.Equal(t, []uuid.UUID{wallets[1].WalletId, wallets[2].WalletId, wallets[3].WalletId, wallets[4].WalletId}, got)
+}
+
+func TestClient_SearchWallets(t *testing.T) {
+	server, m := newServer(t, nil)
+	client := newClient(t, server, WithToken("token"))
+	ctx := context.Background()
+
+	minAmount := int64(100)
+	m.auth.EXPECT().ParseToken("token").Return(1, nil).AnyTimes()
+	m.wallet.EXPECT().GetAllFromUser(gomock.Any(), 1, models.WalletFilter{
+		Name:        "trip",
+		Tags:        []string{"travel", "2024"},
+		MinAmount:   &minAmount,
+		CreatedFrom: &createdAt,
+		OrderBy:     models.WalletsByAmount,
+		Desc:        true,
+	}, models.Page{Limit: 50}).Return(nil, nil)
+
+	filter := WalletFilter{
+		Name:        "trip",
+		Tags:        []string{"travel", "2024"},
+		MinAmount:   &minAmount,
+		CreatedFrom: createdAt,
+		Sort:        "-amount",
+	}
+	for _, err := range client.SearchWallets(ctx, filter, ListOptions{}) {
+		require.NoError(t, err)
+	}
+}
+
+func TestClient_UpdateWallet(t *testing.T) {
+	server, m := newServer(t, nil)
+	client := newClient(t, server, WithToken("token"))
+	ctx := context.Background()
+
+	name, isDefault, tags := "Trip", true, []string{"travel"}
+	version := int64(2)
+	m.auth.EXPECT().ParseToken("token").Return(1, nil).AnyTimes()
+	gomock.InOrder(
+		m.wallet.EXPECT().Update(gomock.Any(), 1, walletId, models.WalletUpdate{Name: &name, Tags: &tags, IsDefault: &isDefault, ExpectedVersion: &version}).
+			Return(models.Wallet{
+				WalletId:  walletId,
+				UserId:    1,
+				CreatedAt: createdAt,
+				UpdatedAt: createdAt,
+				Status:    models.WalletActive,
+				Version:   3,
+				Name:      name,
+				Tags:      tags,
+				IsDefault: true,
+			}, nil),
+		m.wallet.EXPECT().Update(gomock.Any(), 1, walletId, models.WalletUpdate{Name: &name}).Return(models.Wallet{}, models.ErrWalletClosed),
+	)
+
+	wallet, err := client.UpdateWallet(ctx, walletId, WalletUpdate{Name: &name, Tags: &tags, IsDefault: &isDefault, IfVersion: &version})
+	require.NoError(t, err)
+	assert.Equal(t, Wallet{
+		WalletId:  walletId,
+		UserId:    1,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		Status:    WalletActive,
+		Version:   3,
+		Name:      name,
+		Tags:      tags,
+		IsDefault: true,
+	}, wallet)
+
+	_, err = client.UpdateWallet(ctx, walletId, WalletUpdate{Name: &name})
+	assert.ErrorIs(t, err, ErrConflict)
+}
+
+func TestClient_ListTransactions(t *testing.T) {
+	server, m := newServer(t, nil)
+	client := newClient(t, server)
+	ctx := context.Background()
+
+	transaction := models.Transaction{
+		TransactionId: uuid.New(),
+		WalletId:      walletId,
+		OperationType: models.Deposit,
+		Amount:        100,
+		CreatedAt:     createdAt,
+		Seq:           1,
+		Hash:          "9f2c",
+	}
+	gomock.InOrder(
+		m.transaction.EXPECT().GetAll(gomock.Any(), models.TransactionFilter{}, models.Page{Limit: 50}).Return([]models.Transaction{transaction}, nil),
+		m.transaction.EXPECT().GetAll(gomock.Any(), models.TransactionFilter{}, models.Page{Limit: 50}).Return(nil, errors.New("connection refused")),
+	)
+
+	var got []Transaction
+	for transaction, err := range client.ListTransactions(ctx, ListOptions{}) {
+		require.NoError(t, err)
+		got = append(got, transaction)
+	}
+	assert.Equal(t, []Transaction{{
+		TransactionId: transaction.TransactionId,
+		WalletId:      walletId,
+		OperationType: Deposit,
+		Amount:        100,
+		CreatedAt:     createdAt,
+		Seq:           1,
+		Hash:          "9f2c",
+	}}, got)
+
+	// Errors end the iteration.
+	calls := 0
+	for _, err := range client.ListTransactions(ctx, ListOptions{}) {
+		assert.EqualError(t, err, "wallets api: 500 service failure")
+		calls++
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestClient_SearchTransactions(t *testing.T) {
+	server, m := newServer(t, nil)
+	client := newClient(t, server)
+	ctx := context.Background()
+
+	categoryId := uuid.New()
+	m.transaction.EXPECT().GetAll(gomock.Any(), models.TransactionFilter{
+		CategoryId:  &categoryId,
+		ExternalRef: "inv-7",
+		Search:      "coffee",
+		Metadata:    map[string]string{"source": "pos", "tip": "true"},
+	}, models.Page{Limit: 50}).Return(nil, nil)
+
+	filter := TransactionFilter{
+		CategoryId:  &categoryId,
+		ExternalRef: "inv-7",
+		Search:      "coffee",
+		Metadata:    map[string]string{"source": "pos", "tip": "true"},
+	}
+	for _, err := range client.SearchTransactions(ctx, filter, ListOptions{}) {
+		require.NoError(t, err)
+	}
+}
+
+func TestClient_Annotations(t *testing.T) {
+	server, m := newServer(t, nil)
+	client := newClient(t, server, WithToken("token"))
+	ctx := context.Background()
+
+	transactionId, categoryId := uuid.New(), uuid.New()
+	annotation := models.Annotation{TransactionId: transactionId, Version: 2, CategoryId: &categoryId, Notes: "lunch", CreatedAt: createdAt}
+	m.auth.EXPECT().ParseToken("token").Return(1, nil).AnyTimes()
+	m.transaction.EXPECT().Annotate(gomock.Any(), 1, transactionId, models.AnnotationInput{CategoryId: &categoryId, Notes: "lunch"}).Return(annotation, nil)
+	m.transaction.EXPECT().GetAnnotations(gomock.Any(), 1, transactionId, models.Page{Limit: 50}).Return([]models.Annotation{annotation}, nil)
+
+	got, err := client.AnnotateTransaction(ctx, transactionId, AnnotationInput{CategoryId: &categoryId, Notes: "lunch"})
+	require.NoError(t, err)
+	want := Annotation{TransactionId: transactionId, Version: 2, CategoryId: &categoryId, Notes: "lunch", CreatedAt: createdAt}
+	assert.Equal(t, want, got)
+
+	var versions []Annotation
+	for annotation, err := range client.ListAnnotations(ctx, transactionId, ListOptions{}) {
+		require.NoError(t, err)
+		versions = append(versions, annotation)
+	}
+	assert.Equal(t, []Annotation{want}, versions)
+}
+
+func TestClient_Categories(t *testing.T) {
+	server, m := newServer(t, nil)
+	client := newClient(t, server, WithToken("token"))
+	ctx := context.Background()
+
+	categoryId := uuid.New()
+	m.auth.EXPECT().ParseToken("token").Return(1, nil).AnyTimes()
+	gomock.InOrder(
+		m.category.EXPECT().Create(gomock.Any(), 1, models.CategoryInput{Name: "Food"}).Return(categoryId, nil),
+		m.category.EXPECT().Create(gomock.Any(), 1, models.CategoryInput{Name: "Food"}).Return(uuid.Nil, models.ErrCategoryExists),
+	)
+	m.category.EXPECT().GetAll(gomock.Any(), 1, models.Page{Limit: 50}).Return([]models.Category{{CategoryId: categoryId, UserId: 1, Name: "Food", CreatedAt: createdAt}}, nil)
+	m.category.EXPECT().Delete(gomock.Any(), 1, categoryId).Return(models.ErrCategoryInUse)
+
+	id, err := client.CreateCategory(ctx, CategoryInput{Name: "Food"})
+	require.NoError(t, err)
+	assert.Equal(t, categoryId, id)
+
+	_, err = client.CreateCategory(ctx, CategoryInput{Name: "Food"})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	var categories []Category
+	for category, err := range client.ListCategories(ctx, ListOptions{}) {
+		require.NoError(t, err)
+		categories = append(categories, category)
+	}
+	assert.Equal(t, []Category{{CategoryId: categoryId, Name: "Food", CreatedAt: createdAt}}, categories)
+
+	assert.ErrorIs(t, client.DeleteCategory(ctx, categoryId), ErrConflict)
+}
+
+// failFirst answers the first n requests with status.
+func failFirst(n int32, status int, requests *atomic.Int32) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) <= n {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				w.Write([]byte(`{"message":"try later"}`))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	testTable := []struct {
+		name             string
+		status           int
+		failures         int32
+		call             func(c *Client, m mocks) error
+		expectedRequests int32
+		expectedErr      error
+	}{
+		{
+			name:     "Idempotent Call Retried",
+			status:   http.StatusServiceUnavailable,
+			failures: 2,
+			call: func(c *Client, m mocks) error {
+				m.auth.EXPECT().ParseToken("token").Return(1, nil)
+				m.wallet.EXPECT().GetByIdFromUser(gomock.Any(), 1, walletId).Return(models.Wallet{
+					WalletId: walletId, UserId: 1, CreatedAt: createdAt, UpdatedAt: createdAt, Status: models.WalletActive, Version: 1,
+				}, nil)
+				_, err := c.GetWallet(context.Background(), walletId)
+				return err
+			},
+			expectedRequests: 3,
+		},
+		{
+			name:     "Attempts Exhausted",
+			status:   http.StatusServiceUnavailable,
+			failures: 3,
+			call: func(c *Client, m mocks) error {
+				_, err := c.GetWallet(context.Background(), walletId)
+				return err
+			},
+			expectedRequests: 3,
+			expectedErr:      ErrUnavailable,
+		},
+		{
+			name:     "Transaction Not Retried",
+			status:   http.StatusServiceUnavailable,
+			failures: 1,
+			call: func(c *Client, m mocks) error {
+				_, err := c.CreateTransaction(context.Background(), TransactionInput{WalletId: walletId, OperationType: Deposit, Amount: 1})
+				return err
+			},
+			expectedRequests: 1,
+			expectedErr:      ErrUnavailable,
+		},
+		{
+			name:     "Rate Limited Transaction Retried",
+			status:   http.StatusTooManyRequests,
+			failures: 1,
+			call: func(c *Client, m mocks) error {
+				m.transaction.EXPECT().Create(gomock.Any(), gomock.Any()).Return(walletId, nil)
+				_, err := c.CreateTransaction(context.Background(), TransactionInput{WalletId: walletId, OperationType: Deposit, Amount: 1})
+				return err
+			},
+			expectedRequests: 2,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server, m := newServer(t, failFirst(testCase.failures, testCase.status, &requests))
+			client := newClient(t, server, WithToken("token"))
+
+			err := testCase.call(client, m)
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, err, testCase.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.expectedRequests, requests.Load())
+		})
+	}
+}
+
+func TestClient_RetryCancelled(t *testing.T) {
+	var requests atomic.Int32
+	server, _ := newServer(t, failFirst(10, http.StatusServiceUnavailable, &requests))
+	client := newClient(t, server, WithToken("token"), WithRetry(5, time.Hour, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetWallet(ctx, walletId)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("wallets.example.com")
+	assert.Error(t, err)
+
+	c, err := New("https://wallets.example.com/")
+	require.NoError(t, err)
+	assert.Equal(t, "https://wallets.example.com/api/v1/wallets/", c.baseURL.JoinPath(walletsPath).String())
+}
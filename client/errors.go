@@ -0,0 +1,113 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors by response status.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	// ErrPreconditionFailed means the wallet changed since the version the
+	// call was conditional on.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrTooLarge           = errors.New("request body too large")
+	ErrRateLimited        = errors.New("rate limit exceeded")
+	ErrUnavailable        = errors.New("service unavailable")
+)
+
+// Errors by the reason the API gives. They are more specific than the status
+// errors: ErrInsufficientFunds is also an ErrConflict.
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrWalletFrozen       = errors.New("wallet is frozen")
+	ErrWalletClosed       = errors.New("wallet is closed")
+	ErrNonZeroBalance     = errors.New("wallet balance is not zero")
+	ErrInsufficientFunds  = errors.New("insufficient funds")
+	ErrInvalidSweepTarget = errors.New("invalid sweep destination wallet")
+)
+
+var statusErrors = map[int]error{
+	http.StatusBadRequest:            ErrBadRequest,
+	http.StatusUnauthorized:          ErrUnauthorized,
+	http.StatusForbidden:             ErrForbidden,
+	http.StatusNotFound:              ErrNotFound,
+	http.StatusConflict:              ErrConflict,
+	http.StatusPreconditionFailed:    ErrPreconditionFailed,
+	http.StatusRequestEntityTooLarge: ErrTooLarge,
+	http.StatusTooManyRequests:       ErrRateLimited,
+	http.StatusServiceUnavailable:    ErrUnavailable,
+}
+
+var reasonErrors = []error{
+	ErrInvalidCredentials,
+	ErrWalletFrozen,
+	ErrWalletClosed,
+	ErrNonZeroBalance,
+	ErrInsufficientFunds,
+	ErrInvalidSweepTarget,
+}
+
+// Error is a response of the API with a status other than 2xx.
+type Error struct {
+	StatusCode int
+	// Message is the message of the response body, or the status text when
+	// the body has none.
+	Message   string
+	RequestId string
+	// RetryAfter is set for rate limited calls.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("wallets api: %d %s", e.StatusCode, e.Message)
+}
+
+// Is matches the status and reason errors of this package.
+func (e *Error) Is(target error) bool {
+	if statusErrors[e.StatusCode] == target {
+		return true
+	}
+
+	for _, reason := range reasonErrors {
+		if reason == target {
+			message := reason.Error()
+			return e.Message == message || strings.HasPrefix(e.Message, message+": ")
+		}
+	}
+
+	return false
+}
+
+func newError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		RequestId:  resp.Header.Get("X-Request-ID"),
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body); err == nil && body.Message != "" {
+		apiErr.Message = body.Message
+	} else {
+		apiErr.Message = strings.ToLower(http.StatusText(resp.StatusCode))
+	}
+
+	return apiErr
+}
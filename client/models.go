@@ -0,0 +1,203 @@
+package client
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OperationType string
+
+const (
+	Deposit  OperationType = "DEPOSIT"
+	Withdraw OperationType = "WITHDRAW"
+)
+
+type WalletStatus string
+
+const (
+	WalletActive WalletStatus = "ACTIVE"
+	WalletFrozen WalletStatus = "FROZEN"
+	WalletClosed WalletStatus = "CLOSED"
+)
+
+type SignUpInput struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type Wallet struct {
+	WalletId    uuid.UUID    `json:"walletId"`
+	UserId      int          `json:"userId"`
+	Amount      int64        `json:"amount"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+	Status      WalletStatus `json:"status"`
+	ClosedAt    *time.Time   `json:"closedAt,omitempty"`
+	CloseReason *string      `json:"closeReason,omitempty"`
+	// Version is incremented on every change of the wallet, labels
+	// included.
+	Version     int64    `json:"version"`
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Color       string   `json:"color,omitempty"`
+	Icon        string   `json:"icon,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// IsDefault marks the one wallet of the user picked as default.
+	IsDefault bool `json:"isDefault,omitempty"`
+}
+
+// WalletUpdate changes the labels of a wallet. Only the fields set are
+// changed; an empty string or list clears the label.
+type WalletUpdate struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	// Color is "#rrggbb".
+	Color *string   `json:"color,omitempty"`
+	Icon  *string   `json:"icon,omitempty"`
+	Tags  *[]string `json:"tags,omitempty"`
+	// IsDefault set to true makes the wallet the default one instead of the
+	// previous default wallet of the user.
+	IsDefault *bool `json:"isDefault,omitempty"`
+	// IfVersion, when set, updates the wallet only if it is still at this
+	// version. Otherwise the call fails with ErrPreconditionFailed.
+	IfVersion *int64 `json:"-"`
+}
+
+// WalletFilter selects the wallets matching every field set, in the order
+// of Sort.
+type WalletFilter struct {
+	// Name matches names containing it, ignoring case.
+	Name string
+	// Tags matches wallets with all of them.
+	Tags      []string
+	MinAmount *int64
+	MaxAmount *int64
+	// CreatedFrom and CreatedTo bound the creation time, To excluded.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// Sort is "createdAt", "name" or "amount", descending with a leading
+	// "-". Without it wallets come oldest first.
+	Sort string
+}
+
+// CloseWalletInput describes how a wallet is closed. A wallet with a
+// non-zero balance can only be closed when SweepTo names another wallet of
+// the same user to receive the remaining funds.
+type CloseWalletInput struct {
+	Reason  string
+	SweepTo *uuid.UUID
+	// IfVersion, when set, closes the wallet only if it is still at this
+	// version. Otherwise the call fails with ErrPreconditionFailed.
+	IfVersion *int64
+}
+
+type TransactionInput struct {
+	WalletId      uuid.UUID     `json:"walletId"`
+	OperationType OperationType `json:"operationType"`
+	Amount        int64         `json:"amount"`
+	Description   *string       `json:"description,omitempty"`
+	// CategoryId is a category of the owner of the wallet.
+	CategoryId  *uuid.UUID `json:"categoryId,omitempty"`
+	ExternalRef *string    `json:"externalRef,omitempty"`
+	// Metadata must be at most 4 KiB as compact JSON.
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// IfVersion, when set, applies the transaction only if the wallet is
+	// still at this version. Otherwise the call fails with
+	// ErrPreconditionFailed.
+	IfVersion *int64 `json:"-"`
+}
+
+type Transaction struct {
+	TransactionId uuid.UUID     `json:"transactionId"`
+	WalletId      uuid.UUID     `json:"walletId"`
+	OperationType OperationType `json:"operationType"`
+	Amount        int64         `json:"amount"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	// Seq is the position of the transaction in the hash chain of its
+	// wallet. Hash covers its contents and PrevHash, the hash of the
+	// transaction before it.
+	Seq         int64          `json:"seq,omitempty"`
+	PrevHash    string         `json:"prevHash,omitempty"`
+	Hash        string         `json:"hash,omitempty"`
+	Description *string        `json:"description,omitempty"`
+	CategoryId  *uuid.UUID     `json:"categoryId,omitempty"`
+	ExternalRef *string        `json:"externalRef,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+// TransactionFilter selects the transactions matching every field set.
+type TransactionFilter struct {
+	// CategoryId matches the category the transaction was recorded with.
+	CategoryId  *uuid.UUID
+	ExternalRef string
+	// Search matches descriptions containing it, ignoring case.
+	Search string
+	// Metadata matches top-level metadata values. Values other than strings
+	// are written as JSON, e.g. "true" or "2".
+	Metadata map[string]string
+}
+
+// Annotation is a version of what the owner of a transaction can change
+// about it: its category and notes.
+type Annotation struct {
+	TransactionId uuid.UUID  `json:"transactionId"`
+	Version       int64      `json:"version"`
+	CategoryId    *uuid.UUID `json:"categoryId,omitempty"`
+	Notes         string     `json:"notes"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+type AnnotationInput struct {
+	CategoryId *uuid.UUID `json:"categoryId,omitempty"`
+	Notes      string     `json:"notes,omitempty"`
+}
+
+type Category struct {
+	CategoryId uuid.UUID  `json:"categoryId"`
+	ParentId   *uuid.UUID `json:"parentId,omitempty"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type CategoryInput struct {
+	Name     string     `json:"name"`
+	ParentId *uuid.UUID `json:"parentId,omitempty"`
+}
+
+type Interval string
+
+const (
+	Hour  Interval = "hour"
+	Day   Interval = "day"
+	Week  Interval = "week"
+	Month Interval = "month"
+)
+
+// BalanceHistoryOptions selects the periods of a balance history: those
+// overlapping [From, To). A zero Interval is a day and a zero To is now.
+type BalanceHistoryOptions struct {
+	Interval Interval
+	From     time.Time
+	To       time.Time
+}
+
+// BalancePoint is the closing balance of the period starting at Date.
+type BalancePoint struct {
+	Date    time.Time `json:"date"`
+	Balance int64     `json:"balance"`
+}
+
+// ListOptions selects where listing starts and how many items are fetched
+// per request. A zero PageSize uses the server's default.
+type ListOptions struct {
+	PageSize int
+	Offset   int
+}
+
+type listResponse[T any] struct {
+	Data   []T `json:"data"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+const transactionsPath = "/api/v1/transactions/"
+
+// CreateTransaction applies a deposit or withdrawal to a wallet and returns
+// the id of the transaction. It is not retried after the server may have
+// processed it, so a transaction is never applied twice.
+func (c *Client) CreateTransaction(ctx context.Context, input TransactionInput) (uuid.UUID, error) {
+	var out struct {
+		Id uuid.UUID `json:"uuid"`
+	}
+	err := c.do(ctx, call{method: http.MethodPost, path: transactionsPath, in: input, ifVersion: input.IfVersion, out: &out})
+
+	return out.Id, err
+}
+
+// ListTransactions iterates over transactions, oldest first, fetching them a
+// page at a time. Iteration stops at the first error.
+func (c *Client) ListTransactions(ctx context.Context, opts ListOptions) iter.Seq2[Transaction, error] {
+	return list[Transaction](ctx, c, transactionsPath, nil, opts, false)
+}
+
+// SearchTransactions is ListTransactions for the transactions matching the
+// filter.
+func (c *Client) SearchTransactions(ctx context.Context, filter TransactionFilter, opts ListOptions) iter.Seq2[Transaction, error] {
+	query := url.Values{}
+	if filter.CategoryId != nil {
+		query.Set("category", filter.CategoryId.String())
+	}
+	if filter.ExternalRef != "" {
+		query.Set("externalRef", filter.ExternalRef)
+	}
+	if filter.Search != "" {
+		query.Set("q", filter.Search)
+	}
+	for key, value := range filter.Metadata {
+		query.Set("metadata["+key+"]", value)
+	}
+
+	return list[Transaction](ctx, c, transactionsPath, query, opts, false)
+}
+
+// AnnotateTransaction sets the category and notes of a transaction of the
+// user, and returns the new version of its annotation.
+func (c *Client) AnnotateTransaction(ctx context.Context, transactionId uuid.UUID, input AnnotationInput) (Annotation, error) {
+	var annotation Annotation
+	err := c.do(ctx, call{method: http.MethodPut, path: transactionsPath + transactionId.String() + "/annotation", in: input, out: &annotation, auth: true})
+
+	return annotation, err
+}
+
+// ListAnnotations iterates over the versions of the annotation of a
+// transaction of the user, newest first.
+func (c *Client) ListAnnotations(ctx context.Context, transactionId uuid.UUID, opts ListOptions) iter.Seq2[Annotation, error] {
+	return list[Annotation](ctx, c, transactionsPath+transactionId.String()+"/annotations", nil, opts, true)
+}
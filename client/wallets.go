@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const walletsPath = "/api/v1/wallets/"
+
+// CreateWallet creates a wallet with a zero balance and returns its id.
+func (c *Client) CreateWallet(ctx context.Context) (uuid.UUID, error) {
+	var out struct {
+		Id uuid.UUID `json:"uuid"`
+	}
+	err := c.do(ctx, call{method: http.MethodPost, path: walletsPath, out: &out, auth: true})
+
+	return out.Id, err
+}
+
+// ListWallets iterates over the wallets of the user, oldest first, fetching
+// them a page at a time. Iteration stops at the first error.
+func (c *Client) ListWallets(ctx context.Context, opts ListOptions) iter.Seq2[Wallet, error] {
+	return list[Wallet](ctx, c, walletsPath, nil, opts, true)
+}
+
+// SearchWallets is ListWallets for the wallets matching the filter.
+func (c *Client) SearchWallets(ctx context.Context, filter WalletFilter, opts ListOptions) iter.Seq2[Wallet, error] {
+	query := url.Values{}
+	if filter.Name != "" {
+		query.Set("name", filter.Name)
+	}
+	for _, tag := range filter.Tags {
+		query.Add("tag", tag)
+	}
+	if filter.MinAmount != nil {
+		query.Set("minAmount", strconv.FormatInt(*filter.MinAmount, 10))
+	}
+	if filter.MaxAmount != nil {
+		query.Set("maxAmount", strconv.FormatInt(*filter.MaxAmount, 10))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query.Set("createdFrom", filter.CreatedFrom.Format(time.RFC3339Nano))
+	}
+	if !filter.CreatedTo.IsZero() {
+		query.Set("createdTo", filter.CreatedTo.Format(time.RFC3339Nano))
+	}
+	if filter.Sort != "" {
+		query.Set("sort", filter.Sort)
+	}
+
+	return list[Wallet](ctx, c, walletsPath, query, opts, true)
+}
+
+func (c *Client) GetWallet(ctx context.Context, walletId uuid.UUID) (Wallet, error) {
+	var wallet Wallet
+	err := c.do(ctx, call{method: http.MethodGet, path: walletsPath + walletId.String(), out: &wallet, auth: true})
+
+	return wallet, err
+}
+
+// UpdateWallet changes the labels of the wallet and returns it.
+func (c *Client) UpdateWallet(ctx context.Context, walletId uuid.UUID, update WalletUpdate) (Wallet, error) {
+	var wallet Wallet
+	err := c.do(ctx, call{method: http.MethodPatch, path: walletsPath + walletId.String(), in: update, out: &wallet, ifVersion: update.IfVersion, auth: true})
+
+	return wallet, err
+}
+
+// DeleteWallet closes the wallet. Closed wallets are kept for history.
+func (c *Client) DeleteWallet(ctx context.Context, walletId uuid.UUID, input CloseWalletInput) error {
+	query := url.Values{}
+	if input.Reason != "" {
+		query.Set("reason", input.Reason)
+	}
+	if input.SweepTo != nil {
+		query.Set("sweepTo", input.SweepTo.String())
+	}
+
+	return c.do(ctx, call{method: http.MethodDelete, path: walletsPath + walletId.String(), query: query, ifVersion: input.IfVersion, auth: true})
+}
+
+// GetBalance returns the balance of the wallet at a past moment: the sum of
+// the transactions made before it.
+func (c *Client) GetBalance(ctx context.Context, walletId uuid.UUID, at time.Time) (int64, error) {
+	query := url.Values{}
+	query.Set("at", at.Format(time.RFC3339Nano))
+
+	var out struct {
+		Balance int64 `json:"balance"`
+	}
+	err := c.do(ctx, call{method: http.MethodGet, path: walletsPath + walletId.String() + "/balance", query: query, out: &out, auth: true})
+
+	return out.Balance, err
+}
+
+// GetBalanceHistory returns the closing balances of the wallet, oldest first.
+func (c *Client) GetBalanceHistory(ctx context.Context, walletId uuid.UUID, opts BalanceHistoryOptions) ([]BalancePoint, error) {
+	query := url.Values{}
+	if opts.Interval != "" {
+		query.Set("interval", string(opts.Interval))
+	}
+	query.Set("from", opts.From.Format(time.RFC3339Nano))
+	if !opts.To.IsZero() {
+		query.Set("to", opts.To.Format(time.RFC3339Nano))
+	}
+
+	var out struct {
+		Data []BalancePoint `json:"data"`
+	}
+	err := c.do(ctx, call{method: http.MethodGet, path: walletsPath + walletId.String() + "/balance-history", query: query, out: &out, auth: true})
+
+	return out.Data, err
+}
@@ -0,0 +1,476 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/Yoshisoul/rest-wallets/internal/service"
+	"github.com/google/uuid"
+)
+
+type command struct {
+	summary string
+	run     func(ctx context.Context, services *service.Service, args []string) error
+}
+
+var commands = map[string]command{
+	"create-user":       {"create a user", createUser},
+	"reset-password":    {"set a new password for a user", resetPassword},
+	"list-wallets":      {"list the wallets of a user", listWallets},
+	"freeze-wallet":     {"forbid withdrawals from a wallet", setFrozen(true)},
+	"unfreeze-wallet":   {"allow withdrawals from a frozen wallet again", setFrozen(false)},
+	"close-wallet":      {"close a wallet, optionally sweeping its balance", closeWallet},
+	"adjust":            {"post a manual balance adjustment", adjust},
+	"history":           {"dump the transaction history of a wallet", history},
+	"reconcile":         {"check wallet balances against their transactions", reconcile},
+	"list-cases":        {"list the correction cases of balance mismatches", listCases},
+	"resolve-case":      {"resolve an open correction case", resolveCase},
+	"verify-chain":      {"verify the transaction hash chain of a wallet", verifyChain},
+	"checkpoint":        {"publish a signed checkpoint of the hash chains", checkpoint},
+	"list-checkpoints":  {"list the published checkpoints", listCheckpoints},
+	"verify-checkpoint": {"verify a checkpoint against the stored transactions", verifyCheckpoint},
+}
+
+// errMismatches makes reconcile exit non-zero when balances are off, so it
+// can be used as a check from scripts.
+var errMismatches = errors.New("balance mismatches found")
+
+// errChainBroken and errCheckpointInvalid make verify-chain and
+// verify-checkpoint exit non-zero when verification fails.
+var (
+	errChainBroken       = errors.New("hash chain is broken")
+	errCheckpointInvalid = errors.New("checkpoint is invalid")
+)
+
+func createUser(ctx context.Context, services *service.Service, args []string) error {
+	flags := flag.NewFlagSet("create-user", flag.ExitOnError)
+	name := flags.String("name", "", "display name")
+	username := flags.String("username", "", "login")
+	password := flags.String("password", "", "password, read from stdin when omitted")
+	flags.Parse(args)
+
+	if *name == "" || *username == "" {
+		return errors.New("-name and -username are required")
+	}
+
+	if err := readPassword(password); err != nil {
+		return err
+	}
+
+	id, err := services.Authorization.CreateUser(ctx, models.SignUpInput{
+		Name:     *name,
+		Username: *username,
+		Password: *password,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(id)
+	return nil
+}
+
+func resetPassword(ctx context.Context, services *service.Service, args []string) error {
+	flags := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	username := flags.String("username", "", "login")
+	password := flags.String("password", "", "new password, read from stdin when omitted")
+	flags.Parse(args)
+
+	if *username == "" {
+		return errors.New("-username is required")
+	}
+
+	if err := readPassword(password); err != nil {
+		return err
+	}
+
+	return services.Authorization.ResetPassword(ctx, *username, *password)
+}
+
+func listWallets(ctx context.Context, services *service.Service, args []string) error {
+	flags := flag.NewFlagSet("list-wallets", flag.ExitOnError)
+	username := flags.String("username", "", "login of the wallets owner")
+	flags.Parse(args)
+
+	if *username == "" {
+		return errors.New("-username is required")
+	}
+
+	user, err := services.Authorization.GetUser(ctx, *username)
+	if err != nil {
+		return err
+	}
+
+	wallets, err := services.Wallet.GetAllFromUser(ctx, user.Id, models.WalletFilter{}, models.Page{})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WALLET\tSTATUS\tAMOUNT\tCREATED")
+	for _, wallet := range wallets {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", wallet.WalletId, wallet.Status, wallet.Amount, wallet.CreatedAt.Format(time.RFC3339))
+	}
+
+	return w.Flush()
+}
+
+func setFrozen(frozen bool) func(ctx context.Context, services *service.Service, args []string) error {
+	return func(ctx context.Context, services *service.Service, args []string) error {
+		name := "freeze-wallet"
+		if !frozen {
+			name = "unfreeze-wallet"
+		}
+
+		flags := flag.NewFlagSet(name, flag.ExitOnError)
+		walletId := flags.String("wallet", "", "wallet id")
+		flags.Parse(args)
+
+		id, err := uuid.Parse(*walletId)
+		if err != nil {
+			return fmt.Errorf("invalid -wallet: %w", err)
+		}
+
+		return services.Wallet.SetFrozen(ctx, id, frozen)
+	}
+}
+
+func closeWallet(ctx context.Context, services *service.Service, args []string) error {
+	flags := flag.NewFlagSet("close-wallet", flag.ExitOnError)
+	walletId := flags.String("wallet", "", "wallet id")
+	sweepTo := flags.String("sweep-to", "", "wallet of the same user that receives the remaining balance")
+	reason := flags.String("reason", "", "why the wallet is closed")
+	flags.Parse(args)
+
+	id, err := uuid.Parse(*walletId)
+	if err != nil {
+		return fmt.Errorf("invalid -wallet: %w", err)
+	}
+
+	input := models.CloseWalletInput{Reason: *reason}
+	if *sweepTo != "" {
+		target, err := uuid.Parse(*sweepTo)
+		if err != nil {
+			return fmt.Errorf("invalid -sweep-to: %w", err)
+		}
+		input.SweepTo = &target
+	}
+
+	wallet, err := services.Wallet.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return services.Wallet.Close(ctx, wallet.UserId, id, input)
+}
+
+func adjust(ctx context.Context, services *service.Service, args []string) error {
+	flags := flag.NewFlagSet("adjust", flag.ExitOnError)
+	walletId := flags.String("wallet", "", "wallet id")
+	operationType := flags.String("type", "", "DEPOSIT or WITHDRAW")
+	amount := flags.Int64("amount", 0, "amount, must be positive")
+	reason := flags.String("reason", "", "why the adjustment is made, required")
+	flags.Parse(args)
+
+	id, err := uuid.Parse(*walletId)
+	if err != nil {
+		return fmt.Errorf("invalid -wallet: %w", err)
+	}
+
+	if *amount <= 0 {
+		return errors.New("-amount must be positive")
+	}
+
+	transactionId, err := services.Transaction.Adjust(ctx, models.AdjustmentInput{
+		WalletId:      id,
+		OperationType: models.OperationType(strings.ToUpper(*operationType)),
+		Amount:        *amount,
+		Reason:        *reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(transactionId)
+	return nil
+}
+
+func history(ctx context.Context, services *service.Service, args []string) error {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	walletId := flags.String("wallet", "", "wallet id")
+	format := flags.String("format", "json", "output format: json or csv")
+	flags.Parse(args)
+
+	id, err := uuid.Parse(*walletId)
+	if err != nil {
+		return fmt.Errorf("invalid -wallet: %w", err)
+	}
+
+	transactions, err := services.Transaction.GetAllFromWallet(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		return writeJSON(os.Stdout, transactions)
+	case "csv":
+		return writeCSV(os.Stdout, transactions)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+}
+
+func reconcile(ctx context.Context, services *service.Service, args []string) error {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	openCases := flags.Bool("open-cases", false, "open a correction case for each mismatch")
+	format := flags.String("format", "text", "output format: text or json")
+	flags.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	report, err := services.Reconciliation.Run(ctx, *openCases)
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("%d wallets checked, %d mismatches\n", report.Wallets, len(report.Mismatches))
+		if len(report.Mismatches) > 0 {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "WALLET\tUSER\tSTORED\tCOMPUTED\tDIFFERENCE\tTRANSACTIONS\tCASE")
+			for _, mismatch := range report.Mismatches {
+				caseId := "-"
+				if mismatch.CaseId != nil {
+					caseId = mismatch.CaseId.String()
+				}
+				fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%s\n", mismatch.WalletId, mismatch.UserId,
+					mismatch.Stored, mismatch.Computed, mismatch.Difference, mismatch.Transactions, caseId)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(report.Mismatches) > 0 {
+		return errMismatches
+	}
+	return nil
+}
+
+func listCases(ctx context.Context, services *service.Service, args []string) error {
+	flags := flag.NewFlagSet("list-cases", flag.ExitOnError)
+	status := flags.String("status", "OPEN", "OPEN, RESOLVED or empty for all")
+	flags.Parse(args)
+
+	caseStatus := models.CaseStatus(strings.ToUpper(*status))
+	if caseStatus != "" && caseStatus != models.CaseOpen && caseStatus != models.CaseResolved {
+		return fmt.Errorf("unknown status %q", *status)
+	}
+
+	cases, err := services.Reconciliation.GetCases(ctx, caseStatus, models.Page{})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CASE\tWALLET\tSTATUS\tSTORED\tCOMPUTED\tOPENED\tRESOLUTION")
+	for _, c := range cases {
+		resolution := ""
+		if c.Resolution != nil {
+			resolution = *c.Resolution
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\t%s\n", c.CaseId, c.WalletId, c.Status,
+			c.Stored, c.Computed, c.OpenedAt.Format(time.RFC3339), resolution)
+	}
+
+	return w.Flush()
+}
+
+func resolveCase(ctx context.Context, services *service.Service, args []string) error {
+	flags := flag.NewFlagSet("resolve-case", flag.ExitOnError)
+	caseId := flags.String("case", "", "correction case id")
+	resolution := flags.String("resolution", "", "how the mismatch was dealt with, required")
+	flags.Parse(args)
+
+	id, err := uuid.Parse(*caseId)
+	if err != nil {
+		return fmt.Errorf("invalid -case: %w", err)
+	}
+
+	return services.Reconciliation.ResolveCase(ctx, id, *resolution)
+}
+
+func verifyChain(ctx context.Context, services *service.Service, args []string) error {
+	flags := flag.NewFlagSet("verify-chain", flag.ExitOnError)
+	walletId := flags.String("wallet", "", "wallet id")
+	flags.Parse(args)
+
+	id, err := uuid.Parse(*walletId)
+	if err != nil {
+		return fmt.Errorf("invalid -wallet: %w", err)
+	}
+
+	verification, err := services.Chain.Verify(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if verification.Break != nil {
+		fmt.Printf("%d transactions, broken at seq %d (transaction %s): %s\n", verification.Transactions,
+			verification.Break.Seq, verification.Break.TransactionId, verification.Break.Reason)
+		return errChainBroken
+	}
+	fmt.Printf("%d transactions, valid, head %s\n", verification.Transactions, verification.Head)
+	return nil
+}
+
+func checkpoint(ctx context.Context, services *service.Service, args []string) error {
+	flags := flag.NewFlagSet("checkpoint", flag.ExitOnError)
+	delay := flags.Duration("delay", time.Minute, "cover the transactions created before this long ago")
+	flags.Parse(args)
+
+	checkpoint, err := services.Chain.Checkpoint(ctx, time.Now().Add(-*delay).Truncate(time.Second))
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(checkpoint)
+}
+
+func listCheckpoints(ctx context.Context, services *service.Service, args []string) error {
+	flags := flag.NewFlagSet("list-checkpoints", flag.ExitOnError)
+	limit := flags.Int("limit", 20, "how many of the latest checkpoints to list, 0 for all")
+	flags.Parse(args)
+
+	checkpoints, err := services.Chain.GetCheckpoints(ctx, models.Page{Limit: *limit})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECKPOINT\tAT\tWALLETS\tTRANSACTIONS\tROOT")
+	for _, c := range checkpoints {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", c.CheckpointId, c.At.UTC().Format(time.RFC3339), c.Wallets, c.Transactions, c.Root)
+	}
+
+	return w.Flush()
+}
+
+func verifyCheckpoint(ctx context.Context, services *service.Service, args []string) error {
+	flags := flag.NewFlagSet("verify-checkpoint", flag.ExitOnError)
+	checkpointId := flags.String("checkpoint", "", "checkpoint id")
+	flags.Parse(args)
+
+	id, err := uuid.Parse(*checkpointId)
+	if err != nil {
+		return fmt.Errorf("invalid -checkpoint: %w", err)
+	}
+
+	verification, err := services.Chain.VerifyCheckpoint(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("root matches: %t (computed %s)\nsignature valid: %t\nkey matches: %t\n",
+		verification.RootMatches, verification.ComputedRoot, verification.SignatureValid, verification.KeyMatches)
+	if !verification.Valid {
+		return errCheckpointInvalid
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, transactions []models.Transaction) error {
+	if transactions == nil {
+		transactions = []models.Transaction{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(transactions)
+}
+
+// writeCSV writes the transactions with their details, the metadata as
+// compact JSON. Missing details are left empty.
+func writeCSV(w io.Writer, transactions []models.Transaction) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"transaction_id", "wallet_id", "operation_type", "amount", "created_at",
+		"description", "category_id", "external_ref", "metadata"})
+	for _, transaction := range transactions {
+		var description, categoryId, externalRef string
+		if transaction.Description != nil {
+			description = *transaction.Description
+		}
+		if transaction.CategoryId != nil {
+			categoryId = transaction.CategoryId.String()
+		}
+		if transaction.ExternalRef != nil {
+			externalRef = *transaction.ExternalRef
+		}
+		var metadata bytes.Buffer
+		if len(transaction.Metadata) > 0 {
+			if err := json.Compact(&metadata, transaction.Metadata); err != nil {
+				return err
+			}
+		}
+
+		writer.Write([]string{
+			transaction.TransactionId.String(),
+			transaction.WalletId.String(),
+			string(transaction.OperationType),
+			strconv.FormatInt(transaction.Amount, 10),
+			transaction.CreatedAt.Format(time.RFC3339Nano),
+			description,
+			categoryId,
+			externalRef,
+			metadata.String(),
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// readPassword reads the password from the first line of stdin unless it was
+// given as a flag, so that it doesn't have to appear in the shell history.
+func readPassword(password *string) error {
+	if *password != "" {
+		return nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	*password = strings.TrimRight(line, "\r\n")
+	if *password == "" {
+		return errors.New("password is required")
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+// Command admin is the operator tool for the wallet service. It uses the same
+// configuration as the service, so it can be run from inside the container:
+//
+//	./admin list-wallets -username alice
+//	./admin adjust -wallet <id> -type DEPOSIT -amount 100 -reason "refund #42"
+//	./admin reconcile -open-cases
+//	./admin verify-chain -wallet <id>
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Yoshisoul/rest-wallets/internal/config"
+	"github.com/Yoshisoul/rest-wallets/internal/repository"
+	"github.com/Yoshisoul/rest-wallets/internal/service"
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logrus.Fatal(err.Error())
+	}
+
+	if err := cfg.Validate(); err != nil {
+		logrus.Fatalf("invalid configuration:\n%s", err.Error())
+	}
+
+	db, err := repository.NewDB(cfg.DB.Repository())
+	if err != nil {
+		logrus.Fatalf("error loading db: %s", err.Error())
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), cfg.Auth, cfg.Checkpoints)
+	if err := cmd.run(context.Background(), services, os.Args[2:]); err != nil {
+		db.Close()
+		logrus.Fatalf("%s: %s", os.Args[1], err.Error())
+	}
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: admin <command> [flags]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", name, commands[name].summary)
+	}
+}
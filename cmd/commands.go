@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Yoshisoul/rest-wallets/internal/config"
+	"github.com/Yoshisoul/rest-wallets/internal/repository"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	migrateUsage = "usage: migrate up | down N | status | force VERSION"
+	configUsage  = "usage: config print"
+)
+
+// runCommand runs a one-off subcommand instead of starting the server.
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(cfg, args[1:])
+	case "config":
+		return runConfig(cfg, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// runConfig prints the effective configuration with secrets redacted. It
+// prints even an invalid configuration, followed by what is wrong with it.
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg.Redacted()); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return nil
+}
+
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	if cfg.Storage != "db" {
+		return fmt.Errorf("storage %s has no migrations", cfg.Storage)
+	}
+
+	db, err := repository.NewDB(cfg.DB.Repository())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := repository.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch args[0] {
+	case "up":
+		if err := migrator.Up(); err != nil {
+			return err
+		}
+	case "down":
+		steps, err := intArg(args)
+		if err != nil {
+			return err
+		}
+		if err := migrator.Down(steps); err != nil {
+			return err
+		}
+	case "force":
+		version, err := intArg(args)
+		if err != nil {
+			return err
+		}
+		if err := migrator.Force(version); err != nil {
+			return err
+		}
+	case "status":
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("version: %d\ndirty: %t\nlatest: %d\n", status.Version, status.Dirty, status.Latest)
+	return nil
+}
+
+func migrateUp(db *sqlx.DB) error {
+	migrator, err := repository.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if err := migrator.Up(); err != nil {
+		return err
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	logrus.Printf("database schema is at version %d", status.Version)
+	return nil
+}
+
+func intArg(args []string) (int, error) {
+	if len(args) != 2 {
+		return 0, errors.New(migrateUsage)
+	}
+
+	n, err := strconv.Atoi(args[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q: %w", args[1], err)
+	}
+
+	return n, nil
+}
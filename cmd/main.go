@@ -2,72 +2,179 @@ package main
 
 import (
 	"context"
+	"flag"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	wallets "github.com/Yoshisoul/rest-wallets"
+	"github.com/Yoshisoul/rest-wallets/internal/config"
 	"github.com/Yoshisoul/rest-wallets/internal/handler"
+	"github.com/Yoshisoul/rest-wallets/internal/health"
+	"github.com/Yoshisoul/rest-wallets/internal/lifecycle"
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
+	"github.com/Yoshisoul/rest-wallets/internal/ratelimit"
 	"github.com/Yoshisoul/rest-wallets/internal/repository"
 	"github.com/Yoshisoul/rest-wallets/internal/service"
-	"github.com/joho/godotenv"
+	"github.com/Yoshisoul/rest-wallets/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
+	_ "modernc.org/sqlite"
 )
 
 func main() {
+	autoMigrate := flag.Bool("auto-migrate", false, "apply pending migrations before starting the server")
+	flag.Parse()
+
 	logrus.SetFormatter(new(logrus.JSONFormatter))
-	if err := initConfig(); err != nil {
-		logrus.Fatalf("error loading cfg .yml file: %s", err.Error())
+	cfg, err := config.Load()
+	if err != nil {
+		logrus.Fatal(err.Error())
 	}
 
-	if err := godotenv.Load("config.env"); err != nil {
-		logrus.Fatalf("error loading cfg .env file: %s", err.Error())
+	if flag.NArg() > 0 {
+		if err := runCommand(cfg, flag.Args()); err != nil {
+			logrus.Fatalf("error running %s command: %s", flag.Arg(0), err.Error())
+		}
+		return
 	}
 
-	db, err := repository.NewPostgresDB(repository.Config{
-		Host:     viper.GetString("db.host"),
-		Port:     viper.GetString("db.port"),
-		Username: viper.GetString("db.username"),
-		Password: os.Getenv("POSTGRES_PASSWORD"),
-		DBName:   viper.GetString("db.dbname"),
-		SSLMode:  viper.GetString("db.sslmode"),
-	})
+	if err := cfg.Validate(); err != nil {
+		logrus.Fatalf("invalid configuration:\n%s", err.Error())
+	}
+	cfg.Log.SetupLogging()
+	if cfg.Auth.SigningKey == config.DefaultSigningKey {
+		logrus.Warn("auth.signing_key is not set, tokens are signed with the built-in development key")
+	}
+
+	workers := new(health.Workers)
+	app := lifecycle.NewManager(cfg.Shutdown.Timeout, workers)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
-		logrus.Fatalf("error loading db: %s", err.Error())
+		logrus.Fatalf("error setting up tracing: %s", err.Error())
+	}
+	app.AddCloser("tracing", shutdownTracing)
+
+	var db *sqlx.DB
+	var cluster *repository.Cluster
+	var repos *repository.Repository
+	if cfg.Storage == "memory" {
+		logrus.Warn("storage is memory, all data is lost when the service stops")
+		repos = repository.NewMemoryRepository()
+	} else {
+		db, err = repository.NewDB(cfg.DB.Repository())
+		if err != nil {
+			logrus.Fatalf("error loading db: %s", err.Error())
+		}
+		app.AddCloser("db", func(context.Context) error { return db.Close() })
+
+		if *autoMigrate || cfg.Features.AutoMigrate {
+			if err := migrateUp(db); err != nil {
+				logrus.Fatalf("error applying migrations: %s", err.Error())
+			}
+		}
+
+		if len(cfg.DB.Replicas) > 0 {
+			cluster, err = repository.NewCluster(db, cfg.DB.Repository())
+			if err != nil {
+				logrus.Fatalf("error loading replicas: %s", err.Error())
+			}
+			app.AddCloser("replicas", func(context.Context) error { return cluster.Close() })
+			app.AddWorker("replica checks", cluster.Run)
+			repos = repository.NewClusterRepository(cluster)
+		} else {
+			repos = repository.NewRepository(db)
+		}
 	}
 
-	repos := repository.NewRepository(db)
-	services := service.NewService(repos)
-	handlers := handler.NewHandler(services)
+	services := service.NewService(repos, cfg.Auth, cfg.Checkpoints)
 
-	srv := new(wallets.Server)
-	go func() {
-		if err := srv.Run(viper.GetString("port"), handlers.InitRoutes()); err != nil {
-			logrus.Fatalf("error running http server: %s", err.Error())
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		var store ratelimit.Store = ratelimit.NewMemoryStore()
+		if cfg.RateLimit.Backend == "postgres" {
+			store = repository.NewRateLimitPostgres(db)
 		}
-	}()
+		limiter = ratelimit.NewLimiter(store, cfg.RateLimit.Limits())
+		app.AddWorker("rate limit sweeper", limiter.Run)
+	}
 
-	logrus.Print("Rest-wallets Started")
+	// The memory storage sums its transactions directly.
+	if cfg.Snapshots.Enabled && db != nil {
+		snapshotter := service.NewSnapshotter(services.Balance, cfg.Snapshots.Interval, cfg.Snapshots.Delay)
+		app.AddWorker("balance snapshots", snapshotter.Run)
+	}
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
-	<-quit
+	if cfg.Reconciliation.Enabled {
+		reconciler := service.NewReconciler(services.Reconciliation, cfg.Reconciliation.Interval, cfg.Reconciliation.OpenCases)
+		app.AddWorker("reconciliation", reconciler.Run)
+	}
+
+	if cfg.Checkpoints.Enabled {
+		checkpointer := service.NewCheckpointer(services.Chain, cfg.Checkpoints.Interval, cfg.Checkpoints.Delay)
+		app.AddWorker("checkpoints", checkpointer.Run)
+	}
+
+	handlers := handler.NewHandler(services, cfg.HTTP, cfg.Tracing.ServiceName, limiter)
+	router := handlers.InitRoutes()
+
+	checker := health.NewChecker(cfg.Health.CheckTimeout)
+	if db != nil {
+		checker.Register(db.DriverName(), health.Database(db))
+		checker.Register("migrations", health.Migrations(db))
+	}
+	if cluster != nil {
+		checker.Register("replicas", cluster.Check)
+	}
+	checker.Register("workers", workers.Check)
+	router.GET("/healthz", gin.WrapH(checker.LiveHandler()))
+	router.GET("/readyz", gin.WrapH(checker.ReadyHandler()))
+	app.OnDrain(func() {
+		checker.Drain()
+		time.Sleep(cfg.Health.DrainDelay)
+	})
+
+	if cfg.Metrics.Enabled {
+		if db != nil {
+			metrics.RegisterDBStats(db.DB, db.DriverName())
+		}
+		if cfg.Metrics.Port == "" {
+			router.GET("/metrics", gin.WrapH(metrics.Handler()))
+		} else {
+			// Scraped from inside the network, so served without TLS.
+			metricsCfg := cfg.HTTP
+			metricsCfg.Port = cfg.Metrics.Port
+			metricsCfg.TLS = config.TLSConfig{}
+			metricsSrv, err := wallets.NewServer(metricsCfg, metrics.Handler())
+			if err != nil {
+				logrus.Fatalf("error creating metrics server: %s", err.Error())
+			}
+			app.AddServer("metrics server", metricsSrv)
+		}
+	}
 
-	logrus.Print("Rest-wallets Shutting Down")
-	if err := srv.Shutdown(context.Background()); err != nil {
-		logrus.Errorf("error occured on server shutting down: %s", err.Error())
+	srv, err := wallets.NewServer(cfg.HTTP, router)
+	if err != nil {
+		logrus.Fatalf("error creating http server: %s", err.Error())
 	}
-	if err := db.Close(); err != nil {
-		logrus.Errorf("error occured on db connection close: %s", err.Error())
+	app.AddServer("http server", srv)
+	if cfg.HTTP.TLS.Enabled {
+		app.AddWorker("tls reload", srv.ReloadOnSignal)
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+
+	logrus.Print("Rest-wallets Started")
+	err = app.Run(ctx)
+	stop()
 	logrus.Print("Rest-wallets Exited")
-}
 
-func initConfig() error {
-	viper.AddConfigPath("configs")
-	viper.SetConfigName("config")
-	return viper.ReadInConfig()
+	if err != nil {
+		logrus.Errorf("error occured on shutting down: %s", err.Error())
+		os.Exit(1)
+	}
 }
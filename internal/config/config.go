@@ -0,0 +1,669 @@
+// Package config loads the settings shared by the service and the admin
+// tool.
+//
+// Values come from, in increasing priority: built-in defaults,
+// configs/config.yml, and environment variables named after the key with the
+// WALLETS_ prefix (http.read_timeout is WALLETS_HTTP_READ_TIMEOUT). config.env
+// is loaded into the environment first when it exists. Secrets can also be
+// read from a file named by the variable with a _FILE suffix, for example
+// WALLETS_AUTH_SIGNING_KEY_FILE=/run/secrets/signing_key.
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/ratelimit"
+	"github.com/Yoshisoul/rest-wallets/internal/repository"
+	"github.com/joho/godotenv"
+	"github.com/mitchellh/mapstructure"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	envPrefix = "WALLETS"
+	redacted  = "[REDACTED]"
+
+	// DefaultSalt and DefaultSigningKey are the values the service used before
+	// they became configurable. The salt must stay the same for existing
+	// password hashes to keep working; the signing key should be overridden.
+	DefaultSalt       = "sdjkf598234yhskjdfg"
+	DefaultSigningKey = "jksh45j6hKDSFGHe64f"
+)
+
+// secretKeys are never printed and can be read from files.
+var secretKeys = []string{"db.password", "auth.salt", "auth.signing_key", "checkpoints.signing_key"}
+
+type Config struct {
+	// Storage is db, the database chosen by db.driver, or memory to keep all
+	// data in the process, which is lost on exit and only meant for tests and
+	// local development.
+	Storage        string               `yaml:"storage"`
+	HTTP           HTTPConfig           `yaml:"http"`
+	DB             DBConfig             `yaml:"db"`
+	Auth           AuthConfig           `yaml:"auth"`
+	Log            LogConfig            `yaml:"log"`
+	Metrics        MetricsConfig        `yaml:"metrics"`
+	Tracing        TracingConfig        `yaml:"tracing"`
+	Health         HealthConfig         `yaml:"health"`
+	Shutdown       ShutdownConfig       `yaml:"shutdown"`
+	RateLimit      RateLimitConfig      `yaml:"rate_limit"`
+	Snapshots      SnapshotsConfig      `yaml:"snapshots"`
+	Reconciliation ReconciliationConfig `yaml:"reconciliation"`
+	Checkpoints    CheckpointsConfig    `yaml:"checkpoints"`
+	Features       FeaturesConfig       `yaml:"features"`
+}
+
+type HTTPConfig struct {
+	Port              string        `yaml:"port"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes"`
+	MaxBodyBytes      int64         `yaml:"max_body_bytes"`
+	// H2C serves HTTP/2 without TLS, for use behind a proxy that terminates
+	// TLS. With TLS enabled HTTP/2 is always available.
+	H2C bool `yaml:"h2c"`
+	// TrustedProxies are the addresses or CIDRs whose X-Forwarded-For header
+	// is trusted for the client IP, which rate limits are keyed by.
+	TrustedProxies []string       `yaml:"trusted_proxies"`
+	TLS            TLSConfig      `yaml:"tls"`
+	CORS           CORSConfig     `yaml:"cors"`
+	Security       SecurityConfig `yaml:"security"`
+	// ValidateOpenAPI checks requests and responses against the OpenAPI
+	// document. It is meant for development and tests: responses are
+	// buffered, and ones that break the contract are replaced with a 500.
+	ValidateOpenAPI bool `yaml:"validate_openapi"`
+}
+
+// CORSConfig lets browsers on AllowedOrigins call the API. CORS is off while
+// AllowedOrigins is empty.
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+type SecurityConfig struct {
+	// HSTSMaxAge is sent in Strict-Transport-Security, 0 leaves the header
+	// out. Browsers only honour it over HTTPS.
+	HSTSMaxAge     time.Duration `yaml:"hsts_max_age"`
+	ReferrerPolicy string        `yaml:"referrer_policy"`
+}
+
+// TLSConfig is reloaded from disk on SIGHUP, except for the client CA.
+type TLSConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	MinVersion string `yaml:"min_version"`
+	// ClientAuth is none, optional (verify a certificate when one is
+	// presented) or require.
+	ClientAuth   string `yaml:"client_auth"`
+	ClientCAFile string `yaml:"client_ca_file"`
+	// ClientIdentities maps the common name of verified client certificates
+	// to service identities. Certificates not listed here are rejected.
+	ClientIdentities []ClientIdentity `yaml:"client_identities"`
+}
+
+type ClientIdentity struct {
+	CommonName string `yaml:"common_name"`
+	Identity   string `yaml:"identity"`
+}
+
+type DBConfig struct {
+	// Driver is postgres or sqlite. SQLite keeps the database in the file at
+	// Path and ignores the connection settings.
+	Driver          string        `yaml:"driver"`
+	Path            string        `yaml:"path"`
+	Host            string        `yaml:"host"`
+	Port            string        `yaml:"port"`
+	Username        string        `yaml:"username"`
+	Password        string        `yaml:"password"`
+	DBName          string        `yaml:"dbname"`
+	SSLMode         string        `yaml:"sslmode"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	// ConnectTimeout is how long the first connection to Postgres is retried
+	// at startup, waiting ConnectBackoff after the first failure and twice as
+	// long after each further one.
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+	ConnectBackoff time.Duration `yaml:"connect_backoff"`
+	// BreakerThreshold failed connections in a row make requests fail fast
+	// with 503 for BreakerCooldown, after which a probe connection is tried.
+	// Zero disables the breaker.
+	BreakerThreshold int           `yaml:"breaker_threshold"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown"`
+	// Replicas are Postgres read replicas that take the reads of the wallet
+	// and transaction queries. They use the credentials of the primary.
+	Replicas []ReplicaConfig `yaml:"replicas"`
+	// ReplicaMaxLag is the replication lag past which a replica stops taking
+	// reads until it catches up.
+	ReplicaMaxLag        time.Duration `yaml:"replica_max_lag"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval"`
+	// ReadYourWritesWindow is how long a client reads from the primary after
+	// it wrote, so it sees its own writes.
+	ReadYourWritesWindow time.Duration `yaml:"read_your_writes_window"`
+}
+
+type ReplicaConfig struct {
+	Host string `yaml:"host"`
+	Port string `yaml:"port"`
+}
+
+type AuthConfig struct {
+	Salt       string        `yaml:"salt"`
+	SigningKey string        `yaml:"signing_key"`
+	TokenTTL   time.Duration `yaml:"token_ttl"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+type MetricsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Port serves /metrics on a separate listener when set, keeping it off
+	// the public port. When empty, /metrics is served next to the API.
+	Port string `yaml:"port"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, stdout or otlp. With none, trace ids are still
+	// generated and propagated, so they show up in the logs.
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the host:port of an OTLP/HTTP collector. The standard
+	// OTEL_EXPORTER_OTLP_* variables are honoured as well.
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+	ServiceName string  `yaml:"service_name"`
+}
+
+type HealthConfig struct {
+	// CheckTimeout bounds each readiness check.
+	CheckTimeout time.Duration `yaml:"check_timeout"`
+	// DrainDelay is how long /readyz fails before the server stops accepting
+	// requests on shutdown, long enough for load balancers to notice.
+	DrainDelay time.Duration `yaml:"drain_delay"`
+}
+
+type ShutdownConfig struct {
+	// Timeout bounds stopping servers, workers and the database pool once
+	// draining is over. Requests still running after it are cancelled.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// RateLimitGroups are the route groups limits can be set for.
+var RateLimitGroups = []string{"default", "auth", "transactions"}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Backend is memory, with limits per instance, or postgres, with limits
+	// shared by all instances.
+	Backend string                    `yaml:"backend"`
+	Groups  map[string]RateLimitGroup `yaml:"groups"`
+}
+
+// RateLimitGroup allows Requests per Period on average, and bursts of up to
+// Burst requests.
+type RateLimitGroup struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+	Burst    int           `yaml:"burst"`
+}
+
+func (c RateLimitConfig) Limits() map[string]ratelimit.Limit {
+	limits := make(map[string]ratelimit.Limit, len(c.Groups))
+	for name, group := range c.Groups {
+		limits[name] = ratelimit.Limit{
+			Rate:   float64(group.Requests) / group.Period.Seconds(),
+			Burst:  group.Burst,
+			Window: group.Period,
+		}
+	}
+
+	return limits
+}
+
+// SnapshotsConfig schedules the balance snapshots that past balances are
+// computed from. They are taken at every multiple of Interval in UTC, Delay
+// later so that transactions dated before have committed.
+type SnapshotsConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+	Delay    time.Duration `yaml:"delay"`
+}
+
+// ReconciliationConfig schedules the reconciliation of wallet balances with
+// their transactions, run at every multiple of Interval in UTC. OpenCases
+// opens a correction case for each mismatch found.
+type ReconciliationConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Interval  time.Duration `yaml:"interval"`
+	OpenCases bool          `yaml:"open_cases"`
+}
+
+// CheckpointsConfig schedules the signed checkpoints over the transaction
+// hash chains, published at every multiple of Interval in UTC, Delay later
+// so that transactions dated before have committed. SigningKey is the
+// base64 32-byte Ed25519 seed the checkpoints are signed with.
+type CheckpointsConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	Interval   time.Duration `yaml:"interval"`
+	Delay      time.Duration `yaml:"delay"`
+	SigningKey string        `yaml:"signing_key"`
+}
+
+// PrivateKey decodes SigningKey. It is nil when no key is set.
+func (c CheckpointsConfig) PrivateKey() (ed25519.PrivateKey, error) {
+	if c.SigningKey == "" {
+		return nil, nil
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(c.SigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding signing key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("signing key must be %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+type FeaturesConfig struct {
+	// AutoMigrate applies pending migrations at startup, like --auto-migrate.
+	AutoMigrate bool `yaml:"auto_migrate"`
+}
+
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("storage", "db")
+
+	v.SetDefault("http.port", "8080")
+	v.SetDefault("http.read_timeout", 10*time.Second)
+	v.SetDefault("http.read_header_timeout", 5*time.Second)
+	v.SetDefault("http.write_timeout", 10*time.Second)
+	v.SetDefault("http.idle_timeout", 2*time.Minute)
+	v.SetDefault("http.max_header_bytes", 1<<20)
+	v.SetDefault("http.max_body_bytes", 1<<20)
+	v.SetDefault("http.h2c", false)
+	v.SetDefault("http.trusted_proxies", []string{})
+	v.SetDefault("http.cors.allowed_origins", []string{})
+	v.SetDefault("http.cors.allowed_methods", []string{"GET", "POST", "PATCH", "DELETE"})
+	v.SetDefault("http.cors.allowed_headers", []string{"Authorization", "Content-Type", "If-Match", "If-None-Match", "X-Request-ID"})
+	v.SetDefault("http.cors.exposed_headers", []string{"ETag", "Retry-After", "X-Request-ID",
+		"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"})
+	v.SetDefault("http.cors.allow_credentials", false)
+	v.SetDefault("http.cors.max_age", 12*time.Hour)
+	v.SetDefault("http.security.hsts_max_age", 365*24*time.Hour)
+	v.SetDefault("http.security.referrer_policy", "no-referrer")
+	v.SetDefault("http.validate_openapi", false)
+	v.SetDefault("http.tls.enabled", false)
+	v.SetDefault("http.tls.cert_file", "")
+	v.SetDefault("http.tls.key_file", "")
+	v.SetDefault("http.tls.min_version", "1.2")
+	v.SetDefault("http.tls.client_auth", "none")
+	v.SetDefault("http.tls.client_ca_file", "")
+
+	v.SetDefault("db.driver", "postgres")
+	v.SetDefault("db.path", "wallets.db")
+	v.SetDefault("db.host", "localhost")
+	v.SetDefault("db.port", "5432")
+	v.SetDefault("db.username", "postgres")
+	v.SetDefault("db.password", "")
+	v.SetDefault("db.dbname", "postgres")
+	v.SetDefault("db.sslmode", "disable")
+	v.SetDefault("db.max_open_conns", 0)
+	v.SetDefault("db.max_idle_conns", 2)
+	v.SetDefault("db.conn_max_lifetime", time.Duration(0))
+	v.SetDefault("db.connect_timeout", time.Minute)
+	v.SetDefault("db.connect_backoff", 500*time.Millisecond)
+	v.SetDefault("db.breaker_threshold", 5)
+	v.SetDefault("db.breaker_cooldown", 10*time.Second)
+	v.SetDefault("db.replicas", []ReplicaConfig{})
+	v.SetDefault("db.replica_max_lag", 2*time.Second)
+	v.SetDefault("db.replica_check_interval", 2*time.Second)
+	v.SetDefault("db.read_your_writes_window", 5*time.Second)
+
+	v.SetDefault("auth.salt", DefaultSalt)
+	v.SetDefault("auth.signing_key", DefaultSigningKey)
+	v.SetDefault("auth.token_ttl", 12*time.Hour)
+
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.format", "json")
+
+	v.SetDefault("metrics.enabled", true)
+	v.SetDefault("metrics.port", "")
+
+	v.SetDefault("tracing.exporter", "none")
+	v.SetDefault("tracing.endpoint", "localhost:4318")
+	v.SetDefault("tracing.insecure", false)
+	v.SetDefault("tracing.sample_ratio", 1.0)
+	v.SetDefault("tracing.service_name", "rest-wallets")
+
+	v.SetDefault("health.check_timeout", 2*time.Second)
+	v.SetDefault("health.drain_delay", 5*time.Second)
+
+	v.SetDefault("shutdown.timeout", 20*time.Second)
+
+	v.SetDefault("rate_limit.enabled", true)
+	v.SetDefault("rate_limit.backend", "memory")
+	for group, limit := range map[string]RateLimitGroup{
+		"default":      {Requests: 300, Period: time.Minute, Burst: 60},
+		"auth":         {Requests: 10, Period: time.Minute, Burst: 5},
+		"transactions": {Requests: 60, Period: time.Minute, Burst: 10},
+	} {
+		v.SetDefault("rate_limit.groups."+group+".requests", limit.Requests)
+		v.SetDefault("rate_limit.groups."+group+".period", limit.Period)
+		v.SetDefault("rate_limit.groups."+group+".burst", limit.Burst)
+	}
+
+	v.SetDefault("snapshots.enabled", true)
+	v.SetDefault("snapshots.interval", 24*time.Hour)
+	v.SetDefault("snapshots.delay", time.Minute)
+
+	v.SetDefault("reconciliation.enabled", true)
+	v.SetDefault("reconciliation.interval", 24*time.Hour)
+	v.SetDefault("reconciliation.open_cases", true)
+
+	v.SetDefault("checkpoints.enabled", false)
+	v.SetDefault("checkpoints.interval", time.Hour)
+	v.SetDefault("checkpoints.delay", time.Minute)
+	v.SetDefault("checkpoints.signing_key", "")
+
+	v.SetDefault("features.auto_migrate", false)
+}
+
+// Load reads configs/config.yml and config.env relative to the working
+// directory. The result is not validated, call Validate before using it.
+func Load() (*Config, error) {
+	return load("configs", "config.env")
+}
+
+func load(configDir, envFile string) (*Config, error) {
+	if err := godotenv.Load(envFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error loading cfg .env file: %w", err)
+	}
+
+	v := viper.New()
+	setDefaults(v)
+
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	// POSTGRES_PASSWORD is shared with the postgres container.
+	if err := v.BindEnv("db.password", envName("db.password"), "POSTGRES_PASSWORD"); err != nil {
+		return nil, err
+	}
+
+	v.AddConfigPath(configDir)
+	v.SetConfigName("config")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("error loading cfg .yml file: %w", err)
+	}
+
+	if err := loadSecretFiles(v); err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	err := v.Unmarshal(&cfg, func(dc *mapstructure.DecoderConfig) {
+		dc.TagName = "yaml"
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error decoding config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+func loadSecretFiles(v *viper.Viper) error {
+	for _, key := range secretKeys {
+		path := os.Getenv(envName(key) + "_FILE")
+		if path == "" && key == "db.password" {
+			path = os.Getenv("POSTGRES_PASSWORD_FILE")
+		}
+		if path == "" {
+			continue
+		}
+
+		secret, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: error reading secret file: %w", key, err)
+		}
+
+		v.Set(key, strings.TrimRight(string(secret), "\r\n"))
+	}
+
+	return nil
+}
+
+func envName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Validate reports every invalid setting at once, each prefixed with its key.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(oneOf(c.Storage, "db", "memory"), "storage", "must be db or memory, got %q", c.Storage)
+
+	check(isPort(c.HTTP.Port), "http.port", "must be a port number, got %q", c.HTTP.Port)
+	check(c.HTTP.ReadTimeout > 0, "http.read_timeout", "must be positive, got %s", c.HTTP.ReadTimeout)
+	check(c.HTTP.WriteTimeout > 0, "http.write_timeout", "must be positive, got %s", c.HTTP.WriteTimeout)
+	check(c.HTTP.ReadHeaderTimeout > 0, "http.read_header_timeout", "must be positive, got %s", c.HTTP.ReadHeaderTimeout)
+	check(c.HTTP.IdleTimeout >= 0, "http.idle_timeout", "must not be negative, got %s", c.HTTP.IdleTimeout)
+	check(c.HTTP.MaxHeaderBytes > 0, "http.max_header_bytes", "must be positive, got %d", c.HTTP.MaxHeaderBytes)
+	check(c.HTTP.MaxBodyBytes > 0, "http.max_body_bytes", "must be positive, got %d", c.HTTP.MaxBodyBytes)
+	for _, proxy := range c.HTTP.TrustedProxies {
+		check(isIPOrCIDR(proxy), "http.trusted_proxies", "must be IP addresses or CIDRs, got %q", proxy)
+	}
+	c.HTTP.TLS.validate(check)
+	for _, origin := range c.HTTP.CORS.AllowedOrigins {
+		check(origin == "*" || isOrigin(origin), "http.cors.allowed_origins", "must be * or scheme://host[:port], got %q", origin)
+		check(origin != "*" || !c.HTTP.CORS.AllowCredentials, "http.cors.allowed_origins", "* can't be combined with allow_credentials")
+	}
+	check(c.HTTP.CORS.MaxAge >= 0, "http.cors.max_age", "must not be negative, got %s", c.HTTP.CORS.MaxAge)
+	check(c.HTTP.Security.HSTSMaxAge >= 0, "http.security.hsts_max_age", "must not be negative, got %s", c.HTTP.Security.HSTSMaxAge)
+
+	check(oneOf(c.DB.Driver, "postgres", "sqlite"), "db.driver", "must be postgres or sqlite, got %q", c.DB.Driver)
+	if c.DB.Driver == "sqlite" {
+		check(c.DB.Path != "", "db.path", "must be set for the sqlite driver")
+	} else {
+		check(c.DB.Host != "", "db.host", "must be set")
+		check(isPort(c.DB.Port), "db.port", "must be a port number, got %q", c.DB.Port)
+		check(c.DB.Username != "", "db.username", "must be set")
+		check(c.DB.DBName != "", "db.dbname", "must be set")
+		check(oneOf(c.DB.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full"),
+			"db.sslmode", "unknown mode %q", c.DB.SSLMode)
+	}
+	check(c.DB.MaxOpenConns >= 0, "db.max_open_conns", "must not be negative, got %d", c.DB.MaxOpenConns)
+	check(c.DB.MaxIdleConns >= 0, "db.max_idle_conns", "must not be negative, got %d", c.DB.MaxIdleConns)
+	check(c.DB.MaxOpenConns == 0 || c.DB.MaxIdleConns <= c.DB.MaxOpenConns,
+		"db.max_idle_conns", "must not exceed db.max_open_conns (%d), got %d", c.DB.MaxOpenConns, c.DB.MaxIdleConns)
+	check(c.DB.ConnMaxLifetime >= 0, "db.conn_max_lifetime", "must not be negative, got %s", c.DB.ConnMaxLifetime)
+	check(c.DB.ConnectTimeout >= 0, "db.connect_timeout", "must not be negative, got %s", c.DB.ConnectTimeout)
+	check(c.DB.ConnectTimeout == 0 || c.DB.ConnectBackoff > 0, "db.connect_backoff", "must be positive, got %s", c.DB.ConnectBackoff)
+	check(c.DB.BreakerThreshold >= 0, "db.breaker_threshold", "must not be negative, got %d", c.DB.BreakerThreshold)
+	check(c.DB.BreakerThreshold == 0 || c.DB.BreakerCooldown > 0, "db.breaker_cooldown", "must be positive, got %s", c.DB.BreakerCooldown)
+	check(len(c.DB.Replicas) == 0 || c.DB.Driver == "postgres", "db.replicas", "require db.driver postgres")
+	for _, replica := range c.DB.Replicas {
+		check(replica.Host != "" && isPort(replica.Port), "db.replicas", "host and port must be set, got %q", net.JoinHostPort(replica.Host, replica.Port))
+	}
+	check(c.DB.ReplicaMaxLag >= 0, "db.replica_max_lag", "must not be negative, got %s", c.DB.ReplicaMaxLag)
+	check(c.DB.ReplicaCheckInterval > 0, "db.replica_check_interval", "must be positive, got %s", c.DB.ReplicaCheckInterval)
+	check(c.DB.ReadYourWritesWindow >= c.DB.ReplicaMaxLag, "db.read_your_writes_window",
+		"must not be shorter than db.replica_max_lag (%s), got %s", c.DB.ReplicaMaxLag, c.DB.ReadYourWritesWindow)
+
+	check(c.Auth.Salt != "", "auth.salt", "must be set with %s or %s_FILE", envName("auth.salt"), envName("auth.salt"))
+	check(c.Auth.SigningKey != "", "auth.signing_key", "must be set with %s or %s_FILE", envName("auth.signing_key"), envName("auth.signing_key"))
+	check(c.Auth.TokenTTL > 0, "auth.token_ttl", "must be positive, got %s", c.Auth.TokenTTL)
+
+	_, err := logrus.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level", "unknown level %q", c.Log.Level)
+	check(oneOf(c.Log.Format, "json", "text"), "log.format", "must be json or text, got %q", c.Log.Format)
+
+	check(c.Metrics.Port == "" || isPort(c.Metrics.Port), "metrics.port", "must be empty or a port number, got %q", c.Metrics.Port)
+	check(c.Metrics.Port == "" || c.Metrics.Port != c.HTTP.Port, "metrics.port", "must differ from http.port")
+
+	check(oneOf(c.Tracing.Exporter, "none", "stdout", "otlp"), "tracing.exporter", "must be none, stdout or otlp, got %q", c.Tracing.Exporter)
+	check(c.Tracing.Exporter != "otlp" || c.Tracing.Endpoint != "", "tracing.endpoint", "must be set for the otlp exporter")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	check(c.Tracing.ServiceName != "", "tracing.service_name", "must be set")
+
+	check(c.Health.CheckTimeout > 0, "health.check_timeout", "must be positive, got %s", c.Health.CheckTimeout)
+	check(c.Health.DrainDelay >= 0, "health.drain_delay", "must not be negative, got %s", c.Health.DrainDelay)
+
+	check(oneOf(c.RateLimit.Backend, "memory", "postgres"), "rate_limit.backend", "must be memory or postgres, got %q", c.RateLimit.Backend)
+	check(c.RateLimit.Backend != "postgres" || (c.Storage == "db" && c.DB.Driver == "postgres"), "rate_limit.backend", "postgres requires storage db with db.driver postgres")
+	for name, group := range c.RateLimit.Groups {
+		key := "rate_limit.groups." + name
+		check(oneOf(name, RateLimitGroups...), key, "unknown group, expected one of %s", strings.Join(RateLimitGroups, ", "))
+		check(group.Requests > 0, key+".requests", "must be positive, got %d", group.Requests)
+		check(group.Period > 0, key+".period", "must be positive, got %s", group.Period)
+		check(group.Burst > 0, key+".burst", "must be positive, got %d", group.Burst)
+	}
+
+	check(c.Snapshots.Interval >= time.Minute, "snapshots.interval", "must be at least 1m, got %s", c.Snapshots.Interval)
+	check(c.Snapshots.Delay >= 0 && c.Snapshots.Delay < c.Snapshots.Interval, "snapshots.delay",
+		"must not be negative or exceed snapshots.interval (%s), got %s", c.Snapshots.Interval, c.Snapshots.Delay)
+	check(c.Reconciliation.Interval >= time.Minute, "reconciliation.interval", "must be at least 1m, got %s", c.Reconciliation.Interval)
+
+	check(c.Checkpoints.Interval >= time.Minute, "checkpoints.interval", "must be at least 1m, got %s", c.Checkpoints.Interval)
+	check(c.Checkpoints.Delay >= 0 && c.Checkpoints.Delay < c.Checkpoints.Interval, "checkpoints.delay",
+		"must not be negative or exceed checkpoints.interval (%s), got %s", c.Checkpoints.Interval, c.Checkpoints.Delay)
+	check(!c.Checkpoints.Enabled || c.Checkpoints.SigningKey != "", "checkpoints.signing_key",
+		"must be set with %s or %s_FILE when checkpoints are enabled", envName("checkpoints.signing_key"), envName("checkpoints.signing_key"))
+	_, err = c.Checkpoints.PrivateKey()
+	check(err == nil, "checkpoints.signing_key", "%v", err)
+
+	check(c.Shutdown.Timeout > 0, "shutdown.timeout", "must be positive, got %s", c.Shutdown.Timeout)
+
+	return errors.Join(errs...)
+}
+
+func (c TLSConfig) validate(check func(ok bool, key, format string, args ...any)) {
+	check(oneOf(c.ClientAuth, "none", "optional", "require"), "http.tls.client_auth", "must be none, optional or require, got %q", c.ClientAuth)
+	if !c.Enabled {
+		check(c.ClientAuth == "none", "http.tls.client_auth", "requires http.tls.enabled")
+		return
+	}
+
+	check(c.CertFile != "", "http.tls.cert_file", "must be set when TLS is enabled")
+	check(c.KeyFile != "", "http.tls.key_file", "must be set when TLS is enabled")
+	check(oneOf(c.MinVersion, "1.2", "1.3"), "http.tls.min_version", "must be 1.2 or 1.3, got %q", c.MinVersion)
+	check(c.ClientAuth == "none" || c.ClientCAFile != "", "http.tls.client_ca_file", "must be set when client_auth is %s", c.ClientAuth)
+
+	seen := make(map[string]bool, len(c.ClientIdentities))
+	for _, identity := range c.ClientIdentities {
+		check(identity.CommonName != "" && identity.Identity != "", "http.tls.client_identities", "common_name and identity must be set")
+		check(!seen[identity.CommonName], "http.tls.client_identities", "duplicate common_name %q", identity.CommonName)
+		seen[identity.CommonName] = true
+	}
+}
+
+// Redacted returns a copy of the config that is safe to print.
+func (c Config) Redacted() Config {
+	for _, secret := range []*string{&c.DB.Password, &c.Auth.Salt, &c.Auth.SigningKey, &c.Checkpoints.SigningKey} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+
+	return c
+}
+
+func (c DBConfig) Repository() repository.Config {
+	replicas := make([]repository.Replica, len(c.Replicas))
+	for i, replica := range c.Replicas {
+		replicas[i] = repository.Replica{Host: replica.Host, Port: replica.Port}
+	}
+
+	return repository.Config{
+		Driver:          c.Driver,
+		Path:            c.Path,
+		Host:            c.Host,
+		Port:            c.Port,
+		Username:        c.Username,
+		Password:        c.Password,
+		DBName:          c.DBName,
+		SSLMode:         c.SSLMode,
+		MaxOpenConns:    c.MaxOpenConns,
+		MaxIdleConns:    c.MaxIdleConns,
+		ConnMaxLifetime: c.ConnMaxLifetime,
+
+		ConnectTimeout:   c.ConnectTimeout,
+		ConnectBackoff:   c.ConnectBackoff,
+		BreakerThreshold: c.BreakerThreshold,
+		BreakerCooldown:  c.BreakerCooldown,
+
+		Replicas:             replicas,
+		ReplicaMaxLag:        c.ReplicaMaxLag,
+		ReplicaCheckInterval: c.ReplicaCheckInterval,
+		ReadYourWritesWindow: c.ReadYourWritesWindow,
+	}
+}
+
+// SetupLogging applies the log settings to the standard logrus logger.
+func (c LogConfig) SetupLogging() {
+	level, err := logrus.ParseLevel(c.Level)
+	if err == nil {
+		logrus.SetLevel(level)
+	}
+
+	if c.Format == "text" {
+		logrus.SetFormatter(new(logrus.TextFormatter))
+	} else {
+		logrus.SetFormatter(new(logrus.JSONFormatter))
+	}
+}
+
+func isPort(s string) bool {
+	port, err := strconv.Atoi(s)
+	return err == nil && port > 0 && port <= 65535
+}
+
+func isOrigin(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == "" && u.RawQuery == ""
+}
+
+func isIPOrCIDR(s string) bool {
+	if _, _, err := net.ParseCIDR(s); err == nil {
+		return true
+	}
+
+	return net.ParseIP(s) != nil
+}
+
+func oneOf(s string, values ...string) bool {
+	for _, value := range values {
+		if s == value {
+			return true
+		}
+	}
+
+	return false
+}
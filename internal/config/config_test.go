@@ -0,0 +1,183 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, yml string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte(yml), 0o600))
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Defaults and file", func(t *testing.T) {
+		dir := writeConfig(t, "db:\n  host: db\n  max_open_conns: 10\n")
+
+		cfg, err := load(dir, filepath.Join(dir, "missing.env"))
+		require.NoError(t, err)
+		assert.Equal(t, "db", cfg.Storage)
+		assert.Equal(t, "postgres", cfg.DB.Driver)
+		assert.Equal(t, "8080", cfg.HTTP.Port)
+		assert.Equal(t, 10*time.Second, cfg.HTTP.ReadTimeout)
+		assert.Equal(t, "db", cfg.DB.Host)
+		assert.Equal(t, 10, cfg.DB.MaxOpenConns)
+		assert.Equal(t, DefaultSalt, cfg.Auth.Salt)
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("Environment overrides", func(t *testing.T) {
+		dir := writeConfig(t, "http:\n  port: \"8080\"\n")
+		t.Setenv("WALLETS_HTTP_PORT", "9090")
+		t.Setenv("WALLETS_AUTH_TOKEN_TTL", "30m")
+		t.Setenv("POSTGRES_PASSWORD", "from-postgres-env")
+
+		cfg, err := load(dir, filepath.Join(dir, "missing.env"))
+		require.NoError(t, err)
+		assert.Equal(t, "9090", cfg.HTTP.Port)
+		assert.Equal(t, 30*time.Minute, cfg.Auth.TokenTTL)
+		assert.Equal(t, "from-postgres-env", cfg.DB.Password)
+	})
+
+	t.Run("Env file", func(t *testing.T) {
+		dir := writeConfig(t, "")
+		envFile := filepath.Join(dir, "config.env")
+		require.NoError(t, os.WriteFile(envFile, []byte("WALLETS_DB_PASSWORD=from-env-file\n"), 0o600))
+		t.Cleanup(func() { os.Unsetenv("WALLETS_DB_PASSWORD") })
+
+		cfg, err := load(dir, envFile)
+		require.NoError(t, err)
+		assert.Equal(t, "from-env-file", cfg.DB.Password)
+	})
+
+	t.Run("Secret files", func(t *testing.T) {
+		dir := writeConfig(t, "")
+		secret := filepath.Join(dir, "signing_key")
+		require.NoError(t, os.WriteFile(secret, []byte("file-key\n"), 0o600))
+		t.Setenv("WALLETS_AUTH_SIGNING_KEY", "env-key")
+		t.Setenv("WALLETS_AUTH_SIGNING_KEY_FILE", secret)
+
+		cfg, err := load(dir, filepath.Join(dir, "missing.env"))
+		require.NoError(t, err)
+		assert.Equal(t, "file-key", cfg.Auth.SigningKey)
+	})
+
+	t.Run("Missing secret file", func(t *testing.T) {
+		dir := writeConfig(t, "")
+		t.Setenv("WALLETS_DB_PASSWORD_FILE", filepath.Join(dir, "missing"))
+
+		_, err := load(dir, filepath.Join(dir, "missing.env"))
+		assert.ErrorContains(t, err, "db.password")
+	})
+
+	t.Run("Missing config file", func(t *testing.T) {
+		_, err := load(t.TempDir(), "missing.env")
+		assert.Error(t, err)
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	dir := writeConfig(t, "")
+	cfg, err := load(dir, filepath.Join(dir, "missing.env"))
+	require.NoError(t, err)
+
+	cfg.HTTP.Port = "http"
+	cfg.DB.SSLMode = "sometimes"
+	cfg.DB.MaxOpenConns = 5
+	cfg.DB.MaxIdleConns = 10
+	cfg.Auth.SigningKey = ""
+	cfg.Log.Level = "loud"
+	cfg.Tracing.SampleRatio = 1.5
+	cfg.HTTP.TLS.ClientAuth = "require"
+	cfg.HTTP.CORS.AllowedOrigins = []string{"https://app.example.com/path"}
+	cfg.Storage = "memory"
+	cfg.RateLimit.Backend = "postgres"
+	cfg.Snapshots.Delay = 2 * cfg.Snapshots.Interval
+	cfg.Reconciliation.Interval = time.Second
+	cfg.Checkpoints.Enabled = true
+
+	err = cfg.Validate()
+	require.Error(t, err)
+	for _, key := range []string{"http.port", "db.sslmode", "db.max_idle_conns", "auth.signing_key", "log.level", "tracing.sample_ratio", "http.tls.client_auth", "http.cors.allowed_origins", "rate_limit.backend", "snapshots.delay", "reconciliation.interval", "checkpoints.signing_key"} {
+		assert.ErrorContains(t, err, key)
+	}
+	assert.NotContains(t, err.Error(), "db.host")
+}
+
+func TestConfig_ValidateSQLite(t *testing.T) {
+	dir := writeConfig(t, "db:\n  driver: sqlite\n  path: \"\"\n  host: \"\"\n")
+	cfg, err := load(dir, filepath.Join(dir, "missing.env"))
+	require.NoError(t, err)
+
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "db.path")
+	assert.NotContains(t, err.Error(), "db.host")
+
+	cfg.DB.Path = "wallets.db"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestConfig_ValidateReplicas(t *testing.T) {
+	dir := writeConfig(t, "db:\n  driver: sqlite\n  replicas:\n    - host: replica\n      port: \"5432\"\n  read_your_writes_window: 1s\n")
+	cfg, err := load(dir, filepath.Join(dir, "missing.env"))
+	require.NoError(t, err)
+	require.Len(t, cfg.DB.Replicas, 1)
+
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "db.replicas")
+	assert.ErrorContains(t, err, "db.read_your_writes_window")
+
+	cfg.DB.Driver = "postgres"
+	cfg.DB.ReadYourWritesWindow = cfg.DB.ReplicaMaxLag
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, "replica", cfg.DB.Repository().Replicas[0].Host)
+}
+
+func TestConfig_ValidateCheckpoints(t *testing.T) {
+	dir := writeConfig(t, "checkpoints:\n  enabled: true\n  signing_key: \"c2hvcnQ=\"\n")
+	cfg, err := load(dir, filepath.Join(dir, "missing.env"))
+	require.NoError(t, err)
+
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "checkpoints.signing_key: signing key must be 32 bytes, got 5")
+
+	cfg.Checkpoints.SigningKey = base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize))
+	require.NoError(t, cfg.Validate())
+	key, err := cfg.Checkpoints.PrivateKey()
+	require.NoError(t, err)
+	assert.Len(t, key, ed25519.PrivateKeySize)
+
+	cfg.Checkpoints.SigningKey = ""
+	cfg.Checkpoints.Enabled = false
+	require.NoError(t, cfg.Validate())
+	key, err = cfg.Checkpoints.PrivateKey()
+	require.NoError(t, err)
+	assert.Nil(t, key)
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	cfg := Config{
+		DB:          DBConfig{Host: "db", Password: "secret"},
+		Auth:        AuthConfig{Salt: "salt", SigningKey: ""},
+		Checkpoints: CheckpointsConfig{SigningKey: "seed"},
+	}
+
+	got := cfg.Redacted()
+	assert.Equal(t, "db", got.DB.Host)
+	assert.Equal(t, redacted, got.DB.Password)
+	assert.Equal(t, redacted, got.Auth.Salt)
+	assert.Equal(t, "", got.Auth.SigningKey)
+	assert.Equal(t, redacted, got.Checkpoints.SigningKey)
+	assert.Equal(t, "secret", cfg.DB.Password)
+}
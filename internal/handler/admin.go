@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// serviceIdentity only lets through clients with a service identity, mapped
+// from their client certificate by clientIdentity.
+func serviceIdentity(c *gin.Context) {
+	if _, ok := c.Get(serviceCtx); !ok {
+		newErrorResponse(c, http.StatusForbidden, "client certificate required")
+	}
+}
+
+func (h *Handler) reconcile(c *gin.Context) {
+	openCases := false
+	if value := c.Query("openCases"); value != "" {
+		var err error
+		if openCases, err = strconv.ParseBool(value); err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "invalid openCases param")
+			return
+		}
+	}
+
+	report, err := h.services.Reconciliation.Run(c.Request.Context(), openCases)
+	if err != nil {
+		serviceFailure(c, err, "service failure")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+type getCorrectionCasesResponse struct {
+	Cases  []models.CorrectionCase `json:"data"`
+	Limit  int                     `json:"limit"`
+	Offset int                     `json:"offset"`
+}
+
+func (h *Handler) getCorrectionCases(c *gin.Context) {
+	status := models.CaseStatus(c.Query("status"))
+	if status != "" && status != models.CaseOpen && status != models.CaseResolved {
+		newErrorResponse(c, http.StatusBadRequest, "invalid status param")
+		return
+	}
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	cases, err := h.services.Reconciliation.GetCases(c.Request.Context(), status, page)
+	if err != nil {
+		serviceFailure(c, err, "service failure")
+		return
+	}
+	if cases == nil {
+		cases = []models.CorrectionCase{}
+	}
+
+	c.JSON(http.StatusOK, getCorrectionCasesResponse{
+		Cases:  cases,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	})
+}
+
+type resolveCaseInput struct {
+	Resolution string `json:"resolution" binding:"required,max=255"`
+}
+
+func (h *Handler) resolveCorrectionCase(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	var input resolveCaseInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	err = h.services.Reconciliation.ResolveCase(c.Request.Context(), id, input.Resolution)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, statusResponse{Status: "ok"})
+	case errors.Is(err, sql.ErrNoRows):
+		newErrorResponse(c, http.StatusNotFound, "open correction case not found")
+	case errors.Is(err, models.ErrResolutionRequired), errors.Is(err, models.ErrResolutionTooLong):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		serviceFailure(c, err, "service failure")
+	}
+}
+
+func (h *Handler) verifyChain(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	verification, err := h.services.Chain.Verify(c.Request.Context(), id)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, verification)
+	case errors.Is(err, sql.ErrNoRows):
+		newErrorResponse(c, http.StatusNotFound, "wallet not found")
+	default:
+		serviceFailure(c, err, "service failure")
+	}
+}
+
+type getCheckpointsResponse struct {
+	Checkpoints []models.Checkpoint `json:"data"`
+	Limit       int                 `json:"limit"`
+	Offset      int                 `json:"offset"`
+}
+
+func (h *Handler) getCheckpoints(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	checkpoints, err := h.services.Chain.GetCheckpoints(c.Request.Context(), page)
+	if err != nil {
+		serviceFailure(c, err, "service failure")
+		return
+	}
+	if checkpoints == nil {
+		checkpoints = []models.Checkpoint{}
+	}
+
+	c.JSON(http.StatusOK, getCheckpointsResponse{
+		Checkpoints: checkpoints,
+		Limit:       page.Limit,
+		Offset:      page.Offset,
+	})
+}
+
+func (h *Handler) verifyCheckpoint(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	verification, err := h.services.Chain.VerifyCheckpoint(c.Request.Context(), id)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, verification)
+	case errors.Is(err, sql.ErrNoRows):
+		newErrorResponse(c, http.StatusNotFound, "checkpoint not found")
+	default:
+		serviceFailure(c, err, "service failure")
+	}
+}
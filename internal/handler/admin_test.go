@@ -0,0 +1,484 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/config"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/Yoshisoul/rest-wallets/internal/service"
+	mockService "github.com/Yoshisoul/rest-wallets/internal/service/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func setServiceMiddleware(identity string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(serviceCtx, identity)
+		c.Next()
+	}
+}
+
+func TestHandler_reconcile(t *testing.T) {
+	type mockBehavior func(s *mockService.MockReconciliation)
+
+	walletId := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	caseId := uuid.MustParse("111e2222-e89b-12d3-a456-426614174000")
+	startedAt := time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                string
+		query               string
+		identity            string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:     "OK",
+			query:    "?openCases=true",
+			identity: "reconciler",
+			mockBehavior: func(s *mockService.MockReconciliation) {
+				s.EXPECT().Run(gomock.Any(), true).Return(models.ReconciliationReport{
+					StartedAt:  startedAt,
+					FinishedAt: startedAt.Add(time.Second),
+					Wallets:    3,
+					Mismatches: []models.BalanceMismatch{{
+						WalletId: walletId, UserId: 1, Status: models.WalletActive,
+						Stored: 45, Computed: 30, Difference: 15, Deposits: 50, Withdrawals: 20, Transactions: 2,
+						CaseId: &caseId,
+					}},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"startedAt":"2025-02-10T00:00:00Z","finishedAt":"2025-02-10T00:00:01Z","wallets":3,"mismatches":[
+			{"walletId":"123e4567-e89b-12d3-a456-426614174000","userId":1,"status":"ACTIVE","stored":45,"computed":30,"difference":15,
+			"deposits":50,"withdrawals":20,"transactions":2,"caseId":"111e2222-e89b-12d3-a456-426614174000"}]}`,
+		},
+		{
+			name:     "Without Cases",
+			identity: "reconciler",
+			mockBehavior: func(s *mockService.MockReconciliation) {
+				s.EXPECT().Run(gomock.Any(), false).Return(models.ReconciliationReport{
+					StartedAt:  startedAt,
+					FinishedAt: startedAt,
+					Mismatches: []models.BalanceMismatch{},
+				}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"startedAt":"2025-02-10T00:00:00Z","finishedAt":"2025-02-10T00:00:00Z","wallets":0,"mismatches":[]}`,
+		},
+		{
+			name:                "No Client Certificate",
+			mockBehavior:        func(s *mockService.MockReconciliation) {},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"message":"client certificate required"}`,
+		},
+		{
+			name:                "Invalid Open Cases",
+			query:               "?openCases=maybe",
+			identity:            "reconciler",
+			mockBehavior:        func(s *mockService.MockReconciliation) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid openCases param"}`,
+		},
+		{
+			name:     "Service Failure",
+			identity: "reconciler",
+			mockBehavior: func(s *mockService.MockReconciliation) {
+				s.EXPECT().Run(gomock.Any(), false).Return(models.ReconciliationReport{}, errors.New("connection refused"))
+			},
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"message":"service failure"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			reconciliation := mockService.NewMockReconciliation(c)
+			testCase.mockBehavior(reconciliation)
+
+			services := &service.Service{Reconciliation: reconciliation}
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
+
+			r := gin.New()
+			if testCase.identity != "" {
+				r.Use(setServiceMiddleware(testCase.identity))
+			}
+			r.POST("/admin/reconciliation", serviceIdentity, handler.reconcile)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/admin/reconciliation"+testCase.query, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getCorrectionCases(t *testing.T) {
+	type mockBehavior func(s *mockService.MockReconciliation)
+
+	walletId := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	caseId := uuid.MustParse("111e2222-e89b-12d3-a456-426614174000")
+	openedAt := time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                string
+		query               string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:  "OK",
+			query: "?status=OPEN&limit=10",
+			mockBehavior: func(s *mockService.MockReconciliation) {
+				s.EXPECT().GetCases(gomock.Any(), models.CaseOpen, models.Page{Limit: 10}).Return([]models.CorrectionCase{{
+					CaseId: caseId, WalletId: walletId, Stored: 45, Computed: 30, Status: models.CaseOpen, OpenedAt: openedAt,
+				}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"data":[{"caseId":"111e2222-e89b-12d3-a456-426614174000","walletId":"123e4567-e89b-12d3-a456-426614174000",
+			"stored":45,"computed":30,"status":"OPEN","openedAt":"2025-02-10T00:00:00Z"}],"limit":10,"offset":0}`,
+		},
+		{
+			name: "Empty",
+			mockBehavior: func(s *mockService.MockReconciliation) {
+				s.EXPECT().GetCases(gomock.Any(), models.CaseStatus(""), models.Page{Limit: defaultPageLimit}).Return(nil, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[],"limit":50,"offset":0}`,
+		},
+		{
+			name:                "Invalid Status",
+			query:               "?status=CLOSED",
+			mockBehavior:        func(s *mockService.MockReconciliation) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid status param"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			reconciliation := mockService.NewMockReconciliation(c)
+			testCase.mockBehavior(reconciliation)
+
+			services := &service.Service{Reconciliation: reconciliation}
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
+
+			r := gin.New()
+			r.GET("/admin/correction-cases", handler.getCorrectionCases)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin/correction-cases"+testCase.query, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_resolveCorrectionCase(t *testing.T) {
+	type mockBehavior func(s *mockService.MockReconciliation)
+
+	caseId := uuid.MustParse("111e2222-e89b-12d3-a456-426614174000")
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"resolution":"adjusted with a WITHDRAW of 15"}`,
+			mockBehavior: func(s *mockService.MockReconciliation) {
+				s.EXPECT().ResolveCase(gomock.Any(), caseId, "adjusted with a WITHDRAW of 15").Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:                "Missing Resolution",
+			inputBody:           `{}`,
+			mockBehavior:        func(s *mockService.MockReconciliation) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:                "Resolution Too Long",
+			inputBody:           `{"resolution":"` + strings.Repeat("a", 256) + `"}`,
+			mockBehavior:        func(s *mockService.MockReconciliation) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Blank Resolution",
+			inputBody: `{"resolution":"  "}`,
+			mockBehavior: func(s *mockService.MockReconciliation) {
+				s.EXPECT().ResolveCase(gomock.Any(), caseId, "  ").Return(models.ErrResolutionRequired)
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"resolution is required"}`,
+		},
+		{
+			name:      "Resolution Too Long For Service",
+			inputBody: `{"resolution":"` + strings.Repeat("a", 255) + `"}`,
+			mockBehavior: func(s *mockService.MockReconciliation) {
+				s.EXPECT().ResolveCase(gomock.Any(), caseId, strings.Repeat("a", 255)).Return(models.ErrResolutionTooLong)
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"resolution is too long"}`,
+		},
+		{
+			name:      "Not Open",
+			inputBody: `{"resolution":"duplicate"}`,
+			mockBehavior: func(s *mockService.MockReconciliation) {
+				s.EXPECT().ResolveCase(gomock.Any(), caseId, "duplicate").Return(sql.ErrNoRows)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"open correction case not found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			reconciliation := mockService.NewMockReconciliation(c)
+			testCase.mockBehavior(reconciliation)
+
+			services := &service.Service{Reconciliation: reconciliation}
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
+
+			r := gin.New()
+			r.POST("/admin/correction-cases/:id/resolve", handler.resolveCorrectionCase)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/admin/correction-cases/"+caseId.String()+"/resolve", bytes.NewBufferString(testCase.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_verifyChain(t *testing.T) {
+	type mockBehavior func(s *mockService.MockChain)
+
+	walletId := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	transactionId := uuid.MustParse("222e3333-e89b-12d3-a456-426614174000")
+
+	testTable := []struct {
+		name                string
+		id                  string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "OK",
+			id:   walletId.String(),
+			mockBehavior: func(s *mockService.MockChain) {
+				s.EXPECT().Verify(gomock.Any(), walletId).Return(models.ChainVerification{
+					WalletId: walletId, Transactions: 2, Head: "9f2c", Valid: true,
+				}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"walletId":"123e4567-e89b-12d3-a456-426614174000","transactions":2,"head":"9f2c","valid":true}`,
+		},
+		{
+			name: "Broken",
+			id:   walletId.String(),
+			mockBehavior: func(s *mockService.MockChain) {
+				s.EXPECT().Verify(gomock.Any(), walletId).Return(models.ChainVerification{
+					WalletId: walletId, Transactions: 2,
+					Break: &models.ChainBreak{Seq: 2, TransactionId: transactionId, Reason: models.BreakHash},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"walletId":"123e4567-e89b-12d3-a456-426614174000","transactions":2,"valid":false,
+			"break":{"seq":2,"transactionId":"222e3333-e89b-12d3-a456-426614174000","reason":"hash_mismatch"}}`,
+		},
+		{
+			name: "Not Found",
+			id:   walletId.String(),
+			mockBehavior: func(s *mockService.MockChain) {
+				s.EXPECT().Verify(gomock.Any(), walletId).Return(models.ChainVerification{}, sql.ErrNoRows)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"wallet not found"}`,
+		},
+		{
+			name:                "Invalid Id",
+			id:                  "wallet",
+			mockBehavior:        func(s *mockService.MockChain) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid id param"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			chain := mockService.NewMockChain(c)
+			testCase.mockBehavior(chain)
+
+			services := &service.Service{Chain: chain}
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
+
+			r := gin.New()
+			r.GET("/admin/wallets/:id/chain", handler.verifyChain)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin/wallets/"+testCase.id+"/chain", nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getCheckpoints(t *testing.T) {
+	type mockBehavior func(s *mockService.MockChain)
+
+	checkpointId := uuid.MustParse("333e4444-e89b-12d3-a456-426614174000")
+	at := time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                string
+		query               string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:  "OK",
+			query: "?limit=10",
+			mockBehavior: func(s *mockService.MockChain) {
+				s.EXPECT().GetCheckpoints(gomock.Any(), models.Page{Limit: 10}).Return([]models.Checkpoint{{
+					CheckpointId: checkpointId, At: at, Wallets: 2, Transactions: 5,
+					Root: "ab12", PublicKey: "a2V5", Signature: "c2ln", CreatedAt: at.Add(time.Minute),
+				}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"data":[{"checkpointId":"333e4444-e89b-12d3-a456-426614174000","at":"2025-02-10T00:00:00Z","wallets":2,"transactions":5,
+			"root":"ab12","publicKey":"a2V5","signature":"c2ln","createdAt":"2025-02-10T00:01:00Z"}],"limit":10,"offset":0}`,
+		},
+		{
+			name: "Empty",
+			mockBehavior: func(s *mockService.MockChain) {
+				s.EXPECT().GetCheckpoints(gomock.Any(), models.Page{Limit: defaultPageLimit}).Return(nil, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[],"limit":50,"offset":0}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			chain := mockService.NewMockChain(c)
+			testCase.mockBehavior(chain)
+
+			services := &service.Service{Chain: chain}
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
+
+			r := gin.New()
+			r.GET("/admin/checkpoints", handler.getCheckpoints)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin/checkpoints"+testCase.query, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_verifyCheckpoint(t *testing.T) {
+	type mockBehavior func(s *mockService.MockChain)
+
+	checkpointId := uuid.MustParse("333e4444-e89b-12d3-a456-426614174000")
+	at := time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)
+	checkpoint := models.Checkpoint{
+		CheckpointId: checkpointId, At: at, Wallets: 2, Transactions: 5,
+		Root: "ab12", PublicKey: "a2V5", Signature: "c2ln", CreatedAt: at,
+	}
+
+	testTable := []struct {
+		name                string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "Root Mismatch",
+			mockBehavior: func(s *mockService.MockChain) {
+				s.EXPECT().VerifyCheckpoint(gomock.Any(), checkpointId).Return(models.CheckpointVerification{
+					Checkpoint: checkpoint, ComputedRoot: "cd34", SignatureValid: true, KeyMatches: true,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"checkpoint":{"checkpointId":"333e4444-e89b-12d3-a456-426614174000","at":"2025-02-10T00:00:00Z","wallets":2,"transactions":5,
+			"root":"ab12","publicKey":"a2V5","signature":"c2ln","createdAt":"2025-02-10T00:00:00Z"},
+			"computedRoot":"cd34","rootMatches":false,"signatureValid":true,"keyMatches":true,"valid":false}`,
+		},
+		{
+			name: "Not Found",
+			mockBehavior: func(s *mockService.MockChain) {
+				s.EXPECT().VerifyCheckpoint(gomock.Any(), checkpointId).Return(models.CheckpointVerification{}, sql.ErrNoRows)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"checkpoint not found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			chain := mockService.NewMockChain(c)
+			testCase.mockBehavior(chain)
+
+			services := &service.Service{Chain: chain}
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
+
+			r := gin.New()
+			r.GET("/admin/checkpoints/:id/verify", handler.verifyCheckpoint)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin/checkpoints/"+checkpointId.String()+"/verify", nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -12,14 +12,13 @@ import (
 func (h *Handler) signUp(c *gin.Context) {
 	var input models.SignUpInput
 
-	if err := c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+	if !bindJSON(c, &input) {
 		return
 	}
 
-	id, err := h.services.Authorization.CreateUser(input)
+	id, err := h.services.Authorization.CreateUser(c.Request.Context(), input)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, "service failure")
+		serviceFailure(c, err, "service failure")
 		return
 	}
 
@@ -31,18 +30,17 @@ func (h *Handler) signUp(c *gin.Context) {
 func (h *Handler) signIn(c *gin.Context) {
 	var input models.SignInInput
 
-	if err := c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+	if !bindJSON(c, &input) {
 		return
 	}
 
-	token, err := h.services.Authorization.GenerateToken(input.Username, input.Password)
+	token, err := h.services.Authorization.GenerateToken(c.Request.Context(), input.Username, input.Password)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			newErrorResponse(c, http.StatusNotFound, "invalid username or password")
 			return
 		}
-		newErrorResponse(c, http.StatusInternalServerError, "service failure")
+		serviceFailure(c, err, "service failure")
 		return
 	}
 
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/Yoshisoul/rest-wallets/internal/config"
 	"github.com/Yoshisoul/rest-wallets/internal/models"
 	"github.com/Yoshisoul/rest-wallets/internal/service"
 	mockService "github.com/Yoshisoul/rest-wallets/internal/service/mocks"
@@ -36,7 +37,7 @@ func TestHandler_signup(t *testing.T) {
 				Password: "pass",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user models.SignUpInput) {
-				s.EXPECT().CreateUser(user).Return(1, nil)
+				s.EXPECT().CreateUser(gomock.Any(), user).Return(1, nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `{"id":1}`,
@@ -57,7 +58,7 @@ func TestHandler_signup(t *testing.T) {
 				Password: "pass",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user models.SignUpInput) {
-				s.EXPECT().CreateUser(user).Return(1, errors.New("service failure"))
+				s.EXPECT().CreateUser(gomock.Any(), user).Return(1, errors.New("service failure"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"service failure"}`,
@@ -74,7 +75,7 @@ func TestHandler_signup(t *testing.T) {
 			testCase.mockBehavior(auth, testCase.mockExpInput)
 
 			services := &service.Service{Authorization: auth}
-			handler := NewHandler(services)
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
 
 			// Test Server
 			r := gin.New()
@@ -114,7 +115,7 @@ func TestHandler_signIn(t *testing.T) {
 				Password: "pass",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user models.SignInInput) {
-				s.EXPECT().GenerateToken(user.Username, user.Password).Return("token", nil)
+				s.EXPECT().GenerateToken(gomock.Any(), user.Username, user.Password).Return("token", nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `{"token":"token"}`,
@@ -134,7 +135,7 @@ func TestHandler_signIn(t *testing.T) {
 				Password: "pass",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user models.SignInInput) {
-				s.EXPECT().GenerateToken(user.Username, user.Password).Return("", errors.New("service failure"))
+				s.EXPECT().GenerateToken(gomock.Any(), user.Username, user.Password).Return("", errors.New("service failure"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"service failure"}`,
@@ -147,7 +148,7 @@ func TestHandler_signIn(t *testing.T) {
 				Password: "invalid",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user models.SignInInput) {
-				s.EXPECT().GenerateToken(user.Username, user.Password).Return("", sql.ErrNoRows)
+				s.EXPECT().GenerateToken(gomock.Any(), user.Username, user.Password).Return("", sql.ErrNoRows)
 			},
 			expectedStatusCode:  404,
 			expectedRequestBody: `{"message":"invalid username or password"}`,
@@ -164,7 +165,7 @@ func TestHandler_signIn(t *testing.T) {
 			testCase.mockBehavior(auth, testCase.mockExpInput)
 
 			services := &service.Service{Authorization: auth}
-			handler := NewHandler(services)
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
 
 			// Test Server
 			r := gin.New()
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type balanceResponse struct {
+	WalletId uuid.UUID `json:"walletId"`
+	At       time.Time `json:"at"`
+	Balance  int64     `json:"balance"`
+}
+
+func (h *Handler) getWalletBalance(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	at, ok := queryTime(c, "at", time.Now())
+	if !ok {
+		return
+	}
+
+	balance, err := h.services.Balance.At(c.Request.Context(), userId, id, at)
+	if err != nil {
+		balanceFailure(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, balanceResponse{
+		WalletId: id,
+		At:       at.UTC(),
+		Balance:  balance,
+	})
+}
+
+type balanceHistoryResponse struct {
+	WalletId uuid.UUID             `json:"walletId"`
+	Interval models.Interval       `json:"interval"`
+	Points   []models.BalancePoint `json:"data"`
+}
+
+func (h *Handler) getBalanceHistory(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	input := models.BalanceHistoryInput{Interval: models.Interval(c.DefaultQuery("interval", string(models.Day)))}
+	if !input.Interval.Valid() {
+		newErrorResponse(c, http.StatusBadRequest, "invalid interval param")
+		return
+	}
+
+	if c.Query("from") == "" {
+		newErrorResponse(c, http.StatusBadRequest, "from param is required")
+		return
+	}
+	var ok bool
+	if input.From, ok = queryTime(c, "from", time.Time{}); !ok {
+		return
+	}
+	if input.To, ok = queryTime(c, "to", time.Now()); !ok {
+		return
+	}
+
+	points, err := h.services.Balance.History(c.Request.Context(), userId, id, input)
+	if err != nil {
+		balanceFailure(c, err)
+		return
+	}
+	if points == nil {
+		points = []models.BalancePoint{}
+	}
+
+	c.JSON(http.StatusOK, balanceHistoryResponse{
+		WalletId: id,
+		Interval: input.Interval,
+		Points:   points,
+	})
+}
+
+// queryTime reads an RFC 3339 timestamp from the query param name, or
+// returns def without it. When it is invalid it answers 400 and returns
+// false.
+func queryTime(c *gin.Context, name string, def time.Time) (time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return def, true
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid "+name+" param")
+		return t, false
+	}
+
+	return t, true
+}
+
+func balanceFailure(c *gin.Context, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		newErrorResponse(c, http.StatusNotFound, "wallet not found")
+		return
+	}
+	if errors.Is(err, models.ErrFutureTime) || errors.Is(err, models.ErrInvalidRange) || errors.Is(err, models.ErrTooManyPoints) {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	serviceFailure(c, err, "service failure")
+}
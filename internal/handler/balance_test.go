@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/config"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/Yoshisoul/rest-wallets/internal/service"
+	mockService "github.com/Yoshisoul/rest-wallets/internal/service/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_getWalletBalance(t *testing.T) {
+	type mockBehavior func(s *mockService.MockBalance, userId int)
+
+	walletId := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	at := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                string
+		target              string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			target: "/wallets/" + walletId.String() + "/balance?at=2025-02-10T15:00:00%2B03:00",
+			mockBehavior: func(s *mockService.MockBalance, userId int) {
+				s.EXPECT().At(gomock.Any(), userId, walletId, gomock.Cond(func(t time.Time) bool { return t.Equal(at) })).Return(int64(150), nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"walletId":"123e4567-e89b-12d3-a456-426614174000","at":"2025-02-10T12:00:00Z","balance":150}`,
+		},
+		{
+			name:                "Invalid At",
+			target:              "/wallets/" + walletId.String() + "/balance?at=yesterday",
+			mockBehavior:        func(s *mockService.MockBalance, userId int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid at param"}`,
+		},
+		{
+			name:   "Future",
+			target: "/wallets/" + walletId.String() + "/balance?at=2999-01-01T00:00:00Z",
+			mockBehavior: func(s *mockService.MockBalance, userId int) {
+				s.EXPECT().At(gomock.Any(), userId, walletId, gomock.Any()).Return(int64(0), models.ErrFutureTime)
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"time is in the future"}`,
+		},
+		{
+			name:   "Not Found",
+			target: "/wallets/" + walletId.String() + "/balance",
+			mockBehavior: func(s *mockService.MockBalance, userId int) {
+				s.EXPECT().At(gomock.Any(), userId, walletId, gomock.Any()).Return(int64(0), sql.ErrNoRows)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"wallet not found"}`,
+		},
+		{
+			name:   "Service Failure",
+			target: "/wallets/" + walletId.String() + "/balance",
+			mockBehavior: func(s *mockService.MockBalance, userId int) {
+				s.EXPECT().At(gomock.Any(), userId, walletId, gomock.Any()).Return(int64(0), errors.New("connection refused"))
+			},
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"message":"service failure"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			balance := mockService.NewMockBalance(c)
+			testCase.mockBehavior(balance, 1)
+
+			services := &service.Service{Balance: balance}
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
+
+			r := gin.New()
+			r.Use(setUserIdMiddleware(1))
+			r.GET("/wallets/:id/balance", handler.getWalletBalance)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.target, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getBalanceHistory(t *testing.T) {
+	type mockBehavior func(s *mockService.MockBalance, userId int)
+
+	walletId := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                string
+		query               string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:  "OK",
+			query: "?interval=month&from=2025-02-01T00:00:00Z&to=2025-04-01T00:00:00Z",
+			mockBehavior: func(s *mockService.MockBalance, userId int) {
+				s.EXPECT().History(gomock.Any(), userId, walletId, models.BalanceHistoryInput{Interval: models.Month, From: from, To: to}).
+					Return([]models.BalancePoint{{Date: from, Balance: 10}, {Date: from.AddDate(0, 1, 0), Balance: 25}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"walletId":"123e4567-e89b-12d3-a456-426614174000","interval":"month","data":[
+			{"date":"2025-02-01T00:00:00Z","balance":10},
+			{"date":"2025-03-01T00:00:00Z","balance":25}]}`,
+		},
+		{
+			name:  "Default Interval",
+			query: "?from=2025-02-01T00:00:00Z&to=2025-04-01T00:00:00Z",
+			mockBehavior: func(s *mockService.MockBalance, userId int) {
+				s.EXPECT().History(gomock.Any(), userId, walletId, models.BalanceHistoryInput{Interval: models.Day, From: from, To: to}).Return(nil, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"walletId":"123e4567-e89b-12d3-a456-426614174000","interval":"day","data":[]}`,
+		},
+		{
+			name:                "Invalid Interval",
+			query:               "?interval=year&from=2025-02-01T00:00:00Z",
+			mockBehavior:        func(s *mockService.MockBalance, userId int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid interval param"}`,
+		},
+		{
+			name:                "Missing From",
+			query:               "?interval=day",
+			mockBehavior:        func(s *mockService.MockBalance, userId int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"from param is required"}`,
+		},
+		{
+			name:                "Invalid To",
+			query:               "?from=2025-02-01T00:00:00Z&to=2025-04-01",
+			mockBehavior:        func(s *mockService.MockBalance, userId int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid to param"}`,
+		},
+		{
+			name:  "Invalid Range",
+			query: "?from=2025-04-01T00:00:00Z&to=2025-02-01T00:00:00Z",
+			mockBehavior: func(s *mockService.MockBalance, userId int) {
+				s.EXPECT().History(gomock.Any(), userId, walletId, gomock.Any()).Return(nil, models.ErrInvalidRange)
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"from must be before to"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			balance := mockService.NewMockBalance(c)
+			testCase.mockBehavior(balance, 1)
+
+			services := &service.Service{Balance: balance}
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
+
+			r := gin.New()
+			r.Use(setUserIdMiddleware(1))
+			r.GET("/wallets/:id/balance-history", handler.getBalanceHistory)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/wallets/"+walletId.String()+"/balance-history"+testCase.query, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) createCategory(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	var input models.CategoryInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	id, err := h.services.Category.Create(c.Request.Context(), userId, input)
+	if err != nil {
+		if errors.Is(err, models.ErrNameRequired) || errors.Is(err, models.ErrUnknownCategory) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrCategoryExists) {
+			newErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		serviceFailure(c, err, "service failure")
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"uuid": id,
+	})
+}
+
+type getAllCategoriesResponse struct {
+	Categories []models.Category `json:"data"`
+	Limit      int               `json:"limit"`
+	Offset     int               `json:"offset"`
+}
+
+func (h *Handler) getAllCategories(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	categories, err := h.services.Category.GetAll(c.Request.Context(), userId, page)
+	if err != nil {
+		serviceFailure(c, err, "service failure")
+		return
+	}
+	if categories == nil {
+		categories = []models.Category{}
+	}
+
+	c.JSON(http.StatusOK, getAllCategoriesResponse{
+		Categories: categories,
+		Limit:      page.Limit,
+		Offset:     page.Offset,
+	})
+}
+
+func (h *Handler) deleteCategory(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Category.Delete(c.Request.Context(), userId, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			newErrorResponse(c, http.StatusNotFound, "category not found")
+			return
+		}
+		if errors.Is(err, models.ErrCategoryInUse) {
+			newErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		serviceFailure(c, err, "service failure")
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{Status: "ok"})
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/config"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/Yoshisoul/rest-wallets/internal/service"
+	mockService "github.com/Yoshisoul/rest-wallets/internal/service/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_createCategory(t *testing.T) {
+	type mockBehavior func(s *mockService.MockCategory, input models.CategoryInput)
+
+	parentId := uuid.MustParse("333e4444-e89b-12d3-a456-426614174000")
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		mockExpInput        models.CategoryInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:         "Ok",
+			inputBody:    `{"name": "groceries", "parentId": "333e4444-e89b-12d3-a456-426614174000"}`,
+			mockExpInput: models.CategoryInput{Name: "groceries", ParentId: &parentId},
+			mockBehavior: func(s *mockService.MockCategory, input models.CategoryInput) {
+				s.EXPECT().Create(gomock.Any(), 1, input).Return(uuid.MustParse("444e5555-e89b-12d3-a456-426614174000"), nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"uuid":"444e5555-e89b-12d3-a456-426614174000"}`,
+		},
+		{
+			name:         "Exists",
+			inputBody:    `{"name": "food"}`,
+			mockExpInput: models.CategoryInput{Name: "food"},
+			mockBehavior: func(s *mockService.MockCategory, input models.CategoryInput) {
+				s.EXPECT().Create(gomock.Any(), 1, input).Return(uuid.Nil, models.ErrCategoryExists)
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"message":"category already exists"}`,
+		},
+		{
+			name:         "Unknown parent",
+			inputBody:    `{"name": "groceries", "parentId": "333e4444-e89b-12d3-a456-426614174000"}`,
+			mockExpInput: models.CategoryInput{Name: "groceries", ParentId: &parentId},
+			mockBehavior: func(s *mockService.MockCategory, input models.CategoryInput) {
+				s.EXPECT().Create(gomock.Any(), 1, input).Return(uuid.Nil, models.ErrUnknownCategory)
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"unknown category"}`,
+		},
+		{
+			name:                "Missing name",
+			inputBody:           `{}`,
+			mockBehavior:        func(s *mockService.MockCategory, input models.CategoryInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:         "Service Failure",
+			inputBody:    `{"name": "food"}`,
+			mockExpInput: models.CategoryInput{Name: "food"},
+			mockBehavior: func(s *mockService.MockCategory, input models.CategoryInput) {
+				s.EXPECT().Create(gomock.Any(), 1, input).Return(uuid.Nil, errors.New("service failure"))
+			},
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"message":"service failure"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			category := mockService.NewMockCategory(ctrl)
+			testCase.mockBehavior(category, testCase.mockExpInput)
+
+			services := &service.Service{Category: category}
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/categories", setUserIdMiddleware(1), handler.createCategory)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/categories", bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Asserts
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getAllCategories(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	category := mockService.NewMockCategory(ctrl)
+	category.EXPECT().GetAll(gomock.Any(), 1, models.Page{Limit: 50}).Return([]models.Category{
+		{
+			CategoryId: uuid.MustParse("333e4444-e89b-12d3-a456-426614174000"),
+			UserId:     1,
+			Name:       "food",
+			CreatedAt:  time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+		},
+	}, nil)
+
+	handler := NewHandler(&service.Service{Category: category}, config.HTTPConfig{}, "rest-wallets", nil)
+	r := gin.New()
+	r.GET("/categories", setUserIdMiddleware(1), handler.getAllCategories)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/categories", nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"data":[{
+		"categoryId":"333e4444-e89b-12d3-a456-426614174000",
+		"name":"food",
+		"createdAt":"2025-02-10T00:00:00Z"}],
+		"limit":50,
+		"offset":0}`, w.Body.String())
+}
+
+func TestHandler_deleteCategory(t *testing.T) {
+	type mockBehavior func(s *mockService.MockCategory, id uuid.UUID)
+
+	testTable := []struct {
+		name                string
+		inputId             string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:    "Ok",
+			inputId: "333e4444-e89b-12d3-a456-426614174000",
+			mockBehavior: func(s *mockService.MockCategory, id uuid.UUID) {
+				s.EXPECT().Delete(gomock.Any(), 1, id).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:    "In use",
+			inputId: "333e4444-e89b-12d3-a456-426614174000",
+			mockBehavior: func(s *mockService.MockCategory, id uuid.UUID) {
+				s.EXPECT().Delete(gomock.Any(), 1, id).Return(models.ErrCategoryInUse)
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"message":"category is in use"}`,
+		},
+		{
+			name:    "Not found",
+			inputId: "333e4444-e89b-12d3-a456-426614174000",
+			mockBehavior: func(s *mockService.MockCategory, id uuid.UUID) {
+				s.EXPECT().Delete(gomock.Any(), 1, id).Return(sql.ErrNoRows)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"category not found"}`,
+		},
+		{
+			name:                "Invalid Category ID",
+			inputId:             "invalid",
+			mockBehavior:        func(s *mockService.MockCategory, id uuid.UUID) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid id param"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			category := mockService.NewMockCategory(ctrl)
+			id, _ := uuid.Parse(testCase.inputId)
+			testCase.mockBehavior(category, id)
+
+			services := &service.Service{Category: category}
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
+
+			// Test Server
+			r := gin.New()
+			r.DELETE("/categories/:id", setUserIdMiddleware(1), handler.deleteCategory)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/categories/"+testCase.inputId, nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Asserts
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -1,22 +1,93 @@
 package handler
 
 import (
+	"io"
+	"net/http"
+
+	"github.com/Yoshisoul/rest-wallets/api"
+	"github.com/Yoshisoul/rest-wallets/internal/config"
+	"github.com/Yoshisoul/rest-wallets/internal/ratelimit"
 	"github.com/Yoshisoul/rest-wallets/internal/service"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// Request bodies of these routes are small JSON documents; the global
+// http.max_body_bytes only bounds routes without a limit of their own.
+// Transactions leave room for their metadata.
+const (
+	authBodyLimit        = 4 << 10
+	transactionBodyLimit = 16 << 10
+	categoryBodyLimit    = 4 << 10
+	walletBodyLimit      = 4 << 10
+	adminBodyLimit       = 4 << 10
+)
+
+// maxMetadataFilters bounds the metadata params of a list of transactions,
+// maxTagFilters the tag params of a list of wallets.
+const (
+	maxMetadataFilters = 5
+	maxTagFilters      = 5
 )
 
 type Handler struct {
 	services *service.Service
+	cfg      config.HTTPConfig
+	// serviceName names the server in the request spans.
+	serviceName string
+	// identities maps client certificate common names to service identities.
+	identities map[string]string
+	// limiter is nil when rate limiting is disabled.
+	limiter *ratelimit.Limiter
 }
 
-func NewHandler(services *service.Service) *Handler {
-	return &Handler{services: services}
+func NewHandler(services *service.Service, cfg config.HTTPConfig, serviceName string, limiter *ratelimit.Limiter) *Handler {
+	identities := make(map[string]string, len(cfg.TLS.ClientIdentities))
+	for _, identity := range cfg.TLS.ClientIdentities {
+		identities[identity.CommonName] = identity.Identity
+	}
+
+	return &Handler{services: services, cfg: cfg, serviceName: serviceName, identities: identities, limiter: limiter}
 }
 
 func (h *Handler) InitRoutes() *gin.Engine {
 	router := gin.New()
+	// Lets the gin context stand in for the request context, e.g. in logs.
+	router.ContextWithFallback = true
+	// Validated by config, so this can't fail.
+	_ = router.SetTrustedProxies(h.cfg.TrustedProxies)
+	router.Use(
+		otelgin.Middleware(h.serviceName, otelgin.WithFilter(isTraced)),
+		requestId,
+		accessLog,
+		observeRequest,
+		gin.CustomRecoveryWithWriter(io.Discard, recovery),
+		h.securityHeaders,
+	)
+	// Preflight requests carry no credentials, so CORS goes before the
+	// client identity and rate limits.
+	if cors := h.corsHandler(); cors != nil {
+		router.Use(cors)
+	}
+	router.Use(
+		h.clientIdentity,
+		session,
+		limitBody(h.cfg.MaxBodyBytes),
+	)
+	if h.cfg.ValidateOpenAPI {
+		spec, err := loadSpec()
+		if err != nil {
+			// The spec is embedded in the binary, so this is a build error.
+			panic(err)
+		}
+		router.Use(validateOpenAPI(spec))
+	}
+
+	router.GET("/openapi.json", serveSpec)
+	docs := router.Group("/docs", docsHeaders)
+	docs.StaticFS("/", http.FS(api.Docs))
 
-	auth := router.Group("/auth")
+	auth := router.Group("/auth", h.rateLimit("auth"), limitBody(authBodyLimit))
 	{
 		auth.POST("/sign-up", h.signUp)
 		auth.POST("/sign-in", h.signIn)
@@ -24,23 +95,45 @@ func (h *Handler) InitRoutes() *gin.Engine {
 
 	api := router.Group("/api/v1")
 	{
-		wallets := api.Group("/wallets", h.userIdentity)
+		wallets := api.Group("/wallets", h.userIdentity, h.rateLimit("default"), walletSession)
 		{
 			wallets.POST("/", h.createWallet)
 			wallets.GET("/", h.getAllWalletsFromUser)
 			wallets.GET("/:id", h.getWalletById)
+			wallets.GET("/:id/balance", h.getWalletBalance)
+			wallets.GET("/:id/balance-history", h.getBalanceHistory)
+			wallets.PATCH("/:id", limitBody(walletBodyLimit), h.updateWallet)
 			wallets.DELETE("/:id", h.deleteWallet)
 			// updates using transactions
 		}
 
 		transcactions := api.Group("/transactions")
 		{
-			transcactions.POST("/", h.createTransaction)
-			transcactions.GET("/", h.getAllTransactions)
-			transcactions.GET("/:id", h.getTransactionById)
-			// can't update and delete transactions
+			transcactions.POST("/", h.rateLimit("transactions"), limitBody(transactionBodyLimit), h.createTransaction)
+			transcactions.GET("/", h.rateLimit("default"), h.getAllTransactions)
+			transcactions.GET("/:id", h.rateLimit("default"), h.getTransactionById)
+			// can't update and delete transactions, only annotate them
+			transcactions.PUT("/:id/annotation", h.userIdentity, h.rateLimit("default"), limitBody(transactionBodyLimit), h.annotateTransaction)
+			transcactions.GET("/:id/annotations", h.userIdentity, h.rateLimit("default"), h.getTransactionAnnotations)
+		}
+
+		categories := api.Group("/categories", h.userIdentity, h.rateLimit("default"), limitBody(categoryBodyLimit))
+		{
+			categories.POST("/", h.createCategory)
+			categories.GET("/", h.getAllCategories)
+			categories.DELETE("/:id", h.deleteCategory)
 		}
 	}
 
+	admin := router.Group("/admin", serviceIdentity, h.rateLimit("default"), limitBody(adminBodyLimit))
+	{
+		admin.POST("/reconciliation", h.reconcile)
+		admin.GET("/correction-cases", h.getCorrectionCases)
+		admin.POST("/correction-cases/:id/resolve", h.resolveCorrectionCase)
+		admin.GET("/wallets/:id/chain", h.verifyChain)
+		admin.GET("/checkpoints", h.getCheckpoints)
+		admin.GET("/checkpoints/:id/verify", h.verifyCheckpoint)
+	}
+
 	return router
 }
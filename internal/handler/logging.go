@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIdHeader = "X-Request-ID"
+	requestIdCtx    = "requestId"
+
+	maxRequestIdLength = 128
+)
+
+// sensitiveParams are substrings of query parameter names whose values are
+// never written to the logs.
+var sensitiveParams = []string{"password", "token", "secret", "key", "authorization"}
+
+// requestId propagates the X-Request-ID header of the caller, or generates one
+// when it is missing or unusable, and echoes it in the response.
+func requestId(c *gin.Context) {
+	id := c.GetHeader(requestIdHeader)
+	if !validRequestId(id) {
+		id = uuid.NewString()
+	}
+
+	c.Set(requestIdCtx, id)
+	c.Header(requestIdHeader, id)
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("http.request_id", id))
+
+	c.Next()
+}
+
+func validRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// accessLog writes one entry per request. Client errors are logged at WARN,
+// server errors at ERROR, and the messages passed to newErrorResponse are
+// included in the entry.
+func accessLog(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	status := c.Writer.Status()
+	fields := logrus.Fields{
+		"method":     c.Request.Method,
+		"route":      c.FullPath(),
+		"path":       c.Request.URL.Path,
+		"status":     status,
+		"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		"bytes":      c.Writer.Size(),
+		"client_ip":  c.ClientIP(),
+		"request_id": c.GetString(requestIdCtx),
+	}
+	if query := redactQuery(c.Request.URL.Query()); query != "" {
+		fields["query"] = query
+	}
+	if userId, ok := c.Get(userCtx); ok {
+		fields["user_id"] = userId
+	}
+	if identity, ok := c.Get(serviceCtx); ok {
+		fields["service"] = identity
+	}
+	if len(c.Errors) > 0 {
+		fields["error"] = strings.Join(c.Errors.Errors(), "; ")
+	}
+
+	entry := logrus.WithContext(c).WithFields(fields)
+	msg := fmt.Sprintf("%s %s %d", c.Request.Method, c.Request.URL.Path, status)
+	switch {
+	case probePaths[c.Request.URL.Path] && status < http.StatusBadRequest:
+		entry.Debug(msg)
+	case status >= http.StatusInternalServerError:
+		entry.Error(msg)
+	case status >= http.StatusBadRequest:
+		entry.Warn(msg)
+	default:
+		entry.Info(msg)
+	}
+}
+
+func redactQuery(query url.Values) string {
+	for name, values := range query {
+		if isSensitive(name) {
+			for i := range values {
+				values[i] = "[REDACTED]"
+			}
+		}
+	}
+
+	return query.Encode()
+}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, sensitive := range sensitiveParams {
+		if strings.Contains(name, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recovery turns a panic in a handler into a 500 response and logs it with
+// the stack trace.
+func recovery(c *gin.Context, recovered any) {
+	logrus.WithContext(c).WithFields(logrus.Fields{
+		"request_id": c.GetString(requestIdCtx),
+		"panic":      fmt.Sprint(recovered),
+		"stack":      string(debug.Stack()),
+	}).Error("recovered from panic")
+
+	newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestId(t *testing.T) {
+	testTable := []struct {
+		name     string
+		header   string
+		expectId string
+	}{
+		{
+			name:     "Propagated",
+			header:   "abc-123",
+			expectId: "abc-123",
+		},
+		{
+			name: "Generated",
+		},
+		{
+			name:   "Invalid",
+			header: "bad id\n",
+		},
+		{
+			name:   "Too Long",
+			header: strings.Repeat("a", maxRequestIdLength+1),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/", requestId, func(c *gin.Context) {
+				c.String(200, c.GetString(requestIdCtx))
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			if testCase.header != "" {
+				req.Header.Set(requestIdHeader, testCase.header)
+			}
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(requestIdHeader)
+			assert.Equal(t, id, w.Body.String())
+			if testCase.expectId != "" {
+				assert.Equal(t, testCase.expectId, id)
+			} else {
+				assert.Len(t, id, 36)
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	testTable := []struct {
+		name          string
+		target        string
+		handler       gin.HandlerFunc
+		expectedCode  int
+		expectedLevel logrus.Level
+		expectedError string
+	}{
+		{
+			name:          "Ok",
+			target:        "/wallets/1",
+			handler:       func(c *gin.Context) { c.Status(200) },
+			expectedCode:  200,
+			expectedLevel: logrus.InfoLevel,
+		},
+		{
+			name:          "Client Error",
+			target:        "/wallets/1",
+			handler:       func(c *gin.Context) { newErrorResponse(c, 404, "wallet not found") },
+			expectedCode:  404,
+			expectedLevel: logrus.WarnLevel,
+			expectedError: "wallet not found",
+		},
+		{
+			name:          "Server Error",
+			target:        "/wallets/1",
+			handler:       func(c *gin.Context) { newErrorResponse(c, 500, "service failure") },
+			expectedCode:  500,
+			expectedLevel: logrus.ErrorLevel,
+			expectedError: "service failure",
+		},
+		{
+			name:          "Panic",
+			target:        "/wallets/1",
+			handler:       func(c *gin.Context) { panic("boom") },
+			expectedCode:  500,
+			expectedLevel: logrus.ErrorLevel,
+			expectedError: "internal server error",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			hook := test.NewGlobal()
+			defer hook.Reset()
+
+			r := gin.New()
+			r.Use(requestId, accessLog, gin.CustomRecoveryWithWriter(io.Discard, recovery))
+			r.GET("/wallets/:id", func(c *gin.Context) {
+				c.Set(userCtx, 7)
+			}, testCase.handler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", testCase.target, nil))
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			entry := hook.LastEntry()
+			require.NotNil(t, entry)
+			assert.Equal(t, testCase.expectedLevel, entry.Level)
+			assert.Equal(t, "/wallets/:id", entry.Data["route"])
+			assert.Equal(t, testCase.expectedCode, entry.Data["status"])
+			assert.Equal(t, 7, entry.Data["user_id"])
+			assert.Equal(t, w.Header().Get(requestIdHeader), entry.Data["request_id"])
+			if testCase.expectedError != "" {
+				assert.Equal(t, testCase.expectedError, entry.Data["error"])
+			} else {
+				assert.NotContains(t, entry.Data, "error")
+			}
+		})
+	}
+}
+
+func TestAccessLog_RedactsQuery(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	r := gin.New()
+	r.Use(accessLog)
+	r.GET("/", func(c *gin.Context) { c.Status(200) })
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/?reason=refund&access_token=abc&Password=hunter2", nil))
+
+	query := hook.LastEntry().Data["query"].(string)
+	assert.Contains(t, query, "reason=refund")
+	assert.NotContains(t, query, "abc")
+	assert.NotContains(t, query, "hunter2")
+}
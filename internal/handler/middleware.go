@@ -2,42 +2,146 @@ package handler
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
+	"github.com/Yoshisoul/rest-wallets/internal/service"
+	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 const (
 	authorizationHeader = "Authorization"
 	userCtx             = "userId"
+	serviceCtx          = "service"
 )
 
 func (h *Handler) userIdentity(c *gin.Context) {
 	header := c.GetHeader(authorizationHeader)
 	if header == "" {
-		newErrorResponse(c, http.StatusUnauthorized, "empty auth header")
+		h.unauthorized(c, "empty auth header")
 		return
 	}
 
 	headerParts := strings.Split(header, " ")
 	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-		newErrorResponse(c, http.StatusUnauthorized, "invalid auth header")
+		h.unauthorized(c, "invalid auth header")
 		return
 	}
 
 	if len(headerParts[1]) == 0 {
-		newErrorResponse(c, http.StatusUnauthorized, "token is empty")
+		h.unauthorized(c, "token is empty")
 		return
 	}
 
 	userId, err := h.services.Authorization.ParseToken(headerParts[1])
 	if err != nil {
-		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		h.unauthorized(c, err.Error())
 		return
 	}
 
 	c.Set(userCtx, userId)
+	c.Request = c.Request.WithContext(service.WithSession(c.Request.Context(), fmt.Sprintf("user:%d", userId)))
+}
+
+// unauthorized answers 401 to a request without a valid token. Routes with
+// users are limited per user, after userIdentity, so failures count against
+// the auth limit of the client IP instead, like failed sign-ins: tokens
+// can't be guessed faster than passwords.
+func (h *Handler) unauthorized(c *gin.Context, message string) {
+	if h.limit(c, "auth", "ip:"+c.ClientIP()) {
+		newErrorResponse(c, http.StatusUnauthorized, message)
+	}
+}
+
+// clientIdentity maps a verified client certificate to the service identity
+// configured for its common name. Requests presenting a certificate that maps
+// to no identity are rejected; requests without one are left to userIdentity.
+func (h *Handler) clientIdentity(c *gin.Context) {
+	if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+		return
+	}
+
+	commonName := c.Request.TLS.VerifiedChains[0][0].Subject.CommonName
+	identity, ok := h.identities[commonName]
+	if !ok {
+		newErrorResponse(c, http.StatusForbidden, "unknown client certificate")
+		return
+	}
+
+	c.Set(serviceCtx, identity)
+}
+
+// session identifies the client for read-your-writes consistency by its
+// service identity. userIdentity adds the user and walletSession the wallet.
+// The IP is no key: every client behind a proxy or NAT would share it.
+func session(c *gin.Context) {
+	if identity, ok := c.Get(serviceCtx); ok {
+		c.Request = c.Request.WithContext(service.WithSession(c.Request.Context(), fmt.Sprintf("service:%s", identity)))
+	}
+}
+
+// walletSession adds the wallet of the id param to the session, so that
+// reads of a wallet see the transactions written to it without a token.
+func walletSession(c *gin.Context) {
+	if walletId, err := uuid.Parse(c.Param("id")); err == nil {
+		addWalletSession(c, walletId)
+	}
+}
+
+func addWalletSession(c *gin.Context, walletId uuid.UUID) {
+	c.Request = c.Request.WithContext(service.WithSession(c.Request.Context(), "wallet:"+walletId.String()))
+}
+
+// limitBody caps how much of the request body handlers can read. Requests
+// announcing a larger body are rejected before it is read. Limits nest: the
+// smallest of the global and the route limit applies.
+func limitBody(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			newErrorResponse(c, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	}
+}
+
+// securityHeaders are sent with every response. The API only serves JSON,
+// so the content security policy forbids loading anything at all.
+func (h *Handler) securityHeaders(c *gin.Context) {
+	header := c.Writer.Header()
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("X-Frame-Options", "DENY")
+	header.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+	header.Set("Referrer-Policy", h.cfg.Security.ReferrerPolicy)
+	if maxAge := h.cfg.Security.HSTSMaxAge; maxAge > 0 {
+		header.Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d; includeSubDomains", int64(maxAge.Seconds())))
+	}
+}
+
+// corsHandler answers preflight requests and adds the CORS headers for the
+// configured origins. It returns nil when CORS is disabled.
+func (h *Handler) corsHandler() gin.HandlerFunc {
+	cfg := h.cfg.CORS
+	if len(cfg.AllowedOrigins) == 0 {
+		return nil
+	}
+
+	return cors.New(cors.Config{
+		AllowOrigins:     cfg.AllowedOrigins,
+		AllowMethods:     cfg.AllowedMethods,
+		AllowHeaders:     cfg.AllowedHeaders,
+		ExposeHeaders:    cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	})
 }
 
 func getUserId(c *gin.Context) (int, error) {
@@ -55,3 +159,69 @@ func getUserId(c *gin.Context) (int, error) {
 
 	return idInt, nil
 }
+
+func observeRequest(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+
+	metrics.ObserveRequest(c.Request.Method, route, strconv.Itoa(c.Writer.Status()), time.Since(start).Seconds())
+}
+
+// probePaths are polled by Prometheus and orchestrators. They are not traced,
+// and logged at DEBUG unless they fail.
+var probePaths = map[string]bool{"/metrics": true, "/healthz": true, "/readyz": true}
+
+func isTraced(r *http.Request) bool {
+	return !probePaths[r.URL.Path]
+}
+
+// rateLimit applies the limit of the route group, keyed by the authenticated
+// user when there is one and by client IP otherwise.
+func (h *Handler) rateLimit(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if userId, ok := c.Get(userCtx); ok {
+			key = fmt.Sprintf("user:%d", userId)
+		}
+
+		h.limit(c, group, key)
+	}
+}
+
+// limit counts the request against the limit of group for key, sets the
+// RateLimit headers and answers 429 when the limit is exceeded. It reports
+// whether the request may go on.
+func (h *Handler) limit(c *gin.Context, group, key string) bool {
+	if h.limiter == nil {
+		return true
+	}
+
+	result := h.limiter.Allow(c.Request.Context(), group, key)
+	if result.Limit.Burst == 0 {
+		return true
+	}
+
+	limit := result.Limit
+	c.Header("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", ceilSeconds(result.Reset))
+	c.Header("RateLimit-Policy", fmt.Sprintf("%.0f;w=%.0f;burst=%d", limit.Rate*limit.Window.Seconds(), limit.Window.Seconds(), limit.Burst))
+
+	if !result.Allowed {
+		metrics.ObserveRateLimited(group)
+		c.Header("Retry-After", ceilSeconds(result.RetryAfter))
+		newErrorResponse(c, http.StatusTooManyRequests, "rate limit exceeded")
+		return false
+	}
+
+	return true
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -1,14 +1,24 @@
 package handler
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"errors"
 	"fmt"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Yoshisoul/rest-wallets/internal/config"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/Yoshisoul/rest-wallets/internal/ratelimit"
 	"github.com/Yoshisoul/rest-wallets/internal/service"
 	mockService "github.com/Yoshisoul/rest-wallets/internal/service/mocks"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
@@ -85,7 +95,7 @@ func TestHandler_userIdnentity(t *testing.T) {
 			testCase.mockBehavior(auth, testCase.token)
 
 			services := &service.Service{Authorization: auth}
-			handler := NewHandler(services)
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
 
 			// Test Server
 			r := gin.New() // test endpoint
@@ -150,3 +160,330 @@ func TestGetUserId(t *testing.T) {
 		})
 	}
 }
+
+// verified is the state of a TLS connection with a verified client
+// certificate for commonName.
+func verified(commonName string) *tls.ConnectionState {
+	return &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: commonName}}}},
+	}
+}
+
+func TestHandler_clientIdentity(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		tls                  *tls.ConnectionState
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Plain HTTP",
+			expectedStatusCode:   200,
+			expectedResponseBody: "",
+		},
+		{
+			name:                 "No Client Certificate",
+			tls:                  &tls.ConnectionState{},
+			expectedStatusCode:   200,
+			expectedResponseBody: "",
+		},
+		{
+			name:                 "Known Certificate",
+			tls:                  verified("reconciler.internal"),
+			expectedStatusCode:   200,
+			expectedResponseBody: "reconciler",
+		},
+		{
+			name:                 "Unknown Certificate",
+			tls:                  verified("someone.internal"),
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"unknown client certificate"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			handler := NewHandler(nil, config.HTTPConfig{TLS: config.TLSConfig{
+				ClientIdentities: []config.ClientIdentity{{CommonName: "reconciler.internal", Identity: "reconciler"}},
+			}}, "rest-wallets", nil)
+
+			r := gin.New()
+			r.GET("/internal", handler.clientIdentity, func(c *gin.Context) {
+				c.String(200, c.GetString(serviceCtx))
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/internal", nil)
+			req.TLS = testCase.tls
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_rateLimit(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{
+		"auth": {Rate: 1.0 / 60, Burst: 2, Window: time.Minute},
+	})
+	handler := NewHandler(nil, config.HTTPConfig{}, "rest-wallets", limiter)
+
+	r := gin.New()
+	r.POST("/auth", handler.rateLimit("auth"), func(c *gin.Context) { c.Status(200) })
+	r.POST("/user", func(c *gin.Context) { c.Set(userCtx, 1) }, handler.rateLimit("auth"), func(c *gin.Context) { c.Status(200) })
+	r.POST("/open", handler.rateLimit("default"), func(c *gin.Context) { c.Status(200) })
+
+	send := func(path, ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", path, nil)
+		req.RemoteAddr = ip + ":1234"
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("/auth", "10.0.0.1")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1;w=60;burst=2", w.Header().Get("RateLimit-Policy"))
+
+	assert.Equal(t, 200, send("/auth", "10.0.0.1").Code)
+
+	w = send("/auth", "10.0.0.1")
+	assert.Equal(t, 429, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Equal(t, `{"message":"rate limit exceeded"}`, w.Body.String())
+
+	// Another client IP and an authenticated user have their own buckets.
+	assert.Equal(t, 200, send("/auth", "10.0.0.2").Code)
+	assert.Equal(t, 200, send("/user", "10.0.0.1").Code)
+
+	// Groups without a limit send no headers.
+	w = send("/open", "10.0.0.1")
+	assert.Equal(t, 200, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestHandler_unauthorized(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	auth := mockService.NewMockAuthorization(c)
+	auth.EXPECT().ParseToken("guess").Return(0, errors.New("invalid token")).Times(3)
+	auth.EXPECT().ParseToken("token").Return(1, nil)
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{
+		"auth": {Rate: 1.0 / 60, Burst: 2, Window: time.Minute},
+	})
+	handler := NewHandler(&service.Service{Authorization: auth}, config.HTTPConfig{}, "rest-wallets", limiter)
+
+	r := gin.New()
+	r.GET("/wallets", handler.userIdentity, handler.rateLimit("default"), func(c *gin.Context) { c.Status(200) })
+
+	send := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/wallets", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Failed tokens count against the auth limit of the IP.
+	assert.Equal(t, 401, send("guess").Code)
+	assert.Equal(t, 401, send("guess").Code)
+	w := send("guess")
+	assert.Equal(t, 429, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// Valid tokens don't.
+	assert.Equal(t, 200, send("token").Code)
+}
+
+func TestHandler_session(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	auth := mockService.NewMockAuthorization(c)
+	wallet := mockService.NewMockWallet(c)
+	transaction := mockService.NewMockTransaction(c)
+	handler := NewHandler(&service.Service{Authorization: auth, Wallet: wallet, Transaction: transaction}, config.HTTPConfig{MaxBodyBytes: 1 << 20}, "rest-wallets", nil)
+	r := handler.InitRoutes()
+
+	walletId := uuid.New()
+	sessions := map[int][]string{}
+	auth.EXPECT().ParseToken("alice").Return(1, nil).AnyTimes()
+	auth.EXPECT().ParseToken("bob").Return(2, nil).AnyTimes()
+	wallet.EXPECT().GetByIdFromUser(gomock.Any(), gomock.Any(), walletId).DoAndReturn(
+		func(ctx context.Context, userId int, walletId uuid.UUID) (models.Wallet, error) {
+			sessions[userId] = service.SessionKeys(ctx)
+			return models.Wallet{WalletId: walletId, UserId: userId}, nil
+		}).Times(2)
+	transaction.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input models.TransactionInput) (uuid.UUID, error) {
+			sessions[0] = service.SessionKeys(ctx)
+			return uuid.New(), nil
+		})
+
+	// Both users are behind the same proxy.
+	send := func(method, target, token, body string) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.RemoteAddr = "10.0.0.1:1234"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+	}
+	send("GET", "/api/v1/wallets/"+walletId.String(), "alice", "")
+	send("GET", "/api/v1/wallets/"+walletId.String(), "bob", "")
+	send("POST", "/api/v1/transactions/", "", `{"walletId":"`+walletId.String()+`","operationType":"DEPOSIT","amount":100}`)
+
+	// The users share no key, so a write of one doesn't send the reads of
+	// the other to the primary. A transaction written without a token is
+	// keyed by its wallet.
+	assert.ElementsMatch(t, []string{"user:1", "wallet:" + walletId.String()}, sessions[1])
+	assert.ElementsMatch(t, []string{"user:2", "wallet:" + walletId.String()}, sessions[2])
+	assert.Equal(t, []string{"wallet:" + walletId.String()}, sessions[0])
+}
+
+func TestHandler_limitBody(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		chunked              bool
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Ok",
+			inputBody:            `{"username":"user"}`,
+			expectedStatusCode:   200,
+			expectedResponseBody: "user",
+		},
+		{
+			name:                 "Content Length Too Large",
+			inputBody:            `{"username":"` + strings.Repeat("a", 64) + `"}`,
+			expectedStatusCode:   413,
+			expectedResponseBody: `{"message":"request body too large"}`,
+		},
+		{
+			name:                 "Streamed Body Too Large",
+			inputBody:            `{"username":"` + strings.Repeat("a", 64) + `"}`,
+			chunked:              true,
+			expectedStatusCode:   413,
+			expectedResponseBody: `{"message":"request body too large"}`,
+		},
+		{
+			name:                 "Invalid Body",
+			inputBody:            `{"username":`,
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/", limitBody(1<<10), limitBody(32), func(c *gin.Context) {
+				var input struct {
+					Username string `json:"username"`
+				}
+				if !bindJSON(c, &input) {
+					return
+				}
+				c.String(200, input.Username)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/", strings.NewReader(testCase.inputBody))
+			if testCase.chunked {
+				req.ContentLength = -1
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_securityHeaders(t *testing.T) {
+	handler := NewHandler(nil, config.HTTPConfig{
+		Security: config.SecurityConfig{HSTSMaxAge: time.Hour, ReferrerPolicy: "no-referrer"},
+	}, "rest-wallets", nil)
+
+	r := gin.New()
+	r.GET("/", handler.securityHeaders, func(c *gin.Context) { c.Status(200) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, "max-age=3600; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+	assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", w.Header().Get("Content-Security-Policy"))
+}
+
+func TestHandler_cors(t *testing.T) {
+	handler := NewHandler(nil, config.HTTPConfig{
+		MaxBodyBytes: 1 << 20,
+		CORS: config.CORSConfig{
+			AllowedOrigins:   []string{"https://app.example.com"},
+			AllowedMethods:   []string{"GET", "POST"},
+			AllowedHeaders:   []string{"Authorization", "Content-Type"},
+			ExposedHeaders:   []string{"X-Request-ID"},
+			AllowCredentials: true,
+			MaxAge:           time.Hour,
+		},
+	}, "rest-wallets", nil)
+	r := handler.InitRoutes()
+
+	testTable := []struct {
+		name               string
+		method             string
+		origin             string
+		expectedStatusCode int
+		expectedOrigin     string
+	}{
+		{
+			name:               "Preflight",
+			method:             "OPTIONS",
+			origin:             "https://app.example.com",
+			expectedStatusCode: 204,
+			expectedOrigin:     "https://app.example.com",
+		},
+		{
+			name:               "Preflight Unknown Origin",
+			method:             "OPTIONS",
+			origin:             "https://evil.example.com",
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "Simple Request",
+			method:             "GET",
+			origin:             "https://app.example.com",
+			expectedStatusCode: 401,
+			expectedOrigin:     "https://app.example.com",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, "/api/v1/wallets/", nil)
+			req.Header.Set("Origin", testCase.origin)
+			req.Header.Set("Access-Control-Request-Method", "GET")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			if testCase.expectedOrigin != "" {
+				assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+			}
+		})
+	}
+}
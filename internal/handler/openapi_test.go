@@ -0,0 +1,638 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/config"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/Yoshisoul/rest-wallets/internal/service"
+	mockService "github.com/Yoshisoul/rest-wallets/internal/service/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSpec_CoversRoutes(t *testing.T) {
+	spec, err := loadSpec()
+	require.NoError(t, err)
+
+	handler := NewHandler(nil, config.HTTPConfig{MaxBodyBytes: 1 << 20}, "rest-wallets", nil)
+	for _, route := range handler.InitRoutes().Routes() {
+		if route.Path == "/openapi.json" || strings.HasPrefix(route.Path, "/docs/") {
+			continue
+		}
+
+		path := strings.ReplaceAll(route.Path, ":id", uuid.NewString())
+		_, _, err := spec.FindRoute(httptest.NewRequest(route.Method, path, nil))
+		assert.NoError(t, err, "%s %s is not in the spec", route.Method, route.Path)
+	}
+}
+
+// TestHandler_contract runs the handlers behind the validation middleware,
+// so responses that drift from the spec fail with 500.
+func TestHandler_contract(t *testing.T) {
+	walletId := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type mocks struct {
+		auth        *mockService.MockAuthorization
+		wallet      *mockService.MockWallet
+		transaction *mockService.MockTransaction
+		balance     *mockService.MockBalance
+		reconcile   *mockService.MockReconciliation
+		chain       *mockService.MockChain
+		category    *mockService.MockCategory
+	}
+
+	testTable := []struct {
+		name       string
+		method     string
+		target     string
+		inputBody  string
+		header     http.Header
+		authorized bool
+		// service sends a client certificate mapped to a service identity.
+		service            bool
+		mockBehavior       func(m mocks)
+		expectedStatusCode int
+	}{
+		{
+			name:      "Sign Up",
+			method:    "POST",
+			target:    "/auth/sign-up",
+			inputBody: `{"name":"Test","username":"test","password":"qwerty"}`,
+			mockBehavior: func(m mocks) {
+				m.auth.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(1, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Sign Up Missing Field",
+			method:             "POST",
+			target:             "/auth/sign-up",
+			inputBody:          `{"username":"test","password":"qwerty"}`,
+			mockBehavior:       func(m mocks) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:      "Sign In Invalid Password",
+			method:    "POST",
+			target:    "/auth/sign-in",
+			inputBody: `{"username":"test","password":"qwerty"}`,
+			mockBehavior: func(m mocks) {
+				m.auth.EXPECT().GenerateToken(gomock.Any(), "test", "qwerty").Return("", sql.ErrNoRows)
+			},
+			expectedStatusCode: 404,
+		},
+		{
+			name:       "Create Wallet",
+			method:     "POST",
+			target:     "/api/v1/wallets/",
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.wallet.EXPECT().Create(gomock.Any(), 1).Return(walletId, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Create Wallet Unauthorized",
+			method:             "POST",
+			target:             "/api/v1/wallets/",
+			mockBehavior:       func(m mocks) {},
+			expectedStatusCode: 401,
+		},
+		{
+			name:       "List Wallets Empty",
+			method:     "GET",
+			target:     "/api/v1/wallets/",
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.wallet.EXPECT().GetAllFromUser(gomock.Any(), 1, models.WalletFilter{}, models.Page{Limit: 50}).Return(nil, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:       "List Wallets Filtered",
+			method:     "GET",
+			target:     "/api/v1/wallets/?name=trip&tag=home&tag=shared&minAmount=10&sort=-name",
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				minAmount := int64(10)
+				m.wallet.EXPECT().GetAllFromUser(gomock.Any(), 1, models.WalletFilter{
+					Name:      "trip",
+					Tags:      []string{"home", "shared"},
+					MinAmount: &minAmount,
+					OrderBy:   models.WalletsByName,
+					Desc:      true,
+				}, models.Page{Limit: 50}).Return([]models.Wallet{{
+					WalletId:  walletId,
+					UserId:    1,
+					Amount:    10,
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+					Status:    models.WalletActive,
+					Version:   2,
+					Name:      "Trip",
+					Tags:      models.Tags{"home", "shared"},
+				}}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:   "List Wallets Unknown Sort",
+			method: "GET",
+			target: "/api/v1/wallets/?sort=status",
+			// Rejected by the spec before the token is checked.
+			header:             http.Header{"Authorization": {"Bearer token"}},
+			mockBehavior:       func(m mocks) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:       "Update Wallet",
+			method:     "PATCH",
+			target:     "/api/v1/wallets/" + walletId.String(),
+			inputBody:  `{"name":"Savings","color":"#00FF00","tags":["home"],"isDefault":true}`,
+			header:     http.Header{"If-Match": {`"2"`}},
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.wallet.EXPECT().Update(gomock.Any(), 1, walletId, gomock.Any()).Return(models.Wallet{
+					WalletId:  walletId,
+					UserId:    1,
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+					Status:    models.WalletActive,
+					Version:   3,
+					Name:      "Savings",
+					Color:     "#00ff00",
+					Tags:      models.Tags{"home"},
+					IsDefault: true,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Update Wallet Invalid Color",
+			method:             "PATCH",
+			target:             "/api/v1/wallets/" + walletId.String(),
+			inputBody:          `{"color":"green"}`,
+			header:             http.Header{"Authorization": {"Bearer token"}},
+			mockBehavior:       func(m mocks) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:       "Update Closed Wallet",
+			method:     "PATCH",
+			target:     "/api/v1/wallets/" + walletId.String(),
+			inputBody:  `{"isDefault":true}`,
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.wallet.EXPECT().Update(gomock.Any(), 1, walletId, gomock.Any()).Return(models.Wallet{}, models.ErrWalletClosed)
+			},
+			expectedStatusCode: 409,
+		},
+		{
+			name:       "Get Closed Wallet",
+			method:     "GET",
+			target:     "/api/v1/wallets/" + walletId.String(),
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				reason := "moved"
+				m.wallet.EXPECT().GetByIdFromUser(gomock.Any(), 1, walletId).Return(models.Wallet{
+					WalletId:    walletId,
+					UserId:      1,
+					CreatedAt:   createdAt,
+					UpdatedAt:   createdAt,
+					Status:      models.WalletClosed,
+					ClosedAt:    &createdAt,
+					CloseReason: &reason,
+					Version:     2,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:       "Get Wallet Not Modified",
+			method:     "GET",
+			target:     "/api/v1/wallets/" + walletId.String(),
+			header:     http.Header{"If-None-Match": {`"2"`}},
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.wallet.EXPECT().GetByIdFromUser(gomock.Any(), 1, walletId).Return(models.Wallet{WalletId: walletId, Version: 2}, nil)
+			},
+			expectedStatusCode: 304,
+		},
+		{
+			name:       "Get Wallet Balance",
+			method:     "GET",
+			target:     "/api/v1/wallets/" + walletId.String() + "/balance?at=2024-01-02T00:00:00Z",
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.balance.EXPECT().At(gomock.Any(), 1, walletId, createdAt.AddDate(0, 0, 1)).Return(int64(100), nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:       "Get Balance History",
+			method:     "GET",
+			target:     "/api/v1/wallets/" + walletId.String() + "/balance-history?interval=day&from=2024-01-01T00:00:00Z&to=2024-01-03T00:00:00Z",
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.balance.EXPECT().History(gomock.Any(), 1, walletId, gomock.Any()).Return([]models.BalancePoint{
+					{Date: createdAt, Balance: 100},
+					{Date: createdAt.AddDate(0, 0, 1), Balance: 40},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:       "Get Balance History Too Many Points",
+			method:     "GET",
+			target:     "/api/v1/wallets/" + walletId.String() + "/balance-history?interval=hour&from=2020-01-01T00:00:00Z",
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.balance.EXPECT().History(gomock.Any(), 1, walletId, gomock.Any()).Return(nil, models.ErrTooManyPoints)
+			},
+			expectedStatusCode: 400,
+		},
+		{
+			name:      "Create Transaction Version Mismatch",
+			method:    "POST",
+			target:    "/api/v1/transactions/",
+			inputBody: `{"walletId":"` + walletId.String() + `","operationType":"WITHDRAW","amount":10}`,
+			header:    http.Header{"If-Match": {`"2"`}},
+			mockBehavior: func(m mocks) {
+				m.transaction.EXPECT().Create(gomock.Any(), gomock.Any()).Return(uuid.Nil, models.ErrVersionMismatch)
+			},
+			expectedStatusCode: 412,
+		},
+		{
+			name:               "Get Wallet Invalid Id",
+			method:             "GET",
+			target:             "/api/v1/wallets/1",
+			authorized:         true,
+			mockBehavior:       func(m mocks) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:       "Close Wallet Conflict",
+			method:     "DELETE",
+			target:     "/api/v1/wallets/" + walletId.String() + "?reason=moved",
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.wallet.EXPECT().Close(gomock.Any(), 1, walletId, gomock.Any()).Return(models.ErrNonZeroBalance)
+			},
+			expectedStatusCode: 409,
+		},
+		{
+			name:      "Create Transaction",
+			method:    "POST",
+			target:    "/api/v1/transactions/",
+			inputBody: `{"walletId":"` + walletId.String() + `","operationType":"DEPOSIT","amount":100}`,
+			mockBehavior: func(m mocks) {
+				m.transaction.EXPECT().Create(gomock.Any(), gomock.Any()).Return(walletId, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Create Transaction Unknown Operation",
+			method:             "POST",
+			target:             "/api/v1/transactions/",
+			inputBody:          `{"walletId":"` + walletId.String() + `","operationType":"REFUND","amount":100}`,
+			mockBehavior:       func(m mocks) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:   "List Transactions",
+			method: "GET",
+			target: "/api/v1/transactions/?limit=2&offset=4",
+			mockBehavior: func(m mocks) {
+				m.transaction.EXPECT().GetAll(gomock.Any(), models.TransactionFilter{}, models.Page{Limit: 2, Offset: 4}).Return([]models.Transaction{{
+					TransactionId: walletId,
+					WalletId:      walletId,
+					OperationType: models.Deposit,
+					Amount:        100,
+					CreatedAt:     createdAt,
+				}}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:   "List Transactions Filtered",
+			method: "GET",
+			target: "/api/v1/transactions/?category=" + walletId.String() + "&q=lunch&metadata[shop]=cafe",
+			mockBehavior: func(m mocks) {
+				description, externalRef := "Lunch", "order-1"
+				m.transaction.EXPECT().GetAll(gomock.Any(), models.TransactionFilter{
+					CategoryId: &walletId,
+					Search:     "lunch",
+					Metadata:   map[string]string{"shop": "cafe"},
+				}, models.Page{Limit: 50}).Return([]models.Transaction{{
+					TransactionId: walletId,
+					WalletId:      walletId,
+					OperationType: models.Deposit,
+					Amount:        100,
+					CreatedAt:     createdAt,
+					Description:   &description,
+					CategoryId:    &walletId,
+					ExternalRef:   &externalRef,
+					Metadata:      models.Metadata(`{"shop":"cafe"}`),
+				}}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:      "Create Transaction With Details",
+			method:    "POST",
+			target:    "/api/v1/transactions/",
+			inputBody: `{"walletId":"` + walletId.String() + `","operationType":"DEPOSIT","amount":100,"description":"Lunch","metadata":{"shop":"cafe"}}`,
+			mockBehavior: func(m mocks) {
+				m.transaction.EXPECT().Create(gomock.Any(), gomock.Any()).Return(walletId, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:       "Annotate Transaction",
+			method:     "PUT",
+			target:     "/api/v1/transactions/" + walletId.String() + "/annotation",
+			inputBody:  `{"categoryId":"` + walletId.String() + `","notes":"birthday"}`,
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.transaction.EXPECT().Annotate(gomock.Any(), 1, walletId, gomock.Any()).Return(models.Annotation{
+					TransactionId: walletId, Version: 2, CategoryId: &walletId, Notes: "birthday", CreatedAt: createdAt,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:       "List Annotations",
+			method:     "GET",
+			target:     "/api/v1/transactions/" + walletId.String() + "/annotations",
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.transaction.EXPECT().GetAnnotations(gomock.Any(), 1, walletId, models.Page{Limit: 50}).Return([]models.Annotation{
+					{TransactionId: walletId, Version: 1, CreatedAt: createdAt},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:       "Create Category Exists",
+			method:     "POST",
+			target:     "/api/v1/categories/",
+			inputBody:  `{"name":"food"}`,
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.category.EXPECT().Create(gomock.Any(), 1, models.CategoryInput{Name: "food"}).Return(uuid.Nil, models.ErrCategoryExists)
+			},
+			expectedStatusCode: 409,
+		},
+		{
+			name:       "List Categories",
+			method:     "GET",
+			target:     "/api/v1/categories/",
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.category.EXPECT().GetAll(gomock.Any(), 1, models.Page{Limit: 50}).Return([]models.Category{
+					{CategoryId: walletId, UserId: 1, ParentId: &walletId, Name: "food", CreatedAt: createdAt},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:       "Delete Category In Use",
+			method:     "DELETE",
+			target:     "/api/v1/categories/" + walletId.String(),
+			authorized: true,
+			mockBehavior: func(m mocks) {
+				m.category.EXPECT().Delete(gomock.Any(), 1, walletId).Return(models.ErrCategoryInUse)
+			},
+			expectedStatusCode: 409,
+		},
+		{
+			name:   "Get Transaction Service Failure",
+			method: "GET",
+			target: "/api/v1/transactions/" + walletId.String(),
+			mockBehavior: func(m mocks) {
+				m.transaction.EXPECT().GetById(gomock.Any(), walletId).Return(models.Transaction{}, errors.New("connection refused"))
+			},
+			expectedStatusCode: 500,
+		},
+		{
+			name:    "Reconcile",
+			method:  "POST",
+			target:  "/admin/reconciliation?openCases=true",
+			service: true,
+			mockBehavior: func(m mocks) {
+				caseId := uuid.New()
+				m.reconcile.EXPECT().Run(gomock.Any(), true).Return(models.ReconciliationReport{
+					StartedAt:  createdAt,
+					FinishedAt: createdAt,
+					Wallets:    1,
+					Mismatches: []models.BalanceMismatch{{
+						WalletId: walletId, UserId: 1, Status: models.WalletActive,
+						Stored: 10, Computed: 0, Difference: 10, CaseId: &caseId,
+					}},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Reconcile Without Certificate",
+			method:             "POST",
+			target:             "/admin/reconciliation",
+			mockBehavior:       func(m mocks) {},
+			expectedStatusCode: 403,
+		},
+		{
+			name:    "List Correction Cases",
+			method:  "GET",
+			target:  "/admin/correction-cases?status=RESOLVED",
+			service: true,
+			mockBehavior: func(m mocks) {
+				resolution := "refunded"
+				m.reconcile.EXPECT().GetCases(gomock.Any(), models.CaseResolved, models.Page{Limit: 50}).Return([]models.CorrectionCase{{
+					CaseId: walletId, WalletId: walletId, Stored: 10, Computed: 0,
+					Status: models.CaseResolved, OpenedAt: createdAt, ResolvedAt: &createdAt, Resolution: &resolution,
+				}}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:      "Resolve Correction Case",
+			method:    "POST",
+			target:    "/admin/correction-cases/" + walletId.String() + "/resolve",
+			inputBody: `{"resolution":"refunded"}`,
+			service:   true,
+			mockBehavior: func(m mocks) {
+				m.reconcile.EXPECT().ResolveCase(gomock.Any(), walletId, "refunded").Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:    "Verify Chain",
+			method:  "GET",
+			target:  "/admin/wallets/" + walletId.String() + "/chain",
+			service: true,
+			mockBehavior: func(m mocks) {
+				m.chain.EXPECT().Verify(gomock.Any(), walletId).Return(models.ChainVerification{
+					WalletId: walletId, Transactions: 3,
+					Break: &models.ChainBreak{Seq: 2, TransactionId: walletId, Reason: models.BreakPrevHash},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:    "List Checkpoints",
+			method:  "GET",
+			target:  "/admin/checkpoints",
+			service: true,
+			mockBehavior: func(m mocks) {
+				m.chain.EXPECT().GetCheckpoints(gomock.Any(), models.Page{Limit: 50}).Return([]models.Checkpoint{{
+					CheckpointId: walletId, At: createdAt, Wallets: 1, Transactions: 3,
+					Root: "ab12", PublicKey: "a2V5", Signature: "c2ln", CreatedAt: createdAt,
+				}}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:    "Verify Checkpoint",
+			method:  "GET",
+			target:  "/admin/checkpoints/" + walletId.String() + "/verify",
+			service: true,
+			mockBehavior: func(m mocks) {
+				m.chain.EXPECT().VerifyCheckpoint(gomock.Any(), walletId).Return(models.CheckpointVerification{
+					Checkpoint: models.Checkpoint{
+						CheckpointId: walletId, At: createdAt, Root: "ab12", PublicKey: "a2V5", Signature: "c2ln", CreatedAt: createdAt,
+					},
+					ComputedRoot: "ab12", RootMatches: true, SignatureValid: true, KeyMatches: true, Valid: true,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			m := mocks{
+				auth:        mockService.NewMockAuthorization(c),
+				wallet:      mockService.NewMockWallet(c),
+				transaction: mockService.NewMockTransaction(c),
+				balance:     mockService.NewMockBalance(c),
+				reconcile:   mockService.NewMockReconciliation(c),
+				chain:       mockService.NewMockChain(c),
+				category:    mockService.NewMockCategory(c),
+			}
+			testCase.mockBehavior(m)
+			if testCase.authorized {
+				m.auth.EXPECT().ParseToken("token").Return(1, nil)
+			}
+
+			services := &service.Service{Authorization: m.auth, Wallet: m.wallet, Transaction: m.transaction, Category: m.category,
+				Balance: m.balance, Reconciliation: m.reconcile, Chain: m.chain}
+			handler := NewHandler(services, config.HTTPConfig{MaxBodyBytes: 1 << 20, ValidateOpenAPI: true, TLS: config.TLSConfig{
+				ClientIdentities: []config.ClientIdentity{{CommonName: "reconciler.internal", Identity: "reconciler"}},
+			}}, "rest-wallets", nil)
+			r := handler.InitRoutes()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.target, strings.NewReader(testCase.inputBody))
+			for name, values := range testCase.header {
+				req.Header[name] = values
+			}
+			if testCase.inputBody != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if testCase.authorized {
+				req.Header.Set("Authorization", "Bearer token")
+			}
+			if testCase.service {
+				req.TLS = verified("reconciler.internal")
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestValidateOpenAPI_Response(t *testing.T) {
+	spec, err := loadSpec()
+	require.NoError(t, err)
+
+	testTable := []struct {
+		name               string
+		handler            gin.HandlerFunc
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name: "Ok",
+			handler: func(c *gin.Context) {
+				c.JSON(200, getAllTransactionsResponse{Transactions: []models.Transaction{}, Limit: 50})
+			},
+			expectedStatusCode: 200,
+			expectedBody:       `{"data":[],"limit":50,"offset":0}`,
+		},
+		{
+			name: "Missing Field",
+			handler: func(c *gin.Context) {
+				c.JSON(200, map[string]any{"wallets": []any{}})
+			},
+			expectedStatusCode: 500,
+			expectedBody:       `{"message":"response does not match openapi spec"}`,
+		},
+		{
+			name: "Undocumented Status",
+			handler: func(c *gin.Context) {
+				c.JSON(418, errorResponce{"teapot"})
+			},
+			expectedStatusCode: 500,
+			expectedBody:       `{"message":"response does not match openapi spec"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(validateOpenAPI(spec))
+			r.GET("/api/v1/transactions/", testCase.handler)
+			r.GET("/other", func(c *gin.Context) { c.String(418, "not in spec") })
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/transactions/", nil))
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+
+			// Routes missing from the spec are not validated.
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/other", nil))
+			assert.Equal(t, 418, w.Code)
+		})
+	}
+}
+
+func TestHandler_docs(t *testing.T) {
+	r := NewHandler(nil, config.HTTPConfig{MaxBodyBytes: 1 << 20}, "rest-wallets", nil).InitRoutes()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"openapi": "3.1.0"`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/docs/", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `<script src="docs.js"`)
+	assert.Equal(t, docsPolicy, w.Header().Get("Content-Security-Policy"))
+}
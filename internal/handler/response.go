@@ -1,8 +1,14 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
 	"github.com/gin-gonic/gin"
-	"github.com/sirupsen/logrus"
 )
 
 type errorResponce struct {
@@ -13,7 +19,129 @@ type statusResponse struct {
 	Status string `json:"status"`
 }
 
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// parsePage reads the limit and offset query params of list routes. When
+// they are invalid it answers 400 and returns false.
+func parsePage(c *gin.Context) (models.Page, bool) {
+	page := models.Page{Limit: defaultPageLimit}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			newErrorResponse(c, http.StatusBadRequest, "invalid limit param")
+			return page, false
+		}
+		page.Limit = limit
+	}
+
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			newErrorResponse(c, http.StatusBadRequest, "invalid offset param")
+			return page, false
+		}
+		page.Offset = offset
+	}
+
+	return page, true
+}
+
+// newErrorResponse aborts the request with the message. The message is
+// logged once, by the access log, at a level matching the status code.
 func newErrorResponse(c *gin.Context, statusCode int, message string) {
-	logrus.Error(message)
+	c.Error(errors.New(message))
 	c.AbortWithStatusJSON(statusCode, errorResponce{message})
 }
+
+// serviceFailure answers an error the handler has no status for: 503 while
+// the database is unavailable, 500 with message otherwise.
+func serviceFailure(c *gin.Context, err error, message string) {
+	var unavailable *models.UnavailableError
+	if errors.As(err, &unavailable) {
+		c.Header("Retry-After", ceilSeconds(max(unavailable.RetryAfter, time.Second)))
+		newErrorResponse(c, http.StatusServiceUnavailable, "service unavailable")
+		return
+	}
+
+	newErrorResponse(c, http.StatusInternalServerError, message)
+}
+
+// bindJSON decodes the request body into obj. When it fails it answers 413
+// for bodies over the route limit, 400 otherwise, and returns false.
+func bindJSON(c *gin.Context, obj any) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		newErrorResponse(c, http.StatusRequestEntityTooLarge, "request body too large")
+		return false
+	}
+
+	newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+	return false
+}
+
+// walletETag is the entity tag of a wallet: its version.
+func walletETag(wallet models.Wallet) string {
+	return `"` + strconv.FormatInt(wallet.Version, 10) + `"`
+}
+
+// ifMatch reads the wallet version a mutating request is conditional on from
+// the If-Match header. The version is nil without the header or for "*". A
+// tag that is weak or not a wallet version never matches under the strong
+// comparison If-Match uses, so the request fails with 412. Several tags are
+// answered with 400, and ok is false.
+func ifMatch(c *gin.Context) (version *int64, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	if strings.Contains(header, ",") {
+		newErrorResponse(c, http.StatusBadRequest, "If-Match must name a single version")
+		return nil, false
+	}
+
+	expected, ok := parseETag(header)
+	if !ok {
+		// No wallet is at a negative version.
+		expected = -1
+	}
+
+	return &expected, true
+}
+
+// notModified reports whether the If-None-Match header names etag, using the
+// weak comparison of RFC 9110.
+func notModified(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseETag returns the wallet version of a strong entity tag.
+func parseETag(tag string) (int64, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	return version, err == nil
+}
@@ -12,14 +12,35 @@ import (
 
 func (h *Handler) createTransaction(c *gin.Context) {
 	var input models.TransactionInput
-	if err := c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+	if !bindJSON(c, &input) {
 		return
 	}
+	addWalletSession(c, input.WalletId)
 
-	uuid, err := h.services.Transaction.Create(input)
+	var ok bool
+	if input.ExpectedVersion, ok = ifMatch(c); !ok {
+		return
+	}
+
+	uuid, err := h.services.Transaction.Create(c.Request.Context(), input)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, "service failure")
+		if errors.Is(err, sql.ErrNoRows) {
+			newErrorResponse(c, http.StatusNotFound, "wallet not found")
+			return
+		}
+		if errors.Is(err, models.ErrUnknownOperation) || errors.Is(err, models.ErrInvalidAmount) || isInvalidDetail(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrVersionMismatch) {
+			newErrorResponse(c, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		if isWalletConflict(err) {
+			newErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		serviceFailure(c, err, "service failure")
 		return
 	}
 
@@ -28,22 +49,76 @@ func (h *Handler) createTransaction(c *gin.Context) {
 	})
 }
 
+// isInvalidDetail reports whether err rejects the details of a transaction
+// or of its annotation.
+func isInvalidDetail(err error) bool {
+	return errors.Is(err, models.ErrInvalidMetadata) ||
+		errors.Is(err, models.ErrMetadataTooLarge) ||
+		errors.Is(err, models.ErrUnknownCategory)
+}
+
 type getAllTransactionsResponse struct {
 	Transactions []models.Transaction `json:"data"`
+	Limit        int                  `json:"limit"`
+	Offset       int                  `json:"offset"`
 }
 
 func (h *Handler) getAllTransactions(c *gin.Context) {
-	transactions, err := h.services.Transaction.GetAll()
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseTransactionFilter(c)
+	if !ok {
+		return
+	}
+
+	transactions, err := h.services.Transaction.GetAll(c.Request.Context(), filter, page)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, "service failure")
+		serviceFailure(c, err, "service failure")
 		return
 	}
+	if transactions == nil {
+		transactions = []models.Transaction{}
+	}
 
 	c.JSON(http.StatusOK, getAllTransactionsResponse{
 		Transactions: transactions,
+		Limit:        page.Limit,
+		Offset:       page.Offset,
 	})
 }
 
+// parseTransactionFilter reads the filter of a list of transactions from the
+// query: category, externalRef, q and metadata[key]. On invalid params it
+// answers 400 and ok is false.
+func parseTransactionFilter(c *gin.Context) (models.TransactionFilter, bool) {
+	filter := models.TransactionFilter{
+		ExternalRef: c.Query("externalRef"),
+		Search:      c.Query("q"),
+	}
+
+	if value := c.Query("category"); value != "" {
+		categoryId, err := uuid.Parse(value)
+		if err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "invalid category param")
+			return filter, false
+		}
+		filter.CategoryId = &categoryId
+	}
+
+	if metadata := c.QueryMap("metadata"); len(metadata) > 0 {
+		if len(metadata) > maxMetadataFilters {
+			newErrorResponse(c, http.StatusBadRequest, "too many metadata params")
+			return filter, false
+		}
+		filter.Metadata = metadata
+	}
+
+	return filter, true
+}
+
 func (h *Handler) getTransactionById(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -51,15 +126,92 @@ func (h *Handler) getTransactionById(c *gin.Context) {
 		return
 	}
 
-	transaction, err := h.services.Transaction.GetById(id)
+	transaction, err := h.services.Transaction.GetById(c.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			newErrorResponse(c, http.StatusNotFound, "transaction not found")
 			return
 		}
-		newErrorResponse(c, http.StatusInternalServerError, "service failure")
+		serviceFailure(c, err, "service failure")
 		return
 	}
 
 	c.JSON(http.StatusOK, transaction)
 }
+
+func (h *Handler) annotateTransaction(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	var input models.AnnotationInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	annotation, err := h.services.Transaction.Annotate(c.Request.Context(), userId, id, input)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			newErrorResponse(c, http.StatusNotFound, "transaction not found")
+			return
+		}
+		if isInvalidDetail(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		serviceFailure(c, err, "service failure")
+		return
+	}
+
+	c.JSON(http.StatusOK, annotation)
+}
+
+type getAnnotationsResponse struct {
+	Annotations []models.Annotation `json:"data"`
+	Limit       int                 `json:"limit"`
+	Offset      int                 `json:"offset"`
+}
+
+func (h *Handler) getTransactionAnnotations(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	annotations, err := h.services.Transaction.GetAnnotations(c.Request.Context(), userId, id, page)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			newErrorResponse(c, http.StatusNotFound, "transaction not found")
+			return
+		}
+		serviceFailure(c, err, "service failure")
+		return
+	}
+	if annotations == nil {
+		annotations = []models.Annotation{}
+	}
+
+	c.JSON(http.StatusOK, getAnnotationsResponse{
+		Annotations: annotations,
+		Limit:       page.Limit,
+		Offset:      page.Offset,
+	})
+}
@@ -5,9 +5,11 @@ import (
 	"database/sql"
 	"errors"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Yoshisoul/rest-wallets/internal/config"
 	"github.com/Yoshisoul/rest-wallets/internal/models"
 	"github.com/Yoshisoul/rest-wallets/internal/service"
 	mockService "github.com/Yoshisoul/rest-wallets/internal/service/mocks"
@@ -20,9 +22,14 @@ import (
 func TestHandler_createTransaction(t *testing.T) {
 	type mockBehavior func(s *mockService.MockTransaction, input models.TransactionInput)
 
+	version := int64(7)
+	description, externalRef := "Lunch", "order-1"
+	categoryId := uuid.MustParse("333e4444-e89b-12d3-a456-426614174000")
+
 	testTable := []struct {
 		name                string
 		inputBody           string
+		ifMatch             string
 		mockExpInput        models.TransactionInput
 		mockBehavior        mockBehavior
 		expectedStatusCode  int
@@ -37,11 +44,27 @@ func TestHandler_createTransaction(t *testing.T) {
 				Amount:        100,
 			},
 			mockBehavior: func(s *mockService.MockTransaction, input models.TransactionInput) {
-				s.EXPECT().Create(input).Return(uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"), nil)
+				s.EXPECT().Create(gomock.Any(), input).Return(uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"), nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `{"uuid":"111e2222-e89b-12d3-a456-426614174000"}`,
 		},
+		{
+			name:      "Version mismatch",
+			inputBody: `{"walletId": "123e4567-e89b-12d3-a456-426614174000", "operationType":"WITHDRAW", "amount": 100}`,
+			ifMatch:   `"7"`,
+			mockExpInput: models.TransactionInput{
+				WalletId:        uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType:   models.Withdraw,
+				Amount:          100,
+				ExpectedVersion: &version,
+			},
+			mockBehavior: func(s *mockService.MockTransaction, input models.TransactionInput) {
+				s.EXPECT().Create(gomock.Any(), input).Return(uuid.Nil, models.ErrVersionMismatch)
+			},
+			expectedStatusCode:  412,
+			expectedRequestBody: `{"message":"wallet version does not match"}`,
+		},
 		{
 			name:      "Ok Withdraw",
 			inputBody: `{"walletId": "123e4567-e89b-12d3-a456-426614174000", "operationType":"WITHDRAW", "amount": 100}`,
@@ -51,11 +74,53 @@ func TestHandler_createTransaction(t *testing.T) {
 				Amount:        100,
 			},
 			mockBehavior: func(s *mockService.MockTransaction, input models.TransactionInput) {
-				s.EXPECT().Create(input).Return(uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"), nil)
+				s.EXPECT().Create(gomock.Any(), input).Return(uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"), nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"uuid":"111e2222-e89b-12d3-a456-426614174000"}`,
+		},
+		{
+			name: "Ok with details",
+			inputBody: `{"walletId": "123e4567-e89b-12d3-a456-426614174000", "operationType":"DEPOSIT", "amount": 100,
+				"description": "Lunch", "categoryId": "333e4444-e89b-12d3-a456-426614174000", "externalRef": "order-1",
+				"metadata": {"shop": "cafe"}}`,
+			mockExpInput: models.TransactionInput{
+				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType: models.Deposit,
+				Amount:        100,
+				Description:   &description,
+				CategoryId:    &categoryId,
+				ExternalRef:   &externalRef,
+				Metadata:      models.Metadata(`{"shop": "cafe"}`),
+			},
+			mockBehavior: func(s *mockService.MockTransaction, input models.TransactionInput) {
+				s.EXPECT().Create(gomock.Any(), input).Return(uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"), nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `{"uuid":"111e2222-e89b-12d3-a456-426614174000"}`,
 		},
+		{
+			name:      "Invalid metadata",
+			inputBody: `{"walletId": "123e4567-e89b-12d3-a456-426614174000", "operationType":"DEPOSIT", "amount": 100, "metadata": [1]}`,
+			mockExpInput: models.TransactionInput{
+				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType: models.Deposit,
+				Amount:        100,
+				Metadata:      models.Metadata(`[1]`),
+			},
+			mockBehavior: func(s *mockService.MockTransaction, input models.TransactionInput) {
+				s.EXPECT().Create(gomock.Any(), input).Return(uuid.Nil, models.ErrInvalidMetadata)
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"metadata must be a JSON object"}`,
+		},
+		{
+			name:                "Description too long",
+			inputBody:           `{"walletId": "123e4567-e89b-12d3-a456-426614174000", "operationType":"DEPOSIT", "amount": 100, "description": "` + strings.Repeat("a", 501) + `"}`,
+			mockBehavior:        func(s *mockService.MockTransaction, input models.TransactionInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
 		{
 			name:                "Empty fields",
 			inputBody:           `{"walletId": "123e4567-e89b-12d3-a456-426614174000", "operationType":"WITHDRAW"}`,
@@ -70,6 +135,62 @@ func TestHandler_createTransaction(t *testing.T) {
 			expectedStatusCode:  400,
 			expectedRequestBody: `{"message":"invalid input body"}`,
 		},
+		{
+			name:                "Zero amount",
+			inputBody:           `{"walletId": "123e4567-e89b-12d3-a456-426614174000", "operationType":"DEPOSIT", "amount": 0}`,
+			mockBehavior:        func(s *mockService.MockTransaction, input models.TransactionInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:                "Negative amount",
+			inputBody:           `{"walletId": "123e4567-e89b-12d3-a456-426614174000", "operationType":"WITHDRAW", "amount": -5}`,
+			mockBehavior:        func(s *mockService.MockTransaction, input models.TransactionInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Invalid amount",
+			inputBody: `{"walletId": "123e4567-e89b-12d3-a456-426614174000", "operationType":"DEPOSIT", "amount": 100}`,
+			mockExpInput: models.TransactionInput{
+				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType: models.Deposit,
+				Amount:        100,
+			},
+			mockBehavior: func(s *mockService.MockTransaction, input models.TransactionInput) {
+				s.EXPECT().Create(gomock.Any(), input).Return(uuid.UUID{}, models.ErrInvalidAmount)
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"amount must be positive"}`,
+		},
+		{
+			name:      "Wallet not found",
+			inputBody: `{"walletId": "123e4567-e89b-12d3-a456-426614174000", "operationType":"DEPOSIT", "amount": 100}`,
+			mockExpInput: models.TransactionInput{
+				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType: models.Deposit,
+				Amount:        100,
+			},
+			mockBehavior: func(s *mockService.MockTransaction, input models.TransactionInput) {
+				s.EXPECT().Create(gomock.Any(), input).Return(uuid.UUID{}, sql.ErrNoRows)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"wallet not found"}`,
+		},
+		{
+			name:      "Frozen wallet",
+			inputBody: `{"walletId": "123e4567-e89b-12d3-a456-426614174000", "operationType":"WITHDRAW", "amount": 100}`,
+			mockExpInput: models.TransactionInput{
+				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType: models.Withdraw,
+				Amount:        100,
+			},
+			mockBehavior: func(s *mockService.MockTransaction, input models.TransactionInput) {
+				s.EXPECT().Create(gomock.Any(), input).Return(uuid.UUID{}, models.ErrWalletFrozen)
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"message":"wallet is frozen"}`,
+		},
 		{
 			name:      "Service Failure",
 			inputBody: `{"walletId": "123e4567-e89b-12d3-a456-426614174000", "operationType":"DEPOSIT", "amount": 100}`,
@@ -79,7 +200,7 @@ func TestHandler_createTransaction(t *testing.T) {
 				Amount:        100,
 			},
 			mockBehavior: func(s *mockService.MockTransaction, input models.TransactionInput) {
-				s.EXPECT().Create(input).Return(uuid.UUID{}, errors.New("service failure"))
+				s.EXPECT().Create(gomock.Any(), input).Return(uuid.UUID{}, errors.New("service failure"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"service failure"}`,
@@ -96,7 +217,7 @@ func TestHandler_createTransaction(t *testing.T) {
 			testCase.mockBehavior(transaction, testCase.mockExpInput)
 
 			services := &service.Service{Transaction: transaction}
-			handler := NewHandler(services)
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
 
 			// Test Server
 			r := gin.New()
@@ -105,6 +226,9 @@ func TestHandler_createTransaction(t *testing.T) {
 			// Test Request
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("POST", "/transactions", bytes.NewBufferString(testCase.inputBody))
+			if testCase.ifMatch != "" {
+				req.Header.Set("If-Match", testCase.ifMatch)
+			}
 
 			// Perform Request
 			r.ServeHTTP(w, req)
@@ -121,6 +245,7 @@ func TestHandler_getAllTransactions(t *testing.T) {
 
 	testTable := []struct {
 		name                string
+		inputQuery          string
 		mockBehavior        mockBehavior
 		expectedStatusCode  int
 		expectedRequestBody string
@@ -128,7 +253,7 @@ func TestHandler_getAllTransactions(t *testing.T) {
 		{
 			name: "Ok",
 			mockBehavior: func(s *mockService.MockTransaction) {
-				s.EXPECT().GetAll().Return([]models.Transaction{
+				s.EXPECT().GetAll(gomock.Any(), models.TransactionFilter{}, models.Page{Limit: 50}).Return([]models.Transaction{
 					{
 						TransactionId: uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"),
 						WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
@@ -144,12 +269,14 @@ func TestHandler_getAllTransactions(t *testing.T) {
 			"walletId":"123e4567-e89b-12d3-a456-426614174000",
 			"operationType":"DEPOSIT",
 			"amount":100,
-			"createdAt":"2025-02-10T00:00:00Z"}]}`,
+			"createdAt":"2025-02-10T00:00:00Z"}],
+			"limit":50,
+			"offset":0}`,
 		},
 		{
 			name: "Service Failure",
 			mockBehavior: func(s *mockService.MockTransaction) {
-				s.EXPECT().GetAll().Return([]models.Transaction{}, errors.New("service failure"))
+				s.EXPECT().GetAll(gomock.Any(), models.TransactionFilter{}, models.Page{Limit: 50}).Return([]models.Transaction{}, errors.New("service failure"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"service failure"}`,
@@ -157,10 +284,62 @@ func TestHandler_getAllTransactions(t *testing.T) {
 		{
 			name: "Empty",
 			mockBehavior: func(s *mockService.MockTransaction) {
-				s.EXPECT().GetAll().Return([]models.Transaction{}, nil)
+				s.EXPECT().GetAll(gomock.Any(), models.TransactionFilter{}, models.Page{Limit: 50}).Return([]models.Transaction{}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[],"limit":50,"offset":0}`,
+		},
+		{
+			name:       "Page",
+			inputQuery: "?limit=10&offset=20",
+			mockBehavior: func(s *mockService.MockTransaction) {
+				s.EXPECT().GetAll(gomock.Any(), models.TransactionFilter{}, models.Page{Limit: 10, Offset: 20}).Return(nil, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[],"limit":10,"offset":20}`,
+		},
+		{
+			name:       "Filter",
+			inputQuery: "?category=333e4444-e89b-12d3-a456-426614174000&externalRef=order-1&q=lunch&metadata[shop]=cafe&metadata[paid]=true",
+			mockBehavior: func(s *mockService.MockTransaction) {
+				categoryId := uuid.MustParse("333e4444-e89b-12d3-a456-426614174000")
+				s.EXPECT().GetAll(gomock.Any(), models.TransactionFilter{
+					CategoryId:  &categoryId,
+					ExternalRef: "order-1",
+					Search:      "lunch",
+					Metadata:    map[string]string{"shop": "cafe", "paid": "true"},
+				}, models.Page{Limit: 50}).Return(nil, nil)
 			},
 			expectedStatusCode:  200,
-			expectedRequestBody: `{"data":[]}`,
+			expectedRequestBody: `{"data":[],"limit":50,"offset":0}`,
+		},
+		{
+			name:                "Invalid Category",
+			inputQuery:          "?category=food",
+			mockBehavior:        func(s *mockService.MockTransaction) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid category param"}`,
+		},
+		{
+			name:                "Too Many Metadata Params",
+			inputQuery:          "?metadata[a]=1&metadata[b]=2&metadata[c]=3&metadata[d]=4&metadata[e]=5&metadata[f]=6",
+			mockBehavior:        func(s *mockService.MockTransaction) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"too many metadata params"}`,
+		},
+		{
+			name:                "Limit Too Large",
+			inputQuery:          "?limit=201",
+			mockBehavior:        func(s *mockService.MockTransaction) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid limit param"}`,
+		},
+		{
+			name:                "Negative Offset",
+			inputQuery:          "?offset=-1",
+			mockBehavior:        func(s *mockService.MockTransaction) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid offset param"}`,
 		},
 	}
 
@@ -174,7 +353,7 @@ func TestHandler_getAllTransactions(t *testing.T) {
 			testCase.mockBehavior(transaction)
 
 			services := &service.Service{Transaction: transaction}
-			handler := NewHandler(services)
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
 
 			// Test Server
 			r := gin.New()
@@ -182,7 +361,7 @@ func TestHandler_getAllTransactions(t *testing.T) {
 
 			// Test Request
 			w := httptest.NewRecorder()
-			req := httptest.NewRequest("GET", "/transactions", nil)
+			req := httptest.NewRequest("GET", "/transactions"+testCase.inputQuery, nil)
 
 			// Perform Request
 			r.ServeHTTP(w, req)
@@ -208,7 +387,7 @@ func TestHandler_getTransactionById(t *testing.T) {
 			name:    "Ok",
 			inputId: "111e2222-e89b-12d3-a456-426614174000",
 			mockBehavior: func(s *mockService.MockTransaction, id uuid.UUID) {
-				s.EXPECT().GetById(id).Return(models.Transaction{
+				s.EXPECT().GetById(gomock.Any(), id).Return(models.Transaction{
 					TransactionId: uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"),
 					WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
 					OperationType: models.Deposit,
@@ -228,7 +407,7 @@ func TestHandler_getTransactionById(t *testing.T) {
 			name:    "Service Failure",
 			inputId: "111e2222-e89b-12d3-a456-426614174000",
 			mockBehavior: func(s *mockService.MockTransaction, id uuid.UUID) {
-				s.EXPECT().GetById(id).Return(models.Transaction{}, errors.New("service failure"))
+				s.EXPECT().GetById(gomock.Any(), id).Return(models.Transaction{}, errors.New("service failure"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"service failure"}`,
@@ -244,7 +423,7 @@ func TestHandler_getTransactionById(t *testing.T) {
 			name:    "Not found",
 			inputId: "111e2222-e89b-12d3-a456-426614174000",
 			mockBehavior: func(s *mockService.MockTransaction, id uuid.UUID) {
-				s.EXPECT().GetById(id).Return(models.Transaction{}, sql.ErrNoRows)
+				s.EXPECT().GetById(gomock.Any(), id).Return(models.Transaction{}, sql.ErrNoRows)
 			},
 			expectedStatusCode:  404,
 			expectedRequestBody: `{"message":"transaction not found"}`,
@@ -263,7 +442,7 @@ func TestHandler_getTransactionById(t *testing.T) {
 			}
 
 			services := &service.Service{Transaction: transaction}
-			handler := NewHandler(services)
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
 
 			// Test Server
 			r := gin.New()
@@ -282,3 +461,190 @@ func TestHandler_getTransactionById(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_annotateTransaction(t *testing.T) {
+	type mockBehavior func(s *mockService.MockTransaction, id uuid.UUID, input models.AnnotationInput)
+
+	categoryId := uuid.MustParse("333e4444-e89b-12d3-a456-426614174000")
+
+	testTable := []struct {
+		name                string
+		inputId             string
+		inputBody           string
+		mockExpInput        models.AnnotationInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:         "Ok",
+			inputId:      "111e2222-e89b-12d3-a456-426614174000",
+			inputBody:    `{"categoryId": "333e4444-e89b-12d3-a456-426614174000", "notes": "birthday"}`,
+			mockExpInput: models.AnnotationInput{CategoryId: &categoryId, Notes: "birthday"},
+			mockBehavior: func(s *mockService.MockTransaction, id uuid.UUID, input models.AnnotationInput) {
+				s.EXPECT().Annotate(gomock.Any(), 1, id, input).Return(models.Annotation{
+					TransactionId: id,
+					Version:       2,
+					CategoryId:    input.CategoryId,
+					Notes:         input.Notes,
+					CreatedAt:     time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{
+			"transactionId":"111e2222-e89b-12d3-a456-426614174000",
+			"version":2,
+			"categoryId":"333e4444-e89b-12d3-a456-426614174000",
+			"notes":"birthday",
+			"createdAt":"2025-02-10T00:00:00Z"}`,
+		},
+		{
+			name:         "Unknown category",
+			inputId:      "111e2222-e89b-12d3-a456-426614174000",
+			inputBody:    `{"categoryId": "333e4444-e89b-12d3-a456-426614174000"}`,
+			mockExpInput: models.AnnotationInput{CategoryId: &categoryId},
+			mockBehavior: func(s *mockService.MockTransaction, id uuid.UUID, input models.AnnotationInput) {
+				s.EXPECT().Annotate(gomock.Any(), 1, id, input).Return(models.Annotation{}, models.ErrUnknownCategory)
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"unknown category"}`,
+		},
+		{
+			name:         "Not found",
+			inputId:      "111e2222-e89b-12d3-a456-426614174000",
+			inputBody:    `{"notes": "birthday"}`,
+			mockExpInput: models.AnnotationInput{Notes: "birthday"},
+			mockBehavior: func(s *mockService.MockTransaction, id uuid.UUID, input models.AnnotationInput) {
+				s.EXPECT().Annotate(gomock.Any(), 1, id, input).Return(models.Annotation{}, sql.ErrNoRows)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"transaction not found"}`,
+		},
+		{
+			name:                "Notes too long",
+			inputId:             "111e2222-e89b-12d3-a456-426614174000",
+			inputBody:           `{"notes": "` + strings.Repeat("a", 2001) + `"}`,
+			mockBehavior:        func(s *mockService.MockTransaction, id uuid.UUID, input models.AnnotationInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:                "Invalid Transaction ID",
+			inputId:             "invalid",
+			inputBody:           `{"notes": "birthday"}`,
+			mockBehavior:        func(s *mockService.MockTransaction, id uuid.UUID, input models.AnnotationInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid id param"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			transaction := mockService.NewMockTransaction(ctrl)
+			id, _ := uuid.Parse(testCase.inputId)
+			testCase.mockBehavior(transaction, id, testCase.mockExpInput)
+
+			services := &service.Service{Transaction: transaction}
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
+
+			// Test Server
+			r := gin.New()
+			r.PUT("/transactions/:id/annotation", setUserIdMiddleware(1), handler.annotateTransaction)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/transactions/"+testCase.inputId+"/annotation", bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Asserts
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getTransactionAnnotations(t *testing.T) {
+	type mockBehavior func(s *mockService.MockTransaction, id uuid.UUID)
+
+	testTable := []struct {
+		name                string
+		inputId             string
+		inputQuery          string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:       "Ok",
+			inputId:    "111e2222-e89b-12d3-a456-426614174000",
+			inputQuery: "?limit=1",
+			mockBehavior: func(s *mockService.MockTransaction, id uuid.UUID) {
+				s.EXPECT().GetAnnotations(gomock.Any(), 1, id, models.Page{Limit: 1}).Return([]models.Annotation{
+					{TransactionId: id, Version: 2, Notes: "birthday", CreatedAt: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"data":[{
+			"transactionId":"111e2222-e89b-12d3-a456-426614174000",
+			"version":2,
+			"notes":"birthday",
+			"createdAt":"2025-02-10T00:00:00Z"}],
+			"limit":1,
+			"offset":0}`,
+		},
+		{
+			name:    "Empty",
+			inputId: "111e2222-e89b-12d3-a456-426614174000",
+			mockBehavior: func(s *mockService.MockTransaction, id uuid.UUID) {
+				s.EXPECT().GetAnnotations(gomock.Any(), 1, id, models.Page{Limit: 50}).Return(nil, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[],"limit":50,"offset":0}`,
+		},
+		{
+			name:    "Not found",
+			inputId: "111e2222-e89b-12d3-a456-426614174000",
+			mockBehavior: func(s *mockService.MockTransaction, id uuid.UUID) {
+				s.EXPECT().GetAnnotations(gomock.Any(), 1, id, models.Page{Limit: 50}).Return(nil, sql.ErrNoRows)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"transaction not found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			transaction := mockService.NewMockTransaction(ctrl)
+			id, _ := uuid.Parse(testCase.inputId)
+			testCase.mockBehavior(transaction, id)
+
+			services := &service.Service{Transaction: transaction}
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
+
+			// Test Server
+			r := gin.New()
+			r.GET("/transactions/:id/annotations", setUserIdMiddleware(1), handler.getTransactionAnnotations)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/transactions/"+testCase.inputId+"/annotations"+testCase.inputQuery, nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Asserts
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
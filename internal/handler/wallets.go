@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Yoshisoul/rest-wallets/internal/models"
 	"github.com/gin-gonic/gin"
@@ -16,9 +19,9 @@ func (h *Handler) createWallet(c *gin.Context) {
 		return
 	}
 
-	uuid, err := h.services.Wallet.Create(id)
+	uuid, err := h.services.Wallet.Create(c.Request.Context(), id)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, "service failure")
+		serviceFailure(c, err, "service failure")
 		return
 	}
 
@@ -29,6 +32,8 @@ func (h *Handler) createWallet(c *gin.Context) {
 
 type getAllWalletsResponse struct {
 	Wallets []models.Wallet `json:"data"`
+	Limit   int             `json:"limit"`
+	Offset  int             `json:"offset"`
 }
 
 func (h *Handler) getAllWalletsFromUser(c *gin.Context) {
@@ -37,17 +42,116 @@ func (h *Handler) getAllWalletsFromUser(c *gin.Context) {
 		return
 	}
 
-	wallets, err := h.services.Wallet.GetAllFromUser(id)
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseWalletFilter(c)
+	if !ok {
+		return
+	}
+
+	wallets, err := h.services.Wallet.GetAllFromUser(c.Request.Context(), id, filter, page)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		if errors.Is(err, models.ErrInvalidRange) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		serviceFailure(c, err, err.Error())
 		return
 	}
+	if wallets == nil {
+		wallets = []models.Wallet{}
+	}
 
 	c.JSON(http.StatusOK, getAllWalletsResponse{
 		Wallets: wallets,
+		Limit:   page.Limit,
+		Offset:  page.Offset,
 	})
 }
 
+// walletOrders are the values of the sort param of a list of wallets,
+// descending with a leading "-".
+var walletOrders = map[string]models.WalletOrder{
+	"createdAt": models.WalletsByCreation,
+	"name":      models.WalletsByName,
+	"amount":    models.WalletsByAmount,
+}
+
+// parseWalletFilter reads the filter and order of a list of wallets from the
+// query: name, tag, minAmount, maxAmount, createdFrom, createdTo and sort. On
+// invalid params it answers 400 and ok is false.
+func parseWalletFilter(c *gin.Context) (models.WalletFilter, bool) {
+	filter := models.WalletFilter{Name: c.Query("name")}
+	if tags := c.QueryArray("tag"); len(tags) > 0 {
+		if len(tags) > maxTagFilters {
+			newErrorResponse(c, http.StatusBadRequest, "too many tag params")
+			return filter, false
+		}
+		filter.Tags = tags
+	}
+
+	var ok bool
+	if filter.MinAmount, ok = queryAmount(c, "minAmount"); !ok {
+		return filter, false
+	}
+	if filter.MaxAmount, ok = queryAmount(c, "maxAmount"); !ok {
+		return filter, false
+	}
+	if filter.CreatedFrom, ok = queryOptionalTime(c, "createdFrom"); !ok {
+		return filter, false
+	}
+	if filter.CreatedTo, ok = queryOptionalTime(c, "createdTo"); !ok {
+		return filter, false
+	}
+
+	if value := c.Query("sort"); value != "" {
+		order, ok := walletOrders[strings.TrimPrefix(value, "-")]
+		if !ok {
+			newErrorResponse(c, http.StatusBadRequest, "invalid sort param")
+			return filter, false
+		}
+		filter.OrderBy = order
+		filter.Desc = strings.HasPrefix(value, "-")
+	}
+
+	return filter, true
+}
+
+// queryAmount reads a non-negative amount from the query param name, nil
+// without it. When it is invalid it answers 400 and returns false.
+func queryAmount(c *gin.Context, name string) (*int64, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+
+	amount, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || amount < 0 {
+		newErrorResponse(c, http.StatusBadRequest, "invalid "+name+" param")
+		return nil, false
+	}
+
+	return &amount, true
+}
+
+// queryOptionalTime is queryTime for a param without a default: nil without
+// it.
+func queryOptionalTime(c *gin.Context, name string) (*time.Time, bool) {
+	if c.Query(name) == "" {
+		return nil, true
+	}
+
+	t, ok := queryTime(c, name, time.Time{})
+	if !ok {
+		return nil, false
+	}
+
+	return &t, true
+}
+
 func (h *Handler) getWalletById(c *gin.Context) {
 	userId, err := getUserId(c)
 	if err != nil {
@@ -60,19 +164,83 @@ func (h *Handler) getWalletById(c *gin.Context) {
 		return
 	}
 
-	wallet, err := h.services.Wallet.GetByIdFromUser(userId, id)
+	wallet, err := h.services.Wallet.GetByIdFromUser(c.Request.Context(), userId, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			newErrorResponse(c, http.StatusNotFound, "wallet not found")
+			return
+		}
+		serviceFailure(c, err, "service failure")
+		return
+	}
+
+	etag := walletETag(wallet)
+	c.Header("ETag", etag)
+	if notModified(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, wallet)
+}
+
+func (h *Handler) updateWallet(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	var input models.WalletUpdate
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	var ok bool
+	if input.ExpectedVersion, ok = ifMatch(c); !ok {
+		return
+	}
+
+	wallet, err := h.services.Wallet.Update(c.Request.Context(), userId, id, input)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			newErrorResponse(c, http.StatusNotFound, "wallet not found")
 			return
 		}
-		newErrorResponse(c, http.StatusInternalServerError, "service failure")
+		if isInvalidLabel(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrVersionMismatch) {
+			newErrorResponse(c, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrWalletClosed) {
+			newErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		serviceFailure(c, err, "service failure")
 		return
 	}
 
+	c.Header("ETag", walletETag(wallet))
 	c.JSON(http.StatusOK, wallet)
 }
 
+// isInvalidLabel reports whether err rejects an update of the labels of a
+// wallet.
+func isInvalidLabel(err error) bool {
+	return errors.Is(err, models.ErrInvalidColor) ||
+		errors.Is(err, models.ErrInvalidTag) ||
+		errors.Is(err, models.ErrTooManyTags) ||
+		errors.Is(err, models.ErrNothingToUpdate)
+}
+
 func (h *Handler) deleteWallet(c *gin.Context) {
 	userId, err := getUserId(c)
 	if err != nil {
@@ -85,9 +253,36 @@ func (h *Handler) deleteWallet(c *gin.Context) {
 		return
 	}
 
-	err = h.services.Wallet.Delete(userId, id)
+	input := models.CloseWalletInput{Reason: c.Query("reason")}
+	if sweepTo := c.Query("sweepTo"); sweepTo != "" {
+		target, err := uuid.Parse(sweepTo)
+		if err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "invalid sweepTo param")
+			return
+		}
+		input.SweepTo = &target
+	}
+
+	var ok bool
+	if input.ExpectedVersion, ok = ifMatch(c); !ok {
+		return
+	}
+
+	err = h.services.Wallet.Close(c.Request.Context(), userId, id, input)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			newErrorResponse(c, http.StatusNotFound, "wallet not found")
+			return
+		}
+		if errors.Is(err, models.ErrVersionMismatch) {
+			newErrorResponse(c, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		if isWalletConflict(err) {
+			newErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		serviceFailure(c, err, err.Error())
 		return
 	}
 
@@ -95,3 +290,13 @@ func (h *Handler) deleteWallet(c *gin.Context) {
 		Status: "ok",
 	})
 }
+
+// isWalletConflict reports whether err was caused by the wallet's state or
+// balance rather than by a failure of the service.
+func isWalletConflict(err error) bool {
+	return errors.Is(err, models.ErrWalletFrozen) ||
+		errors.Is(err, models.ErrWalletClosed) ||
+		errors.Is(err, models.ErrNonZeroBalance) ||
+		errors.Is(err, models.ErrInsufficientFunds) ||
+		errors.Is(err, models.ErrInvalidSweepTarget)
+}
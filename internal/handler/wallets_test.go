@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Yoshisoul/rest-wallets/internal/config"
 	"github.com/Yoshisoul/rest-wallets/internal/models"
 	"github.com/Yoshisoul/rest-wallets/internal/service"
 	mockService "github.com/Yoshisoul/rest-wallets/internal/service/mocks"
@@ -41,7 +42,7 @@ func TestHandler_createWallet(t *testing.T) {
 			name:        "OK",
 			inputUserId: 1,
 			mockBehavior: func(s *mockService.MockWallet, id int) {
-				s.EXPECT().Create(id).Return(uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"), nil)
+				s.EXPECT().Create(gomock.Any(), id).Return(uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"), nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `{"uuid":"123e4567-e89b-12d3-a456-426614174000"}`,
@@ -50,11 +51,20 @@ func TestHandler_createWallet(t *testing.T) {
 			name:        "Service Failure",
 			inputUserId: 1,
 			mockBehavior: func(s *mockService.MockWallet, id int) {
-				s.EXPECT().Create(id).Return(uuid.UUID{}, errors.New("service failure"))
+				s.EXPECT().Create(gomock.Any(), id).Return(uuid.UUID{}, errors.New("service failure"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"service failure"}`,
 		},
+		{
+			name:        "Database unavailable",
+			inputUserId: 1,
+			mockBehavior: func(s *mockService.MockWallet, id int) {
+				s.EXPECT().Create(gomock.Any(), id).Return(uuid.UUID{}, &models.UnavailableError{RetryAfter: 1500 * time.Millisecond})
+			},
+			expectedStatusCode:  503,
+			expectedRequestBody: `{"message":"service unavailable"}`,
+		},
 		{
 			name:                "UserID not found",
 			inputUserId:         -1,
@@ -74,7 +84,7 @@ func TestHandler_createWallet(t *testing.T) {
 			testCase.mockBehavior(wallet, testCase.inputUserId)
 
 			services := &service.Service{Wallet: wallet}
-			handler := NewHandler(services)
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
 
 			// Test Server
 			r := gin.New()
@@ -102,6 +112,7 @@ func TestHandler_getAllWalletsFromUser(t *testing.T) {
 	testTable := []struct {
 		name                string
 		inputUserId         int
+		query               string
 		mockBehavior        mockBehavior
 		expectedStatusCode  int
 		expectedRequestBody string
@@ -110,13 +121,15 @@ func TestHandler_getAllWalletsFromUser(t *testing.T) {
 			name:        "OK",
 			inputUserId: 1,
 			mockBehavior: func(s *mockService.MockWallet, id int) {
-				s.EXPECT().GetAllFromUser(id).Return([]models.Wallet{
+				s.EXPECT().GetAllFromUser(gomock.Any(), id, models.WalletFilter{}, models.Page{Limit: 50}).Return([]models.Wallet{
 					{
 						WalletId:  uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
 						UserId:    id,
 						Amount:    100,
 						CreatedAt: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
 						UpdatedAt: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+						Status:    models.WalletActive,
+						Version:   3,
 					},
 				}, nil)
 			},
@@ -124,15 +137,19 @@ func TestHandler_getAllWalletsFromUser(t *testing.T) {
 			expectedRequestBody: `{"data":[{
 			"walletId":"123e4567-e89b-12d3-a456-426614174000",
 			"userId":1,
+			"version":3,
 			"amount":100,
 			"createdAt":"2025-02-10T00:00:00Z",
-			"updatedAt":"2025-02-10T00:00:00Z"}]}`,
+			"updatedAt":"2025-02-10T00:00:00Z",
+			"status":"ACTIVE"}],
+			"limit":50,
+			"offset":0}`,
 		},
 		{
 			name:        "Service Failure",
 			inputUserId: 1,
 			mockBehavior: func(s *mockService.MockWallet, id int) {
-				s.EXPECT().GetAllFromUser(id).Return(nil, errors.New("service failure"))
+				s.EXPECT().GetAllFromUser(gomock.Any(), id, models.WalletFilter{}, models.Page{Limit: 50}).Return(nil, errors.New("service failure"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"service failure"}`,
@@ -144,14 +161,104 @@ func TestHandler_getAllWalletsFromUser(t *testing.T) {
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"user id not found"}`,
 		},
+		{
+			name:        "Filtered and sorted",
+			inputUserId: 1,
+			query:       "?name=trip&tag=home&tag=shared&minAmount=10&maxAmount=500&createdFrom=2025-02-01T00:00:00Z&createdTo=2025-03-01T00:00:00Z&sort=-amount",
+			mockBehavior: func(s *mockService.MockWallet, id int) {
+				minAmount, maxAmount := int64(10), int64(500)
+				createdFrom, createdTo := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+				s.EXPECT().GetAllFromUser(gomock.Any(), id, models.WalletFilter{
+					Name:        "trip",
+					Tags:        []string{"home", "shared"},
+					MinAmount:   &minAmount,
+					MaxAmount:   &maxAmount,
+					CreatedFrom: &createdFrom,
+					CreatedTo:   &createdTo,
+					OrderBy:     models.WalletsByAmount,
+					Desc:        true,
+				}, models.Page{Limit: 50}).Return([]models.Wallet{
+					{
+						WalletId:  uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+						UserId:    id,
+						Amount:    100,
+						CreatedAt: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+						UpdatedAt: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+						Status:    models.WalletActive,
+						Version:   3,
+						Name:      "Trip",
+						Color:     "#00ff00",
+						Tags:      models.Tags{"home", "shared"},
+						IsDefault: true,
+					},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"data":[{
+			"walletId":"123e4567-e89b-12d3-a456-426614174000",
+			"userId":1,
+			"version":3,
+			"amount":100,
+			"createdAt":"2025-02-10T00:00:00Z",
+			"updatedAt":"2025-02-10T00:00:00Z",
+			"status":"ACTIVE",
+			"name":"Trip",
+			"color":"#00ff00",
+			"tags":["home","shared"],
+			"isDefault":true}],
+			"limit":50,
+			"offset":0}`,
+		},
+		{
+			name:                "Invalid sort",
+			inputUserId:         1,
+			query:               "?sort=-status",
+			mockBehavior:        func(s *mockService.MockWallet, id int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid sort param"}`,
+		},
+		{
+			name:                "Invalid amount",
+			inputUserId:         1,
+			query:               "?maxAmount=-1",
+			mockBehavior:        func(s *mockService.MockWallet, id int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid maxAmount param"}`,
+		},
+		{
+			name:                "Invalid time",
+			inputUserId:         1,
+			query:               "?createdFrom=yesterday",
+			mockBehavior:        func(s *mockService.MockWallet, id int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid createdFrom param"}`,
+		},
+		{
+			name:                "Too many tags",
+			inputUserId:         1,
+			query:               "?tag=a&tag=b&tag=c&tag=d&tag=e&tag=f",
+			mockBehavior:        func(s *mockService.MockWallet, id int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"too many tag params"}`,
+		},
+		{
+			name:        "Invalid range",
+			inputUserId: 1,
+			query:       "?createdFrom=2025-03-01T00:00:00Z&createdTo=2025-02-01T00:00:00Z",
+			mockBehavior: func(s *mockService.MockWallet, id int) {
+				s.EXPECT().GetAllFromUser(gomock.Any(), id, gomock.Any(), models.Page{Limit: 50}).Return(nil, models.ErrInvalidRange)
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"from must be before to"}`,
+		},
 		{
 			name:        "Empty",
 			inputUserId: 1,
 			mockBehavior: func(s *mockService.MockWallet, id int) {
-				s.EXPECT().GetAllFromUser(id).Return([]models.Wallet{}, nil)
+				s.EXPECT().GetAllFromUser(gomock.Any(), id, models.WalletFilter{}, models.Page{Limit: 50}).Return([]models.Wallet{}, nil)
 			},
 			expectedStatusCode:  200,
-			expectedRequestBody: `{"data":[]}`,
+			expectedRequestBody: `{"data":[],"limit":50,"offset":0}`,
 		},
 	}
 
@@ -165,7 +272,7 @@ func TestHandler_getAllWalletsFromUser(t *testing.T) {
 			testCase.mockBehavior(wallet, testCase.inputUserId)
 
 			services := &service.Service{Wallet: wallet}
-			handler := NewHandler(services)
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
 
 			// Test Server
 			r := gin.New()
@@ -174,7 +281,7 @@ func TestHandler_getAllWalletsFromUser(t *testing.T) {
 
 			// Test Request
 			w := httptest.NewRecorder()
-			req := httptest.NewRequest("GET", "/wallets", nil)
+			req := httptest.NewRequest("GET", "/wallets"+testCase.query, nil)
 			req.Header.Set("Authorization", "Bearer token")
 
 			// Perform Request
@@ -194,36 +301,79 @@ func TestHandler_getWalletById(t *testing.T) {
 		name                string
 		inputUserId         int
 		inputWalletId       string
+		ifNoneMatch         string
 		mockBehavior        mockBehavior
 		expectedStatusCode  int
 		expectedRequestBody string
+		expectedETag        string
 	}{
 		{
 			name:          "OK",
 			inputUserId:   1,
 			inputWalletId: "123e4567-e89b-12d3-a456-426614174000",
 			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID) {
-				s.EXPECT().GetByIdFromUser(userId, walletId).Return(models.Wallet{
+				s.EXPECT().GetByIdFromUser(gomock.Any(), userId, walletId).Return(models.Wallet{
 					WalletId:  walletId,
 					UserId:    userId,
 					Amount:    100,
 					CreatedAt: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
 					UpdatedAt: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+					Status:    models.WalletActive,
+					Version:   3,
 				}, nil)
 			},
 			expectedStatusCode: 200,
 			expectedRequestBody: `{"walletId":"123e4567-e89b-12d3-a456-426614174000",
 			"userId":1,
+			"version":3,
 			"amount":100,
 			"createdAt":"2025-02-10T00:00:00Z",
-			"updatedAt":"2025-02-10T00:00:00Z"}`,
+			"updatedAt":"2025-02-10T00:00:00Z",
+			"status":"ACTIVE"}`,
+			expectedETag: `"3"`,
+		},
+		{
+			name:          "Not modified",
+			inputUserId:   1,
+			inputWalletId: "123e4567-e89b-12d3-a456-426614174000",
+			ifNoneMatch:   `"2", W/"3"`,
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID) {
+				s.EXPECT().GetByIdFromUser(gomock.Any(), userId, walletId).Return(models.Wallet{WalletId: walletId, Version: 3}, nil)
+			},
+			expectedStatusCode: 304,
+			expectedETag:       `"3"`,
+		},
+		{
+			name:          "Modified",
+			inputUserId:   1,
+			inputWalletId: "123e4567-e89b-12d3-a456-426614174000",
+			ifNoneMatch:   `"2"`,
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID) {
+				s.EXPECT().GetByIdFromUser(gomock.Any(), userId, walletId).Return(models.Wallet{
+					WalletId:  walletId,
+					UserId:    userId,
+					CreatedAt: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+					UpdatedAt: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+					Status:    models.WalletActive,
+					Version:   3,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"walletId":"123e4567-e89b-12d3-a456-426614174000",
+			"userId":1,
+			"amount":0,
+			"createdAt":"2025-02-10T00:00:00Z",
+			"updatedAt":"2025-02-10T00:00:00Z",
+			"status":"ACTIVE",
+			"version":3}`,
+			expectedETag: `"3"`,
 		},
 		{
 			name:          "Service Failure",
 			inputUserId:   1,
 			inputWalletId: "123e4567-e89b-12d3-a456-426614174000",
 			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID) {
-				s.EXPECT().GetByIdFromUser(userId, walletId).Return(models.Wallet{}, errors.New("service failure"))
+				s.EXPECT().GetByIdFromUser(gomock.Any(), userId, walletId).Return(models.Wallet{}, errors.New("service failure"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"service failure"}`,
@@ -249,7 +399,7 @@ func TestHandler_getWalletById(t *testing.T) {
 			inputUserId:   1,
 			inputWalletId: "123e4567-e89b-12d3-a456-426614174123",
 			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID) {
-				s.EXPECT().GetByIdFromUser(userId, walletId).Return(models.Wallet{}, sql.ErrNoRows)
+				s.EXPECT().GetByIdFromUser(gomock.Any(), userId, walletId).Return(models.Wallet{}, sql.ErrNoRows)
 			},
 			expectedStatusCode:  404,
 			expectedRequestBody: `{"message":"wallet not found"}`,
@@ -268,7 +418,7 @@ func TestHandler_getWalletById(t *testing.T) {
 			}
 
 			services := &service.Service{Wallet: wallet}
-			handler := NewHandler(services)
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
 
 			// Test Server
 			r := gin.New()
@@ -279,24 +429,38 @@ func TestHandler_getWalletById(t *testing.T) {
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("GET", "/wallets/"+testCase.inputWalletId, nil)
 			req.Header.Set("Authorization", "Bearer token")
+			if testCase.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", testCase.ifNoneMatch)
+			}
 
 			// Perform Request
 			r.ServeHTTP(w, req)
 
 			// Asserts
 			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedETag, w.Header().Get("ETag"))
+			if testCase.expectedStatusCode == 304 {
+				assert.Empty(t, w.Body.String())
+				return
+			}
 			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
 		})
 	}
 }
 
 func TestHandler_deleteWallet(t *testing.T) {
-	type mockBehavior func(s *mockService.MockWallet, userId int, walletId uuid.UUID)
+	type mockBehavior func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput)
+
+	sweepTo := uuid.MustParse("223e4567-e89b-12d3-a456-426614174000")
+	version, noVersion := int64(4), int64(-1)
 
 	testTable := []struct {
 		name                string
 		inputUserId         int
 		inputWalletId       string
+		inputQuery          string
+		ifMatch             string
+		mockExpInput        models.CloseWalletInput
 		mockBehavior        mockBehavior
 		expectedStatusCode  int
 		expectedRequestBody string
@@ -305,18 +469,103 @@ func TestHandler_deleteWallet(t *testing.T) {
 			name:          "OK",
 			inputUserId:   1,
 			inputWalletId: "123e4567-e89b-12d3-a456-426614174000",
-			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID) {
-				s.EXPECT().Delete(userId, walletId).Return(nil)
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				s.EXPECT().Close(gomock.Any(), userId, walletId, input).Return(nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `{"status":"ok"}`,
 		},
+		{
+			name:          "OK with sweep",
+			inputUserId:   1,
+			inputWalletId: "123e4567-e89b-12d3-a456-426614174000",
+			inputQuery:    "?sweepTo=223e4567-e89b-12d3-a456-426614174000&reason=moving",
+			mockExpInput:  models.CloseWalletInput{Reason: "moving", SweepTo: &sweepTo},
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				s.EXPECT().Close(gomock.Any(), userId, walletId, input).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:                "Invalid sweepTo",
+			inputUserId:         1,
+			inputWalletId:       "123e4567-e89b-12d3-a456-426614174000",
+			inputQuery:          "?sweepTo=invalid",
+			mockBehavior:        func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid sweepTo param"}`,
+		},
+		{
+			name:          "Version mismatch",
+			inputUserId:   1,
+			inputWalletId: "123e4567-e89b-12d3-a456-426614174000",
+			ifMatch:       `"4"`,
+			mockExpInput:  models.CloseWalletInput{ExpectedVersion: &version},
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				s.EXPECT().Close(gomock.Any(), userId, walletId, input).Return(models.ErrVersionMismatch)
+			},
+			expectedStatusCode:  412,
+			expectedRequestBody: `{"message":"wallet version does not match"}`,
+		},
+		{
+			name:          "Weak If-Match never matches",
+			inputUserId:   1,
+			inputWalletId: "123e4567-e89b-12d3-a456-426614174000",
+			ifMatch:       `W/"4"`,
+			mockExpInput:  models.CloseWalletInput{ExpectedVersion: &noVersion},
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				s.EXPECT().Close(gomock.Any(), userId, walletId, input).Return(models.ErrVersionMismatch)
+			},
+			expectedStatusCode:  412,
+			expectedRequestBody: `{"message":"wallet version does not match"}`,
+		},
+		{
+			name:          "If-Match any",
+			inputUserId:   1,
+			inputWalletId: "123e4567-e89b-12d3-a456-426614174000",
+			ifMatch:       "*",
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				s.EXPECT().Close(gomock.Any(), userId, walletId, input).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:                "Several If-Match tags",
+			inputUserId:         1,
+			inputWalletId:       "123e4567-e89b-12d3-a456-426614174000",
+			ifMatch:             `"3", "4"`,
+			mockBehavior:        func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"If-Match must name a single version"}`,
+		},
+		{
+			name:          "Non-zero balance",
+			inputUserId:   1,
+			inputWalletId: "123e4567-e89b-12d3-a456-426614174000",
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				s.EXPECT().Close(gomock.Any(), userId, walletId, input).Return(models.ErrNonZeroBalance)
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"message":"wallet balance is not zero"}`,
+		},
+		{
+			name:          "Already closed",
+			inputUserId:   1,
+			inputWalletId: "123e4567-e89b-12d3-a456-426614174000",
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				s.EXPECT().Close(gomock.Any(), userId, walletId, input).Return(models.ErrWalletClosed)
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"message":"wallet is closed"}`,
+		},
 		{
 			name:          "Service Failure",
 			inputUserId:   1,
 			inputWalletId: "123e4567-e89b-12d3-a456-426614174000",
-			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID) {
-				s.EXPECT().Delete(userId, walletId).Return(errors.New("service failure"))
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				s.EXPECT().Close(gomock.Any(), userId, walletId, input).Return(errors.New("service failure"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"service failure"}`,
@@ -325,7 +574,7 @@ func TestHandler_deleteWallet(t *testing.T) {
 			name:                "UserID not found",
 			inputUserId:         -1,
 			inputWalletId:       "123e4567-e89b-12d3-a456-426614174000",
-			mockBehavior:        func(s *mockService.MockWallet, userId int, walletId uuid.UUID) {},
+			mockBehavior:        func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput) {},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"user id not found"}`,
 		},
@@ -333,7 +582,7 @@ func TestHandler_deleteWallet(t *testing.T) {
 			name:                "Invalid Wallet ID",
 			inputUserId:         1,
 			inputWalletId:       "invalid",
-			mockBehavior:        func(s *mockService.MockWallet, userId int, walletId uuid.UUID) {},
+			mockBehavior:        func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput) {},
 			expectedStatusCode:  400,
 			expectedRequestBody: `{"message":"invalid id param"}`,
 		},
@@ -341,10 +590,10 @@ func TestHandler_deleteWallet(t *testing.T) {
 			name:          "Wallet not found",
 			inputUserId:   1,
 			inputWalletId: "123e4567-e89b-12d3-a456-426614174123",
-			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID) {
-				s.EXPECT().Delete(userId, walletId).Return(errors.New("wallet not found"))
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				s.EXPECT().Close(gomock.Any(), userId, walletId, input).Return(sql.ErrNoRows)
 			},
-			expectedStatusCode:  500,
+			expectedStatusCode:  404,
 			expectedRequestBody: `{"message":"wallet not found"}`,
 		},
 	}
@@ -357,11 +606,11 @@ func TestHandler_deleteWallet(t *testing.T) {
 
 			wallet := mockService.NewMockWallet(c)
 			if testCase.name != "Invalid Wallet ID" {
-				testCase.mockBehavior(wallet, testCase.inputUserId, uuid.MustParse(testCase.inputWalletId))
+				testCase.mockBehavior(wallet, testCase.inputUserId, uuid.MustParse(testCase.inputWalletId), testCase.mockExpInput)
 			}
 
 			services := &service.Service{Wallet: wallet}
-			handler := NewHandler(services)
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
 
 			// Test Server
 			r := gin.New()
@@ -370,8 +619,163 @@ func TestHandler_deleteWallet(t *testing.T) {
 
 			// Test Request
 			w := httptest.NewRecorder()
-			req := httptest.NewRequest("DELETE", "/wallets/"+testCase.inputWalletId, nil)
+			req := httptest.NewRequest("DELETE", "/wallets/"+testCase.inputWalletId+testCase.inputQuery, nil)
+			req.Header.Set("Authorization", "Bearer token")
+			if testCase.ifMatch != "" {
+				req.Header.Set("If-Match", testCase.ifMatch)
+			}
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Asserts
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_updateWallet(t *testing.T) {
+	type mockBehavior func(s *mockService.MockWallet, userId int, walletId uuid.UUID, update models.WalletUpdate)
+
+	walletId := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	name, color, yes := "Savings", "#00FF00", true
+	version := int64(3)
+
+	testTable := []struct {
+		name                string
+		inputWalletId       string
+		inputBody           string
+		ifMatch             string
+		mockExpInput        models.WalletUpdate
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+		expectedETag        string
+	}{
+		{
+			name:          "OK",
+			inputWalletId: walletId.String(),
+			inputBody:     `{"name":"Savings","color":"#00FF00","tags":["home"],"isDefault":true}`,
+			ifMatch:       `"3"`,
+			mockExpInput:  models.WalletUpdate{Name: &name, Color: &color, Tags: &[]string{"home"}, IsDefault: &yes, ExpectedVersion: &version},
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, update models.WalletUpdate) {
+				s.EXPECT().Update(gomock.Any(), userId, walletId, update).Return(models.Wallet{
+					WalletId:  walletId,
+					UserId:    userId,
+					CreatedAt: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+					UpdatedAt: time.Date(2025, 2, 11, 0, 0, 0, 0, time.UTC),
+					Status:    models.WalletActive,
+					Version:   4,
+					Name:      "Savings",
+					Color:     "#00ff00",
+					Tags:      models.Tags{"home"},
+					IsDefault: true,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"walletId":"123e4567-e89b-12d3-a456-426614174000","userId":1,"amount":0,
+			"createdAt":"2025-02-10T00:00:00Z","updatedAt":"2025-02-11T00:00:00Z","status":"ACTIVE","version":4,
+			"name":"Savings","color":"#00ff00","tags":["home"],"isDefault":true}`,
+			expectedETag: `"4"`,
+		},
+		{
+			name:                "Name too long",
+			inputWalletId:       walletId.String(),
+			inputBody:           `{"name":"` + strings.Repeat("a", 65) + `"}`,
+			mockBehavior:        func(s *mockService.MockWallet, userId int, walletId uuid.UUID, update models.WalletUpdate) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:          "Invalid color",
+			inputWalletId: walletId.String(),
+			inputBody:     `{"color":"green"}`,
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, update models.WalletUpdate) {
+				s.EXPECT().Update(gomock.Any(), userId, walletId, gomock.Any()).Return(models.Wallet{}, models.ErrInvalidColor)
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"color must be #rrggbb"}`,
+		},
+		{
+			name:          "Nothing to update",
+			inputWalletId: walletId.String(),
+			inputBody:     `{}`,
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, update models.WalletUpdate) {
+				s.EXPECT().Update(gomock.Any(), userId, walletId, update).Return(models.Wallet{}, models.ErrNothingToUpdate)
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"nothing to update"}`,
+		},
+		{
+			name:          "Closed wallet",
+			inputWalletId: walletId.String(),
+			inputBody:     `{"isDefault":true}`,
+			mockExpInput:  models.WalletUpdate{IsDefault: &yes},
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, update models.WalletUpdate) {
+				s.EXPECT().Update(gomock.Any(), userId, walletId, update).Return(models.Wallet{}, models.ErrWalletClosed)
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"message":"wallet is closed"}`,
+		},
+		{
+			name:          "Version mismatch",
+			inputWalletId: walletId.String(),
+			inputBody:     `{"name":"Savings"}`,
+			ifMatch:       `"3"`,
+			mockExpInput:  models.WalletUpdate{Name: &name, ExpectedVersion: &version},
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, update models.WalletUpdate) {
+				s.EXPECT().Update(gomock.Any(), userId, walletId, update).Return(models.Wallet{}, models.ErrVersionMismatch)
+			},
+			expectedStatusCode:  412,
+			expectedRequestBody: `{"message":"wallet version does not match"}`,
+		},
+		{
+			name:          "Wallet not found",
+			inputWalletId: walletId.String(),
+			inputBody:     `{"name":"Savings"}`,
+			mockExpInput:  models.WalletUpdate{Name: &name},
+			mockBehavior: func(s *mockService.MockWallet, userId int, walletId uuid.UUID, update models.WalletUpdate) {
+				s.EXPECT().Update(gomock.Any(), userId, walletId, update).Return(models.Wallet{}, sql.ErrNoRows)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"wallet not found"}`,
+		},
+		{
+			name:                "Invalid Wallet ID",
+			inputWalletId:       "invalid",
+			inputBody:           `{"name":"Savings"}`,
+			mockBehavior:        func(s *mockService.MockWallet, userId int, walletId uuid.UUID, update models.WalletUpdate) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid id param"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			wallet := mockService.NewMockWallet(c)
+			id, _ := uuid.Parse(testCase.inputWalletId)
+			testCase.mockBehavior(wallet, 1, id, testCase.mockExpInput)
+
+			services := &service.Service{Wallet: wallet}
+			handler := NewHandler(services, config.HTTPConfig{}, "rest-wallets", nil)
+
+			// Test Server
+			r := gin.New()
+			r.Use(setUserIdMiddleware(1))
+			r.PATCH("/wallets/:id", handler.updateWallet)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/wallets/"+testCase.inputWalletId, strings.NewReader(testCase.inputBody))
 			req.Header.Set("Authorization", "Bearer token")
+			if testCase.ifMatch != "" {
+				req.Header.Set("If-Match", testCase.ifMatch)
+			}
 
 			// Perform Request
 			r.ServeHTTP(w, req)
@@ -379,6 +783,7 @@ func TestHandler_deleteWallet(t *testing.T) {
 			// Asserts
 			assert.Equal(t, testCase.expectedStatusCode, w.Code)
 			assert.JSONEq(t, testCase.expectedRequestBody, w.Body.String())
+			assert.Equal(t, testCase.expectedETag, w.Header().Get("ETag"))
 		})
 	}
 }
@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Yoshisoul/rest-wallets/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+// Database pings the database.
+func Database(db *sqlx.DB) CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		return "", db.PingContext(ctx)
+	}
+}
+
+// Migrations fails unless the schema is at the latest version embedded in the
+// binary and the last migration completed.
+func Migrations(db *sqlx.DB) CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		status, err := repository.CurrentMigration(ctx, db)
+		if err != nil {
+			return "", err
+		}
+
+		detail := fmt.Sprintf("version %d, expected %d", status.Version, status.Latest)
+		switch {
+		case status.Dirty:
+			return detail, fmt.Errorf("migration %d is dirty", status.Version)
+		case status.Version != status.Latest:
+			return detail, fmt.Errorf("schema is at version %d, expected %d", status.Version, status.Latest)
+		}
+
+		return detail, nil
+	}
+}
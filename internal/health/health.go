@@ -0,0 +1,215 @@
+// Package health serves the liveness and readiness probes.
+//
+// /healthz only tells that the process is serving requests. /readyz runs the
+// registered dependency checks concurrently, each with its own timeout, and
+// fails as soon as the service starts draining before shutdown.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOk          = "ok"
+	StatusFail        = "fail"
+	StatusUnavailable = "unavailable"
+	StatusDraining    = "draining"
+)
+
+// CheckFunc checks one dependency. The detail is reported even when the
+// check passes, e.g. the applied migration version.
+type CheckFunc func(ctx context.Context) (detail string, err error)
+
+type CheckResult struct {
+	Status     string  `json:"status"`
+	Detail     string  `json:"detail,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+type Checker struct {
+	timeout time.Duration
+
+	mu       sync.RWMutex
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+// NewChecker returns a checker that gives every check the given timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a readiness check. Checks are reported under their name.
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Drain makes readiness fail from now on, so load balancers stop sending new
+// requests while the in-flight ones complete.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+func (c *Checker) Ready(ctx context.Context) Report {
+	if c.draining.Load() {
+		return Report{Status: StatusDraining}
+	}
+
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, check.check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOk, Checks: make(map[string]CheckResult, len(checks))}
+	for i, check := range checks {
+		report.Checks[check.name] = results[i]
+		if results[i].Status != StatusOk {
+			report.Status = StatusUnavailable
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check CheckFunc) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	detail, err := check(ctx)
+	result := CheckResult{
+		Status:     StatusOk,
+		Detail:     detail,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// LiveHandler serves /healthz.
+func (c *Checker) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, http.StatusOK, Report{Status: StatusOk})
+	})
+}
+
+// ReadyHandler serves /readyz, answering 503 unless every check passes.
+func (c *Checker) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Ready(r.Context())
+
+		code := http.StatusOK
+		if report.Status != StatusOk {
+			code = http.StatusServiceUnavailable
+		}
+		writeReport(w, code, report)
+	})
+}
+
+func writeReport(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
+
+// Workers tracks the state of background workers. Its Check fails while any
+// worker is in the failed state.
+type Workers struct {
+	mu     sync.Mutex
+	states map[string]workerState
+}
+
+type workerState struct {
+	state string
+	err   error
+}
+
+const (
+	WorkerStarting = "starting"
+	WorkerRunning  = "running"
+	WorkerStopped  = "stopped"
+	WorkerFailed   = "failed"
+)
+
+func (w *Workers) Set(name, state string, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.states == nil {
+		w.states = make(map[string]workerState)
+	}
+	w.states[name] = workerState{state: state, err: err}
+}
+
+func (w *Workers) Check(ctx context.Context) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	names := make([]string, 0, len(w.states))
+	for name := range w.states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	states := make([]string, 0, len(names))
+	var failed []string
+	for _, name := range names {
+		state := w.states[name]
+		states = append(states, name+": "+state.state)
+		if state.state == WorkerFailed {
+			failed = append(failed, name+": "+errString(state.err))
+		}
+	}
+
+	detail := strings.Join(states, ", ")
+	if len(failed) > 0 {
+		return detail, errors.New("failed workers: " + strings.Join(failed, "; "))
+	}
+
+	return detail, nil
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "unknown error"
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,185 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func ok(ctx context.Context) (string, error) {
+	return "fine", nil
+}
+
+func TestChecker_Ready(t *testing.T) {
+	testTable := []struct {
+		name           string
+		checks         map[string]CheckFunc
+		drain          bool
+		expectedCode   int
+		expectedStatus string
+		expectedChecks map[string]string
+	}{
+		{
+			name:           "Ok",
+			checks:         map[string]CheckFunc{"a": ok, "b": ok},
+			expectedCode:   200,
+			expectedStatus: StatusOk,
+			expectedChecks: map[string]string{"a": StatusOk, "b": StatusOk},
+		},
+		{
+			name: "Failing Check",
+			checks: map[string]CheckFunc{"a": ok, "b": func(ctx context.Context) (string, error) {
+				return "", errors.New("down")
+			}},
+			expectedCode:   503,
+			expectedStatus: StatusUnavailable,
+			expectedChecks: map[string]string{"a": StatusOk, "b": StatusFail},
+		},
+		{
+			name: "Timeout",
+			checks: map[string]CheckFunc{"slow": func(ctx context.Context) (string, error) {
+				<-ctx.Done()
+				return "", ctx.Err()
+			}},
+			expectedCode:   503,
+			expectedStatus: StatusUnavailable,
+			expectedChecks: map[string]string{"slow": StatusFail},
+		},
+		{
+			name:           "Draining",
+			checks:         map[string]CheckFunc{"a": ok},
+			drain:          true,
+			expectedCode:   503,
+			expectedStatus: StatusDraining,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			checker := NewChecker(10 * time.Millisecond)
+			for name, check := range testCase.checks {
+				checker.Register(name, check)
+			}
+			if testCase.drain {
+				checker.Drain()
+			}
+
+			w := httptest.NewRecorder()
+			checker.ReadyHandler().ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			var report Report
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+			assert.Equal(t, testCase.expectedStatus, report.Status)
+			assert.Len(t, report.Checks, len(testCase.expectedChecks))
+			for name, status := range testCase.expectedChecks {
+				assert.Equal(t, status, report.Checks[name].Status, name)
+			}
+		})
+	}
+}
+
+func TestChecker_Live(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Register("down", func(ctx context.Context) (string, error) {
+		return "", errors.New("down")
+	})
+	checker.Drain()
+
+	w := httptest.NewRecorder()
+	checker.LiveHandler().ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestWorkers_Check(t *testing.T) {
+	workers := new(Workers)
+	detail, err := workers.Check(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, detail)
+
+	workers.Set("snapshots", WorkerRunning, nil)
+	workers.Set("outbox", WorkerStarting, nil)
+	detail, err = workers.Check(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "outbox: starting, snapshots: running", detail)
+
+	workers.Set("outbox", WorkerFailed, errors.New("connection refused"))
+	_, err = workers.Check(context.Background())
+	assert.EqualError(t, err, "failed workers: outbox: connection refused")
+}
+
+func TestMigrations(t *testing.T) {
+	testTable := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		wantErr string
+	}{
+		{
+			name: "Latest",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest(t), false))
+			},
+		},
+		{
+			name: "Behind",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false))
+			},
+			wantErr: "schema is at version 1",
+		},
+		{
+			name: "Dirty",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest(t), true))
+			},
+			wantErr: "is dirty",
+		},
+		{
+			name: "No Table",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+					WillReturnError(errors.New(`relation "schema_migrations" does not exist`))
+			},
+			wantErr: "does not exist",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			db, mock, err := sqlmock.Newx()
+			require.NoError(t, err)
+			defer db.Close()
+
+			testCase.mock(mock)
+			_, err = Migrations(db)(context.Background())
+			if testCase.wantErr != "" {
+				assert.ErrorContains(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func latest(t *testing.T) int64 {
+	t.Helper()
+
+	version, err := repository.LatestMigration()
+	require.NoError(t, err)
+
+	return int64(version)
+}
@@ -0,0 +1,177 @@
+// Package lifecycle starts the long-running parts of the application and
+// stops them in order.
+//
+// On shutdown the drain hooks run first, then HTTP servers stop accepting
+// requests and wait for the in-flight ones, then background workers are
+// cancelled, and finally closers release shared resources such as the
+// database pool. The whole sequence is bounded by the shutdown timeout.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/health"
+	"github.com/sirupsen/logrus"
+)
+
+// Server is an HTTP server, or anything stopped the same way.
+type Server interface {
+	// Run blocks until the server fails or is shut down, in which case it
+	// returns http.ErrServerClosed.
+	Run() error
+	Shutdown(ctx context.Context) error
+}
+
+// WorkerFunc runs a background worker until ctx is cancelled. Returning
+// before that with an error marks the worker as failed in the readiness
+// check; the rest of the application keeps running.
+type WorkerFunc func(ctx context.Context) error
+
+// CloserFunc releases a resource once nothing uses it anymore.
+type CloserFunc func(ctx context.Context) error
+
+type named[T any] struct {
+	name string
+	fn   T
+}
+
+type Manager struct {
+	shutdownTimeout time.Duration
+	workerStates    *health.Workers
+
+	drains  []func()
+	servers []named[Server]
+	workers []named[WorkerFunc]
+	closers []named[CloserFunc]
+}
+
+// NewManager returns a manager that reports worker states to workerStates.
+func NewManager(shutdownTimeout time.Duration, workerStates *health.Workers) *Manager {
+	return &Manager{shutdownTimeout: shutdownTimeout, workerStates: workerStates}
+}
+
+// OnDrain registers a hook run before anything is stopped, e.g. failing
+// readiness and waiting for load balancers to notice.
+func (m *Manager) OnDrain(hook func()) {
+	m.drains = append(m.drains, hook)
+}
+
+func (m *Manager) AddServer(name string, server Server) {
+	m.servers = append(m.servers, named[Server]{name, server})
+}
+
+func (m *Manager) AddWorker(name string, worker WorkerFunc) {
+	m.workers = append(m.workers, named[WorkerFunc]{name, worker})
+	m.workerStates.Set(name, health.WorkerStarting, nil)
+}
+
+// AddCloser registers a resource to release after servers and workers have
+// stopped. Closers run in reverse order of registration.
+func (m *Manager) AddCloser(name string, closer CloserFunc) {
+	m.closers = append(m.closers, named[CloserFunc]{name, closer})
+}
+
+// Run starts every server and worker, waits until ctx is done or a server
+// fails, and then shuts everything down. The error joins the server failure,
+// if any, with every error that happened during shutdown.
+func (m *Manager) Run(ctx context.Context) error {
+	serverErrs := make(chan error, len(m.servers))
+	for _, server := range m.servers {
+		go func() {
+			err := server.fn.Run()
+			if errors.Is(err, http.ErrServerClosed) {
+				err = nil
+			}
+			if err != nil {
+				err = fmt.Errorf("%s: %w", server.name, err)
+			}
+			serverErrs <- err
+		}()
+	}
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var workers sync.WaitGroup
+	for _, worker := range m.workers {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			m.runWorker(workerCtx, worker)
+		}()
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		logrus.Info("shutdown requested")
+	case runErr = <-serverErrs:
+		logrus.Errorf("stopping after server failure: %s", runErr.Error())
+	}
+
+	return errors.Join(runErr, m.shutdown(stopWorkers, &workers))
+}
+
+func (m *Manager) runWorker(ctx context.Context, worker named[WorkerFunc]) {
+	m.workerStates.Set(worker.name, health.WorkerRunning, nil)
+
+	err := worker.fn(ctx)
+	if err != nil && ctx.Err() == nil {
+		logrus.Errorf("worker %s failed: %s", worker.name, err.Error())
+		m.workerStates.Set(worker.name, health.WorkerFailed, err)
+		return
+	}
+
+	m.workerStates.Set(worker.name, health.WorkerStopped, nil)
+}
+
+func (m *Manager) shutdown(stopWorkers context.CancelFunc, workers *sync.WaitGroup) error {
+	for _, drain := range m.drains {
+		drain()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	var servers sync.WaitGroup
+	var mu sync.Mutex
+	for _, server := range m.servers {
+		servers.Add(1)
+		go func() {
+			defer servers.Done()
+			if err := server.fn.Shutdown(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: shutdown: %w", server.name, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	servers.Wait()
+
+	stopWorkers()
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("workers did not stop: %w", ctx.Err()))
+	}
+
+	for i := len(m.closers) - 1; i >= 0; i-- {
+		closer := m.closers[i]
+		if err := closer.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: close: %w", closer.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
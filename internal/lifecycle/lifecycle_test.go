@@ -0,0 +1,147 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+type fakeServer struct {
+	events  *recorder
+	runErr  error
+	stopped chan struct{}
+	// block makes Shutdown wait for ctx, like a server with a stuck request.
+	block bool
+}
+
+func newFakeServer(events *recorder) *fakeServer {
+	return &fakeServer{events: events, stopped: make(chan struct{})}
+}
+
+func (s *fakeServer) Run() error {
+	if s.runErr != nil {
+		return s.runErr
+	}
+	<-s.stopped
+	return http.ErrServerClosed
+}
+
+func (s *fakeServer) Shutdown(ctx context.Context) error {
+	defer close(s.stopped)
+	if s.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	s.events.add("server stopped")
+	return nil
+}
+
+func TestManager_Run_StopsInOrder(t *testing.T) {
+	events := new(recorder)
+	workers := new(health.Workers)
+	m := NewManager(time.Second, workers)
+
+	m.OnDrain(func() { events.add("drained") })
+	m.AddServer("http", newFakeServer(events))
+	started := make(chan struct{})
+	m.AddWorker("snapshots", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		events.add("worker stopped")
+		return ctx.Err()
+	})
+	m.AddCloser("tracing", func(ctx context.Context) error { events.add("tracing closed"); return nil })
+	m.AddCloser("db", func(ctx context.Context) error { events.add("db closed"); return nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	require.NoError(t, m.Run(ctx))
+	assert.Equal(t, []string{"drained", "server stopped", "worker stopped", "db closed", "tracing closed"}, events.list())
+
+	detail, err := workers.Check(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "snapshots: stopped", detail)
+}
+
+func TestManager_Run_ServerFailure(t *testing.T) {
+	events := new(recorder)
+	m := NewManager(time.Second, new(health.Workers))
+
+	server := newFakeServer(events)
+	server.runErr = errors.New("address already in use")
+	m.AddServer("http", server)
+	m.AddCloser("db", func(ctx context.Context) error { events.add("db closed"); return nil })
+
+	err := m.Run(context.Background())
+	assert.ErrorContains(t, err, "http: address already in use")
+	assert.Equal(t, []string{"server stopped", "db closed"}, events.list())
+}
+
+func TestManager_Run_ShutdownErrors(t *testing.T) {
+	events := new(recorder)
+	m := NewManager(20*time.Millisecond, new(health.Workers))
+
+	server := newFakeServer(events)
+	server.block = true
+	m.AddServer("http", server)
+	m.AddCloser("db", func(ctx context.Context) error { return errors.New("close failed") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := m.Run(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "http: shutdown")
+	assert.ErrorContains(t, err, "db: close: close failed")
+}
+
+func TestManager_Run_WorkerFailure(t *testing.T) {
+	workers := new(health.Workers)
+	m := NewManager(time.Second, workers)
+
+	failed := make(chan struct{})
+	m.AddWorker("outbox", func(ctx context.Context) error {
+		defer close(failed)
+		return errors.New("connection refused")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-failed
+		// Give the manager a moment to record the state.
+		time.Sleep(10 * time.Millisecond)
+		_, err := workers.Check(context.Background())
+		assert.EqualError(t, err, "failed workers: outbox: connection refused")
+		cancel()
+	}()
+
+	require.NoError(t, m.Run(ctx))
+}
@@ -0,0 +1,237 @@
+// Package metrics defines the Prometheus metrics exported by the service.
+// Metrics are registered in the default registry and served by Handler.
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "wallets"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by the rate limiter by route group.",
+	}, []string{"group"})
+
+	transactions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_total",
+		Help:      "Committed wallet transactions by operation type.",
+	}, []string{"operation_type"})
+
+	transactionAmount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transaction_amount_total",
+		Help:      "Sum of committed transaction amounts by operation type.",
+	}, []string{"operation_type"})
+
+	failedOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "failed_operations_total",
+		Help:      "Failed service operations by operation and error kind.",
+	}, []string{"operation", "kind"})
+
+	lockWait = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "lock_wait_seconds",
+		Help:      "Time spent waiting for wallet row locks.",
+		Buckets:   []float64{.0005, .001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	})
+
+	reads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "reads_total",
+		Help:      "Read queries by the database they were routed to, primary or replica.",
+	}, []string{"target"})
+
+	replicaLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "replica_lag_seconds",
+		Help:      "Replication lag of read replicas at the last check.",
+	}, []string{"replica"})
+
+	replicaUsable = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "replica_usable",
+		Help:      "Whether a read replica takes reads (1) or is down or lagging (0).",
+	}, []string{"replica"})
+
+	breakerOpen = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "circuit_open",
+		Help:      "Whether the circuit breaker of the database is open (1) and requests fail fast.",
+	})
+
+	reconciliationMismatches = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "reconciliation",
+		Name:      "mismatches",
+		Help:      "Wallets whose balance differed from their transactions at the last reconciliation.",
+	})
+
+	reconciliationLastRun = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "reconciliation",
+		Name:      "last_run_timestamp_seconds",
+		Help:      "Unix time the last reconciliation finished.",
+	})
+
+	chainBreaks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "chain",
+		Name:      "breaks_total",
+		Help:      "Wallet hash chains found broken, by reason.",
+	}, []string{"reason"})
+
+	chainLastCheckpoint = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "chain",
+		Name:      "last_checkpoint_timestamp_seconds",
+		Help:      "Unix time of the moment covered by the last checkpoint published.",
+	})
+)
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDBStats exports the connection pool statistics of db, labelled
+// with name.
+func RegisterDBStats(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveRequest records a served HTTP request. route is the route pattern,
+// not the raw path, to keep the number of series bounded.
+func ObserveRequest(method, route, status string, seconds float64) {
+	httpRequests.WithLabelValues(method, route, status).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(seconds)
+}
+
+// ObserveRateLimited records a request rejected by the rate limiter.
+func ObserveRateLimited(group string) {
+	rateLimited.WithLabelValues(group).Inc()
+}
+
+// ObserveTransaction records a committed transaction.
+func ObserveTransaction(operationType models.OperationType, amount int64) {
+	transactions.WithLabelValues(string(operationType)).Inc()
+	transactionAmount.WithLabelValues(string(operationType)).Add(float64(amount))
+}
+
+// ObserveLockWait records how long acquiring a wallet row lock took.
+func ObserveLockWait(seconds float64) {
+	lockWait.Observe(seconds)
+}
+
+// ObserveRead records a read query routed to target, primary or replica.
+func ObserveRead(target string) {
+	reads.WithLabelValues(target).Inc()
+}
+
+// ObserveReplica records the result of a replica health check.
+func ObserveReplica(replica string, lagSeconds float64, usable bool) {
+	replicaLag.WithLabelValues(replica).Set(lagSeconds)
+	value := 0.0
+	if usable {
+		value = 1
+	}
+	replicaUsable.WithLabelValues(replica).Set(value)
+}
+
+// ObserveBreaker records the circuit breaker of the database opening or
+// closing.
+func ObserveBreaker(open bool) {
+	value := 0.0
+	if open {
+		value = 1
+	}
+	breakerOpen.Set(value)
+}
+
+// ObserveReconciliation records a finished reconciliation that found
+// mismatches wallets out of balance.
+func ObserveReconciliation(mismatches int, finishedAt time.Time) {
+	reconciliationMismatches.Set(float64(mismatches))
+	reconciliationLastRun.Set(float64(finishedAt.UnixNano()) / 1e9)
+}
+
+// ObserveChainBreak records a wallet hash chain found broken for reason.
+func ObserveChainBreak(reason string) {
+	chainBreaks.WithLabelValues(reason).Inc()
+}
+
+// ObserveCheckpoint records a checkpoint published for the moment at.
+func ObserveCheckpoint(at time.Time) {
+	chainLastCheckpoint.Set(float64(at.UnixNano()) / 1e9)
+}
+
+// ObserveFailure records a failed operation, classified by ErrorKind.
+func ObserveFailure(operation string, err error) {
+	failedOperations.WithLabelValues(operation, ErrorKind(err)).Inc()
+}
+
+// ErrorKind maps an error to a short, bounded label value.
+func ErrorKind(err error) string {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return "not_found"
+	case errors.Is(err, models.ErrInsufficientFunds):
+		return "insufficient_funds"
+	case errors.Is(err, models.ErrWalletFrozen):
+		return "wallet_frozen"
+	case errors.Is(err, models.ErrWalletClosed):
+		return "wallet_closed"
+	case errors.Is(err, models.ErrNonZeroBalance):
+		return "non_zero_balance"
+	case errors.Is(err, models.ErrInvalidSweepTarget):
+		return "invalid_sweep_target"
+	case errors.Is(err, models.ErrUnknownOperation), errors.Is(err, models.ErrInvalidAmount), errors.Is(err, models.ErrReasonRequired), errors.Is(err, models.ErrResolutionRequired),
+		errors.Is(err, models.ErrResolutionTooLong), errors.Is(err, models.ErrFutureTime), errors.Is(err, models.ErrInvalidRange), errors.Is(err, models.ErrTooManyPoints),
+		errors.Is(err, models.ErrInvalidMetadata), errors.Is(err, models.ErrMetadataTooLarge), errors.Is(err, models.ErrUnknownCategory),
+		errors.Is(err, models.ErrNameRequired), errors.Is(err, models.ErrInvalidColor), errors.Is(err, models.ErrInvalidTag),
+		errors.Is(err, models.ErrTooManyTags), errors.Is(err, models.ErrNothingToUpdate):
+		return "invalid_input"
+	case errors.Is(err, models.ErrCategoryExists), errors.Is(err, models.ErrCategoryInUse):
+		return "conflict"
+	case errors.Is(err, models.ErrVersionMismatch):
+		return "version_mismatch"
+	case errors.Is(err, models.ErrUnavailable):
+		return "unavailable"
+	default:
+		return "internal"
+	}
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func TestErrorKind(t *testing.T) {
+	testTable := []struct {
+		err  error
+		kind string
+	}{
+		{err: sql.ErrNoRows, kind: "not_found"},
+		{err: models.ErrInsufficientFunds, kind: "insufficient_funds"},
+		{err: fmt.Errorf("%w: %w", models.ErrInvalidSweepTarget, models.ErrWalletClosed), kind: "wallet_closed"},
+		{err: models.ErrInvalidSweepTarget, kind: "invalid_sweep_target"},
+		{err: models.ErrInvalidAmount, kind: "invalid_input"},
+		{err: models.ErrReasonRequired, kind: "invalid_input"},
+		{err: models.ErrTooManyPoints, kind: "invalid_input"},
+		{err: models.ErrResolutionRequired, kind: "invalid_input"},
+		{err: models.ErrResolutionTooLong, kind: "invalid_input"},
+		{err: models.ErrMetadataTooLarge, kind: "invalid_input"},
+		{err: models.ErrCategoryInUse, kind: "conflict"},
+		{err: models.ErrTooManyTags, kind: "invalid_input"},
+		{err: &models.UnavailableError{RetryAfter: time.Second}, kind: "unavailable"},
+		{err: errors.New("connection reset"), kind: "internal"},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.kind, func(t *testing.T) {
+			assert.Equal(t, testCase.kind, ErrorKind(testCase.err))
+		})
+	}
+}
+
+func TestObserveTransaction(t *testing.T) {
+	count := testutil.ToFloat64(transactions.WithLabelValues(string(models.Deposit)))
+	amount := testutil.ToFloat64(transactionAmount.WithLabelValues(string(models.Deposit)))
+
+	ObserveTransaction(models.Deposit, 150)
+
+	assert.Equal(t, count+1, testutil.ToFloat64(transactions.WithLabelValues(string(models.Deposit))))
+	assert.Equal(t, amount+150, testutil.ToFloat64(transactionAmount.WithLabelValues(string(models.Deposit))))
+}
+
+func TestRegisterDBStats(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	RegisterDBStats(db, "sqlite")
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.NoError(t, err)
+	var names []string
+	for _, family := range families {
+		if family.GetName() != "go_sql_open_connections" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "db_name" {
+					names = append(names, label.GetValue())
+				}
+			}
+		}
+	}
+	assert.Equal(t, []string{"sqlite"}, names)
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Interval is the length of the periods of a balance history. Periods start
+// at the beginning of an hour, day, ISO week or month in UTC.
+type Interval string
+
+const (
+	Hour  Interval = "hour"
+	Day   Interval = "day"
+	Week  Interval = "week"
+	Month Interval = "month"
+)
+
+func (i Interval) Valid() bool {
+	switch i {
+	case Hour, Day, Week, Month:
+		return true
+	}
+	return false
+}
+
+// Start returns the start of the period t falls in.
+func (i Interval) Start(t time.Time) time.Time {
+	t = t.UTC()
+	switch i {
+	case Hour:
+		return t.Truncate(time.Hour)
+	case Week:
+		// The zero time is a Monday, so weeks start on Mondays.
+		return t.Truncate(7 * 24 * time.Hour)
+	case Month:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return t.Truncate(24 * time.Hour)
+	}
+}
+
+// Next returns the start of the period after the one starting at start.
+func (i Interval) Next(start time.Time) time.Time {
+	switch i {
+	case Hour:
+		return start.Add(time.Hour)
+	case Week:
+		return start.AddDate(0, 0, 7)
+	case Month:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// BalanceHistoryInput selects the periods of a balance history: those
+// overlapping [From, To).
+type BalanceHistoryInput struct {
+	Interval Interval
+	From     time.Time
+	To       time.Time
+}
+
+// BalancePoint is the closing balance of the period starting at Date: the
+// balance at its end, or now for the current period.
+type BalancePoint struct {
+	Date    time.Time `json:"date"`
+	Balance int64     `json:"balance"`
+}
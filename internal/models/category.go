@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Category classifies transactions. Every user has their own categories,
+// optionally nested under a parent category.
+type Category struct {
+	CategoryId uuid.UUID  `json:"categoryId" db:"category_id"`
+	UserId     int        `json:"-" db:"user_id"`
+	ParentId   *uuid.UUID `json:"parentId,omitempty" db:"parent_id"`
+	Name       string     `json:"name" db:"name"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+}
+
+type CategoryInput struct {
+	Name     string     `json:"name" binding:"required,max=64"`
+	ParentId *uuid.UUID `json:"parentId"`
+}
+
+// Annotation is what the owner of a transaction can change about it, which
+// the transaction itself can't: its category and notes. Every change adds a
+// version, numbered from 1, and the latest is the current annotation.
+type Annotation struct {
+	TransactionId uuid.UUID `json:"transactionId" db:"transaction_id"`
+	Version       int64     `json:"version" db:"version"`
+	// CategoryId replaces the category the transaction was recorded with,
+	// also when nil.
+	CategoryId *uuid.UUID `json:"categoryId,omitempty" db:"category_id"`
+	Notes      string     `json:"notes" db:"notes"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+}
+
+type AnnotationInput struct {
+	CategoryId *uuid.UUID `json:"categoryId"`
+	Notes      string     `json:"notes" binding:"max=2000"`
+}
+
+// TransactionFilter narrows a list of transactions to those matching every
+// field set.
+type TransactionFilter struct {
+	// CategoryId matches the category the transaction was recorded with.
+	// Annotations are private to the owner of the wallet, so their
+	// categories don't count.
+	CategoryId  *uuid.UUID
+	ExternalRef string
+	// Search matches descriptions containing it, ignoring case.
+	Search string
+	// Metadata matches top-level metadata values, compared as text like
+	// Metadata.Values renders them.
+	Metadata map[string]string
+}
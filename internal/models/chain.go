@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChainHead is the last transaction of a wallet chain.
+type ChainHead struct {
+	WalletId uuid.UUID `db:"wallet_id"`
+	Seq      int64     `db:"seq"`
+	Hash     string    `db:"hash"`
+}
+
+type BreakReason string
+
+const (
+	// BreakSeqGap is a transaction missing from the chain.
+	BreakSeqGap BreakReason = "seq_gap"
+	// BreakPrevHash is a transaction not chained to the one before it.
+	BreakPrevHash BreakReason = "prev_hash_mismatch"
+	// BreakHash is a transaction whose contents don't match its hash.
+	BreakHash BreakReason = "hash_mismatch"
+	// BreakUnsealed is a transaction without a hash: one from before the
+	// chain not sealed yet, or one whose hash was cleared.
+	BreakUnsealed BreakReason = "unsealed"
+)
+
+// ChainBreak is the first link of a wallet chain that fails verification.
+type ChainBreak struct {
+	Seq           int64       `json:"seq"`
+	TransactionId uuid.UUID   `json:"transactionId"`
+	Reason        BreakReason `json:"reason"`
+}
+
+// ChainVerification is the outcome of checking the hash chain of a wallet.
+type ChainVerification struct {
+	WalletId     uuid.UUID   `json:"walletId"`
+	Transactions int64       `json:"transactions"`
+	Head         string      `json:"head,omitempty"`
+	Valid        bool        `json:"valid"`
+	Break        *ChainBreak `json:"break,omitempty"`
+}
+
+// Checkpoint is a signed Merkle root over the heads of the wallet chains at
+// a moment: the transactions created before At.
+type Checkpoint struct {
+	CheckpointId uuid.UUID `json:"checkpointId" db:"checkpoint_id"`
+	At           time.Time `json:"at" db:"at"`
+	Wallets      int64     `json:"wallets" db:"wallets"`
+	Transactions int64     `json:"transactions" db:"transactions"`
+	Root         string    `json:"root" db:"root"`
+	// PublicKey and Signature are base64. The signature is Ed25519 over
+	// SignedContent.
+	PublicKey string    `json:"publicKey" db:"public_key"`
+	Signature string    `json:"signature" db:"signature"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// SignedContent is the message signed by the checkpoint.
+func (c Checkpoint) SignedContent() []byte {
+	return []byte(fmt.Sprintf("rest-wallets checkpoint v1\nat %s\nwallets %d\ntransactions %d\nroot %s\n",
+		c.At.UTC().Format(time.RFC3339Nano), c.Wallets, c.Transactions, c.Root))
+}
+
+// CheckpointVerification is the outcome of checking a checkpoint against the
+// transactions stored now.
+type CheckpointVerification struct {
+	Checkpoint   Checkpoint `json:"checkpoint"`
+	ComputedRoot string     `json:"computedRoot"`
+	RootMatches  bool       `json:"rootMatches"`
+	// SignatureValid checks the signature with the public key of the
+	// checkpoint, KeyMatches whether that is the key configured now.
+	SignatureValid bool `json:"signatureValid"`
+	KeyMatches     bool `json:"keyMatches"`
+	Valid          bool `json:"valid"`
+}
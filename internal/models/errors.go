@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrWalletFrozen       = errors.New("wallet is frozen")
+	ErrWalletClosed       = errors.New("wallet is closed")
+	ErrNonZeroBalance     = errors.New("wallet balance is not zero")
+	ErrInsufficientFunds  = errors.New("insufficient funds")
+	ErrInvalidSweepTarget = errors.New("invalid sweep destination wallet")
+	ErrUnknownOperation   = errors.New("unknown operation type")
+	ErrInvalidAmount      = errors.New("amount must be positive")
+	ErrReasonRequired     = errors.New("reason is required")
+	ErrUnavailable        = errors.New("database is unavailable")
+	ErrVersionMismatch    = errors.New("wallet version does not match")
+	ErrFutureTime         = errors.New("time is in the future")
+	ErrInvalidRange       = errors.New("from must be before to")
+	ErrTooManyPoints      = errors.New("too many points, use a longer interval or a shorter range")
+	ErrResolutionRequired = errors.New("resolution is required")
+	ErrResolutionTooLong  = errors.New("resolution is too long")
+	ErrNoSigningKey       = errors.New("checkpoint signing key is not configured")
+	ErrInvalidMetadata    = errors.New("metadata must be a JSON object")
+	ErrMetadataTooLarge   = errors.New("metadata is too large")
+	ErrUnknownCategory    = errors.New("unknown category")
+	ErrNameRequired       = errors.New("name is required")
+	ErrCategoryExists     = errors.New("category already exists")
+	ErrCategoryInUse      = errors.New("category is in use")
+	ErrInvalidColor       = errors.New("color must be #rrggbb")
+	ErrInvalidTag         = errors.New("tags must not be blank or longer than 32 characters")
+	ErrTooManyTags        = errors.New("too many tags")
+	ErrNothingToUpdate    = errors.New("nothing to update")
+)
+
+// UnavailableError is ErrUnavailable with how long the database is expected
+// to stay unavailable.
+type UnavailableError struct {
+	RetryAfter time.Duration
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrUnavailable, e.RetryAfter.Round(time.Second))
+}
+
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
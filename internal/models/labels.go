@@ -0,0 +1,135 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Limits of the labels of a wallet. Text limits are in characters.
+const (
+	MaxWalletNameLength = 64
+	MaxIconLength       = 32
+	MaxTagLength        = 32
+	MaxWalletTags       = 10
+)
+
+// WalletUpdate changes the labels of a wallet. Fields left nil are kept, and
+// an empty string clears a label.
+type WalletUpdate struct {
+	Name        *string `json:"name" binding:"omitempty,max=64"`
+	Description *string `json:"description" binding:"omitempty,max=500"`
+	// Color is "#rrggbb".
+	Color *string   `json:"color"`
+	Icon  *string   `json:"icon" binding:"omitempty,max=32"`
+	Tags  *[]string `json:"tags"`
+	// IsDefault true makes the wallet the default one of the user in place
+	// of the previous one. A closed wallet can't be the default.
+	IsDefault *bool `json:"isDefault"`
+	// ExpectedVersion, when set, makes the update fail with
+	// ErrVersionMismatch if the wallet is at another version. It comes from
+	// the If-Match header, not the body.
+	ExpectedVersion *int64 `json:"-"`
+}
+
+// Empty reports whether the update changes nothing.
+func (u WalletUpdate) Empty() bool {
+	return u.Name == nil && u.Description == nil && u.Color == nil && u.Icon == nil && u.Tags == nil && u.IsDefault == nil
+}
+
+// Apply returns the wallet with the labels of the update.
+func (u WalletUpdate) Apply(wallet Wallet) Wallet {
+	if u.Name != nil {
+		wallet.Name = *u.Name
+	}
+	if u.Description != nil {
+		wallet.Description = *u.Description
+	}
+	if u.Color != nil {
+		wallet.Color = *u.Color
+	}
+	if u.Icon != nil {
+		wallet.Icon = *u.Icon
+	}
+	if u.Tags != nil {
+		wallet.Tags = nil
+		if len(*u.Tags) > 0 {
+			wallet.Tags = append(Tags(nil), *u.Tags...)
+		}
+	}
+	if u.IsDefault != nil {
+		wallet.IsDefault = *u.IsDefault
+	}
+
+	return wallet
+}
+
+// WalletOrder is the field a list of wallets is sorted by. Ties are broken
+// by creation, then by id.
+type WalletOrder string
+
+const (
+	WalletsByCreation WalletOrder = "createdAt"
+	// WalletsByName sorts names byte by byte, so capitals come first.
+	WalletsByName   WalletOrder = "name"
+	WalletsByAmount WalletOrder = "amount"
+)
+
+// WalletFilter narrows a list of wallets to those matching every field set,
+// and sorts them.
+type WalletFilter struct {
+	// Name matches names containing it, ignoring case.
+	Name string
+	// Tags matches wallets having all of them.
+	Tags      []string
+	MinAmount *int64
+	MaxAmount *int64
+	// CreatedFrom and CreatedTo bound the creation time to
+	// [CreatedFrom, CreatedTo).
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// OrderBy is WalletsByCreation when empty.
+	OrderBy WalletOrder
+	Desc    bool
+}
+
+// Tags are the tags of a wallet. They are stored as a JSON array: JSONB by
+// Postgres and JSON text by SQLite.
+type Tags []string
+
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+
+	data, err := json.Marshal([]string(t))
+	return string(data), err
+}
+
+func (t *Tags) Scan(src any) error {
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return fmt.Errorf("can't scan %T into Tags", src)
+	}
+
+	var tags []string
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return err
+	}
+	// Wallets without tags compare equal however they were read.
+	if len(tags) == 0 {
+		tags = nil
+	}
+	*t = tags
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Limits of the details of a transaction and of its annotation. Text limits
+// are in characters.
+const (
+	MaxDescriptionLength = 500
+	MaxExternalRefLength = 128
+	MaxNotesLength       = 2000
+	MaxCategoryLength    = 64
+	// MaxMetadataBytes bounds the metadata in its canonical form.
+	MaxMetadataBytes = 4 << 10
+)
+
+// Metadata is a free-form JSON object attached to a transaction. It is
+// stored as JSONB by Postgres and as JSON text by SQLite.
+type Metadata json.RawMessage
+
+// Canonical returns the metadata compacted with its object keys sorted, the
+// form it is stored and hashed in, so that it doesn't depend on how the
+// database renders JSON. Empty and null metadata have no canonical form.
+func (m Metadata) Canonical() (Metadata, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+
+	// Numbers are kept exact: as float64 integers above 2^53 would change.
+	decoder := json.NewDecoder(bytes.NewReader(m))
+	decoder.UseNumber()
+	var object map[string]any
+	if err := decoder.Decode(&object); err != nil {
+		return nil, ErrInvalidMetadata
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, ErrInvalidMetadata
+	}
+	if object == nil {
+		return nil, nil
+	}
+
+	if _, err := canonicalNumbers(object); err != nil {
+		return nil, err
+	}
+
+	canonical, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	return canonical, nil
+}
+
+// canonicalNumbers replaces the numbers in a decoded JSON value with their
+// canonical form, the one Postgres prints for a numeric.
+func canonicalNumbers(value any) (any, error) {
+	var err error
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if value[key], err = canonicalNumbers(item); err != nil {
+				return nil, err
+			}
+		}
+	case []any:
+		for i, item := range value {
+			if value[i], err = canonicalNumbers(item); err != nil {
+				return nil, err
+			}
+		}
+	case json.Number:
+		return canonicalNumber(value)
+	}
+
+	return value, nil
+}
+
+// canonicalNumber writes a JSON number in plain decimal notation with its
+// exact digits, as JSONB reads it back: the exponent is applied, the scale of
+// the literal is kept and zero has no sign, so 1e21 is
+// 1000000000000000000000, 1.50 stays 1.50 and 1.5e-3 is 0.0015.
+func canonicalNumber(number json.Number) (json.Number, error) {
+	literal, negative := strings.CutPrefix(string(number), "-")
+	mantissa, exponent := literal, 0
+	if i := strings.IndexAny(literal, "eE"); i >= 0 {
+		var err error
+		mantissa = literal[:i]
+		if exponent, err = strconv.Atoi(literal[i+1:]); err != nil {
+			return "", ErrMetadataTooLarge
+		}
+	}
+	integer, fraction, _ := strings.Cut(mantissa, ".")
+
+	// The digits of the number with scale of them after the decimal point.
+	digits, scale := integer+fraction, len(fraction)-exponent
+	// Bounds the digits written below, as longer numbers can't fit anyway.
+	if len(digits)+max(scale, -scale) > MaxMetadataBytes {
+		return "", ErrMetadataTooLarge
+	}
+	if scale < 0 {
+		digits += strings.Repeat("0", -scale)
+		scale = 0
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+
+	integer = strings.TrimLeft(digits[:len(digits)-scale], "0")
+	if integer == "" {
+		integer = "0"
+	}
+	canonical := integer
+	if scale > 0 {
+		canonical += "." + digits[len(digits)-scale:]
+	}
+	if negative && strings.Trim(digits, "0") != "" {
+		canonical = "-" + canonical
+	}
+
+	return json.Number(canonical), nil
+}
+
+// Values returns the top-level values of the metadata as text: strings as
+// they are and other values as JSON.
+func (m Metadata) Values() map[string]string {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(m, &object); err != nil {
+		return nil
+	}
+
+	values := make(map[string]string, len(object))
+	for key, raw := range object {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			values[key] = s
+		} else if string(raw) != "null" {
+			values[key] = string(raw)
+		}
+	}
+
+	return values
+}
+
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	if len(m) == 0 {
+		return []byte("null"), nil
+	}
+	return m, nil
+}
+
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	*m = append((*m)[:0], data...)
+	return nil
+}
+
+// Value stores empty metadata as NULL.
+func (m Metadata) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return string(m), nil
+}
+
+func (m *Metadata) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		*m = nil
+	case []byte:
+		*m = append(Metadata(nil), src...)
+	case string:
+		*m = Metadata(src)
+	default:
+		return fmt.Errorf("can't scan %T into Metadata", src)
+	}
+
+	return nil
+}
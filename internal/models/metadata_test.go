@@ -0,0 +1,111 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata_Canonical(t *testing.T) {
+	testTable := []struct {
+		name     string
+		metadata Metadata
+		want     Metadata
+		err      error
+	}{
+		{
+			name:     "Sorted and compacted",
+			metadata: Metadata(`{"shop": "cafe", "count": 2, "tags": ["a", {"z": 1, "b": null}]}`),
+			want:     Metadata(`{"count":2,"shop":"cafe","tags":["a",{"b":null,"z":1}]}`),
+		},
+		{
+			name:     "Large integers kept",
+			metadata: Metadata(`{"orderId": 9007199254740993, "debt": -123456789012345678901234567890}`),
+			want:     Metadata(`{"debt":-123456789012345678901234567890,"orderId":9007199254740993}`),
+		},
+		{
+			name:     "Exponents applied",
+			metadata: Metadata(`{"a": 1e2, "b": 1.5e-3, "c": 1.50E+1, "d": -2.5e0}`),
+			want:     Metadata(`{"a":100,"b":0.0015,"c":15.0,"d":-2.5}`),
+		},
+		{
+			name:     "Scale kept",
+			metadata: Metadata(`{"a": 1.50, "b": 0.000, "c": -0, "d": -0.0}`),
+			want:     Metadata(`{"a":1.50,"b":0.000,"c":0,"d":0.0}`),
+		},
+		{
+			name:     "Large exponent",
+			metadata: Metadata(`{"x": 1e21}`),
+			want:     Metadata(`{"x":1000000000000000000000}`),
+		},
+		{
+			name:     "Large exponent as read back from JSONB",
+			metadata: Metadata(`{"x": 1000000000000000000000}`),
+			want:     Metadata(`{"x":1000000000000000000000}`),
+		},
+		{
+			name:     "Empty",
+			metadata: nil,
+		},
+		{
+			name:     "Null",
+			metadata: Metadata(`null`),
+		},
+		{
+			name:     "Not an object",
+			metadata: Metadata(`[1]`),
+			err:      ErrInvalidMetadata,
+		},
+		{
+			name:     "Trailing data",
+			metadata: Metadata(`{"a": 1} {"b": 2}`),
+			err:      ErrInvalidMetadata,
+		},
+		{
+			name:     "Number too long",
+			metadata: Metadata(`{"a": 1e999999999}`),
+			err:      ErrMetadataTooLarge,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := testCase.metadata.Canonical()
+
+			assert.ErrorIs(t, err, testCase.err)
+			assert.Equal(t, string(testCase.want), string(got))
+			if err == nil {
+				// The canonical form is stable, as it is hashed again when
+				// read back.
+				again, err := got.Canonical()
+				assert.NoError(t, err)
+				assert.Equal(t, string(got), string(again))
+			}
+		})
+	}
+}
+
+// The hash of metadata is computed when a transaction is written and again
+// from what Postgres reads back, so both forms must canonicalize alike.
+func TestMetadata_CanonicalReadBack(t *testing.T) {
+	testTable := []struct {
+		written  Metadata
+		readBack Metadata
+	}{
+		{written: Metadata(`{"x":1e21}`), readBack: Metadata(`{"x": 1000000000000000000000}`)},
+		{written: Metadata(`{"x":1.5e-3}`), readBack: Metadata(`{"x": 0.0015}`)},
+		{written: Metadata(`{"x":-0.0}`), readBack: Metadata(`{"x": 0.0}`)},
+		{written: Metadata(`{"x":[9007199254740993]}`), readBack: Metadata(`{"x": [9007199254740993]}`)},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(string(testCase.written), func(t *testing.T) {
+			written, err := testCase.written.Canonical()
+			assert.NoError(t, err)
+			readBack, err := testCase.readBack.Canonical()
+			assert.NoError(t, err)
+
+			assert.Equal(t, string(written), string(readBack))
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package models
+
+// Page selects part of a list ordered by creation time. A zero Limit means
+// no limit.
+type Page struct {
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BalanceMismatch is a wallet whose stored balance differs from the balance
+// computed from its transactions. Difference is Stored minus Computed.
+type BalanceMismatch struct {
+	WalletId     uuid.UUID    `json:"walletId" db:"wallet_id"`
+	UserId       int          `json:"userId" db:"user_id"`
+	Status       WalletStatus `json:"status" db:"status"`
+	Stored       int64        `json:"stored" db:"stored"`
+	Computed     int64        `json:"computed" db:"computed"`
+	Difference   int64        `json:"difference" db:"difference"`
+	Deposits     int64        `json:"deposits" db:"deposits"`
+	Withdrawals  int64        `json:"withdrawals" db:"withdrawals"`
+	Transactions int64        `json:"transactions" db:"transactions"`
+	// CaseId is the open correction case of the wallet, when cases are
+	// opened.
+	CaseId *uuid.UUID `json:"caseId,omitempty" db:"-"`
+}
+
+// ReconciliationReport is the outcome of recomputing every wallet balance
+// from the transactions.
+type ReconciliationReport struct {
+	StartedAt  time.Time         `json:"startedAt"`
+	FinishedAt time.Time         `json:"finishedAt"`
+	Wallets    int64             `json:"wallets"`
+	Mismatches []BalanceMismatch `json:"mismatches"`
+}
+
+// MaxResolutionLength bounds the resolution of a correction case, in
+// characters.
+const MaxResolutionLength = 255
+
+type CaseStatus string
+
+const (
+	CaseOpen     CaseStatus = "OPEN"
+	CaseResolved CaseStatus = "RESOLVED"
+)
+
+// CorrectionCase records a balance mismatch for an operator to review. It
+// keeps the balances of when it was opened.
+type CorrectionCase struct {
+	CaseId     uuid.UUID  `json:"caseId" db:"case_id"`
+	WalletId   uuid.UUID  `json:"walletId" db:"wallet_id"`
+	Stored     int64      `json:"stored" db:"stored_amount"`
+	Computed   int64      `json:"computed" db:"computed_amount"`
+	Status     CaseStatus `json:"status" db:"status"`
+	OpenedAt   time.Time  `json:"openedAt" db:"opened_at"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty" db:"resolved_at"`
+	Resolution *string    `json:"resolution,omitempty" db:"resolution"`
+}
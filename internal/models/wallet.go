@@ -1,6 +1,10 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,12 +17,48 @@ const (
 	Withdraw OperationType = "WITHDRAW"
 )
 
+type WalletStatus string
+
+const (
+	WalletActive WalletStatus = "ACTIVE"
+	// WalletFrozen wallets accept deposits but refuse withdrawals.
+	WalletFrozen WalletStatus = "FROZEN"
+	// WalletClosed wallets are kept for history and refuse all transactions.
+	WalletClosed WalletStatus = "CLOSED"
+)
+
 type Wallet struct {
-	WalletId  uuid.UUID `json:"walletId" db:"wallet_id"`
-	UserId    int       `json:"userId" db:"user_id"`
-	Amount    int64     `json:"amount" db:"amount"`
-	CreatedAt time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	WalletId    uuid.UUID    `json:"walletId" db:"wallet_id"`
+	UserId      int          `json:"userId" db:"user_id"`
+	Amount      int64        `json:"amount" db:"amount"`
+	CreatedAt   time.Time    `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time    `json:"updatedAt" db:"updated_at"`
+	Status      WalletStatus `json:"status" db:"status"`
+	ClosedAt    *time.Time   `json:"closedAt,omitempty" db:"closed_at"`
+	CloseReason *string      `json:"closeReason,omitempty" db:"close_reason"`
+	// Version starts at 1 and is incremented on every change of the wallet:
+	// balance, status and labels.
+	Version int64 `json:"version" db:"version"`
+	// Name, Description, Color, Icon and Tags are labels the owner sets to
+	// tell the wallets apart.
+	Name        string `json:"name,omitempty" db:"name"`
+	Description string `json:"description,omitempty" db:"description"`
+	Color       string `json:"color,omitempty" db:"color"`
+	Icon        string `json:"icon,omitempty" db:"icon"`
+	Tags        Tags   `json:"tags,omitempty" db:"tags"`
+	// IsDefault marks the one wallet of the user they chose as default.
+	IsDefault bool `json:"isDefault,omitempty" db:"is_default"`
+}
+
+// CloseWalletInput describes how a wallet is closed. A wallet with a
+// non-zero balance can only be closed when SweepTo names another wallet of
+// the same user to receive the remaining funds.
+type CloseWalletInput struct {
+	Reason  string
+	SweepTo *uuid.UUID
+	// ExpectedVersion, when set, makes the close fail with
+	// ErrVersionMismatch if the wallet is at another version.
+	ExpectedVersion *int64
 }
 
 type Transaction struct {
@@ -27,10 +67,116 @@ type Transaction struct {
 	OperationType OperationType `json:"operationType" db:"operation_type" binding:"required"`
 	Amount        int64         `json:"amount" db:"amount" binding:"required"`
 	CreatedAt     time.Time     `json:"createdAt" db:"created_at"`
+	// Seq numbers the transactions of the wallet from 1. Hash chains the
+	// transaction to PrevHash, the hash of the one before it.
+	Seq      int64  `json:"seq,omitempty" db:"seq"`
+	PrevHash string `json:"prevHash,omitempty" db:"prev_hash"`
+	Hash     string `json:"hash,omitempty" db:"hash"`
+	// Description, CategoryId, ExternalRef and Metadata are the details the
+	// transaction was recorded with. Annotation is its current annotation,
+	// if it has one and it was read for the owner of the wallet.
+	Description *string     `json:"description,omitempty" db:"description"`
+	CategoryId  *uuid.UUID  `json:"categoryId,omitempty" db:"category_id"`
+	ExternalRef *string     `json:"externalRef,omitempty" db:"external_ref"`
+	Metadata    Metadata    `json:"metadata,omitempty" db:"metadata"`
+	Annotation  *Annotation `json:"annotation,omitempty" db:"-"`
+}
+
+// ComputeHash returns the chain hash of the transaction: the hex SHA-256 of
+// its contents and PrevHash, joined by "|" in a fixed order. CreatedAt is
+// taken in microseconds, the precision Postgres stores. The details, when
+// the transaction has any, follow as a JSON object with the metadata in
+// canonical form, so the hashes of transactions without them are the same
+// as before details existed.
+func (t Transaction) ComputeHash() string {
+	content := fmt.Sprintf("%s|%s|%d|%s|%d|%d|%s", t.TransactionId, t.WalletId, t.Seq,
+		t.OperationType, t.Amount, t.CreatedAt.UnixMicro(), t.PrevHash)
+	if t.Description != nil || t.CategoryId != nil || t.ExternalRef != nil || len(t.Metadata) > 0 {
+		metadata, err := t.Metadata.Canonical()
+		if err != nil {
+			metadata = t.Metadata
+		}
+		details, _ := json.Marshal(struct {
+			Description *string         `json:"d"`
+			CategoryId  *uuid.UUID      `json:"c"`
+			ExternalRef *string         `json:"r"`
+			Metadata    json.RawMessage `json:"m"`
+		}{t.Description, t.CategoryId, t.ExternalRef, json.RawMessage(metadata)})
+		content += "|" + string(details)
+	}
+	sum := sha256.Sum256([]byte(content))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// AdjustmentInput is a manual balance correction posted by an operator.
+// Unlike regular transactions, adjustments are allowed on frozen wallets.
+type AdjustmentInput struct {
+	WalletId      uuid.UUID
+	OperationType OperationType
+	Amount        int64
+	Reason        string
 }
 
 type TransactionInput struct {
 	WalletId      uuid.UUID     `json:"walletId" db:"wallet_id" binding:"required"`
 	OperationType OperationType `json:"operationType" db:"operation_type" binding:"required"`
-	Amount        int64         `json:"amount" db:"amount" binding:"required"`
+	Amount        int64         `json:"amount" db:"amount" binding:"required,gt=0"`
+	Description   *string       `json:"description" db:"description" binding:"omitempty,max=500"`
+	CategoryId    *uuid.UUID    `json:"categoryId" db:"category_id"`
+	ExternalRef   *string       `json:"externalRef" db:"external_ref" binding:"omitempty,max=128"`
+	// Metadata must be a JSON object of at most MaxMetadataBytes.
+	Metadata Metadata `json:"metadata" db:"metadata"`
+	// ExpectedVersion, when set, makes the transaction fail with
+	// ErrVersionMismatch if the wallet is at another version. It comes from
+	// the If-Match header, not the body.
+	ExpectedVersion *int64 `json:"-" db:"-"`
+}
+
+// CheckOperation reports whether an operation of the given type and amount
+// can be applied to the wallet in its current state.
+func (w Wallet) CheckOperation(operationType OperationType, amount int64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+
+	switch operationType {
+	case Deposit:
+		if w.Status == WalletClosed {
+			return ErrWalletClosed
+		}
+	case Withdraw:
+		switch w.Status {
+		case WalletClosed:
+			return ErrWalletClosed
+		case WalletFrozen:
+			return ErrWalletFrozen
+		}
+		if w.Amount < amount {
+			return ErrInsufficientFunds
+		}
+	default:
+		return ErrUnknownOperation
+	}
+
+	return nil
+}
+
+// CheckVersion reports whether the wallet is still at the expected version.
+// A nil expected version matches any.
+func (w Wallet) CheckVersion(expected *int64) error {
+	if expected != nil && *expected != w.Version {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+// CheckAdjustment is CheckOperation for manual adjustments, which operators
+// may also post to frozen wallets.
+func (w Wallet) CheckAdjustment(operationType OperationType, amount int64) error {
+	if w.Status == WalletFrozen {
+		w.Status = WalletActive
+	}
+
+	return w.CheckOperation(operationType, amount)
 }
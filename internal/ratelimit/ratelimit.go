@@ -0,0 +1,118 @@
+// Package ratelimit implements token bucket rate limiting.
+//
+// Every key has a bucket holding up to Burst tokens that refills at Rate
+// tokens per second; a request takes one token or is rejected. Buckets live
+// in a Store: in memory for a single instance, or in Postgres so that limits
+// hold across instances.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Limit struct {
+	// Rate is the number of tokens added per second.
+	Rate  float64
+	Burst int
+	// Window is the period the limit is expressed in, reported in the
+	// RateLimit-Policy header.
+	Window time.Duration
+}
+
+// Refill is how long an empty bucket takes to fill up.
+func (l Limit) Refill() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+type Store interface {
+	// Take refills the bucket of key and takes one token from it if there is
+	// one. It returns whether a token was taken and how many are left.
+	Take(ctx context.Context, key string, limit Limit) (allowed bool, tokens float64, err error)
+	// Sweep forgets buckets untouched for longer than idle.
+	Sweep(ctx context.Context, idle time.Duration) error
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token, set when not allowed.
+	RetryAfter time.Duration
+}
+
+type Limiter struct {
+	store  Store
+	limits map[string]Limit
+}
+
+// NewLimiter returns a limiter for the given groups of routes.
+func NewLimiter(store Store, limits map[string]Limit) *Limiter {
+	return &Limiter{store: store, limits: limits}
+}
+
+// Allow takes a token for key from the bucket of group. Requests are let
+// through with a zero Limit when the group has no limit or the store fails:
+// an unavailable limiter must not take the API down with it.
+func (l *Limiter) Allow(ctx context.Context, group, key string) Result {
+	limit, ok := l.limits[group]
+	if !ok {
+		return Result{Allowed: true}
+	}
+
+	allowed, tokens, err := l.store.Take(ctx, group+":"+key, limit)
+	if err != nil {
+		logrus.WithContext(ctx).Warnf("rate limiter unavailable, letting request through: %s", err.Error())
+		return Result{Allowed: true}
+	}
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+
+	return result
+}
+
+// Run sweeps idle buckets until ctx is done. Only buckets that would have
+// refilled completely are removed, so sweeping never loosens a limit.
+func (l *Limiter) Run(ctx context.Context) error {
+	var idle time.Duration
+	for _, limit := range l.limits {
+		idle = max(idle, limit.Refill())
+	}
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := l.store.Sweep(ctx, idle); err != nil && ctx.Err() == nil {
+				logrus.Warnf("error sweeping rate limit buckets: %s", err.Error())
+			}
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Max(0, s) * float64(time.Second))
+}
+
+// refill returns the tokens in a bucket last updated at updated.
+func refill(tokens float64, updated, now time.Time, limit Limit) float64 {
+	elapsed := math.Max(0, now.Sub(updated).Seconds())
+	return math.Min(float64(limit.Burst), tokens+elapsed*limit.Rate)
+}
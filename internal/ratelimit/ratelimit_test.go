@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit Limit) (bool, float64, error) {
+	return false, 0, errors.New("connection refused")
+}
+
+func (failingStore) Sweep(ctx context.Context, idle time.Duration) error {
+	return nil
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	// 1 request per second, bursts of 2.
+	limit := Limit{Rate: 1, Burst: 2, Window: time.Second}
+	limiter := NewLimiter(store, map[string]Limit{"auth": limit})
+
+	result := limiter.Allow(context.Background(), "auth", "ip:1")
+	assert.Equal(t, Result{Allowed: true, Limit: limit, Remaining: 1, Reset: time.Second}, result)
+
+	result = limiter.Allow(context.Background(), "auth", "ip:1")
+	assert.Equal(t, Result{Allowed: true, Limit: limit, Remaining: 0, Reset: 2 * time.Second}, result)
+
+	result = limiter.Allow(context.Background(), "auth", "ip:1")
+	assert.Equal(t, Result{Allowed: false, Limit: limit, Remaining: 0, Reset: 2 * time.Second, RetryAfter: time.Second}, result)
+
+	// Other keys have their own bucket.
+	assert.True(t, limiter.Allow(context.Background(), "auth", "ip:2").Allowed)
+
+	now = now.Add(500 * time.Millisecond)
+	result = limiter.Allow(context.Background(), "auth", "ip:1")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, limiter.Allow(context.Background(), "auth", "ip:1").Allowed)
+
+	// Groups without a limit are not limited.
+	assert.Equal(t, Result{Allowed: true}, limiter.Allow(context.Background(), "default", "ip:1"))
+}
+
+func TestLimiter_Allow_StoreFailure(t *testing.T) {
+	limiter := NewLimiter(failingStore{}, map[string]Limit{"auth": {Rate: 1, Burst: 1, Window: time.Second}})
+
+	assert.Equal(t, Result{Allowed: true}, limiter.Allow(context.Background(), "auth", "ip:1"))
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 1, Window: time.Second}
+
+	store.Take(context.Background(), "old", limit)
+	now = now.Add(time.Minute)
+	store.Take(context.Background(), "new", limit)
+
+	assert.NoError(t, store.Sweep(context.Background(), 30*time.Second))
+	assert.NotContains(t, store.buckets, "old")
+	assert.Contains(t, store.buckets, "new")
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+)
+
+type AuthMemory struct {
+	db *MemoryDB
+}
+
+func NewAuthMemory(db *MemoryDB) *AuthMemory {
+	return &AuthMemory{db: db}
+}
+
+func (r *AuthMemory) CreateUser(ctx context.Context, user models.SignUpInput) (int, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer r.db.unlock()
+
+	if _, ok := r.findUser(user.Username); ok {
+		return 0, errDuplicateUsername
+	}
+
+	r.db.lastUserId++
+	r.db.users[r.db.lastUserId] = models.User{
+		Id:       r.db.lastUserId,
+		Name:     user.Name,
+		Username: user.Username,
+		Password: user.Password,
+	}
+
+	return r.db.lastUserId, nil
+}
+
+func (r *AuthMemory) GetUser(ctx context.Context, username, password string) (models.User, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return models.User{}, err
+	}
+	defer r.db.unlock()
+
+	user, ok := r.findUser(username)
+	if !ok || user.Password != password {
+		return models.User{}, sql.ErrNoRows
+	}
+
+	return models.User{Id: user.Id}, nil
+}
+
+func (r *AuthMemory) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return models.User{}, err
+	}
+	defer r.db.unlock()
+
+	user, ok := r.findUser(username)
+	if !ok {
+		return models.User{}, sql.ErrNoRows
+	}
+
+	return models.User{Id: user.Id, Name: user.Name, Username: user.Username}, nil
+}
+
+func (r *AuthMemory) UpdatePassword(ctx context.Context, username, password string) error {
+	if err := r.db.lock(ctx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	user, ok := r.findUser(username)
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	user.Password = password
+	r.db.users[user.Id] = user
+
+	return nil
+}
+
+// findUser looks a user up by username. db must be locked.
+func (r *AuthMemory) findUser(username string) (models.User, bool) {
+	for _, user := range r.db.users {
+		if user.Username == username {
+			return user, true
+		}
+	}
+
+	return models.User{}, false
+}
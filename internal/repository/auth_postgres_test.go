@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"testing"
 
 	models "github.com/Yoshisoul/rest-wallets/internal/models"
@@ -58,7 +61,7 @@ func TestAuthPostgres_CreateUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			got, err := r.CreateUser(tt.input)
+			got, err := r.CreateUser(context.Background(), tt.input)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -124,7 +127,7 @@ func TestAuthPostgres_GetUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			got, err := r.GetUser(tt.input.Username, tt.input.Password)
+			got, err := r.GetUser(context.Background(), tt.input.Username, tt.input.Password)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -135,3 +138,70 @@ func TestAuthPostgres_GetUser(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthPostgres_UpdatePassword(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := NewAuthPostgres(db)
+
+	tests := []struct {
+		name        string
+		mock        func()
+		username    string
+		password    string
+		expectedErr error
+		wantErr     bool
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				mock.ExpectExec(`UPDATE users SET password_hash=\$1 WHERE username=\$2`).
+					WithArgs("hash", "test").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			username: "test",
+			password: "hash",
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mock.ExpectExec(`UPDATE users SET password_hash=\$1 WHERE username=\$2`).
+					WithArgs("hash", "missing").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			username:    "missing",
+			password:    "hash",
+			expectedErr: sql.ErrNoRows,
+			wantErr:     true,
+		},
+		{
+			name: "Exec error",
+			mock: func() {
+				mock.ExpectExec(`UPDATE users SET password_hash=\$1 WHERE username=\$2`).
+					WithArgs("hash", "test").WillReturnError(errors.New("some error"))
+			},
+			username: "test",
+			password: "hash",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.UpdatePassword(context.Background(), tt.username, tt.password)
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.expectedErr != nil {
+					assert.ErrorIs(t, err, tt.expectedErr)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
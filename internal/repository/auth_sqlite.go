@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type AuthSQLite struct {
+	db *sqlx.DB
+}
+
+func NewAuthSQLite(db *sqlx.DB) *AuthSQLite {
+	return &AuthSQLite{db: db}
+}
+
+func (r *AuthSQLite) CreateUser(ctx context.Context, user models.SignUpInput) (int, error) {
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (name, username, password_hash) values ($1, $2, $3) RETURNING id", userTable)
+
+	row := r.db.QueryRowContext(ctx, query, user.Name, user.Username, user.Password)
+	if err := row.Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *AuthSQLite) GetUser(ctx context.Context, username, password string) (models.User, error) {
+	var user models.User
+	query := fmt.Sprintf("SELECT id FROM %s WHERE username=$1 AND password_hash=$2", userTable)
+	err := r.db.GetContext(ctx, &user, query, username, password)
+
+	return user, err
+}
+
+func (r *AuthSQLite) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+	var user models.User
+	query := fmt.Sprintf("SELECT id, name, username FROM %s WHERE username=$1", userTable)
+	err := r.db.GetContext(ctx, &user, query, username)
+
+	return user, err
+}
+
+func (r *AuthSQLite) UpdatePassword(ctx context.Context, username, password string) error {
+	query := fmt.Sprintf("UPDATE %s SET password_hash=$1 WHERE username=$2", userTable)
+	result, err := r.db.ExecContext(ctx, query, password, username)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
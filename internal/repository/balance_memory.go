@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+)
+
+// BalanceMemory sums the transactions in memory on every call. It keeps no
+// snapshots: they would only trade memory for speed.
+type BalanceMemory struct {
+	db *MemoryDB
+}
+
+func NewBalanceMemory(db *MemoryDB) *BalanceMemory {
+	return &BalanceMemory{db: db}
+}
+
+func (r *BalanceMemory) At(ctx context.Context, walletId uuid.UUID, at []time.Time) ([]int64, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	balances := make([]int64, len(at))
+	for _, transaction := range r.db.transactions {
+		if transaction.WalletId != walletId {
+			continue
+		}
+
+		change := transaction.Amount
+		if transaction.OperationType == models.Withdraw {
+			change = -change
+		}
+		for i, t := range at {
+			if transaction.CreatedAt.Before(t) {
+				balances[i] += change
+			}
+		}
+	}
+
+	return balances, nil
+}
+
+func (r *BalanceMemory) TakeSnapshots(ctx context.Context, at time.Time) (int64, error) {
+	return 0, ctx.Err()
+}
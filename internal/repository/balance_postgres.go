@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// signedAmount is the change a transaction made to the balance.
+const signedAmount = "CASE operation_type WHEN 'DEPOSIT' THEN amount ELSE -amount END"
+
+type BalancePostgres struct {
+	db *sqlx.DB
+	// cluster routes reads to replicas.
+	cluster *Cluster
+}
+
+func NewBalancePostgres(db *sqlx.DB) *BalancePostgres {
+	return &BalancePostgres{db: db, cluster: singleNode(db)}
+}
+
+func (r *BalancePostgres) At(ctx context.Context, walletId uuid.UUID, at []time.Time) ([]int64, error) {
+	moments := make([]string, len(at))
+	for i, t := range at {
+		moments[i] = t.Format(time.RFC3339Nano)
+	}
+
+	// A snapshot holds the transactions made before it was taken, so the
+	// ones made from then on are added.
+	query := fmt.Sprintf(`SELECT (COALESCE(s.amount, 0) + COALESCE((
+			SELECT SUM(%s) FROM %s t
+			WHERE t.wallet_id = $1 AND t.created_at >= COALESCE(s.taken_at, '-infinity') AND t.created_at < p.at
+		), 0))::bigint
+		FROM unnest($2::timestamptz[]) WITH ORDINALITY AS p (at, n)
+		LEFT JOIN LATERAL (
+			SELECT amount, taken_at FROM %s
+			WHERE wallet_id = $1 AND taken_at <= p.at
+			ORDER BY taken_at DESC LIMIT 1
+		) s ON true
+		ORDER BY p.n`, signedAmount, transactionTable, snapshotTable)
+
+	var balances []int64
+	err := r.cluster.read(ctx, func(db *sqlx.DB) error {
+		balances = nil
+		return db.SelectContext(ctx, &balances, query, walletId, pq.Array(moments))
+	})
+
+	return balances, err
+}
+
+func (r *BalancePostgres) TakeSnapshots(ctx context.Context, at time.Time) (int64, error) {
+	query := fmt.Sprintf(`INSERT INTO %s (wallet_id, taken_at, amount)
+		SELECT w.wallet_id, $1::timestamptz, COALESCE(s.amount, 0) + COALESCE((
+			SELECT SUM(%s) FROM %s t
+			WHERE t.wallet_id = w.wallet_id AND t.created_at >= COALESCE(s.taken_at, '-infinity') AND t.created_at < $1
+		), 0)
+		FROM %s w
+		LEFT JOIN LATERAL (
+			SELECT amount, taken_at FROM %s
+			WHERE wallet_id = w.wallet_id AND taken_at < $1
+			ORDER BY taken_at DESC LIMIT 1
+		) s ON true
+		WHERE w.created_at < $1 AND (w.closed_at IS NULL OR w.closed_at >= $1)
+		ON CONFLICT (wallet_id, taken_at) DO NOTHING`, snapshotTable, signedAmount, transactionTable, walletTable, snapshotTable)
+
+	result, err := r.db.ExecContext(ctx, query, at)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
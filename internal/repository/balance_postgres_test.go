@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func TestBalance_At(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	r := NewBalancePostgres(db)
+	walletId := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	at := []time.Time{
+		time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	mock.ExpectQuery("FROM unnest\\(\\$2::timestamptz\\[\\]\\) WITH ORDINALITY").
+		WithArgs(walletId, pq.Array([]string{"2021-01-01T00:00:00Z", "2021-01-02T00:00:00Z"})).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(100).AddRow(40))
+
+	got, err := r.At(context.Background(), walletId, at)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{100, 40}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBalance_TakeSnapshots(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	r := NewBalancePostgres(db)
+	at := time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("INSERT INTO balance_snapshots (.+) ON CONFLICT \\(wallet_id, taken_at\\) DO NOTHING").
+		WithArgs(at).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	got, err := r.TakeSnapshots(context.Background(), at)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type BalanceSQLite struct {
+	db *sqlx.DB
+}
+
+func NewBalanceSQLite(db *sqlx.DB) *BalanceSQLite {
+	return &BalanceSQLite{db: db}
+}
+
+// At is At of the Postgres repository. SQLite has no LATERAL joins, so each
+// moment is a query of its own; they are local calls.
+func (r *BalanceSQLite) At(ctx context.Context, walletId uuid.UUID, at []time.Time) ([]int64, error) {
+	// The empty string sorts before every timestamp.
+	query := fmt.Sprintf(`WITH s AS (
+			SELECT amount, taken_at FROM %s
+			WHERE wallet_id = $1 AND taken_at <= $2
+			ORDER BY taken_at DESC LIMIT 1
+		)
+		SELECT COALESCE((SELECT amount FROM s), 0) + COALESCE((
+			SELECT SUM(%s) FROM %s
+			WHERE wallet_id = $1 AND created_at >= COALESCE((SELECT taken_at FROM s), '') AND created_at < $2
+		), 0)`, snapshotTable, signedAmount, transactionTable)
+
+	balances := make([]int64, len(at))
+	for i, t := range at {
+		if err := r.db.GetContext(ctx, &balances[i], query, walletId, t.UTC()); err != nil {
+			return nil, err
+		}
+	}
+
+	return balances, nil
+}
+
+func (r *BalanceSQLite) TakeSnapshots(ctx context.Context, at time.Time) (int64, error) {
+	query := fmt.Sprintf(`INSERT OR IGNORE INTO %[1]s (wallet_id, taken_at, amount)
+		SELECT w.wallet_id, $1, COALESCE((
+			SELECT s.amount FROM %[1]s s
+			WHERE s.wallet_id = w.wallet_id AND s.taken_at < $1
+			ORDER BY s.taken_at DESC LIMIT 1
+		), 0) + COALESCE((
+			SELECT SUM(%[2]s) FROM %[3]s t
+			WHERE t.wallet_id = w.wallet_id AND t.created_at < $1 AND t.created_at >= COALESCE((
+				SELECT MAX(s.taken_at) FROM %[1]s s WHERE s.wallet_id = w.wallet_id AND s.taken_at < $1
+			), '')
+		), 0)
+		FROM %[4]s w
+		WHERE w.created_at < $1 AND (w.closed_at IS NULL OR w.closed_at >= $1)`, snapshotTable, signedAmount, transactionTable, walletTable)
+
+	result, err := r.db.ExecContext(ctx, query, at.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Breaker is a circuit breaker for new database connections.
+//
+// After threshold connections in a row fail, the breaker opens: connecting
+// fails at once with models.ErrUnavailable instead of waiting on a database
+// that is down. Once cooldown has passed a single connection is let through as a
+// probe. It closes the breaker when it succeeds and keeps it open for another
+// cooldown when it fails. Queries on connections already in the pool are not
+// affected; those fail on their own and are discarded.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a connection may be made, and marks it as the probe
+// when the breaker is open.
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+
+	wait := b.cooldown - time.Since(b.openedAt)
+	if b.probing || wait > 0 {
+		return &models.UnavailableError{RetryAfter: max(wait, 0)}
+	}
+
+	b.probing = true
+	return nil
+}
+
+// done records the outcome of a connection let through by allow.
+func (b *Breaker) done(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if err == nil {
+		if b.failures >= b.threshold {
+			logrus.Info("database is back, closing the circuit breaker")
+			metrics.ObserveBreaker(false)
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures < b.threshold {
+		return
+	}
+	if b.failures == b.threshold {
+		logrus.WithError(err).Warnf("database is down, failing fast for %s", b.cooldown)
+		metrics.ObserveBreaker(true)
+	}
+	b.openedAt = time.Now()
+}
+
+// abandon releases a connection let through by allow that was canceled by
+// its caller, which says nothing about the database.
+func (b *Breaker) abandon() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// breakerConnector makes the connections of a pool through a Breaker.
+type breakerConnector struct {
+	driver.Connector
+	breaker *Breaker
+}
+
+func (c breakerConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil && ctx.Err() != nil {
+		c.breaker.abandon()
+		return nil, err
+	}
+
+	c.breaker.done(err)
+	return conn, err
+}
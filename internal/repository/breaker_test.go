@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubConnector fails to connect while err is set.
+type stubConnector struct {
+	err      error
+	attempts int
+}
+
+func (c *stubConnector) Connect(context.Context) (driver.Conn, error) {
+	c.attempts++
+	return nil, c.err
+}
+
+func (c *stubConnector) Driver() driver.Driver {
+	return nil
+}
+
+func TestBreaker(t *testing.T) {
+	stub := &stubConnector{err: errors.New("connection refused")}
+	breaker := NewBreaker(2, 50*time.Millisecond)
+	connector := breakerConnector{Connector: stub, breaker: breaker}
+	ctx := context.Background()
+
+	// Closed: failures reach the database until the threshold.
+	for range 2 {
+		_, err := connector.Connect(ctx)
+		assert.EqualError(t, err, "connection refused")
+	}
+
+	// Open: fails fast without connecting.
+	_, err := connector.Connect(ctx)
+	var unavailable *models.UnavailableError
+	require.ErrorAs(t, err, &unavailable)
+	assert.ErrorIs(t, err, models.ErrUnavailable)
+	assert.Positive(t, unavailable.RetryAfter)
+	assert.Equal(t, 2, stub.attempts)
+
+	// A failed probe keeps it open for another cooldown.
+	time.Sleep(50 * time.Millisecond)
+	_, err = connector.Connect(ctx)
+	assert.EqualError(t, err, "connection refused")
+	_, err = connector.Connect(ctx)
+	assert.ErrorIs(t, err, models.ErrUnavailable)
+	assert.Equal(t, 3, stub.attempts)
+
+	// A successful probe closes it.
+	time.Sleep(50 * time.Millisecond)
+	stub.err = nil
+	_, err = connector.Connect(ctx)
+	assert.NoError(t, err)
+	_, err = connector.Connect(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stub.attempts)
+}
+
+func TestBreaker_CanceledConnect(t *testing.T) {
+	stub := &stubConnector{err: context.Canceled}
+	breaker := NewBreaker(1, time.Minute)
+	connector := breakerConnector{Connector: stub, breaker: breaker}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Canceled by the caller, so not counted against the database.
+	_, err := connector.Connect(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	stub.err = nil
+	_, err = connector.Connect(context.Background())
+	assert.NoError(t, err)
+}
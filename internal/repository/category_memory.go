@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+)
+
+type CategoryMemory struct {
+	db *MemoryDB
+}
+
+func NewCategoryMemory(db *MemoryDB) *CategoryMemory {
+	return &CategoryMemory{db: db}
+}
+
+func (r *CategoryMemory) Create(ctx context.Context, userId int, input models.CategoryInput) (uuid.UUID, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return uuid.Nil, err
+	}
+	defer r.db.unlock()
+
+	for _, category := range r.db.categories {
+		if category.UserId == userId && category.Name == input.Name {
+			return uuid.Nil, models.ErrCategoryExists
+		}
+	}
+
+	if input.ParentId != nil {
+		if _, ok := r.db.categories[*input.ParentId]; !ok {
+			return uuid.Nil, errUnknownParent
+		}
+	}
+
+	if _, ok := r.db.users[userId]; !ok {
+		return uuid.Nil, errUnknownCategoryUser
+	}
+
+	category := models.Category{
+		CategoryId: uuid.New(),
+		UserId:     userId,
+		ParentId:   input.ParentId,
+		Name:       input.Name,
+		CreatedAt:  now(),
+	}
+	r.db.categories[category.CategoryId] = category
+
+	return category.CategoryId, nil
+}
+
+func (r *CategoryMemory) GetAll(ctx context.Context, userId int, page models.Page) ([]models.Category, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var categories []models.Category
+	for _, category := range r.db.categories {
+		if category.UserId == userId {
+			categories = append(categories, category)
+		}
+	}
+	slices.SortFunc(categories, func(a, b models.Category) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return paginate(categories, page), nil
+}
+
+func (r *CategoryMemory) GetById(ctx context.Context, userId int, categoryId uuid.UUID) (models.Category, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return models.Category{}, err
+	}
+	defer r.db.unlock()
+
+	category, ok := r.db.categories[categoryId]
+	if !ok || category.UserId != userId {
+		return models.Category{}, sql.ErrNoRows
+	}
+
+	return category, nil
+}
+
+func (r *CategoryMemory) Delete(ctx context.Context, userId int, categoryId uuid.UUID) error {
+	if err := r.db.lock(ctx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if category, ok := r.db.categories[categoryId]; !ok || category.UserId != userId {
+		return sql.ErrNoRows
+	}
+
+	if r.db.categoryInUse(categoryId) {
+		return models.ErrCategoryInUse
+	}
+	delete(r.db.categories, categoryId)
+
+	return nil
+}
+
+// categoryInUse reports whether the category has subcategories or is
+// referenced by a transaction or an annotation. db must be locked.
+func (db *MemoryDB) categoryInUse(categoryId uuid.UUID) bool {
+	for _, category := range db.categories {
+		if category.ParentId != nil && *category.ParentId == categoryId {
+			return true
+		}
+	}
+
+	for _, transaction := range db.transactions {
+		if transaction.CategoryId != nil && *transaction.CategoryId == categoryId {
+			return true
+		}
+	}
+
+	for _, versions := range db.annotations {
+		for _, annotation := range versions {
+			if annotation.CategoryId != nil && *annotation.CategoryId == categoryId {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type CategoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewCategoryPostgres(db *sqlx.DB) *CategoryPostgres {
+	return &CategoryPostgres{db: db}
+}
+
+func (r *CategoryPostgres) Create(ctx context.Context, userId int, input models.CategoryInput) (uuid.UUID, error) {
+	return createCategory(ctx, r.db, userId, input, time.Now())
+}
+
+// createCategory is Create of the Postgres and SQLite repositories, which
+// share the upsert syntax.
+func createCategory(ctx context.Context, db *sqlx.DB, userId int, input models.CategoryInput, now time.Time) (uuid.UUID, error) {
+	var id uuid.UUID
+	query := fmt.Sprintf(`INSERT INTO %s (category_id, user_id, parent_id, name, created_at) values ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, name) DO NOTHING RETURNING category_id`, categoryTable)
+
+	err := db.QueryRowContext(ctx, query, uuid.New(), userId, input.ParentId, input.Name, now).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, models.ErrCategoryExists
+	}
+
+	return id, err
+}
+
+func (r *CategoryPostgres) GetAll(ctx context.Context, userId int, page models.Page) ([]models.Category, error) {
+	var categories []models.Category
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1 ORDER BY name LIMIT $2 OFFSET $3", categoryTable)
+	err := r.db.SelectContext(ctx, &categories, query, userId, limitArg(page), page.Offset)
+
+	return categories, err
+}
+
+func (r *CategoryPostgres) GetById(ctx context.Context, userId int, categoryId uuid.UUID) (models.Category, error) {
+	var category models.Category
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1 AND category_id = $2", categoryTable)
+	err := r.db.GetContext(ctx, &category, query, userId, categoryId)
+
+	return category, err
+}
+
+func (r *CategoryPostgres) Delete(ctx context.Context, userId int, categoryId uuid.UUID) error {
+	// The row lock waits for the transactions referencing the category to
+	// end, and keeps new ones from referencing it.
+	return deleteCategory(ctx, r.db, userId, categoryId, " FOR UPDATE")
+}
+
+// deleteCategory is Delete of the Postgres and SQLite repositories. lock
+// ends the query reading the category, to lock it until the delete.
+func deleteCategory(ctx context.Context, db *sqlx.DB, userId int, categoryId uuid.UUID, lock string) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	query := fmt.Sprintf("SELECT category_id FROM %s WHERE user_id = $1 AND category_id = $2%s", categoryTable, lock)
+	if err := tx.GetContext(ctx, &id, query, userId, categoryId); err != nil {
+		return err
+	}
+
+	var inUse bool
+	query = fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %[1]s WHERE parent_id = $1)
+		OR EXISTS (SELECT 1 FROM %[2]s WHERE category_id = $1)
+		OR EXISTS (SELECT 1 FROM %[3]s WHERE category_id = $1)`, categoryTable, transactionTable, annotationTable)
+	if err := tx.GetContext(ctx, &inUse, query, categoryId); err != nil {
+		return err
+	}
+	if inUse {
+		return models.ErrCategoryInUse
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s WHERE category_id = $1", categoryTable)
+	if _, err := tx.ExecContext(ctx, query, categoryId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type CategorySQLite struct {
+	db *sqlx.DB
+}
+
+func NewCategorySQLite(db *sqlx.DB) *CategorySQLite {
+	return &CategorySQLite{db: db}
+}
+
+func (r *CategorySQLite) Create(ctx context.Context, userId int, input models.CategoryInput) (uuid.UUID, error) {
+	return createCategory(ctx, r.db, userId, input, sqliteNow())
+}
+
+func (r *CategorySQLite) GetAll(ctx context.Context, userId int, page models.Page) ([]models.Category, error) {
+	var categories []models.Category
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1 ORDER BY name LIMIT $2 OFFSET $3", categoryTable)
+	err := r.db.SelectContext(ctx, &categories, query, userId, sqliteLimitArg(page), page.Offset)
+
+	return categories, err
+}
+
+func (r *CategorySQLite) GetById(ctx context.Context, userId int, categoryId uuid.UUID) (models.Category, error) {
+	var category models.Category
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1 AND category_id = $2", categoryTable)
+	err := r.db.GetContext(ctx, &category, query, userId, categoryId)
+
+	return category, err
+}
+
+func (r *CategorySQLite) Delete(ctx context.Context, userId int, categoryId uuid.UUID) error {
+	// The transaction holds the write lock, which covers the category.
+	return deleteCategory(ctx, r.db, userId, categoryId, "")
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+)
+
+type ChainMemory struct {
+	db *MemoryDB
+}
+
+func NewChainMemory(db *MemoryDB) *ChainMemory {
+	return &ChainMemory{db: db}
+}
+
+func (r *ChainMemory) Links(ctx context.Context, walletId uuid.UUID) ([]models.Transaction, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	// Transactions are appended in chain order.
+	var transactions []models.Transaction
+	for _, transaction := range r.db.transactions {
+		if transaction.WalletId == walletId {
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	return transactions, nil
+}
+
+// Seal has nothing to do: every transaction is chained when it is recorded.
+func (r *ChainMemory) Seal(ctx context.Context) (int64, error) {
+	return 0, ctx.Err()
+}
+
+func (r *ChainMemory) Heads(ctx context.Context, at time.Time) ([]models.ChainHead, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	heads := make(map[uuid.UUID]models.ChainHead)
+	for _, transaction := range r.db.transactions {
+		if transaction.CreatedAt.Before(at) {
+			heads[transaction.WalletId] = models.ChainHead{WalletId: transaction.WalletId, Seq: transaction.Seq, Hash: transaction.Hash}
+		}
+	}
+
+	sorted := make([]models.ChainHead, 0, len(heads))
+	for _, head := range heads {
+		sorted = append(sorted, head)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].WalletId[:], sorted[j].WalletId[:]) < 0
+	})
+
+	return sorted, nil
+}
+
+func (r *ChainMemory) CreateCheckpoint(ctx context.Context, checkpoint models.Checkpoint) (models.Checkpoint, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return models.Checkpoint{}, err
+	}
+	defer r.db.unlock()
+
+	for _, stored := range r.db.checkpoints {
+		if stored.At.Equal(checkpoint.At) {
+			return stored, nil
+		}
+	}
+
+	r.db.checkpoints = append(r.db.checkpoints, checkpoint)
+	return checkpoint, nil
+}
+
+func (r *ChainMemory) GetCheckpoints(ctx context.Context, page models.Page) ([]models.Checkpoint, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	checkpoints := make([]models.Checkpoint, len(r.db.checkpoints))
+	copy(checkpoints, r.db.checkpoints)
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].At.After(checkpoints[j].At)
+	})
+
+	return paginate(checkpoints, page), nil
+}
+
+func (r *ChainMemory) GetCheckpoint(ctx context.Context, checkpointId uuid.UUID) (models.Checkpoint, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return models.Checkpoint{}, err
+	}
+	defer r.db.unlock()
+
+	for _, checkpoint := range r.db.checkpoints {
+		if checkpoint.CheckpointId == checkpointId {
+			return checkpoint, nil
+		}
+	}
+
+	return models.Checkpoint{}, sql.ErrNoRows
+}
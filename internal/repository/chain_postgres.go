@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// headsQuery selects the last transaction of each wallet created before $1,
+// ordered by wallet. It is valid for both Postgres and SQLite.
+var headsQuery = fmt.Sprintf(`SELECT t.wallet_id, t.seq, t.hash
+	FROM %[1]s t
+	JOIN (
+		SELECT wallet_id, MAX(seq) AS seq FROM %[1]s WHERE created_at < $1 GROUP BY wallet_id
+	) h ON h.wallet_id = t.wallet_id AND h.seq = t.seq
+	ORDER BY t.wallet_id`, transactionTable)
+
+type ChainPostgres struct {
+	db *sqlx.DB
+}
+
+func NewChainPostgres(db *sqlx.DB) *ChainPostgres {
+	return &ChainPostgres{db: db}
+}
+
+func (r *ChainPostgres) Links(ctx context.Context, walletId uuid.UUID) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	query := fmt.Sprintf("SELECT * FROM %s WHERE wallet_id = $1 ORDER BY seq", transactionTable)
+	err := r.db.SelectContext(ctx, &transactions, query, walletId)
+
+	return transactions, err
+}
+
+func (r *ChainPostgres) Seal(ctx context.Context) (int64, error) {
+	return seal(ctx, r.db, func(ctx context.Context, tx *sqlx.Tx, walletId uuid.UUID) error {
+		_, err := lockWallet(ctx, tx, walletId)
+		return err
+	})
+}
+
+func (r *ChainPostgres) Heads(ctx context.Context, at time.Time) ([]models.ChainHead, error) {
+	var heads []models.ChainHead
+	err := r.db.SelectContext(ctx, &heads, headsQuery, at)
+
+	return heads, err
+}
+
+func (r *ChainPostgres) CreateCheckpoint(ctx context.Context, checkpoint models.Checkpoint) (models.Checkpoint, error) {
+	return createCheckpoint(ctx, r.db, checkpoint)
+}
+
+// createCheckpoint is CreateCheckpoint of the Postgres and SQLite
+// repositories, which share the upsert syntax.
+func createCheckpoint(ctx context.Context, db *sqlx.DB, checkpoint models.Checkpoint) (models.Checkpoint, error) {
+	query := fmt.Sprintf(`INSERT INTO %s (checkpoint_id, at, wallets, transactions, root, public_key, signature, created_at)
+		VALUES (:checkpoint_id, :at, :wallets, :transactions, :root, :public_key, :signature, :created_at)
+		ON CONFLICT (at) DO NOTHING`, checkpointTable)
+	if _, err := db.NamedExecContext(ctx, query, checkpoint); err != nil {
+		return models.Checkpoint{}, err
+	}
+
+	var stored models.Checkpoint
+	query = fmt.Sprintf("SELECT * FROM %s WHERE at = $1", checkpointTable)
+	err := db.GetContext(ctx, &stored, query, checkpoint.At)
+
+	return stored, err
+}
+
+func (r *ChainPostgres) GetCheckpoints(ctx context.Context, page models.Page) ([]models.Checkpoint, error) {
+	var checkpoints []models.Checkpoint
+	query := fmt.Sprintf("SELECT * FROM %s ORDER BY at DESC LIMIT $1 OFFSET $2", checkpointTable)
+	err := r.db.SelectContext(ctx, &checkpoints, query, limitArg(page), page.Offset)
+
+	return checkpoints, err
+}
+
+func (r *ChainPostgres) GetCheckpoint(ctx context.Context, checkpointId uuid.UUID) (models.Checkpoint, error) {
+	var checkpoint models.Checkpoint
+	query := fmt.Sprintf("SELECT * FROM %s WHERE checkpoint_id = $1", checkpointTable)
+	err := r.db.GetContext(ctx, &checkpoint, query, checkpointId)
+
+	return checkpoint, err
+}
+
+// chainHead returns the last transaction of the wallet chain, sealing the
+// chain first if it ends with transactions from before it. A wallet without
+// transactions has an empty head at seq 0. tx must hold the wallet lock.
+func chainHead(ctx context.Context, tx *sqlx.Tx, walletId uuid.UUID) (models.ChainHead, error) {
+	head := models.ChainHead{WalletId: walletId}
+	query := fmt.Sprintf("SELECT wallet_id, seq, hash FROM %s WHERE wallet_id = $1 ORDER BY seq DESC LIMIT 1", transactionTable)
+	err := tx.GetContext(ctx, &head, query, walletId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return head, nil
+	}
+	if err != nil || head.Hash != "" {
+		return head, err
+	}
+
+	sealed, err := sealWallet(ctx, tx, walletId)
+	if err != nil || sealed == 0 {
+		return head, err
+	}
+	err = tx.GetContext(ctx, &head, query, walletId)
+
+	return head, err
+}
+
+// newLink returns the transaction chained to head, the head of its wallet.
+func newLink(head models.ChainHead, transaction models.TransactionInput, createdAt time.Time) models.Transaction {
+	link := models.Transaction{
+		TransactionId: uuid.New(),
+		WalletId:      transaction.WalletId,
+		OperationType: transaction.OperationType,
+		Amount:        transaction.Amount,
+		CreatedAt:     createdAt,
+		Seq:           head.Seq + 1,
+		PrevHash:      head.Hash,
+		Description:   transaction.Description,
+		CategoryId:    transaction.CategoryId,
+		ExternalRef:   transaction.ExternalRef,
+		Metadata:      transaction.Metadata,
+	}
+	link.Hash = link.ComputeHash()
+
+	return link
+}
+
+// insertLink records the transaction, which must already be chained to the
+// wallet head, and returns its id.
+func insertLink(ctx context.Context, tx *sqlx.Tx, link models.Transaction) (uuid.UUID, error) {
+	var id uuid.UUID
+	query := fmt.Sprintf(`INSERT INTO %s (transaction_id, wallet_id, operation_type, amount, created_at, seq, prev_hash, hash,
+		description, category_id, external_ref, metadata)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING transaction_id`, transactionTable)
+
+	row := tx.QueryRowContext(ctx, query, link.TransactionId, link.WalletId, link.OperationType, link.Amount, link.CreatedAt,
+		link.Seq, link.PrevHash, link.Hash, link.Description, link.CategoryId, link.ExternalRef, link.Metadata)
+	err := row.Scan(&id)
+
+	return id, err
+}
+
+// sealWallet computes the hashes of the wallet transactions from before the
+// chain, once, and returns how many it sealed. Other empty hashes were
+// cleared after the fact: they are left for Verify to report. tx must hold
+// the wallet lock.
+func sealWallet(ctx context.Context, tx *sqlx.Tx, walletId uuid.UUID) (int64, error) {
+	var last int64
+	query := fmt.Sprintf("SELECT seq FROM %s WHERE wallet_id = $1", legacyChainTable)
+	err := tx.GetContext(ctx, &last, query, walletId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var transactions []models.Transaction
+	query = fmt.Sprintf("SELECT * FROM %s WHERE wallet_id = $1 AND seq <= $2 ORDER BY seq", transactionTable)
+	if err := tx.SelectContext(ctx, &transactions, query, walletId, last); err != nil {
+		return 0, err
+	}
+
+	var sealed int64
+	prev := ""
+	update := fmt.Sprintf("UPDATE %s SET prev_hash = $1, hash = $2 WHERE transaction_id = $3", transactionTable)
+	for _, transaction := range transactions {
+		if transaction.Hash == "" {
+			transaction.PrevHash = prev
+			transaction.Hash = transaction.ComputeHash()
+			if _, err := tx.ExecContext(ctx, update, transaction.PrevHash, transaction.Hash, transaction.TransactionId); err != nil {
+				return 0, err
+			}
+			sealed++
+		}
+		prev = transaction.Hash
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s WHERE wallet_id = $1", legacyChainTable)
+	if _, err := tx.ExecContext(ctx, query, walletId); err != nil {
+		return 0, err
+	}
+
+	return sealed, nil
+}
+
+// seal seals the chains of every wallet with transactions from before the
+// chain, each in its own transaction holding the wallet lock taken by lock.
+func seal(ctx context.Context, db *sqlx.DB, lock func(ctx context.Context, tx *sqlx.Tx, walletId uuid.UUID) error) (int64, error) {
+	var walletIds []uuid.UUID
+	query := fmt.Sprintf("SELECT wallet_id FROM %s", legacyChainTable)
+	if err := db.SelectContext(ctx, &walletIds, query); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, walletId := range walletIds {
+		tx, err := db.BeginTxx(ctx, nil)
+		if err != nil {
+			return total, err
+		}
+
+		if err := lock(ctx, tx, walletId); err != nil {
+			tx.Rollback()
+			return total, err
+		}
+
+		sealed, err := sealWallet(ctx, tx, walletId)
+		if err != nil {
+			tx.Rollback()
+			return total, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return total, err
+		}
+		total += sealed
+	}
+
+	return total, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+// headRows is the head of a wallet chain, or no rows for seq 0.
+func headRows(walletId uuid.UUID, seq int64, hash string) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"wallet_id", "seq", "hash"})
+	if seq > 0 {
+		rows.AddRow(walletId, seq, hash)
+	}
+	return rows
+}
+
+func transactionRows(transactions ...models.Transaction) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"transaction_id", "wallet_id", "operation_type", "amount", "created_at", "seq", "prev_hash", "hash"})
+	for _, t := range transactions {
+		rows.AddRow(t.TransactionId, t.WalletId, t.OperationType, t.Amount, t.CreatedAt, t.Seq, t.PrevHash, t.Hash)
+	}
+	return rows
+}
+
+func TestChain_Seal(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	r := NewChainPostgres(db)
+	walletId := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Both transactions are from before the chain.
+	first := models.Transaction{TransactionId: uuid.New(), WalletId: walletId, OperationType: models.Deposit, Amount: 100, CreatedAt: createdAt, Seq: 1}
+	legacy := models.Transaction{TransactionId: uuid.New(), WalletId: walletId, OperationType: models.Withdraw, Amount: 30, CreatedAt: createdAt, Seq: 2}
+	sealed := first
+	sealed.Hash = sealed.ComputeHash()
+	legacySealed := legacy
+	legacySealed.PrevHash = sealed.Hash
+	legacySealed.Hash = legacySealed.ComputeHash()
+
+	mock.ExpectQuery("SELECT wallet_id FROM legacy_chains").
+		WillReturnRows(sqlmock.NewRows([]string{"wallet_id"}).AddRow(walletId))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+		WithArgs(walletId).
+		WillReturnRows(sqlmock.NewRows([]string{"wallet_id", "user_id", "amount"}).AddRow(walletId, 1, 70))
+	mock.ExpectQuery("SELECT seq FROM legacy_chains WHERE wallet_id = \\$1").
+		WithArgs(walletId).
+		WillReturnRows(sqlmock.NewRows([]string{"seq"}).AddRow(2))
+	mock.ExpectQuery("SELECT \\* FROM transactions WHERE wallet_id = \\$1 AND seq <= \\$2 ORDER BY seq").
+		WithArgs(walletId, 2).
+		WillReturnRows(transactionRows(first, legacy))
+	mock.ExpectExec("UPDATE transactions SET prev_hash = \\$1, hash = \\$2 WHERE transaction_id = \\$3").
+		WithArgs("", sealed.Hash, first.TransactionId).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE transactions SET prev_hash = \\$1, hash = \\$2 WHERE transaction_id = \\$3").
+		WithArgs(sealed.Hash, legacySealed.Hash, legacy.TransactionId).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM legacy_chains WHERE wallet_id = \\$1").
+		WithArgs(walletId).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	count, err := r.Seal(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChain_Heads(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	r := NewChainPostgres(db)
+	walletId := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT t.wallet_id, t.seq, t.hash FROM transactions t JOIN \\((.+) WHERE created_at < \\$1 GROUP BY wallet_id \\) h (.+) ORDER BY t.wallet_id").
+		WithArgs(at).
+		WillReturnRows(headRows(walletId, 3, "ab12"))
+
+	heads, err := r.Heads(context.Background(), at)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ChainHead{{WalletId: walletId, Seq: 3, Hash: "ab12"}}, heads)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ChainSQLite struct {
+	db *sqlx.DB
+}
+
+func NewChainSQLite(db *sqlx.DB) *ChainSQLite {
+	return &ChainSQLite{db: db}
+}
+
+func (r *ChainSQLite) Links(ctx context.Context, walletId uuid.UUID) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	query := fmt.Sprintf("SELECT * FROM %s WHERE wallet_id = $1 ORDER BY seq", transactionTable)
+	err := r.db.SelectContext(ctx, &transactions, query, walletId)
+
+	return transactions, err
+}
+
+func (r *ChainSQLite) Seal(ctx context.Context) (int64, error) {
+	// Transactions hold the database write lock, which covers the wallet.
+	return seal(ctx, r.db, func(context.Context, *sqlx.Tx, uuid.UUID) error { return nil })
+}
+
+func (r *ChainSQLite) Heads(ctx context.Context, at time.Time) ([]models.ChainHead, error) {
+	var heads []models.ChainHead
+	err := r.db.SelectContext(ctx, &heads, headsQuery, at.UTC())
+
+	return heads, err
+}
+
+func (r *ChainSQLite) CreateCheckpoint(ctx context.Context, checkpoint models.Checkpoint) (models.Checkpoint, error) {
+	checkpoint.At = checkpoint.At.UTC()
+	checkpoint.CreatedAt = checkpoint.CreatedAt.UTC()
+	return createCheckpoint(ctx, r.db, checkpoint)
+}
+
+func (r *ChainSQLite) GetCheckpoints(ctx context.Context, page models.Page) ([]models.Checkpoint, error) {
+	var checkpoints []models.Checkpoint
+	query := fmt.Sprintf("SELECT * FROM %s ORDER BY at DESC LIMIT $1 OFFSET $2", checkpointTable)
+	err := r.db.SelectContext(ctx, &checkpoints, query, sqliteLimitArg(page), page.Offset)
+
+	return checkpoints, err
+}
+
+func (r *ChainSQLite) GetCheckpoint(ctx context.Context, checkpointId uuid.UUID) (models.Checkpoint, error) {
+	var checkpoint models.Checkpoint
+	query := fmt.Sprintf("SELECT * FROM %s WHERE checkpoint_id = $1", checkpointTable)
+	err := r.db.GetContext(ctx, &checkpoint, query, checkpointId)
+
+	return checkpoint, err
+}
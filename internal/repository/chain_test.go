@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChain_SealLegacy checks that the transactions recorded before the
+// chain was added are sealed into a valid chain, and only them.
+func TestChain_SealLegacy(t *testing.T) {
+	ctx := context.Background()
+	db, err := NewSQLiteDB(Config{Path: filepath.Join(t.TempDir(), "wallets.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := NewMigrator(db)
+	require.NoError(t, err)
+	t.Cleanup(func() { migrator.Close() })
+	// Back to version 8, from before the chain.
+	latest, err := LatestMigration()
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Down(int(latest)-8))
+
+	r := NewRepository(db)
+	userId, err := r.CreateUser(ctx, models.SignUpInput{Name: "Test", Username: "alice", Password: "hash"})
+	require.NoError(t, err)
+	sealed, lazy := uuid.New(), uuid.New()
+	createdAt := sqliteNow().Add(-time.Hour)
+	for _, walletId := range []uuid.UUID{sealed, lazy} {
+		_, err = db.Exec(fmt.Sprintf("INSERT INTO %s (wallet_id, user_id, amount, created_at, updated_at) VALUES ($1, $2, 30, $3, $3)", walletTable), walletId, userId, createdAt)
+		require.NoError(t, err)
+		for i, amount := range []int64{10, 20} {
+			query := fmt.Sprintf("INSERT INTO %s (transaction_id, wallet_id, operation_type, amount, created_at) VALUES ($1, $2, 'DEPOSIT', $3, $4)", transactionTable)
+			_, err = db.Exec(query, uuid.New(), walletId, amount, createdAt.Add(time.Duration(i)*time.Minute))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, migrator.Up())
+
+	// Recording a transaction seals the chain of its wallet first.
+	_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: lazy, OperationType: models.Withdraw, Amount: 5})
+	require.NoError(t, err)
+
+	links, err := r.Links(ctx, sealed)
+	require.NoError(t, err)
+	require.Len(t, links, 2)
+	for _, link := range links {
+		assert.Empty(t, link.Hash, "not sealed yet")
+	}
+
+	count, err := r.Seal(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	count, err = r.Seal(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	for walletId, length := range map[uuid.UUID]int{sealed: 2, lazy: 3} {
+		links, err := r.Links(ctx, walletId)
+		require.NoError(t, err)
+		require.Len(t, links, length)
+
+		prev := ""
+		for i, link := range links {
+			assert.Equal(t, int64(i+1), link.Seq)
+			assert.Equal(t, prev, link.PrevHash)
+			assert.Equal(t, link.ComputeHash(), link.Hash)
+			prev = link.Hash
+		}
+	}
+
+	// A hash cleared after sealing is a break, not a transaction to seal.
+	links, err = r.Links(ctx, lazy)
+	require.NoError(t, err)
+	cleared := links[len(links)-1]
+	_, err = db.Exec(fmt.Sprintf("UPDATE %s SET hash = '' WHERE transaction_id = $1", transactionTable), cleared.TransactionId)
+	require.NoError(t, err)
+
+	count, err = r.Seal(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: lazy, OperationType: models.Deposit, Amount: 5})
+	require.NoError(t, err)
+
+	links, err = r.Links(ctx, lazy)
+	require.NoError(t, err)
+	require.Len(t, links, 4)
+	assert.Empty(t, links[2].Hash, "not sealed again")
+	assert.Empty(t, links[3].PrevHash)
+}
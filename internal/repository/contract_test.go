@@ -0,0 +1,873 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// postgresDSNEnv names a database the Postgres contract tests may wipe.
+const postgresDSNEnv = "WALLETS_TEST_POSTGRES_DSN"
+
+func TestMemoryRepository_Contract(t *testing.T) {
+	testRepositoryContract(t, func(t *testing.T) *Repository {
+		return NewMemoryRepository()
+	})
+}
+
+func TestSQLiteRepository_Contract(t *testing.T) {
+	dir := t.TempDir()
+	databases := 0
+
+	testRepositoryContract(t, func(t *testing.T) *Repository {
+		databases++
+		db, err := NewSQLiteDB(Config{Path: filepath.Join(dir, fmt.Sprintf("wallets%d.db", databases))})
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		migrator, err := NewMigrator(db)
+		require.NoError(t, err)
+		require.NoError(t, migrator.Up())
+		require.NoError(t, migrator.Close())
+
+		return NewRepository(db)
+	})
+}
+
+func TestPostgresRepository_Contract(t *testing.T) {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresDSNEnv)
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := NewMigrator(db)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up())
+
+	testRepositoryContract(t, func(t *testing.T) *Repository {
+		query := fmt.Sprintf("TRUNCATE %s, %s, %s, %s, %s, %s, %s, %s, %s, %s RESTART IDENTITY CASCADE", annotationTable, checkpointTable, caseTable,
+			legacyChainTable, snapshotTable, adjustmentTable, transactionTable, categoryTable, walletTable, userTable)
+		_, err := db.Exec(query)
+		require.NoError(t, err)
+
+		return NewRepository(db)
+	})
+}
+
+// testRepositoryContract checks the behavior every Repository implementation
+// must share. newRepo returns an empty repository.
+func testRepositoryContract(t *testing.T, newRepo func(t *testing.T) *Repository) {
+	ctx := context.Background()
+
+	newUser := func(t *testing.T, r *Repository, username string) int {
+		id, err := r.CreateUser(ctx, models.SignUpInput{Name: "Test", Username: username, Password: "hash"})
+		require.NoError(t, err)
+		return id
+	}
+
+	newWallet := func(t *testing.T, r *Repository, userId int, amount int64) uuid.UUID {
+		id, err := r.Wallet.Create(ctx, userId)
+		require.NoError(t, err)
+		if amount > 0 {
+			_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: id, OperationType: models.Deposit, Amount: amount})
+			require.NoError(t, err)
+		}
+		return id
+	}
+
+	firstLinkAt := func(t *testing.T, r *Repository, walletId uuid.UUID) time.Time {
+		links, err := r.Links(ctx, walletId)
+		require.NoError(t, err)
+		require.NotEmpty(t, links)
+		return links[0].CreatedAt
+	}
+
+	balance := func(t *testing.T, r *Repository, walletId uuid.UUID) int64 {
+		wallet, err := r.Wallet.GetById(ctx, walletId)
+		require.NoError(t, err)
+		return wallet.Amount
+	}
+
+	t.Run("Users", func(t *testing.T) {
+		r := newRepo(t)
+		id := newUser(t, r, "alice")
+
+		_, err := r.CreateUser(ctx, models.SignUpInput{Name: "Other", Username: "alice", Password: "other"})
+		assert.Error(t, err, "duplicate username")
+
+		user, err := r.GetUser(ctx, "alice", "hash")
+		require.NoError(t, err)
+		assert.Equal(t, models.User{Id: id}, user)
+
+		_, err = r.GetUser(ctx, "alice", "wrong")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		user, err = r.GetUserByUsername(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, models.User{Id: id, Name: "Test", Username: "alice"}, user)
+
+		require.NoError(t, r.UpdatePassword(ctx, "alice", "new"))
+		_, err = r.GetUser(ctx, "alice", "new")
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, r.UpdatePassword(ctx, "bob", "new"), sql.ErrNoRows)
+		_, err = r.GetUserByUsername(ctx, "bob")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Wallets", func(t *testing.T) {
+		r := newRepo(t)
+		alice := newUser(t, r, "alice")
+		bob := newUser(t, r, "bob")
+
+		_, err := r.Wallet.Create(ctx, bob+1)
+		assert.Error(t, err, "unknown user")
+
+		var ids []uuid.UUID
+		for range 3 {
+			ids = append(ids, newWallet(t, r, alice, 0))
+		}
+		newWallet(t, r, bob, 0)
+
+		wallet, err := r.GetByIdFromUser(ctx, alice, ids[0])
+		require.NoError(t, err)
+		assert.Equal(t, alice, wallet.UserId)
+		assert.Equal(t, int64(0), wallet.Amount)
+		assert.Equal(t, models.WalletActive, wallet.Status)
+		assert.Nil(t, wallet.ClosedAt)
+
+		_, err = r.GetByIdFromUser(ctx, bob, ids[0])
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		_, err = r.Wallet.GetById(ctx, uuid.New())
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		all, err := r.GetAllFromUser(ctx, alice, models.WalletFilter{}, models.Page{})
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+
+		first, err := r.GetAllFromUser(ctx, alice, models.WalletFilter{}, models.Page{Limit: 2})
+		require.NoError(t, err)
+		rest, err := r.GetAllFromUser(ctx, alice, models.WalletFilter{}, models.Page{Limit: 2, Offset: 2})
+		require.NoError(t, err)
+		assert.Equal(t, all, append(first, rest...))
+
+		none, err := r.GetAllFromUser(ctx, alice, models.WalletFilter{}, models.Page{Offset: 3})
+		require.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("Wallet labels", func(t *testing.T) {
+		r := newRepo(t)
+		alice := newUser(t, r, "alice")
+		bob := newUser(t, r, "bob")
+		first := newWallet(t, r, alice, 0)
+		second := newWallet(t, r, alice, 0)
+		foreign := newWallet(t, r, bob, 0)
+
+		name, color, tags := "Savings", "#00ff00", []string{"home", "long-term"}
+		wallet, err := r.Wallet.Update(ctx, alice, first, models.WalletUpdate{Name: &name, Color: &color, Tags: &tags})
+		require.NoError(t, err)
+		assert.Equal(t, "Savings", wallet.Name)
+		assert.Equal(t, "#00ff00", wallet.Color)
+		assert.Equal(t, models.Tags{"home", "long-term"}, wallet.Tags)
+		assert.Equal(t, int64(2), wallet.Version)
+		stored, err := r.Wallet.GetById(ctx, first)
+		require.NoError(t, err)
+		assert.Equal(t, wallet, stored)
+
+		// Labels left out are kept, empty ones are cleared.
+		empty, noTags := "", []string{}
+		wallet, err = r.Wallet.Update(ctx, alice, first, models.WalletUpdate{Color: &empty, Tags: &noTags})
+		require.NoError(t, err)
+		assert.Equal(t, "Savings", wallet.Name)
+		assert.Empty(t, wallet.Color)
+		assert.Nil(t, wallet.Tags)
+
+		yes, no := true, false
+		wallet, err = r.Wallet.Update(ctx, alice, first, models.WalletUpdate{IsDefault: &yes})
+		require.NoError(t, err)
+		assert.True(t, wallet.IsDefault)
+
+		// Making another wallet the default unsets the previous one.
+		wallet, err = r.Wallet.Update(ctx, alice, second, models.WalletUpdate{IsDefault: &yes})
+		require.NoError(t, err)
+		assert.True(t, wallet.IsDefault)
+		previous, err := r.Wallet.GetById(ctx, first)
+		require.NoError(t, err)
+		assert.False(t, previous.IsDefault)
+		assert.Equal(t, int64(5), previous.Version)
+
+		// The default of each user is their own.
+		_, err = r.Wallet.Update(ctx, bob, foreign, models.WalletUpdate{IsDefault: &yes})
+		require.NoError(t, err)
+		wallet, err = r.Wallet.GetById(ctx, second)
+		require.NoError(t, err)
+		assert.True(t, wallet.IsDefault)
+
+		wallet, err = r.Wallet.Update(ctx, alice, second, models.WalletUpdate{IsDefault: &no})
+		require.NoError(t, err)
+		assert.False(t, wallet.IsDefault)
+
+		stale := int64(1)
+		_, err = r.Wallet.Update(ctx, alice, first, models.WalletUpdate{Name: &name, ExpectedVersion: &stale})
+		assert.ErrorIs(t, err, models.ErrVersionMismatch)
+		_, err = r.Wallet.Update(ctx, bob, first, models.WalletUpdate{Name: &name})
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		_, err = r.Wallet.Update(ctx, alice, uuid.New(), models.WalletUpdate{Name: &name})
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		// Closing the default wallet leaves the user without one.
+		_, err = r.Wallet.Update(ctx, alice, first, models.WalletUpdate{IsDefault: &yes})
+		require.NoError(t, err)
+		require.NoError(t, r.Close(ctx, alice, first, models.CloseWalletInput{}))
+		closed, err := r.Wallet.GetById(ctx, first)
+		require.NoError(t, err)
+		assert.False(t, closed.IsDefault)
+		assert.Equal(t, "Savings", closed.Name)
+
+		_, err = r.Wallet.Update(ctx, alice, first, models.WalletUpdate{IsDefault: &yes})
+		assert.ErrorIs(t, err, models.ErrWalletClosed)
+		_, err = r.Wallet.Update(ctx, alice, first, models.WalletUpdate{Name: &name})
+		assert.ErrorIs(t, err, models.ErrWalletClosed)
+	})
+
+	t.Run("Wallet filters", func(t *testing.T) {
+		r := newRepo(t)
+		alice := newUser(t, r, "alice")
+		bob := newUser(t, r, "bob")
+
+		labels := []struct {
+			name   string
+			tags   []string
+			amount int64
+		}{
+			{"travel_fund", []string{"trip", "shared"}, 300},
+			{"Rent", []string{"home"}, 100},
+			{"groceries", []string{"home", "shared"}, 200},
+			{"Travel 100%", nil, 0},
+		}
+		var ids []uuid.UUID
+		for _, label := range labels {
+			id := newWallet(t, r, alice, label.amount)
+			_, err := r.Wallet.Update(ctx, alice, id, models.WalletUpdate{Name: &label.name, Tags: &label.tags})
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		foreign := newWallet(t, r, bob, 500)
+		_, err := r.Wallet.Update(ctx, bob, foreign, models.WalletUpdate{Name: &labels[0].name, Tags: &labels[0].tags})
+		require.NoError(t, err)
+
+		second, err := r.Wallet.GetById(ctx, ids[1])
+		require.NoError(t, err)
+		minAmount, maxAmount := int64(100), int64(200)
+
+		for name, tc := range map[string]struct {
+			filter models.WalletFilter
+			want   []uuid.UUID
+		}{
+			"All":              {models.WalletFilter{}, ids},
+			"Name":             {models.WalletFilter{Name: "TRAVEL"}, []uuid.UUID{ids[0], ids[3]}},
+			"Name wildcards":   {models.WalletFilter{Name: "l_"}, []uuid.UUID{ids[0]}},
+			"Name percent":     {models.WalletFilter{Name: "%"}, []uuid.UUID{ids[3]}},
+			"Tag":              {models.WalletFilter{Tags: []string{"home"}}, []uuid.UUID{ids[1], ids[2]}},
+			"Tags":             {models.WalletFilter{Tags: []string{"home", "shared"}}, []uuid.UUID{ids[2]}},
+			"Amount":           {models.WalletFilter{MinAmount: &minAmount, MaxAmount: &maxAmount}, []uuid.UUID{ids[1], ids[2]}},
+			"Created from":     {models.WalletFilter{CreatedFrom: &second.CreatedAt}, ids[1:]},
+			"Created to":       {models.WalletFilter{CreatedTo: &second.CreatedAt}, ids[:1]},
+			"By name":          {models.WalletFilter{OrderBy: models.WalletsByName}, []uuid.UUID{ids[1], ids[3], ids[2], ids[0]}},
+			"By amount desc":   {models.WalletFilter{OrderBy: models.WalletsByAmount, Desc: true}, []uuid.UUID{ids[0], ids[2], ids[1], ids[3]}},
+			"By creation desc": {models.WalletFilter{Desc: true}, []uuid.UUID{ids[3], ids[2], ids[1], ids[0]}},
+		} {
+			wallets, err := r.GetAllFromUser(ctx, alice, tc.filter, models.Page{})
+			require.NoError(t, err, name)
+			var got []uuid.UUID
+			for _, wallet := range wallets {
+				got = append(got, wallet.WalletId)
+			}
+			assert.Equal(t, tc.want, got, name)
+		}
+
+		page, err := r.GetAllFromUser(ctx, alice, models.WalletFilter{OrderBy: models.WalletsByAmount}, models.Page{Limit: 2, Offset: 1})
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		assert.Equal(t, []uuid.UUID{ids[1], ids[2]}, []uuid.UUID{page[0].WalletId, page[1].WalletId})
+	})
+
+	t.Run("Transactions", func(t *testing.T) {
+		r := newRepo(t)
+		walletId := newWallet(t, r, newUser(t, r, "alice"), 0)
+
+		depositId, err := r.Transaction.Create(ctx, models.TransactionInput{WalletId: walletId, OperationType: models.Deposit, Amount: 100})
+		require.NoError(t, err)
+		_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: walletId, OperationType: models.Withdraw, Amount: 30})
+		require.NoError(t, err)
+		assert.Equal(t, int64(70), balance(t, r, walletId))
+
+		_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: walletId, OperationType: models.Withdraw, Amount: 71})
+		assert.ErrorIs(t, err, models.ErrInsufficientFunds)
+		_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: walletId, OperationType: models.Deposit, Amount: 0})
+		assert.ErrorIs(t, err, models.ErrInvalidAmount)
+		_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: walletId, OperationType: models.Withdraw, Amount: -5})
+		assert.ErrorIs(t, err, models.ErrInvalidAmount)
+		_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: walletId, OperationType: "TRANSFER", Amount: 1})
+		assert.ErrorIs(t, err, models.ErrUnknownOperation)
+		_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: uuid.New(), OperationType: models.Deposit, Amount: 1})
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.Equal(t, int64(70), balance(t, r, walletId), "failed transactions must not move the balance")
+
+		transaction, err := r.Transaction.GetById(ctx, depositId)
+		require.NoError(t, err)
+		assert.Equal(t, walletId, transaction.WalletId)
+		assert.Equal(t, models.Deposit, transaction.OperationType)
+		assert.Equal(t, int64(100), transaction.Amount)
+		_, err = r.Transaction.GetById(ctx, uuid.New())
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		fromWallet, err := r.GetAllFromWallet(ctx, walletId)
+		require.NoError(t, err)
+		require.Len(t, fromWallet, 2)
+		assert.Contains(t, []uuid.UUID{fromWallet[0].TransactionId, fromWallet[1].TransactionId}, depositId)
+
+		all, err := r.Transaction.GetAll(ctx, models.TransactionFilter{}, models.Page{})
+		require.NoError(t, err)
+		assert.Equal(t, fromWallet, all)
+		page, err := r.Transaction.GetAll(ctx, models.TransactionFilter{}, models.Page{Limit: 1, Offset: 1})
+		require.NoError(t, err)
+		assert.Equal(t, all[1:], page)
+	})
+
+	t.Run("Categories", func(t *testing.T) {
+		r := newRepo(t)
+		alice, bob := newUser(t, r, "alice"), newUser(t, r, "bob")
+
+		foodId, err := r.Category.Create(ctx, alice, models.CategoryInput{Name: "food"})
+		require.NoError(t, err)
+		groceriesId, err := r.Category.Create(ctx, alice, models.CategoryInput{Name: "groceries", ParentId: &foodId})
+		require.NoError(t, err)
+		_, err = r.Category.Create(ctx, alice, models.CategoryInput{Name: "food"})
+		assert.ErrorIs(t, err, models.ErrCategoryExists)
+		_, err = r.Category.Create(ctx, bob, models.CategoryInput{Name: "food"})
+		assert.NoError(t, err, "names are unique per user")
+
+		categories, err := r.Category.GetAll(ctx, alice, models.Page{})
+		require.NoError(t, err)
+		require.Len(t, categories, 2)
+		assert.Equal(t, []string{"food", "groceries"}, []string{categories[0].Name, categories[1].Name})
+		assert.Equal(t, &foodId, categories[1].ParentId)
+		page, err := r.Category.GetAll(ctx, alice, models.Page{Limit: 1, Offset: 1})
+		require.NoError(t, err)
+		assert.Equal(t, categories[1:], page)
+
+		category, err := r.Category.GetById(ctx, alice, groceriesId)
+		require.NoError(t, err)
+		assert.Equal(t, "groceries", category.Name)
+		_, err = r.Category.GetById(ctx, bob, groceriesId)
+		assert.ErrorIs(t, err, sql.ErrNoRows, "category of another user")
+
+		assert.ErrorIs(t, r.Category.Delete(ctx, alice, foodId), models.ErrCategoryInUse, "parent category")
+		assert.ErrorIs(t, r.Category.Delete(ctx, bob, groceriesId), sql.ErrNoRows)
+
+		walletId := newWallet(t, r, alice, 0)
+		_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: walletId, OperationType: models.Deposit, Amount: 10, CategoryId: &groceriesId})
+		require.NoError(t, err)
+		assert.ErrorIs(t, r.Category.Delete(ctx, alice, groceriesId), models.ErrCategoryInUse, "category of a transaction")
+
+		unusedId, err := r.Category.Create(ctx, alice, models.CategoryInput{Name: "unused"})
+		require.NoError(t, err)
+		require.NoError(t, r.Category.Delete(ctx, alice, unusedId))
+		_, err = r.Category.GetById(ctx, alice, unusedId)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Transaction details", func(t *testing.T) {
+		r := newRepo(t)
+		userId := newUser(t, r, "alice")
+		walletId := newWallet(t, r, userId, 0)
+		foodId, err := r.Category.Create(ctx, userId, models.CategoryInput{Name: "food"})
+		require.NoError(t, err)
+		giftsId, err := r.Category.Create(ctx, userId, models.CategoryInput{Name: "gifts"})
+		require.NoError(t, err)
+
+		description, externalRef := "Lunch at 50% off", "order-1"
+		lunchId, err := r.Transaction.Create(ctx, models.TransactionInput{
+			WalletId:      walletId,
+			OperationType: models.Deposit,
+			Amount:        100,
+			Description:   &description,
+			CategoryId:    &foodId,
+			ExternalRef:   &externalRef,
+			Metadata:      models.Metadata(`{"count":2,"orderId":9007199254740993,"paid":true,"shop":"cafe"}`),
+		})
+		require.NoError(t, err)
+		plainId, err := r.Transaction.Create(ctx, models.TransactionInput{WalletId: walletId, OperationType: models.Deposit, Amount: 5})
+		require.NoError(t, err)
+
+		lunch, err := r.Transaction.GetById(ctx, lunchId)
+		require.NoError(t, err)
+		assert.Equal(t, &description, lunch.Description)
+		assert.Equal(t, &foodId, lunch.CategoryId)
+		assert.Equal(t, &externalRef, lunch.ExternalRef)
+		assert.JSONEq(t, `{"count":2,"orderId":9007199254740993,"paid":true,"shop":"cafe"}`, string(lunch.Metadata))
+		assert.Equal(t, "9007199254740993", lunch.Metadata.Values()["orderId"], "large integers are exact")
+		assert.Nil(t, lunch.Annotation)
+		assert.Equal(t, lunch.Hash, lunch.ComputeHash(), "details are hashed")
+		plain, err := r.Transaction.GetById(ctx, plainId)
+		require.NoError(t, err)
+		assert.Nil(t, plain.Description)
+		assert.Empty(t, plain.Metadata)
+
+		ids := func(filter models.TransactionFilter) []uuid.UUID {
+			t.Helper()
+			transactions, err := r.Transaction.GetAll(ctx, filter, models.Page{})
+			require.NoError(t, err)
+			ids := []uuid.UUID{}
+			for _, transaction := range transactions {
+				ids = append(ids, transaction.TransactionId)
+			}
+			return ids
+		}
+		assert.Equal(t, []uuid.UUID{lunchId}, ids(models.TransactionFilter{CategoryId: &foodId}))
+		assert.Equal(t, []uuid.UUID{lunchId}, ids(models.TransactionFilter{ExternalRef: "order-1"}))
+		assert.Equal(t, []uuid.UUID{}, ids(models.TransactionFilter{ExternalRef: "order"}))
+		assert.Equal(t, []uuid.UUID{lunchId}, ids(models.TransactionFilter{Search: "LUNCH"}))
+		assert.Equal(t, []uuid.UUID{lunchId}, ids(models.TransactionFilter{Search: "50%"}))
+		assert.Equal(t, []uuid.UUID{}, ids(models.TransactionFilter{Search: "5_"}), "wildcards match literally")
+		assert.Equal(t, []uuid.UUID{lunchId}, ids(models.TransactionFilter{Metadata: map[string]string{"shop": "cafe", "count": "2", "paid": "true"}}))
+		assert.Equal(t, []uuid.UUID{lunchId}, ids(models.TransactionFilter{Metadata: map[string]string{"orderId": "9007199254740993"}}))
+		assert.Equal(t, []uuid.UUID{}, ids(models.TransactionFilter{Metadata: map[string]string{"shop": "bar"}}))
+
+		annotations, err := r.GetAnnotations(ctx, lunchId, models.Page{})
+		require.NoError(t, err)
+		assert.Empty(t, annotations)
+
+		first, err := r.Annotate(ctx, lunchId, models.AnnotationInput{CategoryId: &giftsId, Notes: "birthday"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), first.Version)
+		second, err := r.Annotate(ctx, lunchId, models.AnnotationInput{Notes: "not a category"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), second.Version)
+		_, err = r.Annotate(ctx, uuid.New(), models.AnnotationInput{Notes: "unknown"})
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		history, err := r.Transaction.GetAllFromWallet(ctx, walletId)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		lunch = history[0]
+		require.NotNil(t, lunch.Annotation)
+		assert.Equal(t, int64(2), lunch.Annotation.Version)
+		assert.Nil(t, lunch.Annotation.CategoryId)
+		assert.Equal(t, "not a category", lunch.Annotation.Notes)
+		assert.Equal(t, &foodId, lunch.CategoryId, "the transaction keeps its category")
+		assert.Nil(t, history[1].Annotation)
+
+		// Annotations are private to the owner of the wallet.
+		lunch, err = r.Transaction.GetById(ctx, lunchId)
+		require.NoError(t, err)
+		assert.Nil(t, lunch.Annotation)
+		transactions, err := r.Transaction.GetAll(ctx, models.TransactionFilter{}, models.Page{})
+		require.NoError(t, err)
+		for _, transaction := range transactions {
+			assert.Nil(t, transaction.Annotation)
+		}
+		_, err = r.Annotate(ctx, lunchId, models.AnnotationInput{CategoryId: &giftsId})
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{lunchId}, ids(models.TransactionFilter{CategoryId: &foodId}))
+		assert.Equal(t, []uuid.UUID{}, ids(models.TransactionFilter{CategoryId: &giftsId}))
+
+		annotations, err = r.GetAnnotations(ctx, lunchId, models.Page{})
+		require.NoError(t, err)
+		require.Len(t, annotations, 3)
+		assert.Equal(t, []int64{3, 2, 1}, []int64{annotations[0].Version, annotations[1].Version, annotations[2].Version})
+		assert.Equal(t, "birthday", annotations[2].Notes)
+		page, err := r.GetAnnotations(ctx, lunchId, models.Page{Limit: 1, Offset: 1})
+		require.NoError(t, err)
+		assert.Equal(t, annotations[1:2], page)
+
+		assert.ErrorIs(t, r.Category.Delete(ctx, userId, giftsId), models.ErrCategoryInUse, "category of an annotation")
+	})
+
+	t.Run("Frozen wallets", func(t *testing.T) {
+		r := newRepo(t)
+		walletId := newWallet(t, r, newUser(t, r, "alice"), 50)
+
+		require.NoError(t, r.SetFrozen(ctx, walletId, true))
+		_, err := r.Transaction.Create(ctx, models.TransactionInput{WalletId: walletId, OperationType: models.Withdraw, Amount: 10})
+		assert.ErrorIs(t, err, models.ErrWalletFrozen)
+		_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: walletId, OperationType: models.Deposit, Amount: 10})
+		assert.NoError(t, err)
+
+		_, err = r.CreateAdjustment(ctx, models.AdjustmentInput{WalletId: walletId, OperationType: models.Withdraw, Amount: 20, Reason: "chargeback"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(40), balance(t, r, walletId))
+
+		_, err = r.CreateAdjustment(ctx, models.AdjustmentInput{WalletId: walletId, OperationType: models.Withdraw, Amount: 20})
+		assert.Error(t, err, "adjustment without a reason")
+		assert.Equal(t, int64(40), balance(t, r, walletId))
+
+		require.NoError(t, r.SetFrozen(ctx, walletId, false))
+		wallet, err := r.Wallet.GetById(ctx, walletId)
+		require.NoError(t, err)
+		assert.Equal(t, models.WalletActive, wallet.Status)
+
+		assert.ErrorIs(t, r.SetFrozen(ctx, uuid.New(), true), sql.ErrNoRows)
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		r := newRepo(t)
+		alice := newUser(t, r, "alice")
+		bob := newUser(t, r, "bob")
+		source := newWallet(t, r, alice, 80)
+		target := newWallet(t, r, alice, 20)
+		foreign := newWallet(t, r, bob, 0)
+		missing := uuid.New()
+
+		for name, tc := range map[string]struct {
+			userId int
+			input  models.CloseWalletInput
+			err    error
+		}{
+			"Non-zero balance": {alice, models.CloseWalletInput{}, models.ErrNonZeroBalance},
+			"Sweep to itself":  {alice, models.CloseWalletInput{SweepTo: &source}, models.ErrInvalidSweepTarget},
+			"Sweep to other":   {alice, models.CloseWalletInput{SweepTo: &foreign}, models.ErrInvalidSweepTarget},
+			"Sweep to missing": {alice, models.CloseWalletInput{SweepTo: &missing}, models.ErrInvalidSweepTarget},
+			"Other user":       {bob, models.CloseWalletInput{}, sql.ErrNoRows},
+		} {
+			assert.ErrorIs(t, r.Close(ctx, tc.userId, source, tc.input), tc.err, name)
+		}
+		assert.Equal(t, int64(80), balance(t, r, source))
+
+		require.NoError(t, r.Close(ctx, alice, source, models.CloseWalletInput{Reason: "moving", SweepTo: &target}))
+		assert.Equal(t, int64(100), balance(t, r, target))
+
+		closed, err := r.Wallet.GetById(ctx, source)
+		require.NoError(t, err)
+		assert.Equal(t, models.WalletClosed, closed.Status)
+		assert.Equal(t, int64(0), closed.Amount)
+		assert.NotNil(t, closed.ClosedAt)
+		require.NotNil(t, closed.CloseReason)
+		assert.Equal(t, "moving", *closed.CloseReason)
+
+		transactions, err := r.GetAllFromWallet(ctx, source)
+		require.NoError(t, err)
+		require.Len(t, transactions, 2)
+		var swept int64
+		for _, transaction := range transactions {
+			if transaction.OperationType == models.Withdraw {
+				swept += transaction.Amount
+			}
+		}
+		assert.Equal(t, int64(80), swept)
+
+		assert.ErrorIs(t, r.Close(ctx, alice, source, models.CloseWalletInput{}), models.ErrWalletClosed)
+		assert.ErrorIs(t, r.SetFrozen(ctx, source, true), models.ErrWalletClosed)
+		_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: source, OperationType: models.Deposit, Amount: 1})
+		assert.ErrorIs(t, err, models.ErrWalletClosed)
+
+		require.NoError(t, r.Close(ctx, bob, foreign, models.CloseWalletInput{}))
+		closed, err = r.Wallet.GetById(ctx, foreign)
+		require.NoError(t, err)
+		assert.Nil(t, closed.CloseReason)
+	})
+
+	t.Run("Versions", func(t *testing.T) {
+		r := newRepo(t)
+		alice := newUser(t, r, "alice")
+		id := newWallet(t, r, alice, 0)
+
+		version := func() int64 {
+			wallet, err := r.Wallet.GetById(ctx, id)
+			require.NoError(t, err)
+			return wallet.Version
+		}
+		assert.Equal(t, int64(1), version())
+
+		current := version()
+		_, err := r.Transaction.Create(ctx, models.TransactionInput{WalletId: id, OperationType: models.Deposit, Amount: 10, ExpectedVersion: &current})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), version())
+
+		// current is now stale: neither balance nor status change.
+		_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: id, OperationType: models.Withdraw, Amount: 10, ExpectedVersion: &current})
+		assert.ErrorIs(t, err, models.ErrVersionMismatch)
+		assert.Equal(t, int64(10), balance(t, r, id))
+
+		require.NoError(t, r.SetFrozen(ctx, id, true))
+		require.NoError(t, r.SetFrozen(ctx, id, false))
+		assert.Equal(t, int64(4), version())
+
+		_, err = r.Transaction.Create(ctx, models.TransactionInput{WalletId: id, OperationType: models.Withdraw, Amount: 10})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, r.Close(ctx, alice, id, models.CloseWalletInput{ExpectedVersion: &current}), models.ErrVersionMismatch)
+		current = version()
+		require.NoError(t, r.Close(ctx, alice, id, models.CloseWalletInput{ExpectedVersion: &current}))
+		assert.Equal(t, current+1, version())
+	})
+
+	t.Run("Balances", func(t *testing.T) {
+		r := newRepo(t)
+		alice := newUser(t, r, "alice")
+		id := newWallet(t, r, alice, 0)
+		newWallet(t, r, alice, 5)
+
+		// moment returns a time clearly apart from the transactions around it.
+		moment := func() time.Time {
+			time.Sleep(time.Millisecond)
+			defer time.Sleep(time.Millisecond)
+			return time.Now()
+		}
+		transact := func(operationType models.OperationType, amount int64) {
+			_, err := r.Transaction.Create(ctx, models.TransactionInput{WalletId: id, OperationType: operationType, Amount: amount})
+			require.NoError(t, err)
+		}
+
+		created := moment()
+		transact(models.Deposit, 30)
+		deposited := moment()
+		transact(models.Withdraw, 10)
+		withdrawn := moment()
+
+		at := []time.Time{created, deposited, withdrawn}
+		balances, err := r.Balance.At(ctx, id, at)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{0, 30, 20}, balances)
+
+		// Snapshots change how balances are computed, not their values.
+		_, err = r.Balance.TakeSnapshots(ctx, deposited)
+		require.NoError(t, err)
+		taken, err := r.Balance.TakeSnapshots(ctx, deposited)
+		require.NoError(t, err)
+		assert.Zero(t, taken, "snapshots are taken once")
+		_, err = r.Balance.TakeSnapshots(ctx, withdrawn)
+		require.NoError(t, err)
+
+		transact(models.Deposit, 5)
+		balances, err = r.Balance.At(ctx, id, append(at, moment()))
+		require.NoError(t, err)
+		assert.Equal(t, []int64{0, 30, 20, 25}, balances)
+		assert.Equal(t, int64(25), balance(t, r, id))
+
+		balances, err = r.Balance.At(ctx, uuid.New(), []time.Time{withdrawn})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{0}, balances)
+	})
+
+	t.Run("Correction cases", func(t *testing.T) {
+		r := newRepo(t)
+		alice := newUser(t, r, "alice")
+		id := newWallet(t, r, alice, 30)
+		newWallet(t, r, alice, 0)
+
+		wallets, mismatches, err := r.Mismatches(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), wallets)
+		assert.Empty(t, mismatches, "transactions keep balances in line")
+
+		mismatch := models.BalanceMismatch{WalletId: id, Stored: 30, Computed: 20, Difference: 10}
+		caseId, err := r.OpenCase(ctx, mismatch)
+		require.NoError(t, err)
+		again, err := r.OpenCase(ctx, mismatch)
+		require.NoError(t, err)
+		assert.Equal(t, caseId, again, "a wallet has one open case")
+
+		_, err = r.OpenCase(ctx, models.BalanceMismatch{WalletId: uuid.New()})
+		assert.Error(t, err, "unknown wallet")
+
+		cases, err := r.GetCases(ctx, models.CaseOpen, models.Page{})
+		require.NoError(t, err)
+		require.Len(t, cases, 1)
+		assert.Equal(t, caseId, cases[0].CaseId)
+		assert.Equal(t, int64(30), cases[0].Stored)
+		assert.Equal(t, int64(20), cases[0].Computed)
+		assert.Nil(t, cases[0].ResolvedAt)
+
+		require.NoError(t, r.ResolveCase(ctx, caseId, "restored from backup"))
+		assert.ErrorIs(t, r.ResolveCase(ctx, caseId, "twice"), sql.ErrNoRows)
+		assert.ErrorIs(t, r.ResolveCase(ctx, uuid.New(), "unknown"), sql.ErrNoRows)
+
+		cases, err = r.GetCases(ctx, models.CaseOpen, models.Page{})
+		require.NoError(t, err)
+		assert.Empty(t, cases)
+
+		reopened, err := r.OpenCase(ctx, mismatch)
+		require.NoError(t, err)
+		assert.NotEqual(t, caseId, reopened)
+
+		cases, err = r.GetCases(ctx, "", models.Page{})
+		require.NoError(t, err)
+		require.Len(t, cases, 2)
+		assert.Equal(t, models.CaseResolved, cases[0].Status)
+		assert.Equal(t, "restored from backup", *cases[0].Resolution)
+		assert.NotNil(t, cases[0].ResolvedAt)
+		assert.Equal(t, models.CaseOpen, cases[1].Status)
+
+		cases, err = r.GetCases(ctx, "", models.Page{Limit: 1, Offset: 1})
+		require.NoError(t, err)
+		require.Len(t, cases, 1)
+		assert.Equal(t, reopened, cases[0].CaseId)
+	})
+
+	t.Run("Chain", func(t *testing.T) {
+		r := newRepo(t)
+		alice := newUser(t, r, "alice")
+		id := newWallet(t, r, alice, 30)
+		other := newWallet(t, r, alice, 5)
+		empty := newWallet(t, r, alice, 0)
+		_, err := r.Transaction.Create(ctx, models.TransactionInput{WalletId: id, OperationType: models.Withdraw, Amount: 10})
+		require.NoError(t, err)
+
+		links, err := r.Links(ctx, id)
+		require.NoError(t, err)
+		require.Len(t, links, 2)
+		prev := ""
+		for i, link := range links {
+			assert.Equal(t, int64(i+1), link.Seq)
+			assert.Equal(t, prev, link.PrevHash)
+			assert.Equal(t, link.ComputeHash(), link.Hash, "the hash matches the stored row")
+			prev = link.Hash
+		}
+
+		stored, err := r.Transaction.GetById(ctx, links[1].TransactionId)
+		require.NoError(t, err)
+		assert.Equal(t, links[1].Hash, stored.Hash)
+
+		links, err = r.Links(ctx, empty)
+		require.NoError(t, err)
+		assert.Empty(t, links)
+
+		sealed, err := r.Seal(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, sealed, "new transactions are chained when recorded")
+
+		heads, err := r.Heads(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.Len(t, heads, 2, "wallets without transactions have no head")
+		byWallet := map[uuid.UUID]models.ChainHead{heads[0].WalletId: heads[0], heads[1].WalletId: heads[1]}
+		assert.Equal(t, models.ChainHead{WalletId: id, Seq: 2, Hash: prev}, byWallet[id])
+		assert.Equal(t, int64(1), byWallet[other].Seq)
+		assert.Negative(t, bytes.Compare(heads[0].WalletId[:], heads[1].WalletId[:]), "heads are ordered by wallet")
+
+		heads, err = r.Heads(ctx, firstLinkAt(t, r, id))
+		require.NoError(t, err)
+		assert.Empty(t, heads, "nothing was created before the first transaction")
+
+		at := time.Now().Truncate(time.Second).UTC()
+		checkpoint := models.Checkpoint{
+			CheckpointId: uuid.New(), At: at, Wallets: 2, Transactions: 3,
+			Root: "root", PublicKey: "key", Signature: "signature", CreatedAt: at,
+		}
+		created, err := r.CreateCheckpoint(ctx, checkpoint)
+		require.NoError(t, err)
+		assert.Equal(t, checkpoint.CheckpointId, created.CheckpointId)
+		assert.True(t, checkpoint.At.Equal(created.At))
+
+		duplicate := checkpoint
+		duplicate.CheckpointId = uuid.New()
+		created, err = r.CreateCheckpoint(ctx, duplicate)
+		require.NoError(t, err)
+		assert.Equal(t, checkpoint.CheckpointId, created.CheckpointId, "one checkpoint per moment")
+
+		later := checkpoint
+		later.CheckpointId = uuid.New()
+		later.At = at.Add(time.Hour)
+		_, err = r.CreateCheckpoint(ctx, later)
+		require.NoError(t, err)
+
+		checkpoints, err := r.GetCheckpoints(ctx, models.Page{})
+		require.NoError(t, err)
+		require.Len(t, checkpoints, 2)
+		assert.Equal(t, later.CheckpointId, checkpoints[0].CheckpointId, "newest first")
+
+		got, err := r.GetCheckpoint(ctx, checkpoint.CheckpointId)
+		require.NoError(t, err)
+		assert.Equal(t, "root", got.Root)
+		assert.Equal(t, int64(3), got.Transactions)
+
+		_, err = r.GetCheckpoint(ctx, uuid.New())
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Concurrent withdrawals", func(t *testing.T) {
+		r := newRepo(t)
+		walletId := newWallet(t, r, newUser(t, r, "alice"), 20)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 50)
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := r.Transaction.Create(ctx, models.TransactionInput{WalletId: walletId, OperationType: models.Withdraw, Amount: 1})
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+			} else if !errors.Is(err, models.ErrInsufficientFunds) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+		assert.Equal(t, 20, succeeded)
+		assert.Equal(t, int64(0), balance(t, r, walletId))
+	})
+
+	t.Run("Concurrent sweeps", func(t *testing.T) {
+		r := newRepo(t)
+		alice := newUser(t, r, "alice")
+		a := newWallet(t, r, alice, 10)
+		b := newWallet(t, r, alice, 10)
+
+		// Sweeping in opposite directions at once must close exactly one
+		// wallet and never lose funds.
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i, pair := range [][2]uuid.UUID{{a, b}, {b, a}} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = r.Close(ctx, alice, pair[0], models.CloseWalletInput{SweepTo: &pair[1]})
+			}()
+		}
+		wg.Wait()
+
+		require.True(t, (errs[0] == nil) != (errs[1] == nil), "exactly one close must succeed: %v", errs)
+		for _, err := range errs {
+			if err != nil {
+				assert.ErrorIs(t, err, models.ErrInvalidSweepTarget)
+			}
+		}
+		assert.Equal(t, int64(20), balance(t, r, a)+balance(t, r, b))
+	})
+
+	t.Run("Canceled context", func(t *testing.T) {
+		r := newRepo(t)
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := r.Wallet.GetById(canceled, uuid.New())
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
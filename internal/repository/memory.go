@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+)
+
+// Errors for writes that Postgres rejects with a constraint violation.
+var (
+	errDuplicateUsername         = errors.New(`duplicate key value violates unique constraint "users_username_key"`)
+	errUnknownUser               = errors.New(`insert or update on table "wallets" violates foreign key constraint "wallets_user_id_fkey"`)
+	errNonPositiveAmount         = errors.New(`new row for relation "transactions" violates check constraint "transactions_amount_positive"`)
+	errEmptyReason               = errors.New(`new row for relation "adjustments" violates check constraint "adjustments_reason_check"`)
+	errUnknownCaseWallet         = errors.New(`insert or update on table "correction_cases" violates foreign key constraint "correction_cases_wallet_id_fkey"`)
+	errUnknownCategoryUser       = errors.New(`insert or update on table "categories" violates foreign key constraint "categories_user_id_fkey"`)
+	errUnknownParent             = errors.New(`insert or update on table "categories" violates foreign key constraint "categories_parent_id_fkey"`)
+	errUnknownAnnotationCategory = errors.New(`insert or update on table "transaction_annotations" violates foreign key constraint "transaction_annotations_category_id_fkey"`)
+)
+
+// MemoryDB holds the data of the in-memory repositories, for tests and local
+// development. A single lock serializes every operation, so each one is as
+// atomic and isolated as the Postgres transaction holding its row locks.
+type MemoryDB struct {
+	mu sync.Mutex
+
+	lastUserId   int
+	users        map[int]models.User
+	wallets      map[uuid.UUID]models.Wallet
+	transactions []models.Transaction
+	adjustments  map[uuid.UUID]memoryAdjustment
+	cases        []models.CorrectionCase
+	checkpoints  []models.Checkpoint
+	categories   map[uuid.UUID]models.Category
+	// annotations holds the versions of the annotation of each transaction,
+	// oldest first.
+	annotations map[uuid.UUID][]models.Annotation
+}
+
+type memoryAdjustment struct {
+	reason    string
+	createdAt time.Time
+}
+
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		users:       make(map[int]models.User),
+		wallets:     make(map[uuid.UUID]models.Wallet),
+		adjustments: make(map[uuid.UUID]memoryAdjustment),
+		categories:  make(map[uuid.UUID]models.Category),
+		annotations: make(map[uuid.UUID][]models.Annotation),
+	}
+}
+
+// NewMemoryRepository returns repositories sharing a new, empty MemoryDB.
+func NewMemoryRepository() *Repository {
+	db := NewMemoryDB()
+
+	return &Repository{
+		Authorization:  NewAuthMemory(db),
+		Wallet:         NewWalletMemory(db),
+		Transaction:    NewTransactionMemory(db),
+		Category:       NewCategoryMemory(db),
+		Balance:        NewBalanceMemory(db),
+		Reconciliation: NewReconciliationMemory(db),
+		Chain:          NewChainMemory(db),
+	}
+}
+
+// lock takes the database lock unless ctx is done, and reports the wait like
+// lockWallet does for row locks.
+func (db *MemoryDB) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	db.mu.Lock()
+	metrics.ObserveLockWait(time.Since(start).Seconds())
+
+	return nil
+}
+
+func (db *MemoryDB) unlock() {
+	db.mu.Unlock()
+}
+
+// now returns the current time at the precision Postgres stores.
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// applyTransaction is applyTransaction of the Postgres repository: it records
+// the transaction, chained to the last one of the wallet, and moves the
+// wallet balance. db must be locked.
+func (db *MemoryDB) applyTransaction(transaction models.TransactionInput) (uuid.UUID, error) {
+	wallet := db.wallets[transaction.WalletId]
+	switch transaction.OperationType {
+	case models.Deposit:
+		wallet.Amount += transaction.Amount
+	case models.Withdraw:
+		wallet.Amount -= transaction.Amount
+	default:
+		return uuid.Nil, models.ErrUnknownOperation
+	}
+
+	if transaction.Amount <= 0 {
+		return uuid.Nil, errNonPositiveAmount
+	}
+
+	link := newLink(db.chainHead(transaction.WalletId), transaction, now())
+	db.transactions = append(db.transactions, link)
+
+	wallet.UpdatedAt = link.CreatedAt
+	wallet.Version++
+	db.wallets[transaction.WalletId] = wallet
+
+	return link.TransactionId, nil
+}
+
+// chainHead returns the last transaction of the wallet chain. db must be
+// locked.
+func (db *MemoryDB) chainHead(walletId uuid.UUID) models.ChainHead {
+	for i := len(db.transactions) - 1; i >= 0; i-- {
+		if transaction := db.transactions[i]; transaction.WalletId == walletId {
+			return models.ChainHead{WalletId: walletId, Seq: transaction.Seq, Hash: transaction.Hash}
+		}
+	}
+
+	return models.ChainHead{WalletId: walletId}
+}
+
+// paginate returns the page of items, which must already be sorted.
+func paginate[T any](items []T, page models.Page) []T {
+	if page.Offset >= len(items) {
+		return nil
+	}
+	items = items[page.Offset:]
+
+	if page.Limit > 0 && page.Limit < len(items) {
+		items = items[:page.Limit]
+	}
+
+	return items
+}
+
+// sortByCreation sorts like ORDER BY created_at, id.
+func sortByCreation[T any](items []T, key func(T) (time.Time, uuid.UUID)) {
+	sort.SliceStable(items, func(i, j int) bool {
+		ti, idi := key(items[i])
+		tj, idj := key(items[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return bytes.Compare(idi[:], idj[:]) < 0
+	})
+}
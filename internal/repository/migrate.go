@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/Yoshisoul/rest-wallets/schema"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jmoiron/sqlx"
+)
+
+// MigrationStatus is the state of the schema_migrations table compared to
+// the migrations embedded in the binary.
+type MigrationStatus struct {
+	Version uint
+	Dirty   bool
+	Latest  uint
+}
+
+// Migrator applies the embedded migrations. It keeps the schema_migrations
+// table layout of the migrate CLI, so databases migrated with `make migrate`
+// can be managed by the binary and vice versa.
+//
+// Every command that changes the schema holds a Postgres advisory lock for
+// its duration, so concurrent instances started with --auto-migrate wait for
+// each other instead of applying the same migration twice. SQLite databases
+// are local to one instance, and each migration runs in a transaction that
+// takes the database write lock.
+type Migrator struct {
+	m *migrate.Migrate
+}
+
+// NewMigrator returns a migrator for the driver db was opened with.
+func NewMigrator(db *sqlx.DB) (*Migrator, error) {
+	if db.DriverName() == "sqlite" {
+		return newSQLiteMigrator(db)
+	}
+
+	// The driver runs on a dedicated connection: the advisory lock is bound
+	// to a session, and closing the migrator must not close the pool.
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := postgres.WithConnection(context.Background(), conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return newMigrator(schema.Migrations, ".", "postgres", driver)
+}
+
+func newSQLiteMigrator(db *sqlx.DB) (*Migrator, error) {
+	driver, err := sqlite.WithInstance(db.DB, &sqlite.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	return newMigrator(schema.SQLiteMigrations, "sqlite", "sqlite", sharedDriver{driver})
+}
+
+func newMigrator(migrations fs.FS, dir, driverName string, driver database.Driver) (*Migrator, error) {
+	source, err := iofs.New(migrations, dir)
+	if err != nil {
+		driver.Close()
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, driverName, driver)
+	if err != nil {
+		driver.Close()
+		return nil, err
+	}
+
+	return &Migrator{m: m}, nil
+}
+
+// sharedDriver leaves the pool open when the migrator is closed. The sqlite
+// driver only runs on a whole *sql.DB, which it closes along with itself.
+type sharedDriver struct {
+	database.Driver
+}
+
+func (sharedDriver) Close() error {
+	return nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.m.Up())
+}
+
+// Down rolls back the given number of applied migrations.
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return errors.New("number of migrations to roll back must be positive")
+	}
+
+	return ignoreNoChange(m.m.Steps(-steps))
+}
+
+// Force sets the schema version without running any migration and clears
+// the dirty flag. It is meant for recovering from a failed migration.
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+func (m *Migrator) Status() (MigrationStatus, error) {
+	latest, err := LatestMigration()
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return MigrationStatus{Latest: latest}, nil
+	}
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+
+	return MigrationStatus{Version: version, Dirty: dirty, Latest: latest}, nil
+}
+
+func (m *Migrator) Close() error {
+	sourceErr, dbErr := m.m.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+// CurrentMigration reads the applied version straight from schema_migrations.
+// Unlike Migrator.Status it takes no lock, so it is cheap enough for health
+// checks.
+func CurrentMigration(ctx context.Context, db *sqlx.DB) (MigrationStatus, error) {
+	latest, err := LatestMigration()
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+
+	status := MigrationStatus{Latest: latest}
+	err = db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&status.Version, &status.Dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return MigrationStatus{}, err
+	}
+
+	return status, nil
+}
+
+// LatestMigration returns the highest migration version embedded in the
+// binary. The migrations of every driver end at the same version.
+func LatestMigration() (uint, error) {
+	return latestMigration(schema.Migrations, ".")
+}
+
+func latestMigration(migrations fs.FS, dir string) (uint, error) {
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			continue
+		}
+
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		latest = max(latest, uint(version))
+	}
+
+	return latest, nil
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+
+	return err
+}
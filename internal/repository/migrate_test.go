@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/Yoshisoul/rest-wallets/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatestMigration(t *testing.T) {
+	latest, err := LatestMigration()
+	assert.NoError(t, err)
+	assert.NotZero(t, latest)
+
+	// Every version up to the latest must ship both directions.
+	for version := uint(1); version <= latest; version++ {
+		for _, direction := range []string{"up", "down"} {
+			matches, err := fs.Glob(schema.Migrations, fmt.Sprintf("%06d_*.%s.sql", version, direction))
+			assert.NoError(t, err)
+			assert.Len(t, matches, 1, "version %d, direction %s", version, direction)
+		}
+	}
+}
+
+func TestLatestMigration_SQLite(t *testing.T) {
+	latest, err := LatestMigration()
+	assert.NoError(t, err)
+
+	sqliteLatest, err := latestMigration(schema.SQLiteMigrations, "sqlite")
+	assert.NoError(t, err)
+	assert.Equal(t, latest, sqliteLatest, "sqlite migrations must keep up with the postgres ones")
+
+	ups, err := fs.Glob(schema.SQLiteMigrations, "sqlite/*.up.sql")
+	assert.NoError(t, err)
+	downs, err := fs.Glob(schema.SQLiteMigrations, "sqlite/*.down.sql")
+	assert.NoError(t, err)
+	assert.Len(t, downs, len(ups))
+}
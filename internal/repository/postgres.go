@@ -1,37 +1,177 @@
 package repository
 
 import (
+	"context"
+	"database/sql/driver"
 	"fmt"
+	"time"
 
+	"github.com/XSAM/otelsql"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
+// maxConnectBackoff caps the wait between attempts of the first connection.
+const maxConnectBackoff = 10 * time.Second
+
 const (
 	userTable        = "users"
 	walletTable      = "wallets"
 	transactionTable = "transactions"
+	adjustmentTable  = "adjustments"
+	rateLimitTable   = "rate_limits"
+	snapshotTable    = "balance_snapshots"
+	caseTable        = "correction_cases"
+	checkpointTable  = "chain_checkpoints"
+	legacyChainTable = "legacy_chains"
+	categoryTable    = "categories"
+	annotationTable  = "transaction_annotations"
 )
 
 type Config struct {
+	// Driver is postgres or sqlite.
+	Driver string
+	// Path is the database file of the sqlite driver.
+	Path string
+
 	Host     string
 	Port     string
 	Username string
 	Password string
 	DBName   string
 	SSLMode  string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// ConnectTimeout is how long the first connection to Postgres is retried
+	// before giving up. Zero means a single attempt.
+	ConnectTimeout time.Duration
+	// ConnectBackoff is the wait after the first failed attempt. It doubles
+	// after each further one.
+	ConnectBackoff time.Duration
+	// BreakerThreshold is how many connections in a row must fail for the
+	// circuit breaker of the primary to open. Zero disables the breaker.
+	BreakerThreshold int
+	// BreakerCooldown is how long the breaker stays open before it lets a
+	// probe connection through.
+	BreakerCooldown time.Duration
+
+	// Replicas are read replicas of the Postgres primary. They share its
+	// credentials and pool settings.
+	Replicas []Replica
+	// ReplicaMaxLag is how far behind the primary a replica may be and still
+	// take reads.
+	ReplicaMaxLag time.Duration
+	// ReplicaCheckInterval is how often replicas are checked.
+	ReplicaCheckInterval time.Duration
+	// ReadYourWritesWindow is how long reads of a session go to the primary
+	// after it wrote.
+	ReadYourWritesWindow time.Duration
+}
+
+type Replica struct {
+	Host string
+	Port string
+}
+
+// NewDB opens the database of cfg.Driver.
+func NewDB(cfg Config) (*sqlx.DB, error) {
+	if cfg.Driver == "sqlite" {
+		return NewSQLiteDB(cfg)
+	}
+
+	return NewPostgresDB(cfg)
 }
 
+// NewPostgresDB opens the database through otelsql, so every query is traced
+// as a child of the span found in its context. The first connection is
+// retried for up to cfg.ConnectTimeout, so the service can start before the
+// database does, and later ones go through a Breaker.
 func NewPostgresDB(cfg Config) (*sqlx.DB, error) {
-	db, err := sqlx.Open("postgres", fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.Username, cfg.DBName, cfg.Password, cfg.SSLMode))
+	var breaker *Breaker
+	if cfg.BreakerThreshold > 0 {
+		breaker = NewBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
 
+	db, err := openPostgres(cfg, breaker)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := db.Ping(); err != nil {
+	if err := connect(db, cfg); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// connect pings db until it answers or cfg.ConnectTimeout has passed. The
+// wait between attempts starts at cfg.ConnectBackoff and doubles up to
+// maxConnectBackoff.
+func connect(db *sqlx.DB, cfg Config) error {
+	if cfg.ConnectTimeout <= 0 {
+		return db.Ping()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	backoff := cfg.ConnectBackoff
+	for {
+		err := db.PingContext(ctx)
+		if err == nil || time.Until(deadline) < backoff {
+			return err
+		}
+
+		logrus.WithError(err).Warnf("database is not ready, retrying in %s", backoff)
+		time.Sleep(backoff)
+		backoff = min(2*backoff, maxConnectBackoff)
+	}
+}
+
+// openPostgres sets up the connection pool without connecting yet. Unless
+// breaker is nil, new connections go through it.
+func openPostgres(cfg Config, breaker *Breaker) (*sqlx.DB, error) {
+	pqConnector, err := pq.NewConnector(fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.Username, cfg.DBName, cfg.Password, cfg.SSLMode))
+	if err != nil {
 		return nil, err
 	}
 
+	var connector driver.Connector = pqConnector
+	if breaker != nil {
+		connector = breakerConnector{Connector: connector, breaker: breaker}
+	}
+
+	sqlDB := otelsql.OpenDB(connector,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
+
+	db := sqlx.NewDb(sqlDB, "postgres")
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
 	return db, nil
 }
+
+// limitArg is the LIMIT argument for page: LIMIT NULL returns every row.
+func limitArg(page models.Page) any {
+	if page.Limit <= 0 {
+		return nil
+	}
+	return page.Limit
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/ratelimit"
+	"github.com/jmoiron/sqlx"
+)
+
+// RateLimitPostgres keeps token buckets in Postgres so that every instance
+// shares them. A bucket is refilled and taken from in a single upsert, timed
+// by the database clock.
+type RateLimitPostgres struct {
+	db *sqlx.DB
+}
+
+func NewRateLimitPostgres(db *sqlx.DB) *RateLimitPostgres {
+	return &RateLimitPostgres{db: db}
+}
+
+func (r *RateLimitPostgres) Take(ctx context.Context, key string, limit ratelimit.Limit) (bool, float64, error) {
+	// $2 is the burst and $3 the rate per second.
+	refilled := fmt.Sprintf("LEAST($2::float8, %[1]s.tokens + EXTRACT(EPOCH FROM now() - %[1]s.updated_at)::float8 * $3::float8)", rateLimitTable)
+	query := fmt.Sprintf(`INSERT INTO %[1]s (key, tokens, allowed, updated_at) VALUES ($1, $2::float8 - 1, TRUE, now())
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = CASE WHEN %[2]s >= 1 THEN %[2]s - 1 ELSE %[2]s END,
+			allowed = %[2]s >= 1,
+			updated_at = now()
+		RETURNING allowed, tokens`, rateLimitTable, refilled)
+
+	var allowed bool
+	var tokens float64
+	err := r.db.QueryRowContext(ctx, query, key, limit.Burst, limit.Rate).Scan(&allowed, &tokens)
+
+	return allowed, tokens, err
+}
+
+func (r *RateLimitPostgres) Sweep(ctx context.Context, idle time.Duration) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE updated_at < now() - $1 * interval '1 second'", rateLimitTable)
+	_, err := r.db.ExecContext(ctx, query, idle.Seconds())
+
+	return err
+}
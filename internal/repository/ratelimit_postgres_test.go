@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func TestRateLimitPostgres_Take(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := NewRateLimitPostgres(db)
+	limit := ratelimit.Limit{Rate: 0.5, Burst: 5, Window: time.Minute}
+
+	tests := []struct {
+		name        string
+		mock        func()
+		wantAllowed bool
+		wantTokens  float64
+		wantErr     bool
+	}{
+		{
+			name: "Allowed",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"allowed", "tokens"}).AddRow(true, 3.5)
+				mock.ExpectQuery("INSERT INTO rate_limits (.+) ON CONFLICT \\(key\\) DO UPDATE").
+					WithArgs("auth:ip:1", 5, 0.5).WillReturnRows(rows)
+			},
+			wantAllowed: true,
+			wantTokens:  3.5,
+		},
+		{
+			name: "Rejected",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"allowed", "tokens"}).AddRow(false, 0.25)
+				mock.ExpectQuery("INSERT INTO rate_limits").
+					WithArgs("auth:ip:1", 5, 0.5).WillReturnRows(rows)
+			},
+			wantTokens: 0.25,
+		},
+		{
+			name: "Error",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO rate_limits").
+					WithArgs("auth:ip:1", 5, 0.5).WillReturnError(context.DeadlineExceeded)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			allowed, tokens, err := r.Take(context.Background(), "auth:ip:1", limit)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantAllowed, allowed)
+				assert.Equal(t, tt.wantTokens, tokens)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRateLimitPostgres_Sweep(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := NewRateLimitPostgres(db)
+
+	mock.ExpectExec("DELETE FROM rate_limits WHERE updated_at <").
+		WithArgs(float64(600)).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	assert.NoError(t, r.Sweep(context.Background(), 10*time.Minute))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+)
+
+type ReconciliationMemory struct {
+	db *MemoryDB
+}
+
+func NewReconciliationMemory(db *MemoryDB) *ReconciliationMemory {
+	return &ReconciliationMemory{db: db}
+}
+
+func (r *ReconciliationMemory) Mismatches(ctx context.Context) (int64, []models.BalanceMismatch, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return 0, nil, err
+	}
+	defer r.db.unlock()
+
+	sums := make(map[uuid.UUID]*models.BalanceMismatch, len(r.db.wallets))
+	for id, wallet := range r.db.wallets {
+		sums[id] = &models.BalanceMismatch{WalletId: id, UserId: wallet.UserId, Status: wallet.Status, Stored: wallet.Amount}
+	}
+	for _, transaction := range r.db.transactions {
+		sum := sums[transaction.WalletId]
+		switch transaction.OperationType {
+		case models.Deposit:
+			sum.Deposits += transaction.Amount
+		case models.Withdraw:
+			sum.Withdrawals += transaction.Amount
+		}
+		sum.Transactions++
+	}
+
+	var mismatches []models.BalanceMismatch
+	for _, sum := range sums {
+		sum.Computed = sum.Deposits - sum.Withdrawals
+		sum.Difference = sum.Stored - sum.Computed
+		if sum.Difference != 0 {
+			mismatches = append(mismatches, *sum)
+		}
+	}
+	sortByCreation(mismatches, func(m models.BalanceMismatch) (time.Time, uuid.UUID) {
+		return r.db.wallets[m.WalletId].CreatedAt, m.WalletId
+	})
+
+	return int64(len(r.db.wallets)), mismatches, nil
+}
+
+func (r *ReconciliationMemory) OpenCase(ctx context.Context, mismatch models.BalanceMismatch) (uuid.UUID, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return uuid.Nil, err
+	}
+	defer r.db.unlock()
+
+	for _, c := range r.db.cases {
+		if c.WalletId == mismatch.WalletId && c.Status == models.CaseOpen {
+			return c.CaseId, nil
+		}
+	}
+
+	if _, ok := r.db.wallets[mismatch.WalletId]; !ok {
+		return uuid.Nil, errUnknownCaseWallet
+	}
+
+	id := uuid.New()
+	r.db.cases = append(r.db.cases, models.CorrectionCase{
+		CaseId:   id,
+		WalletId: mismatch.WalletId,
+		Stored:   mismatch.Stored,
+		Computed: mismatch.Computed,
+		Status:   models.CaseOpen,
+		OpenedAt: now(),
+	})
+
+	return id, nil
+}
+
+func (r *ReconciliationMemory) GetCases(ctx context.Context, status models.CaseStatus, page models.Page) ([]models.CorrectionCase, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var cases []models.CorrectionCase
+	for _, c := range r.db.cases {
+		if status == "" || c.Status == status {
+			cases = append(cases, c)
+		}
+	}
+	sortByCreation(cases, func(c models.CorrectionCase) (time.Time, uuid.UUID) {
+		return c.OpenedAt, c.CaseId
+	})
+
+	return paginate(cases, page), nil
+}
+
+func (r *ReconciliationMemory) ResolveCase(ctx context.Context, caseId uuid.UUID, resolution string) error {
+	if err := r.db.lock(ctx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	for i, c := range r.db.cases {
+		if c.CaseId == caseId && c.Status == models.CaseOpen {
+			resolvedAt := now()
+			r.db.cases[i].Status = models.CaseResolved
+			r.db.cases[i].ResolvedAt = &resolvedAt
+			r.db.cases[i].Resolution = &resolution
+			return nil
+		}
+	}
+
+	return sql.ErrNoRows
+}
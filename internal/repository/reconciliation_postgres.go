@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// mismatchesQuery selects the wallets whose balance differs from the sum of
+// their transactions. It is valid for both Postgres and SQLite.
+var mismatchesQuery = fmt.Sprintf(`SELECT w.wallet_id, w.user_id, w.status, w.amount AS stored,
+		COALESCE(t.deposits, 0) AS deposits,
+		COALESCE(t.withdrawals, 0) AS withdrawals,
+		COALESCE(t.deposits, 0) - COALESCE(t.withdrawals, 0) AS computed,
+		w.amount - (COALESCE(t.deposits, 0) - COALESCE(t.withdrawals, 0)) AS difference,
+		COALESCE(t.transactions, 0) AS transactions
+	FROM %s w
+	LEFT JOIN (
+		SELECT wallet_id,
+			CAST(SUM(CASE WHEN operation_type = 'DEPOSIT' THEN amount END) AS BIGINT) AS deposits,
+			CAST(SUM(CASE WHEN operation_type = 'WITHDRAW' THEN amount END) AS BIGINT) AS withdrawals,
+			COUNT(*) AS transactions
+		FROM %s
+		GROUP BY wallet_id
+	) t ON t.wallet_id = w.wallet_id
+	WHERE w.amount <> COALESCE(t.deposits, 0) - COALESCE(t.withdrawals, 0)
+	ORDER BY w.created_at, w.wallet_id`, walletTable, transactionTable)
+
+type ReconciliationPostgres struct {
+	db *sqlx.DB
+}
+
+func NewReconciliationPostgres(db *sqlx.DB) *ReconciliationPostgres {
+	return &ReconciliationPostgres{db: db}
+}
+
+func (r *ReconciliationPostgres) Mismatches(ctx context.Context) (int64, []models.BalanceMismatch, error) {
+	// Both queries see the same snapshot, so transactions committed between
+	// them can't show up as a mismatch.
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	var wallets int64
+	if err := tx.GetContext(ctx, &wallets, fmt.Sprintf("SELECT COUNT(*) FROM %s", walletTable)); err != nil {
+		return 0, nil, err
+	}
+
+	var mismatches []models.BalanceMismatch
+	if err := tx.SelectContext(ctx, &mismatches, mismatchesQuery); err != nil {
+		return 0, nil, err
+	}
+
+	return wallets, mismatches, tx.Commit()
+}
+
+func (r *ReconciliationPostgres) OpenCase(ctx context.Context, mismatch models.BalanceMismatch) (uuid.UUID, error) {
+	return openCase(ctx, r.db, mismatch, time.Now())
+}
+
+// openCase is OpenCase of the Postgres and SQLite repositories, which share
+// the upsert syntax.
+func openCase(ctx context.Context, db *sqlx.DB, mismatch models.BalanceMismatch, now time.Time) (uuid.UUID, error) {
+	var id uuid.UUID
+	query := fmt.Sprintf(`INSERT INTO %s (case_id, wallet_id, stored_amount, computed_amount, opened_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (wallet_id) WHERE status = 'OPEN' DO NOTHING
+		RETURNING case_id`, caseTable)
+	err := db.GetContext(ctx, &id, query, uuid.New(), mismatch.WalletId, mismatch.Stored, mismatch.Computed, now)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+
+	query = fmt.Sprintf("SELECT case_id FROM %s WHERE wallet_id = $1 AND status = 'OPEN'", caseTable)
+	err = db.GetContext(ctx, &id, query, mismatch.WalletId)
+
+	return id, err
+}
+
+func (r *ReconciliationPostgres) GetCases(ctx context.Context, status models.CaseStatus, page models.Page) ([]models.CorrectionCase, error) {
+	var cases []models.CorrectionCase
+	query, args := casesQuery(status, limitArg(page), page.Offset)
+	err := r.db.SelectContext(ctx, &cases, query, args...)
+
+	return cases, err
+}
+
+// casesQuery builds the query of GetCases, filtering by status unless it is
+// empty.
+func casesQuery(status models.CaseStatus, limit any, offset int) (string, []any) {
+	if status == "" {
+		return fmt.Sprintf("SELECT * FROM %s ORDER BY opened_at, case_id LIMIT $1 OFFSET $2", caseTable), []any{limit, offset}
+	}
+
+	return fmt.Sprintf("SELECT * FROM %s WHERE status = $1 ORDER BY opened_at, case_id LIMIT $2 OFFSET $3", caseTable), []any{status, limit, offset}
+}
+
+func (r *ReconciliationPostgres) ResolveCase(ctx context.Context, caseId uuid.UUID, resolution string) error {
+	return resolveCase(ctx, r.db, caseId, resolution, time.Now())
+}
+
+func resolveCase(ctx context.Context, db *sqlx.DB, caseId uuid.UUID, resolution string, now time.Time) error {
+	query := fmt.Sprintf("UPDATE %s SET status = 'RESOLVED', resolved_at = $1, resolution = $2 WHERE case_id = $3 AND status = 'OPEN'", caseTable)
+	result, err := db.ExecContext(ctx, query, now, resolution, caseId)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func TestReconciliation_MismatchesPostgres(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	r := NewReconciliationPostgres(db)
+	walletId := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM wallets").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	mock.ExpectQuery("FROM wallets w LEFT JOIN (.+) WHERE w.amount <> ").
+		WillReturnRows(sqlmock.NewRows([]string{"wallet_id", "user_id", "status", "stored", "deposits", "withdrawals", "computed", "difference", "transactions"}).
+			AddRow(walletId, 1, models.WalletActive, 45, 50, 20, 30, 15, 2))
+	mock.ExpectCommit()
+
+	wallets, mismatches, err := r.Mismatches(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), wallets)
+	assert.Equal(t, []models.BalanceMismatch{{
+		WalletId:     walletId,
+		UserId:       1,
+		Status:       models.WalletActive,
+		Stored:       45,
+		Computed:     30,
+		Difference:   15,
+		Deposits:     50,
+		Withdrawals:  20,
+		Transactions: 2,
+	}}, mismatches)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReconciliation_OpenCase(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	r := NewReconciliationPostgres(db)
+	mismatch := models.BalanceMismatch{WalletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"), Stored: 45, Computed: 30}
+	existing := uuid.MustParse("111e2222-e89b-12d3-a456-426614174000")
+
+	// The wallet already has an open case, so the insert returns nothing.
+	mock.ExpectQuery("INSERT INTO correction_cases (.+) ON CONFLICT \\(wallet_id\\) WHERE status = 'OPEN' DO NOTHING").
+		WithArgs(sqlmock.AnyArg(), mismatch.WalletId, mismatch.Stored, mismatch.Computed, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"case_id"}))
+	mock.ExpectQuery("SELECT case_id FROM correction_cases WHERE wallet_id = \\$1 AND status = 'OPEN'").
+		WithArgs(mismatch.WalletId).
+		WillReturnRows(sqlmock.NewRows([]string{"case_id"}).AddRow(existing))
+
+	id, err := r.OpenCase(context.Background(), mismatch)
+	assert.NoError(t, err)
+	assert.Equal(t, existing, id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReconciliation_ResolveCase(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	r := NewReconciliationPostgres(db)
+	caseId := uuid.MustParse("111e2222-e89b-12d3-a456-426614174000")
+
+	testTable := []struct {
+		name        string
+		rows        int64
+		expectedErr error
+	}{
+		{name: "Ok", rows: 1},
+		{name: "Not Open", rows: 0, expectedErr: sql.ErrNoRows},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			mock.ExpectExec("UPDATE correction_cases SET status = 'RESOLVED', resolved_at = \\$1, resolution = \\$2 WHERE case_id = \\$3 AND status = 'OPEN'").
+				WithArgs(sqlmock.AnyArg(), "fixed", caseId).
+				WillReturnResult(sqlmock.NewResult(0, testCase.rows))
+
+			err := r.ResolveCase(context.Background(), caseId, "fixed")
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, err, testCase.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
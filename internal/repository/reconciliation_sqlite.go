@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ReconciliationSQLite struct {
+	db *sqlx.DB
+}
+
+func NewReconciliationSQLite(db *sqlx.DB) *ReconciliationSQLite {
+	return &ReconciliationSQLite{db: db}
+}
+
+func (r *ReconciliationSQLite) Mismatches(ctx context.Context) (int64, []models.BalanceMismatch, error) {
+	// The transaction holds the write lock, so nothing changes between the
+	// queries.
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	var wallets int64
+	if err := tx.GetContext(ctx, &wallets, fmt.Sprintf("SELECT COUNT(*) FROM %s", walletTable)); err != nil {
+		return 0, nil, err
+	}
+
+	var mismatches []models.BalanceMismatch
+	if err := tx.SelectContext(ctx, &mismatches, mismatchesQuery); err != nil {
+		return 0, nil, err
+	}
+
+	return wallets, mismatches, tx.Commit()
+}
+
+func (r *ReconciliationSQLite) OpenCase(ctx context.Context, mismatch models.BalanceMismatch) (uuid.UUID, error) {
+	return openCase(ctx, r.db, mismatch, sqliteNow())
+}
+
+func (r *ReconciliationSQLite) GetCases(ctx context.Context, status models.CaseStatus, page models.Page) ([]models.CorrectionCase, error) {
+	var cases []models.CorrectionCase
+	query, args := casesQuery(status, sqliteLimitArg(page), page.Offset)
+	err := r.db.SelectContext(ctx, &cases, query, args...)
+
+	return cases, err
+}
+
+func (r *ReconciliationSQLite) ResolveCase(ctx context.Context, caseId uuid.UUID, resolution string) error {
+	return resolveCase(ctx, r.db, caseId, resolution, sqliteNow())
+}
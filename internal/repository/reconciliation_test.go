@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReconciliation_Mismatches writes wallet balances behind the back of
+// the repositories, as a manual fix or a bug would, and checks that the
+// drift is found.
+func TestReconciliation_Mismatches(t *testing.T) {
+	testTable := []struct {
+		name string
+		// open returns an empty repository and a function setting the
+		// stored balance of a wallet.
+		open func(t *testing.T) (*Repository, func(walletId uuid.UUID, amount int64))
+	}{
+		{
+			name: "Memory",
+			open: func(t *testing.T) (*Repository, func(uuid.UUID, int64)) {
+				db := NewMemoryDB()
+				repo := &Repository{
+					Authorization:  NewAuthMemory(db),
+					Wallet:         NewWalletMemory(db),
+					Transaction:    NewTransactionMemory(db),
+					Reconciliation: NewReconciliationMemory(db),
+				}
+				return repo, func(walletId uuid.UUID, amount int64) {
+					wallet := db.wallets[walletId]
+					wallet.Amount = amount
+					db.wallets[walletId] = wallet
+				}
+			},
+		},
+		{
+			name: "SQLite",
+			open: func(t *testing.T) (*Repository, func(uuid.UUID, int64)) {
+				db, err := NewSQLiteDB(Config{Path: filepath.Join(t.TempDir(), "wallets.db")})
+				require.NoError(t, err)
+				t.Cleanup(func() { db.Close() })
+
+				migrator, err := NewMigrator(db)
+				require.NoError(t, err)
+				require.NoError(t, migrator.Up())
+				require.NoError(t, migrator.Close())
+
+				return NewRepository(db), func(walletId uuid.UUID, amount int64) {
+					_, err := db.Exec(fmt.Sprintf("UPDATE %s SET amount = $1 WHERE wallet_id = $2", walletTable), amount, walletId)
+					require.NoError(t, err)
+				}
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			r, setAmount := testCase.open(t)
+
+			userId, err := r.CreateUser(ctx, models.SignUpInput{Name: "Test", Username: "alice", Password: "hash"})
+			require.NoError(t, err)
+
+			var ids []uuid.UUID
+			for range 3 {
+				id, err := r.Wallet.Create(ctx, userId)
+				require.NoError(t, err)
+				ids = append(ids, id)
+			}
+			for _, input := range []models.TransactionInput{
+				{WalletId: ids[0], OperationType: models.Deposit, Amount: 50},
+				{WalletId: ids[0], OperationType: models.Withdraw, Amount: 20},
+				{WalletId: ids[1], OperationType: models.Deposit, Amount: 10},
+			} {
+				_, err := r.Transaction.Create(ctx, input)
+				require.NoError(t, err)
+			}
+
+			setAmount(ids[0], 45)
+			setAmount(ids[2], 5)
+
+			wallets, mismatches, err := r.Mismatches(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), wallets)
+			assert.ElementsMatch(t, []models.BalanceMismatch{
+				{
+					WalletId:     ids[0],
+					UserId:       userId,
+					Status:       models.WalletActive,
+					Stored:       45,
+					Computed:     30,
+					Difference:   15,
+					Deposits:     50,
+					Withdrawals:  20,
+					Transactions: 2,
+				},
+				{
+					WalletId:   ids[2],
+					UserId:     userId,
+					Status:     models.WalletActive,
+					Stored:     5,
+					Difference: 5,
+				},
+			}, mismatches)
+		})
+	}
+}
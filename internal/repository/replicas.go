@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// replicaLagQuery returns whether the replica streams from the primary and
+// how many seconds it is behind. A streaming replica that has replayed
+// everything it received is not lagging, however old its last replayed
+// transaction is. One that doesn't stream receives nothing, so it can't
+// tell how far behind it is. Its status is only visible to roles with
+// pg_read_all_stats.
+const replicaLagQuery = `SELECT
+	EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming'),
+	CASE
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END`
+
+// Cluster is a Postgres primary with its read replicas.
+//
+// Writes, and reads that a write depends on, always go to the primary. Other
+// reads go to the replicas in turn, skipping those that failed their last
+// check or lag more than the allowed maximum, and fall back to the primary
+// when no replica is usable or the chosen one fails. A session that wrote
+// reads from the primary for a while afterwards, so it sees its own writes.
+// Sessions are tracked per instance.
+type Cluster struct {
+	primary  *sqlx.DB
+	replicas []*replica
+
+	maxLag   time.Duration
+	interval time.Duration
+	window   time.Duration
+
+	next atomic.Uint64
+
+	mu sync.Mutex
+	// writes holds when each session last wrote.
+	writes map[string]time.Time
+}
+
+type replica struct {
+	name   string
+	db     *sqlx.DB
+	usable atomic.Bool
+}
+
+// NewCluster sets up the replicas of cfg next to primary. Replicas are not
+// used until Run has checked them.
+func NewCluster(primary *sqlx.DB, cfg Config) (*Cluster, error) {
+	c := singleNode(primary)
+	c.maxLag = cfg.ReplicaMaxLag
+	c.interval = cfg.ReplicaCheckInterval
+	c.window = cfg.ReadYourWritesWindow
+
+	for _, r := range cfg.Replicas {
+		replicaCfg := cfg
+		replicaCfg.Host, replicaCfg.Port = r.Host, r.Port
+
+		db, err := openPostgres(replicaCfg, nil)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+
+		c.replicas = append(c.replicas, &replica{name: net.JoinHostPort(r.Host, r.Port), db: db})
+	}
+
+	return c, nil
+}
+
+// singleNode is a cluster without replicas.
+func singleNode(primary *sqlx.DB) *Cluster {
+	return &Cluster{primary: primary, writes: make(map[string]time.Time)}
+}
+
+func (c *Cluster) Primary() *sqlx.DB {
+	return c.primary
+}
+
+// Run checks the replicas every interval until ctx is done. It is a no-op
+// without replicas.
+func (c *Cluster) Run(ctx context.Context) error {
+	if len(c.replicas) == 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.checkReplicas(ctx)
+		c.forgetWrites()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reports how many replicas take reads. It never fails: reads fall back
+// to the primary, which has its own check.
+func (c *Cluster) Check(context.Context) (string, error) {
+	usable := 0
+	for _, r := range c.replicas {
+		if r.usable.Load() {
+			usable++
+		}
+	}
+
+	return fmt.Sprintf("%d of %d replicas usable", usable, len(c.replicas)), nil
+}
+
+// Close closes the replicas. The primary belongs to the caller.
+func (c *Cluster) Close() error {
+	var errs []error
+	for _, r := range c.replicas {
+		errs = append(errs, r.db.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Cluster) checkReplicas(ctx context.Context) {
+	for _, r := range c.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, c.interval)
+		var streaming bool
+		var lag float64
+		err := r.db.QueryRowContext(checkCtx, replicaLagQuery).Scan(&streaming, &lag)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		usable := err == nil && streaming && time.Duration(lag*float64(time.Second)) <= c.maxLag
+		if was := r.usable.Swap(usable); was != usable {
+			entry := logrus.WithFields(logrus.Fields{"replica": r.name, "lag_seconds": lag})
+			switch {
+			case usable:
+				entry.Info("replica takes reads again")
+			case err != nil:
+				entry.WithError(err).Warn("replica is down, reading from the primary")
+			case !streaming:
+				entry.Warn("replica doesn't stream from the primary, reading from the primary")
+			default:
+				entry.Warn("replica is lagging, reading from the primary")
+			}
+		}
+		metrics.ObserveReplica(r.name, lag, usable)
+	}
+}
+
+// read runs query against the database the read should go to, and against
+// the primary again if a replica fails it.
+func (c *Cluster) read(ctx context.Context, query func(db *sqlx.DB) error) error {
+	r := c.pickReplica(ctx)
+	if r == nil {
+		metrics.ObserveRead("primary")
+		return query(c.primary)
+	}
+
+	metrics.ObserveRead("replica")
+	err := query(r.db)
+	if err == nil || errors.Is(err, sql.ErrNoRows) || ctx.Err() != nil {
+		return err
+	}
+
+	// Taken out of rotation until the next check finds it healthy.
+	if r.usable.Swap(false) {
+		logrus.WithField("replica", r.name).WithError(err).Warn("replica query failed, reading from the primary")
+	}
+	metrics.ObserveRead("primary")
+	return query(c.primary)
+}
+
+// pickReplica returns the next usable replica, or nil when the read should
+// go to the primary.
+func (c *Cluster) pickReplica(ctx context.Context) *replica {
+	if len(c.replicas) == 0 || c.wroteRecently(ctx) {
+		return nil
+	}
+
+	start := c.next.Add(1)
+	for i := range uint64(len(c.replicas)) {
+		r := c.replicas[(start+i)%uint64(len(c.replicas))]
+		if r.usable.Load() {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// wrote records a successful write of the session of ctx.
+func (c *Cluster) wrote(ctx context.Context) {
+	keys := SessionKeys(ctx)
+	if len(c.replicas) == 0 || len(keys) == 0 {
+		return
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	for _, key := range keys {
+		c.writes[key] = now
+	}
+	c.mu.Unlock()
+}
+
+func (c *Cluster) wroteRecently(ctx context.Context) bool {
+	keys := SessionKeys(ctx)
+	if len(keys) == 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.ContainsFunc(keys, func(key string) bool {
+		at, ok := c.writes[key]
+		return ok && time.Since(at) < c.window
+	})
+}
+
+// forgetWrites drops sessions whose window has passed.
+func (c *Cluster) forgetWrites() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, at := range c.writes {
+		if time.Since(at) >= c.window {
+			delete(c.writes, key)
+		}
+	}
+}
+
+type sessionKey struct{}
+
+// WithSession adds keys identifying the client of a request, such as its
+// user or the wallet it writes to, to ctx. Reads of a session that wrote
+// under any of its keys go to the primary for a while.
+func WithSession(ctx context.Context, keys ...string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, append(slices.Clip(SessionKeys(ctx)), keys...))
+}
+
+// SessionKeys returns the keys WithSession added to ctx.
+func SessionKeys(ctx context.Context) []string {
+	keys, _ := ctx.Value(sessionKey{}).([]string)
+	return keys
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func newTestCluster(t *testing.T) (*Cluster, sqlmock.Sqlmock, sqlmock.Sqlmock) {
+	t.Helper()
+
+	primary, primaryMock, err := sqlmock.Newx()
+	require.NoError(t, err)
+	t.Cleanup(func() { primary.Close() })
+
+	replicaDB, replicaMock, err := sqlmock.Newx()
+	require.NoError(t, err)
+
+	c := singleNode(primary)
+	c.maxLag = time.Second
+	c.interval = time.Second
+	c.window = time.Minute
+	c.replicas = []*replica{{name: "replica:5432", db: replicaDB}}
+	t.Cleanup(func() { c.Close() })
+
+	return c, primaryMock, replicaMock
+}
+
+func walletRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"wallet_id", "user_id", "amount"}).AddRow(uuid.New(), 1, 0)
+}
+
+func TestCluster_Read(t *testing.T) {
+	testTable := []struct {
+		name        string
+		usable      bool
+		wrote       bool
+		replicaErr  error
+		wantReplica bool
+		wantPrimary bool
+	}{
+		{
+			name:        "Usable replica",
+			usable:      true,
+			wantReplica: true,
+		},
+		{
+			name:        "Unusable replica",
+			usable:      false,
+			wantPrimary: true,
+		},
+		{
+			name:        "Session wrote recently",
+			usable:      true,
+			wrote:       true,
+			wantPrimary: true,
+		},
+		{
+			name:        "Replica fails",
+			usable:      true,
+			replicaErr:  errors.New("connection refused"),
+			wantReplica: true,
+			wantPrimary: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c, primaryMock, replicaMock := newTestCluster(t)
+			c.replicas[0].usable.Store(testCase.usable)
+			r := NewWalletPostgres(c.Primary())
+			r.cluster = c
+
+			ctx := WithSession(context.Background(), "user:1")
+			if testCase.wrote {
+				c.wrote(ctx)
+			}
+
+			if testCase.wantReplica {
+				expect := replicaMock.ExpectQuery("SELECT (.+) FROM wallets")
+				if testCase.replicaErr != nil {
+					expect.WillReturnError(testCase.replicaErr)
+				} else {
+					expect.WillReturnRows(walletRows())
+				}
+			}
+			if testCase.wantPrimary {
+				primaryMock.ExpectQuery("SELECT (.+) FROM wallets").WillReturnRows(walletRows())
+			}
+
+			_, err := r.GetById(ctx, uuid.New())
+			assert.NoError(t, err)
+			assert.NoError(t, primaryMock.ExpectationsWereMet())
+			assert.NoError(t, replicaMock.ExpectationsWereMet())
+
+			if testCase.replicaErr != nil {
+				assert.False(t, c.replicas[0].usable.Load())
+			}
+		})
+	}
+}
+
+func TestCluster_CheckReplicas(t *testing.T) {
+	testTable := []struct {
+		name       string
+		streaming  bool
+		lag        float64
+		err        error
+		wantUsable bool
+	}{
+		{
+			name:       "Caught up",
+			streaming:  true,
+			lag:        0,
+			wantUsable: true,
+		},
+		{
+			name:       "Lagging",
+			streaming:  true,
+			lag:        3,
+			wantUsable: false,
+		},
+		{
+			name:       "Not streaming",
+			streaming:  false,
+			lag:        0,
+			wantUsable: false,
+		},
+		{
+			name:       "Down",
+			err:        errors.New("connection refused"),
+			wantUsable: false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c, _, replicaMock := newTestCluster(t)
+
+			expect := replicaMock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming'\\), CASE")
+			if testCase.err != nil {
+				expect.WillReturnError(testCase.err)
+			} else {
+				expect.WillReturnRows(sqlmock.NewRows([]string{"streaming", "lag"}).AddRow(testCase.streaming, testCase.lag))
+			}
+
+			c.checkReplicas(context.Background())
+
+			assert.Equal(t, testCase.wantUsable, c.replicas[0].usable.Load())
+			assert.NoError(t, replicaMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCluster_ReadYourWrites(t *testing.T) {
+	c, _, _ := newTestCluster(t)
+	c.window = 50 * time.Millisecond
+	c.replicas[0].usable.Store(true)
+
+	c.wrote(WithSession(context.Background(), "wallet:1"))
+
+	// The owner reading the wallet sees the write, another user doesn't wait
+	// for it.
+	owner := WithSession(context.Background(), "user:1", "wallet:1")
+	assert.Nil(t, c.pickReplica(owner))
+	assert.NotNil(t, c.pickReplica(WithSession(context.Background(), "user:2")))
+	assert.NotNil(t, c.pickReplica(context.Background()))
+
+	time.Sleep(c.window)
+	assert.NotNil(t, c.pickReplica(owner))
+
+	c.forgetWrites()
+	assert.Empty(t, c.writes)
+}
@@ -1,40 +1,151 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/Yoshisoul/rest-wallets/internal/models"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
 type Authorization interface {
-	CreateUser(user models.SignUpInput) (int, error)
-	GetUser(username, password string) (models.User, error)
+	CreateUser(ctx context.Context, user models.SignUpInput) (int, error)
+	GetUser(ctx context.Context, username, password string) (models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (models.User, error)
+	UpdatePassword(ctx context.Context, username, password string) error
 }
 
 type Wallet interface {
-	Create(userId int) (uuid.UUID, error)
-	GetAllFromUser(userId int) ([]models.Wallet, error)
-	GetByIdFromUser(userId int, walletId uuid.UUID) (models.Wallet, error)
-	GetById(walletId uuid.UUID) (models.Wallet, error)
-	Delete(userId int, walletId uuid.UUID) error
+	Create(ctx context.Context, userId int) (uuid.UUID, error)
+	// GetAllFromUser lists the wallets of the user matching filter, sorted
+	// as it asks.
+	GetAllFromUser(ctx context.Context, userId int, filter models.WalletFilter, page models.Page) ([]models.Wallet, error)
+	GetByIdFromUser(ctx context.Context, userId int, walletId uuid.UUID) (models.Wallet, error)
+	GetById(ctx context.Context, walletId uuid.UUID) (models.Wallet, error)
+	Close(ctx context.Context, userId int, walletId uuid.UUID, input models.CloseWalletInput) error
+	SetFrozen(ctx context.Context, walletId uuid.UUID, frozen bool) error
+	// Update changes the labels of a wallet of the user and returns it.
+	// Making it the default unsets the previous default wallet of the user.
+	Update(ctx context.Context, userId int, walletId uuid.UUID, update models.WalletUpdate) (models.Wallet, error)
 }
 
 type Transaction interface {
-	Create(transaction models.TransactionInput) (uuid.UUID, error)
-	GetAll() ([]models.Transaction, error)
-	GetById(transactionId uuid.UUID) (models.Transaction, error)
+	Create(ctx context.Context, transaction models.TransactionInput) (uuid.UUID, error)
+	CreateAdjustment(ctx context.Context, adjustment models.AdjustmentInput) (uuid.UUID, error)
+	// GetAll and GetById leave out the annotations, which are only for the
+	// owner of the wallet. GetAllFromWallet includes them.
+	GetAll(ctx context.Context, filter models.TransactionFilter, page models.Page) ([]models.Transaction, error)
+	GetAllFromWallet(ctx context.Context, walletId uuid.UUID) ([]models.Transaction, error)
+	GetById(ctx context.Context, transactionId uuid.UUID) (models.Transaction, error)
+	// Annotate adds the next version of the annotation of the transaction.
+	Annotate(ctx context.Context, transactionId uuid.UUID, input models.AnnotationInput) (models.Annotation, error)
+	// GetAnnotations lists the versions of the annotation newest first.
+	GetAnnotations(ctx context.Context, transactionId uuid.UUID, page models.Page) ([]models.Annotation, error)
+}
+
+// Category keeps the categories of each user.
+type Category interface {
+	// Create fails with models.ErrCategoryExists if the user has a category
+	// of the same name.
+	Create(ctx context.Context, userId int, input models.CategoryInput) (uuid.UUID, error)
+	// GetAll lists the categories of the user by name.
+	GetAll(ctx context.Context, userId int, page models.Page) ([]models.Category, error)
+	GetById(ctx context.Context, userId int, categoryId uuid.UUID) (models.Category, error)
+	// Delete fails with models.ErrCategoryInUse while the category has
+	// subcategories or is referenced by a transaction or by any version of
+	// an annotation.
+	Delete(ctx context.Context, userId int, categoryId uuid.UUID) error
+}
+
+// Balance reads past balances from the transactions, starting from the
+// latest balance snapshot so only the transactions since are summed.
+type Balance interface {
+	// At returns the balance of the wallet before each moment of at: the sum
+	// of the transactions made before it.
+	At(ctx context.Context, walletId uuid.UUID, at []time.Time) ([]int64, error)
+	// TakeSnapshots records the balance before at of every wallet open at
+	// that moment, unless it is already recorded, and returns the number of
+	// snapshots taken.
+	TakeSnapshots(ctx context.Context, at time.Time) (int64, error)
+}
+
+// Reconciliation compares the stored wallet balances with their
+// transactions and keeps the correction cases opened for the differences.
+type Reconciliation interface {
+	// Mismatches returns the number of wallets checked and those whose
+	// balance differs from the sum of their transactions, all read at the
+	// same moment.
+	Mismatches(ctx context.Context) (int64, []models.BalanceMismatch, error)
+	// OpenCase opens a correction case for the mismatch unless its wallet
+	// has an open case already, and returns the id of the open case.
+	OpenCase(ctx context.Context, mismatch models.BalanceMismatch) (uuid.UUID, error)
+	// GetCases lists cases oldest first, only those in status unless it is
+	// empty.
+	GetCases(ctx context.Context, status models.CaseStatus, page models.Page) ([]models.CorrectionCase, error)
+	// ResolveCase closes an open case, or fails with sql.ErrNoRows.
+	ResolveCase(ctx context.Context, caseId uuid.UUID, resolution string) error
+}
+
+// Chain reads the hash chains of the wallet transactions and keeps the
+// signed checkpoints of their heads.
+type Chain interface {
+	// Links returns the transactions of the wallet in chain order.
+	Links(ctx context.Context, walletId uuid.UUID) ([]models.Transaction, error)
+	// Seal computes the hashes of the transactions recorded before the
+	// chain, once, and returns how many it sealed. Hashes cleared later are
+	// never computed again.
+	Seal(ctx context.Context) (int64, error)
+	// Heads returns the last transaction created before at of every wallet
+	// that has one, ordered by wallet id.
+	Heads(ctx context.Context, at time.Time) ([]models.ChainHead, error)
+	// CreateCheckpoint records the checkpoint unless one exists at the same
+	// moment, and returns the one recorded.
+	CreateCheckpoint(ctx context.Context, checkpoint models.Checkpoint) (models.Checkpoint, error)
+	// GetCheckpoints lists checkpoints newest first.
+	GetCheckpoints(ctx context.Context, page models.Page) ([]models.Checkpoint, error)
+	GetCheckpoint(ctx context.Context, checkpointId uuid.UUID) (models.Checkpoint, error)
 }
 
 type Repository struct {
 	Authorization
 	Wallet
 	Transaction
+	Category
+	Balance
+	Reconciliation
+	Chain
 }
 
+// NewRepository returns the repositories for the driver db was opened with.
 func NewRepository(db *sqlx.DB) *Repository {
+	if db.DriverName() == "sqlite" {
+		return NewSQLiteRepository(db)
+	}
+
+	return &Repository{
+		Authorization:  NewAuthPostgres(db),
+		Wallet:         NewWalletPostgres(db),
+		Transaction:    NewTransactionPostgres(db),
+		Category:       NewCategoryPostgres(db),
+		Balance:        NewBalancePostgres(db),
+		Reconciliation: NewReconciliationPostgres(db),
+		Chain:          NewChainPostgres(db),
+	}
+}
+
+// NewClusterRepository returns Postgres repositories that send reads to the
+// replicas of cluster.
+func NewClusterRepository(cluster *Cluster) *Repository {
+	db := cluster.Primary()
+
 	return &Repository{
-		Authorization: NewAuthPostgres(db),
-		Wallet:        NewWalletPostgres(db),
-		Transaction:   NewTransactionPostgres(db),
+		Authorization:  NewAuthPostgres(db),
+		Wallet:         &WalletPostgres{db: db, cluster: cluster},
+		Transaction:    &TransactionPostgres{db: db, cluster: cluster},
+		Category:       NewCategoryPostgres(db),
+		Balance:        &BalancePostgres{db: db, cluster: cluster},
+		Reconciliation: NewReconciliationPostgres(db),
+		Chain:          NewChainPostgres(db),
 	}
 }
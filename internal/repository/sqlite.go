@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/XSAM/otelsql"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/jmoiron/sqlx"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// sqliteBusyTimeout is how long a transaction waits for the write lock held
+// by another one before failing with SQLITE_BUSY.
+const sqliteBusyTimeout = 5 * time.Second
+
+// NewSQLiteDB opens the database file at cfg.Path, creating it if needed.
+//
+// SQLite has no SELECT ... FOR UPDATE. Instead every transaction starts with
+// BEGIN IMMEDIATE and takes the database write lock up front, so a wallet
+// read inside a transaction can't change before the transaction ends, as
+// with lockWallet in Postgres. Writes are serialized; thanks to WAL, reads
+// outside transactions don't wait for them.
+func NewSQLiteDB(cfg Config) (*sqlx.DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", sqliteBusyTimeout.Milliseconds()))
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+
+	sqlDB, err := otelsql.Open("sqlite", "file:"+cfg.Path+"?"+params.Encode(),
+		otelsql.WithAttributes(semconv.DBSystemSqlite),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	db := sqlx.NewDb(sqlDB, "sqlite")
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// NewSQLiteRepository returns the repositories of a database opened with
+// NewSQLiteDB.
+func NewSQLiteRepository(db *sqlx.DB) *Repository {
+	return &Repository{
+		Authorization:  NewAuthSQLite(db),
+		Wallet:         NewWalletSQLite(db),
+		Transaction:    NewTransactionSQLite(db),
+		Category:       NewCategorySQLite(db),
+		Balance:        NewBalanceSQLite(db),
+		Reconciliation: NewReconciliationSQLite(db),
+		Chain:          NewChainSQLite(db),
+	}
+}
+
+// sqliteNow returns the current time in UTC. Timestamps are stored as text,
+// which only sorts in time order when every value has the same zone.
+func sqliteNow() time.Time {
+	return time.Now().UTC()
+}
+
+// sqliteLimitArg is limitArg for SQLite, where a negative LIMIT returns every
+// row and NULL is an error.
+func sqliteLimitArg(page models.Page) int {
+	if page.Limit <= 0 {
+		return -1
+	}
+	return page.Limit
+}
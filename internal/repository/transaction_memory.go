@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+)
+
+type TransactionMemory struct {
+	db *MemoryDB
+}
+
+func NewTransactionMemory(db *MemoryDB) *TransactionMemory {
+	return &TransactionMemory{db: db}
+}
+
+func (r *TransactionMemory) Create(ctx context.Context, transaction models.TransactionInput) (uuid.UUID, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return uuid.Nil, err
+	}
+	defer r.db.unlock()
+
+	wallet, ok := r.db.wallets[transaction.WalletId]
+	if !ok {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	if err := wallet.CheckVersion(transaction.ExpectedVersion); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := wallet.CheckOperation(transaction.OperationType, transaction.Amount); err != nil {
+		return uuid.Nil, err
+	}
+
+	id, err := r.db.applyTransaction(transaction)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	metrics.ObserveTransaction(transaction.OperationType, transaction.Amount)
+	return id, nil
+}
+
+func (r *TransactionMemory) CreateAdjustment(ctx context.Context, adjustment models.AdjustmentInput) (uuid.UUID, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return uuid.Nil, err
+	}
+	defer r.db.unlock()
+
+	wallet, ok := r.db.wallets[adjustment.WalletId]
+	if !ok {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	if err := wallet.CheckAdjustment(adjustment.OperationType, adjustment.Amount); err != nil {
+		return uuid.Nil, err
+	}
+
+	// Checked before applying the transaction, which can't be undone.
+	if adjustment.Reason == "" {
+		return uuid.Nil, errEmptyReason
+	}
+
+	id, err := r.db.applyTransaction(models.TransactionInput{
+		WalletId:      adjustment.WalletId,
+		OperationType: adjustment.OperationType,
+		Amount:        adjustment.Amount,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	r.db.adjustments[id] = memoryAdjustment{reason: adjustment.Reason, createdAt: now()}
+
+	metrics.ObserveTransaction(adjustment.OperationType, adjustment.Amount)
+	return id, nil
+}
+
+func (r *TransactionMemory) GetAll(ctx context.Context, filter models.TransactionFilter, page models.Page) ([]models.Transaction, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var transactions []models.Transaction
+	for _, transaction := range r.db.transactions {
+		if matchTransaction(transaction, filter) {
+			transactions = append(transactions, transaction)
+		}
+	}
+	sortByCreation(transactions, transactionKey)
+
+	return paginate(transactions, page), nil
+}
+
+// matchTransaction is filterTransactions for the memory repository.
+func matchTransaction(transaction models.Transaction, filter models.TransactionFilter) bool {
+	if filter.CategoryId != nil && (transaction.CategoryId == nil || *transaction.CategoryId != *filter.CategoryId) {
+		return false
+	}
+
+	if filter.ExternalRef != "" && (transaction.ExternalRef == nil || *transaction.ExternalRef != filter.ExternalRef) {
+		return false
+	}
+
+	if filter.Search != "" && (transaction.Description == nil ||
+		!strings.Contains(strings.ToLower(*transaction.Description), strings.ToLower(filter.Search))) {
+		return false
+	}
+
+	if len(filter.Metadata) > 0 {
+		values := transaction.Metadata.Values()
+		for key, value := range filter.Metadata {
+			if actual, ok := values[key]; !ok || actual != value {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (r *TransactionMemory) GetAllFromWallet(ctx context.Context, walletId uuid.UUID) ([]models.Transaction, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var transactions []models.Transaction
+	for _, transaction := range r.db.transactions {
+		if transaction.WalletId == walletId {
+			transactions = append(transactions, r.db.annotated(transaction))
+		}
+	}
+	sortByCreation(transactions, transactionKey)
+
+	return transactions, nil
+}
+
+func (r *TransactionMemory) GetById(ctx context.Context, transactionId uuid.UUID) (models.Transaction, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return models.Transaction{}, err
+	}
+	defer r.db.unlock()
+
+	for _, transaction := range r.db.transactions {
+		if transaction.TransactionId == transactionId {
+			return transaction, nil
+		}
+	}
+
+	return models.Transaction{}, sql.ErrNoRows
+}
+
+func (r *TransactionMemory) Annotate(ctx context.Context, transactionId uuid.UUID, input models.AnnotationInput) (models.Annotation, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return models.Annotation{}, err
+	}
+	defer r.db.unlock()
+
+	if !slices.ContainsFunc(r.db.transactions, func(t models.Transaction) bool { return t.TransactionId == transactionId }) {
+		return models.Annotation{}, sql.ErrNoRows
+	}
+
+	if input.CategoryId != nil {
+		if _, ok := r.db.categories[*input.CategoryId]; !ok {
+			return models.Annotation{}, errUnknownAnnotationCategory
+		}
+	}
+
+	versions := r.db.annotations[transactionId]
+	annotation := models.Annotation{
+		TransactionId: transactionId,
+		Version:       int64(len(versions) + 1),
+		CategoryId:    input.CategoryId,
+		Notes:         input.Notes,
+		CreatedAt:     now(),
+	}
+	r.db.annotations[transactionId] = append(versions, annotation)
+
+	return annotation, nil
+}
+
+func (r *TransactionMemory) GetAnnotations(ctx context.Context, transactionId uuid.UUID, page models.Page) ([]models.Annotation, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	annotations := slices.Clone(r.db.annotations[transactionId])
+	slices.Reverse(annotations)
+
+	return paginate(annotations, page), nil
+}
+
+// annotated returns the transaction with its current annotation. db must be
+// locked.
+func (db *MemoryDB) annotated(transaction models.Transaction) models.Transaction {
+	if versions := db.annotations[transaction.TransactionId]; len(versions) > 0 {
+		annotation := versions[len(versions)-1]
+		transaction.Annotation = &annotation
+	}
+
+	return transaction
+}
+
+func transactionKey(t models.Transaction) (time.Time, uuid.UUID) {
+	return t.CreatedAt, t.TransactionId
+}
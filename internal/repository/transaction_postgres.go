@@ -1,9 +1,14 @@
 package repository
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
 	"github.com/Yoshisoul/rest-wallets/internal/models"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -11,67 +16,319 @@ import (
 
 type TransactionPostgres struct {
 	db *sqlx.DB
+	// cluster routes reads to replicas.
+	cluster *Cluster
 }
 
 func NewTransactionPostgres(db *sqlx.DB) *TransactionPostgres {
-	return &TransactionPostgres{db: db}
+	return &TransactionPostgres{db: db, cluster: singleNode(db)}
 }
 
-func (r *TransactionPostgres) Create(transaction models.TransactionInput) (uuid.UUID, error) {
-	tx, err := r.db.Begin()
+func (r *TransactionPostgres) Create(ctx context.Context, transaction models.TransactionInput) (uuid.UUID, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return uuid.Nil, err
 	}
 
-	var id uuid.UUID
-	query := fmt.Sprintf("INSERT INTO %s (transaction_id, wallet_id, operation_type, amount, created_at) values ($1, $2, $3, $4, $5) RETURNING transaction_id", transactionTable)
+	wallet, err := lockWallet(ctx, tx, transaction.WalletId)
+	if err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	if err := wallet.CheckVersion(transaction.ExpectedVersion); err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	if err := wallet.CheckOperation(transaction.OperationType, transaction.Amount); err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	id, err := applyTransaction(ctx, tx, transaction)
+	if err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, err
+	}
+
+	r.cluster.wrote(ctx)
+	metrics.ObserveTransaction(transaction.OperationType, transaction.Amount)
+	return id, nil
+}
+
+func (r *TransactionPostgres) CreateAdjustment(ctx context.Context, adjustment models.AdjustmentInput) (uuid.UUID, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	wallet, err := lockWallet(ctx, tx, adjustment.WalletId)
+	if err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	if err := wallet.CheckAdjustment(adjustment.OperationType, adjustment.Amount); err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
 
-	row := r.db.QueryRow(query, uuid.New(), transaction.WalletId, transaction.OperationType, transaction.Amount, time.Now())
-	if err := row.Scan(&id); err != nil {
+	id, err := applyTransaction(ctx, tx, models.TransactionInput{
+		WalletId:      adjustment.WalletId,
+		OperationType: adjustment.OperationType,
+		Amount:        adjustment.Amount,
+	})
+	if err != nil {
 		tx.Rollback()
 		return uuid.Nil, err
 	}
 
-	lockQuery := fmt.Sprintf("SELECT amount FROM %s WHERE wallet_id = $1 FOR UPDATE", walletTable)
-	_, err = tx.Exec(lockQuery, transaction.WalletId)
+	query := fmt.Sprintf("INSERT INTO %s (transaction_id, reason, created_at) values ($1, $2, $3)", adjustmentTable)
+	_, err = tx.ExecContext(ctx, query, id, adjustment.Reason, time.Now())
 	if err != nil {
 		tx.Rollback()
 		return uuid.Nil, err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, err
+	}
+
+	r.cluster.wrote(ctx)
+	metrics.ObserveTransaction(adjustment.OperationType, adjustment.Amount)
+	return id, nil
+}
+
+// applyTransaction records the transaction, chained to the last one of the
+// wallet, and moves the wallet balance accordingly. The wallet row must
+// already be locked by tx.
+func applyTransaction(ctx context.Context, tx *sqlx.Tx, transaction models.TransactionInput) (uuid.UUID, error) {
 	var updateQuery string
 	switch transaction.OperationType {
 	case models.Deposit:
-		updateQuery = fmt.Sprintf("UPDATE %s SET amount = amount + $1, updated_at = $2 WHERE wallet_id = $3", walletTable)
+		updateQuery = fmt.Sprintf("UPDATE %s SET amount = amount + $1, updated_at = $2, version = version + 1 WHERE wallet_id = $3", walletTable)
 	case models.Withdraw:
-		updateQuery = fmt.Sprintf("UPDATE %s SET amount = amount - $1, updated_at = $2 WHERE wallet_id = $3", walletTable)
+		updateQuery = fmt.Sprintf("UPDATE %s SET amount = amount - $1, updated_at = $2, version = version + 1 WHERE wallet_id = $3", walletTable)
+	default:
+		return uuid.Nil, models.ErrUnknownOperation
 	}
 
-	_, err = tx.Exec(updateQuery, transaction.Amount, time.Now(), transaction.WalletId)
+	head, err := chainHead(ctx, tx, transaction.WalletId)
 	if err != nil {
-		tx.Rollback()
 		return uuid.Nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
+	// Truncated to the precision Postgres stores, so the hash matches the
+	// stored row.
+	link := newLink(head, transaction, time.Now().Truncate(time.Microsecond))
+	id, err := insertLink(ctx, tx, link)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, updateQuery, transaction.Amount, time.Now(), transaction.WalletId); err != nil {
 		return uuid.Nil, err
 	}
 
 	return id, nil
 }
 
-func (r *TransactionPostgres) GetAll() ([]models.Transaction, error) {
-	var transactions []models.Transaction
-	query := fmt.Sprintf("SELECT * FROM %s", transactionTable)
-	err := r.db.Select(&transactions, query)
+func (r *TransactionPostgres) GetAll(ctx context.Context, filter models.TransactionFilter, page models.Page) ([]models.Transaction, error) {
+	var transactions []annotatedTransaction
+	where, args := filterTransactions(filter, postgresMetadataMatch)
+	query := fmt.Sprintf("%s%s ORDER BY t.created_at, t.transaction_id LIMIT $%d OFFSET $%d", transactionsSelect, where, len(args)+1, len(args)+2)
+	err := r.cluster.read(ctx, func(db *sqlx.DB) error {
+		transactions = nil
+		return db.SelectContext(ctx, &transactions, query, append(args, limitArg(page), page.Offset)...)
+	})
 
-	return transactions, err
+	return annotated(transactions), err
 }
 
-func (r *TransactionPostgres) GetById(transactionId uuid.UUID) (models.Transaction, error) {
-	var transaction models.Transaction
-	query := fmt.Sprintf("SELECT * FROM %s WHERE transaction_id = $1", transactionTable)
-	err := r.db.Get(&transaction, query, transactionId)
+func (r *TransactionPostgres) GetAllFromWallet(ctx context.Context, walletId uuid.UUID) ([]models.Transaction, error) {
+	var transactions []annotatedTransaction
+	query := fmt.Sprintf("%s WHERE t.wallet_id = $1 ORDER BY t.created_at", annotatedTransactionsSelect)
+	err := r.cluster.read(ctx, func(db *sqlx.DB) error {
+		transactions = nil
+		return db.SelectContext(ctx, &transactions, query, walletId)
+	})
+
+	return annotated(transactions), err
+}
+
+func (r *TransactionPostgres) GetById(ctx context.Context, transactionId uuid.UUID) (models.Transaction, error) {
+	var transaction annotatedTransaction
+	query := fmt.Sprintf("%s WHERE t.transaction_id = $1", transactionsSelect)
+	err := r.cluster.read(ctx, func(db *sqlx.DB) error {
+		return db.GetContext(ctx, &transaction, query, transactionId)
+	})
+
+	return transaction.transaction(), err
+}
+
+func (r *TransactionPostgres) Annotate(ctx context.Context, transactionId uuid.UUID, input models.AnnotationInput) (models.Annotation, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Annotation{}, err
+	}
+
+	// The transaction row lock serializes the versions of its annotation.
+	var id uuid.UUID
+	query := fmt.Sprintf("SELECT transaction_id FROM %s WHERE transaction_id = $1 FOR UPDATE", transactionTable)
+	if err := tx.GetContext(ctx, &id, query, transactionId); err != nil {
+		tx.Rollback()
+		return models.Annotation{}, err
+	}
+
+	annotation, err := annotate(ctx, tx, transactionId, input, time.Now().Truncate(time.Microsecond))
+	if err != nil {
+		tx.Rollback()
+		return models.Annotation{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Annotation{}, err
+	}
+
+	r.cluster.wrote(ctx)
+	return annotation, nil
+}
+
+func (r *TransactionPostgres) GetAnnotations(ctx context.Context, transactionId uuid.UUID, page models.Page) ([]models.Annotation, error) {
+	var annotations []models.Annotation
+	query := fmt.Sprintf("SELECT * FROM %s WHERE transaction_id = $1 ORDER BY version DESC LIMIT $2 OFFSET $3", annotationTable)
+	err := r.cluster.read(ctx, func(db *sqlx.DB) error {
+		annotations = nil
+		return db.SelectContext(ctx, &annotations, query, transactionId, limitArg(page), page.Offset)
+	})
+
+	return annotations, err
+}
+
+// annotate adds the next version of the annotation of the transaction. tx
+// must serialize the annotations of the transaction.
+func annotate(ctx context.Context, tx *sqlx.Tx, transactionId uuid.UUID, input models.AnnotationInput, createdAt time.Time) (models.Annotation, error) {
+	annotation := models.Annotation{
+		TransactionId: transactionId,
+		CategoryId:    input.CategoryId,
+		Notes:         input.Notes,
+		CreatedAt:     createdAt,
+	}
+
+	query := fmt.Sprintf("SELECT COALESCE(MAX(version), 0) + 1 FROM %s WHERE transaction_id = $1", annotationTable)
+	if err := tx.GetContext(ctx, &annotation.Version, query, transactionId); err != nil {
+		return models.Annotation{}, err
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (transaction_id, version, category_id, notes, created_at)
+		VALUES (:transaction_id, :version, :category_id, :notes, :created_at)`, annotationTable)
+	if _, err := tx.NamedExecContext(ctx, query, annotation); err != nil {
+		return models.Annotation{}, err
+	}
+
+	return annotation, nil
+}
+
+// transactionsSelect selects the transactions, as t, without their
+// annotations, which are only for the owner of the wallet. It is valid for
+// both Postgres and SQLite.
+var transactionsSelect = fmt.Sprintf("SELECT t.* FROM %s t", transactionTable)
+
+// annotatedTransactionsSelect is transactionsSelect with the current
+// annotation, as a.
+var annotatedTransactionsSelect = fmt.Sprintf(`SELECT t.*, a.version AS annotation_version, a.category_id AS annotation_category_id,
+		a.notes AS annotation_notes, a.created_at AS annotation_created_at
+	FROM %[1]s t
+	LEFT JOIN %[2]s a ON a.transaction_id = t.transaction_id
+		AND a.version = (SELECT MAX(version) FROM %[2]s WHERE transaction_id = t.transaction_id)`, transactionTable, annotationTable)
+
+// annotatedTransaction is a row of transactionsSelect or of
+// annotatedTransactionsSelect.
+type annotatedTransaction struct {
+	models.Transaction
+	AnnotationVersion    *int64     `db:"annotation_version"`
+	AnnotationCategoryId *uuid.UUID `db:"annotation_category_id"`
+	AnnotationNotes      *string    `db:"annotation_notes"`
+	AnnotationCreatedAt  *time.Time `db:"annotation_created_at"`
+}
+
+func (t annotatedTransaction) transaction() models.Transaction {
+	transaction := t.Transaction
+	// Postgres renders JSONB its own way.
+	if metadata, err := transaction.Metadata.Canonical(); err == nil {
+		transaction.Metadata = metadata
+	}
+	if t.AnnotationVersion != nil {
+		transaction.Annotation = &models.Annotation{
+			TransactionId: transaction.TransactionId,
+			Version:       *t.AnnotationVersion,
+			CategoryId:    t.AnnotationCategoryId,
+			Notes:         *t.AnnotationNotes,
+			CreatedAt:     *t.AnnotationCreatedAt,
+		}
+	}
+
+	return transaction
+}
+
+func annotated(rows []annotatedTransaction) []models.Transaction {
+	if rows == nil {
+		return nil
+	}
+
+	transactions := make([]models.Transaction, len(rows))
+	for i, row := range rows {
+		transactions[i] = row.transaction()
+	}
+
+	return transactions
+}
+
+// Conditions on a top-level metadata value as text, given the parameters
+// of its key and of the value.
+const (
+	postgresMetadataMatch = "t.metadata ->> %s::text = %s"
+	// json_each gives booleans as 1 and 0.
+	sqliteMetadataMatch = `EXISTS (SELECT 1 FROM json_each(t.metadata) WHERE key = %s
+		AND CASE type WHEN 'true' THEN 'true' WHEN 'false' THEN 'false' ELSE CAST(value AS TEXT) END = %s)`
+)
+
+// likeEscaper escapes the wildcards of LIKE patterns, with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// filterTransactions returns the WHERE clause of transactionsSelect matching
+// filter, empty if it matches every transaction, and its arguments from $1.
+// metadataMatch is the condition on a metadata value of the database.
+func filterTransactions(filter models.TransactionFilter, metadataMatch string) (string, []any) {
+	var conditions []string
+	var args []any
+	param := func(arg any) string {
+		args = append(args, arg)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.CategoryId != nil {
+		conditions = append(conditions, "t.category_id = "+param(*filter.CategoryId))
+	}
+	if filter.ExternalRef != "" {
+		conditions = append(conditions, "t.external_ref = "+param(filter.ExternalRef))
+	}
+	if filter.Search != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(filter.Search)) + "%"
+		conditions = append(conditions, fmt.Sprintf(`lower(t.description) LIKE %s ESCAPE '\'`, param(pattern)))
+	}
+	for _, key := range slices.Sorted(maps.Keys(filter.Metadata)) {
+		conditions = append(conditions, fmt.Sprintf(metadataMatch, param(key), param(filter.Metadata[key])))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
 
-	return transaction, err
+	return " WHERE " + strings.Join(conditions, " AND "), args
 }
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -21,12 +22,22 @@ func TestTransaction_Create(t *testing.T) {
 
 	type mockBehavior func(input models.TransactionInput)
 
+	walletRows := func(walletId uuid.UUID, amount int64, status models.WalletStatus) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"wallet_id", "user_id", "amount", "created_at", "updated_at", "status", "closed_at", "close_reason"}).
+			AddRow(walletId, 1, amount, time.Now(), time.Now(), status, nil, nil)
+	}
+	// The rows above have no version column, so the wallet is at version 0.
+	staleVersion := int64(3)
+	description, externalRef := "Books", "order-1"
+	categoryId := uuid.MustParse("333e4444-e89b-12d3-a456-426614174000")
+
 	testTable := []struct {
 		name         string
 		input        models.TransactionInput
 		expectedId   uuid.UUID
 		mockBehavior mockBehavior
 		wantErr      bool
+		expectedErr  error
 	}{
 		{
 			name: "Ok Deposit",
@@ -38,14 +49,17 @@ func TestTransaction_Create(t *testing.T) {
 			expectedId: uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"),
 			mockBehavior: func(input models.TransactionInput) {
 				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 0, models.WalletActive))
+				mock.ExpectQuery("SELECT wallet_id, seq, hash FROM transactions WHERE wallet_id = \\$1 ORDER BY seq DESC LIMIT 1").
+					WithArgs(input.WalletId).
+					WillReturnRows(headRows(input.WalletId, 0, ""))
 				mock.ExpectQuery("INSERT INTO transactions").
-					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg()).
+					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg(), int64(1), "", sqlmock.AnyArg(), nil, nil, nil, nil).
 					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).
 						AddRow("111e2222-e89b-12d3-a456-426614174000"))
-				mock.ExpectExec("SELECT amount FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
-					WithArgs(input.WalletId).
-					WillReturnResult(sqlmock.NewResult(0, 1))
-				mock.ExpectExec("UPDATE wallets SET amount = amount \\+ \\$1, updated_at = \\$2 WHERE wallet_id = \\$3").
+				mock.ExpectExec("UPDATE wallets SET amount = amount \\+ \\$1, updated_at = \\$2, version = version \\+ 1 WHERE wallet_id = \\$3").
 					WithArgs(input.Amount, sqlmock.AnyArg(), input.WalletId).
 					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit()
@@ -62,14 +76,50 @@ func TestTransaction_Create(t *testing.T) {
 			expectedId: uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"),
 			mockBehavior: func(input models.TransactionInput) {
 				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 100, models.WalletActive))
+				// Chained to the last transaction of the wallet.
+				mock.ExpectQuery("SELECT wallet_id, seq, hash FROM transactions WHERE wallet_id = \\$1 ORDER BY seq DESC LIMIT 1").
+					WithArgs(input.WalletId).
+					WillReturnRows(headRows(input.WalletId, 4, "9f2c"))
 				mock.ExpectQuery("INSERT INTO transactions").
-					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg()).
+					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg(), int64(5), "9f2c", sqlmock.AnyArg(), nil, nil, nil, nil).
 					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).
 						AddRow("111e2222-e89b-12d3-a456-426614174000"))
-				mock.ExpectExec("SELECT amount FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
-					WithArgs(input.WalletId).
+				mock.ExpectExec("UPDATE wallets SET amount = amount \\- \\$1, updated_at = \\$2, version = version \\+ 1 WHERE wallet_id = \\$3").
+					WithArgs(input.Amount, sqlmock.AnyArg(), input.WalletId).
 					WillReturnResult(sqlmock.NewResult(0, 1))
-				mock.ExpectExec("UPDATE wallets SET amount = amount \\- \\$1, updated_at = \\$2 WHERE wallet_id = \\$3").
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "Ok Deposit with details",
+			input: models.TransactionInput{
+				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType: models.Deposit,
+				Amount:        100,
+				Description:   &description,
+				CategoryId:    &categoryId,
+				ExternalRef:   &externalRef,
+				Metadata:      models.Metadata(`{"shop":"books"}`),
+			},
+			expectedId: uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"),
+			mockBehavior: func(input models.TransactionInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 0, models.WalletActive))
+				mock.ExpectQuery("SELECT wallet_id, seq, hash FROM transactions WHERE wallet_id = \\$1 ORDER BY seq DESC LIMIT 1").
+					WithArgs(input.WalletId).
+					WillReturnRows(headRows(input.WalletId, 0, ""))
+				mock.ExpectQuery("INSERT INTO transactions").
+					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg(), int64(1), "", sqlmock.AnyArg(),
+						description, categoryId, externalRef, `{"shop":"books"}`).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).
+						AddRow("111e2222-e89b-12d3-a456-426614174000"))
+				mock.ExpectExec("UPDATE wallets SET amount = amount \\+ \\$1, updated_at = \\$2, version = version \\+ 1 WHERE wallet_id = \\$3").
 					WithArgs(input.Amount, sqlmock.AnyArg(), input.WalletId).
 					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit()
@@ -77,23 +127,119 @@ func TestTransaction_Create(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "Insert Error, rollback",
+			name: "Ok Deposit into frozen wallet",
 			input: models.TransactionInput{
 				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
 				OperationType: models.Deposit,
 				Amount:        100,
 			},
+			expectedId: uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"),
 			mockBehavior: func(input models.TransactionInput) {
 				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 0, models.WalletFrozen))
+				mock.ExpectQuery("SELECT wallet_id, seq, hash FROM transactions WHERE wallet_id = \\$1 ORDER BY seq DESC LIMIT 1").
+					WithArgs(input.WalletId).
+					WillReturnRows(headRows(input.WalletId, 0, ""))
 				mock.ExpectQuery("INSERT INTO transactions").
-					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg()).
+					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg(), int64(1), "", sqlmock.AnyArg(), nil, nil, nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).
+						AddRow("111e2222-e89b-12d3-a456-426614174000"))
+				mock.ExpectExec("UPDATE wallets SET amount = amount \\+ \\$1, updated_at = \\$2, version = version \\+ 1 WHERE wallet_id = \\$3").
+					WithArgs(input.Amount, sqlmock.AnyArg(), input.WalletId).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "Withdraw from frozen wallet, rollback",
+			input: models.TransactionInput{
+				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType: models.Withdraw,
+				Amount:        100,
+			},
+			mockBehavior: func(input models.TransactionInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 100, models.WalletFrozen))
+				mock.ExpectRollback()
+			},
+			wantErr:     true,
+			expectedErr: models.ErrWalletFrozen,
+		},
+		{
+			name: "Deposit into closed wallet, rollback",
+			input: models.TransactionInput{
+				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType: models.Deposit,
+				Amount:        100,
+			},
+			mockBehavior: func(input models.TransactionInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 0, models.WalletClosed))
+				mock.ExpectRollback()
+			},
+			wantErr:     true,
+			expectedErr: models.ErrWalletClosed,
+		},
+		{
+			name: "Insufficient funds, rollback",
+			input: models.TransactionInput{
+				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType: models.Withdraw,
+				Amount:        100,
+			},
+			mockBehavior: func(input models.TransactionInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 50, models.WalletActive))
+				mock.ExpectRollback()
+			},
+			wantErr:     true,
+			expectedErr: models.ErrInsufficientFunds,
+		},
+		{
+			name: "Version mismatch, rollback",
+			input: models.TransactionInput{
+				WalletId:        uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType:   models.Deposit,
+				Amount:          100,
+				ExpectedVersion: &staleVersion,
+			},
+			mockBehavior: func(input models.TransactionInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 0, models.WalletActive))
+				mock.ExpectRollback()
+			},
+			wantErr:     true,
+			expectedErr: models.ErrVersionMismatch,
+		},
+		{
+			name: "Lock Error, rollback",
+			input: models.TransactionInput{
+				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType: models.Deposit,
+				Amount:        100,
+			},
+			mockBehavior: func(input models.TransactionInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
 					WillReturnError(errors.New("some error"))
 				mock.ExpectRollback()
 			},
 			wantErr: true,
 		},
 		{
-			name: "Select Error, rollback",
+			name: "Insert Error, rollback",
 			input: models.TransactionInput{
 				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
 				OperationType: models.Deposit,
@@ -101,12 +247,14 @@ func TestTransaction_Create(t *testing.T) {
 			},
 			mockBehavior: func(input models.TransactionInput) {
 				mock.ExpectBegin()
-				mock.ExpectQuery("INSERT INTO transactions").
-					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg()).
-					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).
-						AddRow("111e2222-e89b-12d3-a456-426614174000"))
-				mock.ExpectExec("SELECT amount FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 0, models.WalletActive))
+				mock.ExpectQuery("SELECT wallet_id, seq, hash FROM transactions WHERE wallet_id = \\$1 ORDER BY seq DESC LIMIT 1").
 					WithArgs(input.WalletId).
+					WillReturnRows(headRows(input.WalletId, 0, ""))
+				mock.ExpectQuery("INSERT INTO transactions").
+					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg(), int64(1), "", sqlmock.AnyArg(), nil, nil, nil, nil).
 					WillReturnError(errors.New("some error"))
 				mock.ExpectRollback()
 			},
@@ -121,14 +269,17 @@ func TestTransaction_Create(t *testing.T) {
 			},
 			mockBehavior: func(input models.TransactionInput) {
 				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 0, models.WalletActive))
+				mock.ExpectQuery("SELECT wallet_id, seq, hash FROM transactions WHERE wallet_id = \\$1 ORDER BY seq DESC LIMIT 1").
+					WithArgs(input.WalletId).
+					WillReturnRows(headRows(input.WalletId, 0, ""))
 				mock.ExpectQuery("INSERT INTO transactions").
-					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg()).
+					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg(), int64(1), "", sqlmock.AnyArg(), nil, nil, nil, nil).
 					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).
 						AddRow("111e2222-e89b-12d3-a456-426614174000"))
-				mock.ExpectExec("SELECT amount FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
-					WithArgs(input.WalletId).
-					WillReturnResult(sqlmock.NewResult(0, 1))
-				mock.ExpectExec("UPDATE wallets SET amount = amount \\+ \\$1, updated_at = \\$2 WHERE wallet_id = \\$3").
+				mock.ExpectExec("UPDATE wallets SET amount = amount \\+ \\$1, updated_at = \\$2, version = version \\+ 1 WHERE wallet_id = \\$3").
 					WithArgs(input.Amount, sqlmock.AnyArg(), input.WalletId).
 					WillReturnError(errors.New("some error"))
 				mock.ExpectRollback()
@@ -144,14 +295,17 @@ func TestTransaction_Create(t *testing.T) {
 			},
 			mockBehavior: func(input models.TransactionInput) {
 				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 0, models.WalletActive))
+				mock.ExpectQuery("SELECT wallet_id, seq, hash FROM transactions WHERE wallet_id = \\$1 ORDER BY seq DESC LIMIT 1").
+					WithArgs(input.WalletId).
+					WillReturnRows(headRows(input.WalletId, 0, ""))
 				mock.ExpectQuery("INSERT INTO transactions").
-					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg()).
+					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg(), int64(1), "", sqlmock.AnyArg(), nil, nil, nil, nil).
 					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).
 						AddRow("111e2222-e89b-12d3-a456-426614174000"))
-				mock.ExpectExec("SELECT amount FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
-					WithArgs(input.WalletId).
-					WillReturnResult(sqlmock.NewResult(0, 1))
-				mock.ExpectExec("UPDATE wallets SET amount = amount \\+ \\$1, updated_at = \\$2 WHERE wallet_id = \\$3").
+				mock.ExpectExec("UPDATE wallets SET amount = amount \\+ \\$1, updated_at = \\$2, version = version \\+ 1 WHERE wallet_id = \\$3").
 					WithArgs(input.Amount, sqlmock.AnyArg(), input.WalletId).
 					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit().WillReturnError(errors.New("some error"))
@@ -176,9 +330,12 @@ func TestTransaction_Create(t *testing.T) {
 	for _, testcase := range testTable {
 		t.Run(testcase.name, func(t *testing.T) {
 			testcase.mockBehavior(testcase.input)
-			got, err := r.Create(testcase.input)
+			got, err := r.Create(context.Background(), testcase.input)
 			if testcase.wantErr {
 				assert.Error(t, err)
+				if testcase.expectedErr != nil {
+					assert.ErrorIs(t, err, testcase.expectedErr)
+				}
 				return
 			} else {
 				assert.NoError(t, err)
@@ -196,9 +353,11 @@ func TestTransaction_GetAll(t *testing.T) {
 
 	r := NewTransactionPostgres(db)
 	type mockBehavior func()
+	categoryId := uuid.MustParse("333e4444-e89b-12d3-a456-426614174000")
 
 	testTable := []struct {
 		name         string
+		filter       models.TransactionFilter
 		expected     []models.Transaction
 		mockBehavior mockBehavior
 		wantErr      bool
@@ -216,16 +375,48 @@ func TestTransaction_GetAll(t *testing.T) {
 				},
 			},
 			mockBehavior: func() {
-				mock.ExpectQuery("SELECT \\* FROM transactions").
+				mock.ExpectQuery("SELECT t\\.\\* FROM transactions t ORDER BY t.created_at, t.transaction_id LIMIT \\$1 OFFSET \\$2").
+					WithArgs(nil, 0).
 					WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "wallet_id", "operation_type", "amount", "created_at"}).
 						AddRow("111e2222-e89b-12d3-a456-426614174000", "123e4567-e89b-12d3-a456-426614174000", models.Deposit, 100, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)))
 			},
 		},
+		{
+			name: "Ok, filtered",
+			filter: models.TransactionFilter{
+				CategoryId:  &categoryId,
+				ExternalRef: "order-1",
+				Search:      "50%_off",
+				Metadata:    map[string]string{"shop": "books", "channel": "web"},
+			},
+			expected: []models.Transaction{
+				{
+					TransactionId: uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"),
+					WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+					OperationType: models.Deposit,
+					Amount:        100,
+					CreatedAt:     time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+					CategoryId:    &categoryId,
+					Metadata:      models.Metadata(`{"channel":"web","shop":"books"}`),
+				},
+			},
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT t\\.\\* FROM transactions t WHERE t.category_id = \\$1 AND t.external_ref = \\$2 "+
+					"AND lower\\(t.description\\) LIKE \\$3 ESCAPE '\\\\' "+
+					"AND t.metadata ->> \\$4::text = \\$5 AND t.metadata ->> \\$6::text = \\$7 "+
+					"ORDER BY t.created_at, t.transaction_id LIMIT \\$8 OFFSET \\$9").
+					WithArgs(categoryId, "order-1", `%50\%\_off%`, "channel", "web", "shop", "books", nil, 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "wallet_id", "operation_type", "amount", "created_at", "category_id", "metadata"}).
+						AddRow("111e2222-e89b-12d3-a456-426614174000", "123e4567-e89b-12d3-a456-426614174000", models.Deposit, 100, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+							categoryId.String(), []byte(`{"shop": "books", "channel": "web"}`)))
+			},
+		},
 		{
 			name:     "Ok, empty",
 			expected: []models.Transaction{},
 			mockBehavior: func() {
-				mock.ExpectQuery("SELECT \\* FROM transactions").
+				mock.ExpectQuery("SELECT t\\.\\* FROM transactions t ORDER BY t.created_at, t.transaction_id LIMIT \\$1 OFFSET \\$2").
+					WithArgs(nil, 0).
 					WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "wallet_id", "operation_type", "amount", "created_at"}))
 			},
 		},
@@ -235,7 +426,7 @@ func TestTransaction_GetAll(t *testing.T) {
 		t.Run(testcase.name, func(t *testing.T) {
 			testcase.mockBehavior()
 
-			got, err := r.GetAll()
+			got, err := r.GetAll(context.Background(), testcase.filter, models.Page{})
 			if testcase.wantErr {
 				assert.Error(t, err)
 				assert.Equal(t, testcase.expectedErr, err)
@@ -275,7 +466,7 @@ func TestTransaction_GetById(t *testing.T) {
 				CreatedAt:     time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
 			},
 			mockBehavior: func(id uuid.UUID) {
-				mock.ExpectQuery("SELECT \\* FROM transactions WHERE transaction_id = \\$1").
+				mock.ExpectQuery("SELECT t\\.\\* FROM transactions t WHERE t.transaction_id = \\$1").
 					WithArgs(id).
 					WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "wallet_id", "operation_type", "amount", "created_at"}).
 						AddRow("111e2222-e89b-12d3-a456-426614174000", "123e4567-e89b-12d3-a456-426614174000", models.Deposit, 100, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)))
@@ -286,7 +477,7 @@ func TestTransaction_GetById(t *testing.T) {
 			inputId:  uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"),
 			expected: models.Transaction{},
 			mockBehavior: func(id uuid.UUID) {
-				mock.ExpectQuery("SELECT \\* FROM transactions WHERE transaction_id = \\$1").
+				mock.ExpectQuery("SELECT t\\.\\* FROM transactions t WHERE t.transaction_id = \\$1").
 					WithArgs(id).
 					WillReturnError(errors.New("sql: no rows in result set"))
 			},
@@ -298,7 +489,7 @@ func TestTransaction_GetById(t *testing.T) {
 		t.Run(testcase.name, func(t *testing.T) {
 			testcase.mockBehavior(testcase.inputId)
 
-			got, err := r.GetById(testcase.inputId)
+			got, err := r.GetById(context.Background(), testcase.inputId)
 			if testcase.wantErr {
 				assert.Error(t, err)
 				return
@@ -309,3 +500,160 @@ func TestTransaction_GetById(t *testing.T) {
 		})
 	}
 }
+
+func TestTransaction_CreateAdjustment(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	r := NewTransactionPostgres(db)
+
+	type mockBehavior func(input models.AdjustmentInput)
+
+	walletRows := func(walletId uuid.UUID, amount int64, status models.WalletStatus) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"wallet_id", "user_id", "amount", "created_at", "updated_at", "status", "closed_at", "close_reason"}).
+			AddRow(walletId, 1, amount, time.Now(), time.Now(), status, nil, nil)
+	}
+
+	testTable := []struct {
+		name         string
+		input        models.AdjustmentInput
+		expectedId   uuid.UUID
+		mockBehavior mockBehavior
+		wantErr      bool
+		expectedErr  error
+	}{
+		{
+			name: "Ok Withdraw from frozen wallet",
+			input: models.AdjustmentInput{
+				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType: models.Withdraw,
+				Amount:        100,
+				Reason:        "chargeback",
+			},
+			expectedId: uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"),
+			mockBehavior: func(input models.AdjustmentInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 100, models.WalletFrozen))
+				mock.ExpectQuery("SELECT wallet_id, seq, hash FROM transactions WHERE wallet_id = \\$1 ORDER BY seq DESC LIMIT 1").
+					WithArgs(input.WalletId).
+					WillReturnRows(headRows(input.WalletId, 0, ""))
+				mock.ExpectQuery("INSERT INTO transactions").
+					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg(), int64(1), "", sqlmock.AnyArg(), nil, nil, nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).
+						AddRow("111e2222-e89b-12d3-a456-426614174000"))
+				mock.ExpectExec("UPDATE wallets SET amount = amount \\- \\$1").
+					WithArgs(input.Amount, sqlmock.AnyArg(), input.WalletId).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO adjustments").
+					WithArgs(uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"), input.Reason, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Closed wallet, rollback",
+			input: models.AdjustmentInput{
+				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType: models.Deposit,
+				Amount:        100,
+				Reason:        "refund",
+			},
+			mockBehavior: func(input models.AdjustmentInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 0, models.WalletClosed))
+				mock.ExpectRollback()
+			},
+			wantErr:     true,
+			expectedErr: models.ErrWalletClosed,
+		},
+		{
+			name: "Adjustment insert error, rollback",
+			input: models.AdjustmentInput{
+				WalletId:      uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				OperationType: models.Deposit,
+				Amount:        100,
+				Reason:        "refund",
+			},
+			mockBehavior: func(input models.AdjustmentInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM wallets WHERE wallet_id = \\$1 FOR UPDATE").
+					WithArgs(input.WalletId).
+					WillReturnRows(walletRows(input.WalletId, 0, models.WalletActive))
+				mock.ExpectQuery("SELECT wallet_id, seq, hash FROM transactions WHERE wallet_id = \\$1 ORDER BY seq DESC LIMIT 1").
+					WithArgs(input.WalletId).
+					WillReturnRows(headRows(input.WalletId, 0, ""))
+				mock.ExpectQuery("INSERT INTO transactions").
+					WithArgs(sqlmock.AnyArg(), input.WalletId, input.OperationType, input.Amount, sqlmock.AnyArg(), int64(1), "", sqlmock.AnyArg(), nil, nil, nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).
+						AddRow("111e2222-e89b-12d3-a456-426614174000"))
+				mock.ExpectExec("UPDATE wallets SET amount = amount \\+ \\$1").
+					WithArgs(input.Amount, sqlmock.AnyArg(), input.WalletId).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO adjustments").
+					WillReturnError(errors.New("some error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testcase := range testTable {
+		t.Run(testcase.name, func(t *testing.T) {
+			testcase.mockBehavior(testcase.input)
+			got, err := r.CreateAdjustment(context.Background(), testcase.input)
+			if testcase.wantErr {
+				assert.Error(t, err)
+				if testcase.expectedErr != nil {
+					assert.ErrorIs(t, err, testcase.expectedErr)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testcase.expectedId, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTransaction_GetAllFromWallet(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	r := NewTransactionPostgres(db)
+	walletId := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+
+	expected := []models.Transaction{
+		{
+			TransactionId: uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"),
+			WalletId:      walletId,
+			OperationType: models.Deposit,
+			Amount:        100,
+			CreatedAt:     time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			Annotation: &models.Annotation{
+				TransactionId: uuid.MustParse("111e2222-e89b-12d3-a456-426614174000"),
+				Version:       2,
+				Notes:         "gift",
+				CreatedAt:     time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	mock.ExpectQuery("SELECT t\\.\\*, .+ FROM transactions t LEFT JOIN transaction_annotations a .+ WHERE t.wallet_id = \\$1 ORDER BY t.created_at").
+		WithArgs(walletId).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "wallet_id", "operation_type", "amount", "created_at",
+			"annotation_version", "annotation_category_id", "annotation_notes", "annotation_created_at"}).
+			AddRow(expected[0].TransactionId, walletId, models.Deposit, 100, expected[0].CreatedAt, 2, nil, "gift", time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)))
+
+	got, err := r.GetAllFromWallet(context.Background(), walletId)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
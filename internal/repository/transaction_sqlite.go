@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type TransactionSQLite struct {
+	db *sqlx.DB
+}
+
+func NewTransactionSQLite(db *sqlx.DB) *TransactionSQLite {
+	return &TransactionSQLite{db: db}
+}
+
+func (r *TransactionSQLite) Create(ctx context.Context, transaction models.TransactionInput) (uuid.UUID, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	wallet, err := getWalletSQLite(ctx, tx, transaction.WalletId)
+	if err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	if err := wallet.CheckVersion(transaction.ExpectedVersion); err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	if err := wallet.CheckOperation(transaction.OperationType, transaction.Amount); err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	id, err := applySQLiteTransaction(ctx, tx, transaction)
+	if err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, err
+	}
+
+	metrics.ObserveTransaction(transaction.OperationType, transaction.Amount)
+	return id, nil
+}
+
+func (r *TransactionSQLite) CreateAdjustment(ctx context.Context, adjustment models.AdjustmentInput) (uuid.UUID, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	wallet, err := getWalletSQLite(ctx, tx, adjustment.WalletId)
+	if err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	if err := wallet.CheckAdjustment(adjustment.OperationType, adjustment.Amount); err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	id, err := applySQLiteTransaction(ctx, tx, models.TransactionInput{
+		WalletId:      adjustment.WalletId,
+		OperationType: adjustment.OperationType,
+		Amount:        adjustment.Amount,
+	})
+	if err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (transaction_id, reason, created_at) values ($1, $2, $3)", adjustmentTable)
+	_, err = tx.ExecContext(ctx, query, id, adjustment.Reason, sqliteNow())
+	if err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, err
+	}
+
+	metrics.ObserveTransaction(adjustment.OperationType, adjustment.Amount)
+	return id, nil
+}
+
+// applySQLiteTransaction is applyTransaction for SQLite. tx must hold the
+// database write lock.
+func applySQLiteTransaction(ctx context.Context, tx *sqlx.Tx, transaction models.TransactionInput) (uuid.UUID, error) {
+	var updateQuery string
+	switch transaction.OperationType {
+	case models.Deposit:
+		updateQuery = fmt.Sprintf("UPDATE %s SET amount = amount + $1, updated_at = $2, version = version + 1 WHERE wallet_id = $3", walletTable)
+	case models.Withdraw:
+		updateQuery = fmt.Sprintf("UPDATE %s SET amount = amount - $1, updated_at = $2, version = version + 1 WHERE wallet_id = $3", walletTable)
+	default:
+		return uuid.Nil, models.ErrUnknownOperation
+	}
+
+	head, err := chainHead(ctx, tx, transaction.WalletId)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	id, err := insertLink(ctx, tx, newLink(head, transaction, sqliteNow()))
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, updateQuery, transaction.Amount, sqliteNow(), transaction.WalletId); err != nil {
+		return uuid.Nil, err
+	}
+
+	return id, nil
+}
+
+func (r *TransactionSQLite) GetAll(ctx context.Context, filter models.TransactionFilter, page models.Page) ([]models.Transaction, error) {
+	var transactions []annotatedTransaction
+	where, args := filterTransactions(filter, sqliteMetadataMatch)
+	query := fmt.Sprintf("%s%s ORDER BY t.created_at, t.transaction_id LIMIT $%d OFFSET $%d", transactionsSelect, where, len(args)+1, len(args)+2)
+	err := r.db.SelectContext(ctx, &transactions, query, append(args, sqliteLimitArg(page), page.Offset)...)
+
+	return annotated(transactions), err
+}
+
+func (r *TransactionSQLite) GetAllFromWallet(ctx context.Context, walletId uuid.UUID) ([]models.Transaction, error) {
+	var transactions []annotatedTransaction
+	query := fmt.Sprintf("%s WHERE t.wallet_id = $1 ORDER BY t.created_at", annotatedTransactionsSelect)
+	err := r.db.SelectContext(ctx, &transactions, query, walletId)
+
+	return annotated(transactions), err
+}
+
+func (r *TransactionSQLite) GetById(ctx context.Context, transactionId uuid.UUID) (models.Transaction, error) {
+	var transaction annotatedTransaction
+	query := fmt.Sprintf("%s WHERE t.transaction_id = $1", transactionsSelect)
+	err := r.db.GetContext(ctx, &transaction, query, transactionId)
+
+	return transaction.transaction(), err
+}
+
+func (r *TransactionSQLite) Annotate(ctx context.Context, transactionId uuid.UUID, input models.AnnotationInput) (models.Annotation, error) {
+	// The transaction holds the write lock, which serializes the versions.
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Annotation{}, err
+	}
+
+	var id uuid.UUID
+	query := fmt.Sprintf("SELECT transaction_id FROM %s WHERE transaction_id = $1", transactionTable)
+	if err := tx.GetContext(ctx, &id, query, transactionId); err != nil {
+		tx.Rollback()
+		return models.Annotation{}, err
+	}
+
+	annotation, err := annotate(ctx, tx, transactionId, input, sqliteNow())
+	if err != nil {
+		tx.Rollback()
+		return models.Annotation{}, err
+	}
+
+	return annotation, tx.Commit()
+}
+
+func (r *TransactionSQLite) GetAnnotations(ctx context.Context, transactionId uuid.UUID, page models.Page) ([]models.Annotation, error) {
+	var annotations []models.Annotation
+	query := fmt.Sprintf("SELECT * FROM %s WHERE transaction_id = $1 ORDER BY version DESC LIMIT $2 OFFSET $3", annotationTable)
+	err := r.db.SelectContext(ctx, &annotations, query, transactionId, sqliteLimitArg(page), page.Offset)
+
+	return annotations, err
+}
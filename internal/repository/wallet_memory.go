@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+)
+
+type WalletMemory struct {
+	db *MemoryDB
+}
+
+func NewWalletMemory(db *MemoryDB) *WalletMemory {
+	return &WalletMemory{db: db}
+}
+
+func (r *WalletMemory) Create(ctx context.Context, userId int) (uuid.UUID, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return uuid.Nil, err
+	}
+	defer r.db.unlock()
+
+	if _, ok := r.db.users[userId]; !ok {
+		return uuid.Nil, errUnknownUser
+	}
+
+	createdAt := now()
+	wallet := models.Wallet{
+		WalletId:  uuid.New(),
+		UserId:    userId,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		Status:    models.WalletActive,
+		Version:   1,
+	}
+	r.db.wallets[wallet.WalletId] = wallet
+
+	return wallet.WalletId, nil
+}
+
+func (r *WalletMemory) GetAllFromUser(ctx context.Context, userId int, filter models.WalletFilter, page models.Page) ([]models.Wallet, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var wallets []models.Wallet
+	for _, wallet := range r.db.wallets {
+		if wallet.UserId == userId && matchWallet(wallet, filter) {
+			wallets = append(wallets, wallet)
+		}
+	}
+
+	sortByCreation(wallets, func(w models.Wallet) (time.Time, uuid.UUID) {
+		return w.CreatedAt, w.WalletId
+	})
+	switch filter.OrderBy {
+	case models.WalletsByName:
+		slices.SortStableFunc(wallets, func(a, b models.Wallet) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	case models.WalletsByAmount:
+		slices.SortStableFunc(wallets, func(a, b models.Wallet) int {
+			return cmp.Compare(a.Amount, b.Amount)
+		})
+	}
+	if filter.Desc {
+		slices.Reverse(wallets)
+	}
+
+	return paginate(wallets, page), nil
+}
+
+// matchWallet is filterWallets for the in-memory wallets.
+func matchWallet(wallet models.Wallet, filter models.WalletFilter) bool {
+	if filter.Name != "" && !strings.Contains(strings.ToLower(wallet.Name), strings.ToLower(filter.Name)) {
+		return false
+	}
+
+	for _, tag := range filter.Tags {
+		if !slices.Contains(wallet.Tags, tag) {
+			return false
+		}
+	}
+
+	if filter.MinAmount != nil && wallet.Amount < *filter.MinAmount {
+		return false
+	}
+	if filter.MaxAmount != nil && wallet.Amount > *filter.MaxAmount {
+		return false
+	}
+
+	if filter.CreatedFrom != nil && wallet.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+	if filter.CreatedTo != nil && !wallet.CreatedAt.Before(*filter.CreatedTo) {
+		return false
+	}
+
+	return true
+}
+
+func (r *WalletMemory) GetByIdFromUser(ctx context.Context, userId int, walletId uuid.UUID) (models.Wallet, error) {
+	wallet, err := r.GetById(ctx, walletId)
+	if err != nil {
+		return models.Wallet{}, err
+	}
+
+	if wallet.UserId != userId {
+		return models.Wallet{}, sql.ErrNoRows
+	}
+
+	return wallet, nil
+}
+
+func (r *WalletMemory) GetById(ctx context.Context, walletId uuid.UUID) (models.Wallet, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return models.Wallet{}, err
+	}
+	defer r.db.unlock()
+
+	wallet, ok := r.db.wallets[walletId]
+	if !ok {
+		return models.Wallet{}, sql.ErrNoRows
+	}
+
+	return wallet, nil
+}
+
+func (r *WalletMemory) Close(ctx context.Context, userId int, walletId uuid.UUID, input models.CloseWalletInput) error {
+	if err := r.db.lock(ctx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	source, target, err := r.findForClose(walletId, input.SweepTo)
+	if err != nil {
+		return err
+	}
+
+	if source.UserId != userId {
+		return sql.ErrNoRows
+	}
+
+	if err := source.CheckVersion(input.ExpectedVersion); err != nil {
+		return err
+	}
+
+	swept, err := checkSweep(userId, source, target)
+	if err != nil {
+		return err
+	}
+
+	if swept > 0 {
+		// Both wallets are known to accept the transfer, so neither call can
+		// fail half way through.
+		if _, err := r.db.applyTransaction(models.TransactionInput{
+			WalletId:      source.WalletId,
+			OperationType: models.Withdraw,
+			Amount:        swept,
+		}); err != nil {
+			return err
+		}
+
+		if _, err := r.db.applyTransaction(models.TransactionInput{
+			WalletId:      target.WalletId,
+			OperationType: models.Deposit,
+			Amount:        swept,
+		}); err != nil {
+			return err
+		}
+	}
+
+	closedAt := now()
+	wallet := r.db.wallets[walletId]
+	wallet.Status = models.WalletClosed
+	wallet.IsDefault = false
+	wallet.ClosedAt = &closedAt
+	wallet.UpdatedAt = closedAt
+	wallet.Version++
+	wallet.CloseReason = nil
+	if input.Reason != "" {
+		reason := input.Reason
+		wallet.CloseReason = &reason
+	}
+	r.db.wallets[walletId] = wallet
+
+	if swept > 0 {
+		metrics.ObserveTransaction(models.Withdraw, swept)
+		metrics.ObserveTransaction(models.Deposit, swept)
+	}
+
+	return nil
+}
+
+func (r *WalletMemory) SetFrozen(ctx context.Context, walletId uuid.UUID, frozen bool) error {
+	if err := r.db.lock(ctx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	wallet, ok := r.db.wallets[walletId]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	if wallet.Status == models.WalletClosed {
+		return models.ErrWalletClosed
+	}
+
+	wallet.Status = models.WalletActive
+	if frozen {
+		wallet.Status = models.WalletFrozen
+	}
+	wallet.UpdatedAt = now()
+	wallet.Version++
+	r.db.wallets[walletId] = wallet
+
+	return nil
+}
+
+func (r *WalletMemory) Update(ctx context.Context, userId int, walletId uuid.UUID, update models.WalletUpdate) (models.Wallet, error) {
+	if err := r.db.lock(ctx); err != nil {
+		return models.Wallet{}, err
+	}
+	defer r.db.unlock()
+
+	wallet, ok := r.db.wallets[walletId]
+	if !ok {
+		return models.Wallet{}, sql.ErrNoRows
+	}
+
+	if err := checkUpdate(userId, wallet, update); err != nil {
+		return models.Wallet{}, err
+	}
+
+	updatedAt := now()
+	if update.IsDefault != nil && *update.IsDefault {
+		for id, previous := range r.db.wallets {
+			if previous.UserId == userId && previous.IsDefault && id != walletId {
+				previous.IsDefault = false
+				previous.UpdatedAt = updatedAt
+				previous.Version++
+				r.db.wallets[id] = previous
+			}
+		}
+	}
+
+	wallet = update.Apply(wallet)
+	wallet.UpdatedAt = updatedAt
+	wallet.Version++
+	r.db.wallets[walletId] = wallet
+
+	return wallet, nil
+}
+
+// findForClose is lockForClose for the in-memory wallets: it looks the wallets
+// up in the same order, so a missing wallet is reported the same way. db must
+// be locked.
+func (r *WalletMemory) findForClose(walletId uuid.UUID, sweepTo *uuid.UUID) (models.Wallet, *models.Wallet, error) {
+	if sweepTo == nil {
+		source, ok := r.db.wallets[walletId]
+		if !ok {
+			return models.Wallet{}, nil, sql.ErrNoRows
+		}
+		return source, nil, nil
+	}
+
+	if *sweepTo == walletId {
+		return models.Wallet{}, nil, models.ErrInvalidSweepTarget
+	}
+
+	ids := []uuid.UUID{walletId, *sweepTo}
+	if bytes.Compare(ids[1][:], ids[0][:]) < 0 {
+		ids[0], ids[1] = ids[1], ids[0]
+	}
+
+	for _, id := range ids {
+		if _, ok := r.db.wallets[id]; ok {
+			continue
+		}
+		if id == *sweepTo {
+			return models.Wallet{}, nil, models.ErrInvalidSweepTarget
+		}
+		return models.Wallet{}, nil, sql.ErrNoRows
+	}
+
+	target := r.db.wallets[*sweepTo]
+	return r.db.wallets[walletId], &target, nil
+}
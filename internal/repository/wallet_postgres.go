@@ -1,9 +1,16 @@
 package repository
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
 	"github.com/Yoshisoul/rest-wallets/internal/models"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -11,62 +18,196 @@ import (
 
 type WalletPostgres struct {
 	db *sqlx.DB
+	// cluster routes reads to replicas.
+	cluster *Cluster
 }
 
 func NewWalletPostgres(db *sqlx.DB) *WalletPostgres {
-	return &WalletPostgres{db: db}
+	return &WalletPostgres{db: db, cluster: singleNode(db)}
 }
 
-func (r *WalletPostgres) Create(userId int) (uuid.UUID, error) {
+func (r *WalletPostgres) Create(ctx context.Context, userId int) (uuid.UUID, error) {
 	var id uuid.UUID
 	query := fmt.Sprintf("INSERT INTO %s (wallet_id, user_id, amount, created_at, updated_at) values ($1, $2, $3, $4, $5) RETURNING wallet_id", walletTable)
 
-	row := r.db.QueryRow(query, uuid.New(), userId, 0, time.Now(), time.Now())
+	row := r.db.QueryRowContext(ctx, query, uuid.New(), userId, 0, time.Now(), time.Now())
 	if err := row.Scan(&id); err != nil {
 		return uuid.Nil, err
 	}
+
+	r.cluster.wrote(ctx)
 	return id, nil
 }
 
-func (r *WalletPostgres) GetAllFromUser(userId int) ([]models.Wallet, error) {
+func (r *WalletPostgres) GetAllFromUser(ctx context.Context, userId int, filter models.WalletFilter, page models.Page) ([]models.Wallet, error) {
 	var wallets []models.Wallet
-	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id=$1", walletTable)
-	err := r.db.Select(&wallets, query, userId)
+	where, args := filterWallets(userId, filter, postgresTagMatch)
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s ORDER BY %s LIMIT $%d OFFSET $%d",
+		walletTable, where, orderWallets(filter, postgresNameOrder), len(args)+1, len(args)+2)
+	err := r.cluster.read(ctx, func(db *sqlx.DB) error {
+		wallets = nil
+		return db.SelectContext(ctx, &wallets, query, append(args, limitArg(page), page.Offset)...)
+	})
 
 	return wallets, err
 }
 
-func (r *WalletPostgres) GetByIdFromUser(userId int, walletId uuid.UUID) (models.Wallet, error) {
+// Conditions and orders of filterWallets and orderWallets that depend on the
+// database. Postgres sorts names byte by byte like SQLite, not by locale.
+const (
+	postgresTagMatch  = "tags @> jsonb_build_array(%s::text)"
+	sqliteTagMatch    = "EXISTS (SELECT 1 FROM json_each(tags) WHERE value = %s)"
+	postgresNameOrder = `name COLLATE "C"`
+	sqliteNameOrder   = "name"
+)
+
+// filterWallets returns the conditions on the wallets of the user matching
+// filter, and their arguments from $1. tagMatch is the condition on a tag of
+// the database.
+func filterWallets(userId int, filter models.WalletFilter, tagMatch string) (string, []any) {
+	var args []any
+	param := func(arg any) string {
+		args = append(args, arg)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"user_id = " + param(userId)}
+	if filter.Name != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(filter.Name)) + "%"
+		conditions = append(conditions, fmt.Sprintf(`lower(name) LIKE %s ESCAPE '\'`, param(pattern)))
+	}
+	for _, tag := range filter.Tags {
+		conditions = append(conditions, fmt.Sprintf(tagMatch, param(tag)))
+	}
+	if filter.MinAmount != nil {
+		conditions = append(conditions, "amount >= "+param(*filter.MinAmount))
+	}
+	if filter.MaxAmount != nil {
+		conditions = append(conditions, "amount <= "+param(*filter.MaxAmount))
+	}
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= "+param(filter.CreatedFrom.UTC()))
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "created_at < "+param(filter.CreatedTo.UTC()))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// orderWallets returns the ORDER BY list of filter. nameOrder is how the
+// database sorts by name.
+func orderWallets(filter models.WalletFilter, nameOrder string) string {
+	columns := []string{"created_at", "wallet_id"}
+	switch filter.OrderBy {
+	case models.WalletsByName:
+		columns = append([]string{nameOrder}, columns...)
+	case models.WalletsByAmount:
+		columns = append([]string{"amount"}, columns...)
+	}
+
+	direction := ""
+	if filter.Desc {
+		direction = " DESC"
+	}
+
+	return strings.Join(columns, direction+", ") + direction
+}
+
+func (r *WalletPostgres) GetByIdFromUser(ctx context.Context, userId int, walletId uuid.UUID) (models.Wallet, error) {
 	var wallet models.Wallet
 	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id=$1 AND wallet_id=$2", walletTable)
-	err := r.db.Get(&wallet, query, userId, walletId)
+	err := r.cluster.read(ctx, func(db *sqlx.DB) error {
+		return db.GetContext(ctx, &wallet, query, userId, walletId)
+	})
 
 	return wallet, err
 }
 
-func (r *WalletPostgres) GetById(walletId uuid.UUID) (models.Wallet, error) {
+func (r *WalletPostgres) GetById(ctx context.Context, walletId uuid.UUID) (models.Wallet, error) {
 	var wallet models.Wallet
 	query := fmt.Sprintf("SELECT * FROM %s WHERE wallet_id=$1", walletTable)
-	err := r.db.Get(&wallet, query, walletId)
+	err := r.cluster.read(ctx, func(db *sqlx.DB) error {
+		return db.GetContext(ctx, &wallet, query, walletId)
+	})
 
 	return wallet, err
 }
 
-func (r *WalletPostgres) Delete(userId int, walletId uuid.UUID) error {
-	tx, err := r.db.Begin()
+func (r *WalletPostgres) Close(ctx context.Context, userId int, walletId uuid.UUID, input models.CloseWalletInput) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	source, target, err := lockForClose(ctx, tx, walletId, input.SweepTo)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if source.UserId != userId {
+		tx.Rollback()
+		return sql.ErrNoRows
+	}
+
+	if err := source.CheckVersion(input.ExpectedVersion); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	swept, err := sweepBeforeClose(ctx, tx, userId, source, target)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	now := time.Now()
+	query := fmt.Sprintf("UPDATE %s SET status = $1, closed_at = $2, close_reason = $3, is_default = false, updated_at = $2, version = version + 1 WHERE wallet_id = $4", walletTable)
+	reason := sql.NullString{String: input.Reason, Valid: input.Reason != ""}
+	_, err = tx.ExecContext(ctx, query, models.WalletClosed, now, reason, walletId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	r.cluster.wrote(ctx)
+	if swept > 0 {
+		metrics.ObserveTransaction(models.Withdraw, swept)
+		metrics.ObserveTransaction(models.Deposit, swept)
+	}
+
+	return nil
+}
+
+func (r *WalletPostgres) SetFrozen(ctx context.Context, walletId uuid.UUID, frozen bool) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	lockQuery := fmt.Sprintf("SELECT * FROM %s WHERE user_id=$1 AND wallet_id=$2 FOR UPDATE", walletTable)
-	_, err = tx.Exec(lockQuery, userId, walletId)
+	wallet, err := lockWallet(ctx, tx, walletId)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	query := fmt.Sprintf("DELETE FROM %s WHERE user_id=$1 AND wallet_id=$2", walletTable)
-	_, err = tx.Exec(query, userId, walletId)
+	if wallet.Status == models.WalletClosed {
+		tx.Rollback()
+		return models.ErrWalletClosed
+	}
+
+	status := models.WalletActive
+	if frozen {
+		status = models.WalletFrozen
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET status = $1, updated_at = $2, version = version + 1 WHERE wallet_id = $3", walletTable)
+	_, err = tx.ExecContext(ctx, query, status, time.Now(), walletId)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -76,5 +217,242 @@ func (r *WalletPostgres) Delete(userId int, walletId uuid.UUID) error {
 		return err
 	}
 
+	r.cluster.wrote(ctx)
 	return nil
 }
+
+func (r *WalletPostgres) Update(ctx context.Context, userId int, walletId uuid.UUID, update models.WalletUpdate) (models.Wallet, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Wallet{}, err
+	}
+
+	ids := []uuid.UUID{walletId}
+	if update.IsDefault != nil && *update.IsDefault {
+		// Serializes the changes of the default wallet of the user, so the
+		// previous default is still the default once locked. Wallets can
+		// still be created meanwhile.
+		query := fmt.Sprintf("SELECT id FROM %s WHERE id = $1 FOR NO KEY UPDATE", userTable)
+		if _, err := tx.ExecContext(ctx, query, userId); err != nil {
+			tx.Rollback()
+			return models.Wallet{}, err
+		}
+
+		previous, err := defaultWallet(ctx, tx, userId)
+		if err != nil {
+			tx.Rollback()
+			return models.Wallet{}, err
+		}
+		if previous != nil && *previous != walletId {
+			ids = append(ids, *previous)
+		}
+	}
+
+	locked, err := lockWallets(ctx, tx, ids)
+	if err != nil {
+		tx.Rollback()
+		return models.Wallet{}, err
+	}
+
+	wallet, err := applyUpdate(ctx, tx, userId, locked, walletId, update, time.Now())
+	if err != nil {
+		tx.Rollback()
+		return models.Wallet{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Wallet{}, err
+	}
+
+	r.cluster.wrote(ctx)
+	return wallet, nil
+}
+
+// defaultWallet returns the id of the default wallet of the user, if any.
+func defaultWallet(ctx context.Context, tx *sqlx.Tx, userId int) (*uuid.UUID, error) {
+	var walletId uuid.UUID
+	query := fmt.Sprintf("SELECT wallet_id FROM %s WHERE user_id = $1 AND is_default", walletTable)
+	err := tx.GetContext(ctx, &walletId, query, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &walletId, nil
+}
+
+// applyUpdate writes the update of the wallet walletId and unsets the
+// default of the other wallets, the previous default if any. wallets must be
+// locked by tx.
+func applyUpdate(ctx context.Context, tx *sqlx.Tx, userId int, wallets map[uuid.UUID]models.Wallet, walletId uuid.UUID,
+	update models.WalletUpdate, now time.Time) (models.Wallet, error) {
+	wallet := wallets[walletId]
+	if err := checkUpdate(userId, wallet, update); err != nil {
+		return models.Wallet{}, err
+	}
+
+	for id := range wallets {
+		if id == walletId {
+			continue
+		}
+		query := fmt.Sprintf("UPDATE %s SET is_default = false, updated_at = $1, version = version + 1 WHERE wallet_id = $2 AND is_default", walletTable)
+		if _, err := tx.ExecContext(ctx, query, now, id); err != nil {
+			return models.Wallet{}, err
+		}
+	}
+
+	labels := update.Apply(wallet)
+	var updated models.Wallet
+	query := fmt.Sprintf(`UPDATE %s SET name = $1, description = $2, color = $3, icon = $4, tags = $5, is_default = $6,
+		updated_at = $7, version = version + 1 WHERE wallet_id = $8 RETURNING *`, walletTable)
+	err := tx.GetContext(ctx, &updated, query, labels.Name, labels.Description, labels.Color, labels.Icon, labels.Tags,
+		labels.IsDefault, now, walletId)
+
+	return updated, err
+}
+
+// checkUpdate reports whether the user can apply the update to the wallet.
+// Closed wallets are kept as they were closed.
+func checkUpdate(userId int, wallet models.Wallet, update models.WalletUpdate) error {
+	if wallet.UserId != userId {
+		return sql.ErrNoRows
+	}
+
+	if err := wallet.CheckVersion(update.ExpectedVersion); err != nil {
+		return err
+	}
+
+	if wallet.Status == models.WalletClosed {
+		return models.ErrWalletClosed
+	}
+
+	return nil
+}
+
+// lockWallet locks the wallet row until tx ends and returns its current state.
+func lockWallet(ctx context.Context, tx *sqlx.Tx, walletId uuid.UUID) (models.Wallet, error) {
+	var wallet models.Wallet
+	query := fmt.Sprintf("SELECT * FROM %s WHERE wallet_id = $1 FOR UPDATE", walletTable)
+
+	start := time.Now()
+	err := tx.GetContext(ctx, &wallet, query, walletId)
+	metrics.ObserveLockWait(time.Since(start).Seconds())
+
+	return wallet, err
+}
+
+// lockWallets locks the wallet rows until tx ends and returns their current
+// state. Rows are locked in the same order as by lockForClose.
+func lockWallets(ctx context.Context, tx *sqlx.Tx, ids []uuid.UUID) (map[uuid.UUID]models.Wallet, error) {
+	ids = slices.Clone(ids)
+	slices.SortFunc(ids, func(a, b uuid.UUID) int {
+		return bytes.Compare(a[:], b[:])
+	})
+
+	locked := make(map[uuid.UUID]models.Wallet, len(ids))
+	for _, id := range ids {
+		wallet, err := lockWallet(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		locked[id] = wallet
+	}
+
+	return locked, nil
+}
+
+// lockForClose locks the wallet being closed and, if given, the sweep
+// destination. Rows are always locked in the same order so that two
+// concurrent sweeps in opposite directions can't deadlock.
+func lockForClose(ctx context.Context, tx *sqlx.Tx, walletId uuid.UUID, sweepTo *uuid.UUID) (models.Wallet, *models.Wallet, error) {
+	if sweepTo == nil {
+		source, err := lockWallet(ctx, tx, walletId)
+		return source, nil, err
+	}
+
+	if *sweepTo == walletId {
+		return models.Wallet{}, nil, models.ErrInvalidSweepTarget
+	}
+
+	ids := []uuid.UUID{walletId, *sweepTo}
+	if bytes.Compare(ids[1][:], ids[0][:]) < 0 {
+		ids[0], ids[1] = ids[1], ids[0]
+	}
+
+	locked := make(map[uuid.UUID]models.Wallet, len(ids))
+	for _, id := range ids {
+		wallet, err := lockWallet(ctx, tx, id)
+		if errors.Is(err, sql.ErrNoRows) && id == *sweepTo {
+			return models.Wallet{}, nil, models.ErrInvalidSweepTarget
+		}
+		if err != nil {
+			return models.Wallet{}, nil, err
+		}
+		locked[id] = wallet
+	}
+
+	target := locked[*sweepTo]
+	return locked[walletId], &target, nil
+}
+
+// sweepBeforeClose moves the remaining balance of source into target so that
+// source can be closed, and returns the amount moved. Both rows must already
+// be locked by tx.
+func sweepBeforeClose(ctx context.Context, tx *sqlx.Tx, userId int, source models.Wallet, target *models.Wallet) (int64, error) {
+	amount, err := checkSweep(userId, source, target)
+	if err != nil || amount == 0 {
+		return 0, err
+	}
+
+	_, err = applyTransaction(ctx, tx, models.TransactionInput{
+		WalletId:      source.WalletId,
+		OperationType: models.Withdraw,
+		Amount:        amount,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = applyTransaction(ctx, tx, models.TransactionInput{
+		WalletId:      target.WalletId,
+		OperationType: models.Deposit,
+		Amount:        amount,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return amount, nil
+}
+
+// checkSweep reports whether source can be closed and how much of its balance
+// has to be moved into target first.
+func checkSweep(userId int, source models.Wallet, target *models.Wallet) (int64, error) {
+	if source.Status == models.WalletClosed {
+		return 0, models.ErrWalletClosed
+	}
+
+	if source.Amount == 0 {
+		return 0, nil
+	}
+
+	if target == nil {
+		return 0, models.ErrNonZeroBalance
+	}
+
+	if target.UserId != userId {
+		return 0, models.ErrInvalidSweepTarget
+	}
+
+	if err := source.CheckOperation(models.Withdraw, source.Amount); err != nil {
+		return 0, err
+	}
+
+	if err := target.CheckOperation(models.Deposit, source.Amount); err != nil {
+		return 0, fmt.Errorf("%w: %w", models.ErrInvalidSweepTarget, err)
+	}
+
+	return source.Amount, nil
+}
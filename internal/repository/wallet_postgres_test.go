@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
@@ -66,7 +68,7 @@ func TestWallet_Create(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior(testCase.userId)
 
-			got, err := r.Create(testCase.userId)
+			got, err := r.Create(context.Background(), testCase.userId)
 			if testCase.wantErr {
 				assert.Error(t, err)
 				return
@@ -90,10 +92,14 @@ func TestWallet_GetAllFromUser(t *testing.T) {
 
 	type mockBehavior func(userId int, expectedOut []models.Wallet)
 
+	minAmount := int64(10)
+	createdFrom := time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)
+
 	testTable := []struct {
 		name         string
 		mockBehavior mockBehavior
 		inputUserId  int
+		filter       models.WalletFilter
 		expectedOut  []models.Wallet
 		wantErr      bool
 	}{
@@ -108,8 +114,34 @@ func TestWallet_GetAllFromUser(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"wallet_id", "user_id", "amount", "created_at", "updated_at"}).
 					AddRow(expectedOut[0].WalletId, expectedOut[0].UserId, expectedOut[0].Amount, expectedOut[0].CreatedAt, expectedOut[0].UpdatedAt).
 					AddRow(expectedOut[1].WalletId, expectedOut[1].UserId, expectedOut[1].Amount, expectedOut[1].CreatedAt, expectedOut[1].UpdatedAt)
-				mock.ExpectQuery(`SELECT \* FROM wallets WHERE user_id=\$1`).
-					WithArgs(userId).
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE user_id = \$1 ORDER BY created_at, wallet_id LIMIT \$2 OFFSET \$3`).
+					WithArgs(userId, 10, 20).
+					WillReturnRows(rows)
+			},
+		},
+		{
+			name:        "Ok, filtered and sorted",
+			inputUserId: 1,
+			filter: models.WalletFilter{
+				Name:        "Trip_",
+				Tags:        []string{"home", "shared"},
+				MinAmount:   &minAmount,
+				CreatedFrom: &createdFrom,
+				OrderBy:     models.WalletsByAmount,
+				Desc:        true,
+			},
+			expectedOut: []models.Wallet{
+				{WalletId: uuid.New(), UserId: 1, Amount: 50, CreatedAt: createdFrom, UpdatedAt: createdFrom, Name: "Trip_2025", Tags: models.Tags{"home", "shared"}},
+			},
+			mockBehavior: func(userId int, expectedOut []models.Wallet) {
+				rows := sqlmock.NewRows([]string{"wallet_id", "user_id", "amount", "created_at", "updated_at", "name", "tags"}).
+					AddRow(expectedOut[0].WalletId, expectedOut[0].UserId, expectedOut[0].Amount, expectedOut[0].CreatedAt, expectedOut[0].UpdatedAt,
+						expectedOut[0].Name, []byte(`["home", "shared"]`))
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE user_id = \$1 AND lower\(name\) LIKE \$2 ESCAPE '\\' `+
+					`AND tags @> jsonb_build_array\(\$3::text\) AND tags @> jsonb_build_array\(\$4::text\) `+
+					`AND amount >= \$5 AND created_at >= \$6 `+
+					`ORDER BY amount DESC, created_at DESC, wallet_id DESC LIMIT \$7 OFFSET \$8`).
+					WithArgs(userId, `%trip\_%`, "home", "shared", minAmount, createdFrom, 10, 20).
 					WillReturnRows(rows)
 			},
 		},
@@ -118,8 +150,8 @@ func TestWallet_GetAllFromUser(t *testing.T) {
 			inputUserId: 1,
 			mockBehavior: func(userId int, expectedOut []models.Wallet) {
 				rows := sqlmock.NewRows([]string{"wallet_id", "user_id", "amount", "created_at", "updated_at"})
-				mock.ExpectQuery(`SELECT \* FROM wallets WHERE user_id=\$1`).
-					WithArgs(userId).
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE user_id = \$1 ORDER BY created_at, wallet_id LIMIT \$2 OFFSET \$3`).
+					WithArgs(userId, 10, 20).
 					WillReturnRows(rows)
 			},
 		},
@@ -129,7 +161,7 @@ func TestWallet_GetAllFromUser(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior(testCase.inputUserId, testCase.expectedOut)
 
-			got, err := r.GetAllFromUser(testCase.inputUserId)
+			got, err := r.GetAllFromUser(context.Background(), testCase.inputUserId, testCase.filter, models.Page{Limit: 10, Offset: 20})
 			if testCase.wantErr {
 				assert.Error(t, err)
 				return
@@ -197,7 +229,7 @@ func TestWallet_GetByIdFromUser(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior(testCase.inputUserId, testCase.inputWalletId, testCase.expectedOut)
 
-			got, err := r.GetByIdFromUser(testCase.inputUserId, testCase.inputWalletId)
+			got, err := r.GetByIdFromUser(context.Background(), testCase.inputUserId, testCase.inputWalletId)
 			if testCase.wantErr {
 				assert.Error(t, err)
 				return
@@ -262,7 +294,7 @@ func TestWallet_GetById(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior(testCase.inputWalletId, testCase.expectedOut)
 
-			got, err := r.GetById(testCase.inputWalletId)
+			got, err := r.GetById(context.Background(), testCase.inputWalletId)
 			if testCase.wantErr {
 				assert.Error(t, err)
 				return
@@ -274,7 +306,7 @@ func TestWallet_GetById(t *testing.T) {
 	}
 }
 
-func TestWallet_Delete(t *testing.T) {
+func TestWallet_Close(t *testing.T) {
 	db, mock, err := sqlmock.Newx()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
@@ -283,35 +315,155 @@ func TestWallet_Delete(t *testing.T) {
 
 	r := NewWalletPostgres(db)
 
-	type mockBehavior func(userId int, walletId uuid.UUID)
+	type mockBehavior func(userId int, walletId uuid.UUID, input models.CloseWalletInput)
+
+	walletRows := func(walletId uuid.UUID, userId int, amount int64, status models.WalletStatus) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"wallet_id", "user_id", "amount", "created_at", "updated_at", "status", "closed_at", "close_reason"}).
+			AddRow(walletId, userId, amount, time.Now(), time.Now(), status, nil, nil)
+	}
+
+	// sweepTo sorts after the closed wallet, so it's always locked second.
+	sweepTo := uuid.MustParse("223e4567-e89b-12d3-a456-426614174000")
 
 	testTable := []struct {
 		name         string
 		mockBehavior mockBehavior
 		userId       int
 		walletId     uuid.UUID
+		input        models.CloseWalletInput
 		wantErr      bool
+		expectedErr  error
 	}{
 		{
 			name:     "OK",
 			userId:   1,
 			walletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
-			mockBehavior: func(userId int, walletId uuid.UUID) {
+			input:    models.CloseWalletInput{Reason: "not needed"},
+			mockBehavior: func(userId int, walletId uuid.UUID, input models.CloseWalletInput) {
 				mock.ExpectBegin()
-				mock.ExpectExec(`SELECT \* FROM wallets WHERE user_id=\$1 AND wallet_id=\$2 FOR UPDATE`).
-					WithArgs(userId, walletId).
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, userId, 0, models.WalletActive))
+				mock.ExpectExec(`UPDATE wallets SET status = \$1, closed_at = \$2, close_reason = \$3, is_default = false, updated_at = \$2, version = version \+ 1 WHERE wallet_id = \$4`).
+					WithArgs(models.WalletClosed, sqlmock.AnyArg(), sqlmock.AnyArg(), walletId).
 					WillReturnResult(sqlmock.NewResult(0, 1))
-				mock.ExpectExec(`DELETE FROM wallets WHERE user_id=\$1 AND wallet_id=\$2`).
-					WithArgs(userId, walletId).
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:     "OK with sweep",
+			userId:   1,
+			walletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+			input:    models.CloseWalletInput{SweepTo: &sweepTo},
+			mockBehavior: func(userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, userId, 70, models.WalletActive))
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(sweepTo).
+					WillReturnRows(walletRows(sweepTo, userId, 30, models.WalletActive))
+				mock.ExpectQuery("SELECT wallet_id, seq, hash FROM transactions WHERE wallet_id = \\$1 ORDER BY seq DESC LIMIT 1").
+					WithArgs(walletId).
+					WillReturnRows(headRows(walletId, 0, ""))
+				mock.ExpectQuery("INSERT INTO transactions").
+					WithArgs(sqlmock.AnyArg(), walletId, models.Withdraw, int64(70), sqlmock.AnyArg(), int64(1), "", sqlmock.AnyArg(), nil, nil, nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(uuid.New()))
+				mock.ExpectExec(`UPDATE wallets SET amount = amount - \$1`).
+					WithArgs(int64(70), sqlmock.AnyArg(), walletId).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT wallet_id, seq, hash FROM transactions WHERE wallet_id = \\$1 ORDER BY seq DESC LIMIT 1").
+					WithArgs(sweepTo).
+					WillReturnRows(headRows(sweepTo, 0, ""))
+				mock.ExpectQuery("INSERT INTO transactions").
+					WithArgs(sqlmock.AnyArg(), sweepTo, models.Deposit, int64(70), sqlmock.AnyArg(), int64(1), "", sqlmock.AnyArg(), nil, nil, nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(uuid.New()))
+				mock.ExpectExec(`UPDATE wallets SET amount = amount \+ \$1`).
+					WithArgs(int64(70), sqlmock.AnyArg(), sweepTo).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`UPDATE wallets SET status = \$1`).
+					WithArgs(models.WalletClosed, sqlmock.AnyArg(), sqlmock.AnyArg(), walletId).
 					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit()
 			},
 		},
+		{
+			name:     "Non-zero balance, rollback",
+			userId:   1,
+			walletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+			mockBehavior: func(userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, userId, 70, models.WalletActive))
+				mock.ExpectRollback()
+			},
+			wantErr:     true,
+			expectedErr: models.ErrNonZeroBalance,
+		},
+		{
+			name:     "Sweep into other user's wallet, rollback",
+			userId:   1,
+			walletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+			input:    models.CloseWalletInput{SweepTo: &sweepTo},
+			mockBehavior: func(userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, userId, 70, models.WalletActive))
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(sweepTo).
+					WillReturnRows(walletRows(sweepTo, userId+1, 0, models.WalletActive))
+				mock.ExpectRollback()
+			},
+			wantErr:     true,
+			expectedErr: models.ErrInvalidSweepTarget,
+		},
+		{
+			name:     "Sweep into itself",
+			userId:   1,
+			walletId: sweepTo,
+			input:    models.CloseWalletInput{SweepTo: &sweepTo},
+			mockBehavior: func(userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			wantErr:     true,
+			expectedErr: models.ErrInvalidSweepTarget,
+		},
+		{
+			name:     "Already closed, rollback",
+			userId:   1,
+			walletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+			mockBehavior: func(userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, userId, 0, models.WalletClosed))
+				mock.ExpectRollback()
+			},
+			wantErr:     true,
+			expectedErr: models.ErrWalletClosed,
+		},
+		{
+			name:     "Other user's wallet, rollback",
+			userId:   1,
+			walletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+			mockBehavior: func(userId int, walletId uuid.UUID, input models.CloseWalletInput) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, userId+1, 0, models.WalletActive))
+				mock.ExpectRollback()
+			},
+			wantErr:     true,
+			expectedErr: sql.ErrNoRows,
+		},
 		{
 			name:     "Begin error",
 			userId:   1,
 			walletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
-			mockBehavior: func(userId int, walletId uuid.UUID) {
+			mockBehavior: func(userId int, walletId uuid.UUID, input models.CloseWalletInput) {
 				mock.ExpectBegin().WillReturnError(errors.New("some error"))
 			},
 			wantErr: true,
@@ -320,26 +472,26 @@ func TestWallet_Delete(t *testing.T) {
 			name:     "Lock error, rollback",
 			userId:   1,
 			walletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
-			mockBehavior: func(userId int, walletId uuid.UUID) {
+			mockBehavior: func(userId int, walletId uuid.UUID, input models.CloseWalletInput) {
 				mock.ExpectBegin()
-				mock.ExpectExec(`SELECT \* FROM wallets WHERE user_id=\$1 AND wallet_id=\$2 FOR UPDATE`).
-					WithArgs(userId, walletId).
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
 					WillReturnError(errors.New("some error"))
 				mock.ExpectRollback()
 			},
 			wantErr: true,
 		},
 		{
-			name:     "Delete error, rollback",
+			name:     "Update error, rollback",
 			userId:   1,
 			walletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
-			mockBehavior: func(userId int, walletId uuid.UUID) {
+			mockBehavior: func(userId int, walletId uuid.UUID, input models.CloseWalletInput) {
 				mock.ExpectBegin()
-				mock.ExpectExec(`SELECT \* FROM wallets WHERE user_id=\$1 AND wallet_id=\$2 FOR UPDATE`).
-					WithArgs(userId, walletId).
-					WillReturnResult(sqlmock.NewResult(0, 1))
-				mock.ExpectExec(`DELETE FROM wallets WHERE user_id=\$1 AND wallet_id=\$2`).
-					WithArgs(userId, walletId).
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, userId, 0, models.WalletActive))
+				mock.ExpectExec(`UPDATE wallets SET status = \$1`).
+					WithArgs(models.WalletClosed, sqlmock.AnyArg(), sqlmock.AnyArg(), walletId).
 					WillReturnError(errors.New("some error"))
 				mock.ExpectRollback()
 			},
@@ -349,13 +501,13 @@ func TestWallet_Delete(t *testing.T) {
 			name:     "Commit error",
 			userId:   1,
 			walletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
-			mockBehavior: func(userId int, walletId uuid.UUID) {
+			mockBehavior: func(userId int, walletId uuid.UUID, input models.CloseWalletInput) {
 				mock.ExpectBegin()
-				mock.ExpectExec(`SELECT \* FROM wallets WHERE user_id=\$1 AND wallet_id=\$2 FOR UPDATE`).
-					WithArgs(userId, walletId).
-					WillReturnResult(sqlmock.NewResult(0, 1))
-				mock.ExpectExec(`DELETE FROM wallets WHERE user_id=\$1 AND wallet_id=\$2`).
-					WithArgs(userId, walletId).
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, userId, 0, models.WalletActive))
+				mock.ExpectExec(`UPDATE wallets SET status = \$1`).
+					WithArgs(models.WalletClosed, sqlmock.AnyArg(), sqlmock.AnyArg(), walletId).
 					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit().WillReturnError(errors.New("some error"))
 			},
@@ -365,15 +517,213 @@ func TestWallet_Delete(t *testing.T) {
 
 	for _, testCase := range testTable {
 		t.Run(testCase.name, func(t *testing.T) {
-			testCase.mockBehavior(testCase.userId, testCase.walletId)
+			testCase.mockBehavior(testCase.userId, testCase.walletId, testCase.input)
 
-			err := r.Delete(testCase.userId, testCase.walletId)
+			err := r.Close(context.Background(), testCase.userId, testCase.walletId, testCase.input)
 			if testCase.wantErr {
 				assert.Error(t, err)
+				if testCase.expectedErr != nil {
+					assert.ErrorIs(t, err, testCase.expectedErr)
+				}
 				return
 			} else {
 				assert.NoError(t, err)
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWallet_SetFrozen(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := NewWalletPostgres(db)
+
+	walletRows := func(walletId uuid.UUID, status models.WalletStatus) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"wallet_id", "user_id", "amount", "created_at", "updated_at", "status", "closed_at", "close_reason"}).
+			AddRow(walletId, 1, 0, time.Now(), time.Now(), status, nil, nil)
+	}
+
+	testTable := []struct {
+		name         string
+		walletId     uuid.UUID
+		frozen       bool
+		mockBehavior func(walletId uuid.UUID)
+		expectedErr  error
+		wantErr      bool
+	}{
+		{
+			name:     "Freeze",
+			walletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+			frozen:   true,
+			mockBehavior: func(walletId uuid.UUID) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, models.WalletActive))
+				mock.ExpectExec(`UPDATE wallets SET status = \$1, updated_at = \$2, version = version \+ 1 WHERE wallet_id = \$3`).
+					WithArgs(models.WalletFrozen, sqlmock.AnyArg(), walletId).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:     "Unfreeze",
+			walletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+			frozen:   false,
+			mockBehavior: func(walletId uuid.UUID) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, models.WalletFrozen))
+				mock.ExpectExec(`UPDATE wallets SET status = \$1, updated_at = \$2, version = version \+ 1 WHERE wallet_id = \$3`).
+					WithArgs(models.WalletActive, sqlmock.AnyArg(), walletId).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:     "Closed wallet, rollback",
+			walletId: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+			frozen:   true,
+			mockBehavior: func(walletId uuid.UUID) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, models.WalletClosed))
+				mock.ExpectRollback()
+			},
+			expectedErr: models.ErrWalletClosed,
+			wantErr:     true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.walletId)
+
+			err := r.SetFrozen(context.Background(), testCase.walletId, testCase.frozen)
+			if testCase.wantErr {
+				assert.ErrorIs(t, err, testCase.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWallet_Update(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := NewWalletPostgres(db)
+
+	walletId := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	previousId := uuid.MustParse("011e4567-e89b-12d3-a456-426614174000")
+	walletRows := func(walletId uuid.UUID, status models.WalletStatus, isDefault bool) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"wallet_id", "user_id", "amount", "created_at", "updated_at", "status", "version", "name", "tags", "is_default"}).
+			AddRow(walletId, 1, 0, time.Now(), time.Now(), status, 3, "", []byte(`[]`), isDefault)
+	}
+	name, yes := "Savings", true
+	stale := int64(2)
+
+	testTable := []struct {
+		name         string
+		update       models.WalletUpdate
+		mockBehavior func()
+		expectedOut  models.Wallet
+		expectedErr  error
+	}{
+		{
+			name:   "Labels",
+			update: models.WalletUpdate{Name: &name, Tags: &[]string{"home"}},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, models.WalletActive, false))
+				mock.ExpectQuery(`UPDATE wallets SET name = \$1, description = \$2, color = \$3, icon = \$4, tags = \$5, is_default = \$6,\s+`+
+					`updated_at = \$7, version = version \+ 1 WHERE wallet_id = \$8 RETURNING \*`).
+					WithArgs("Savings", "", "", "", `["home"]`, false, sqlmock.AnyArg(), walletId).
+					WillReturnRows(sqlmock.NewRows([]string{"wallet_id", "version", "name", "tags"}).AddRow(walletId, 4, "Savings", []byte(`["home"]`)))
+				mock.ExpectCommit()
+			},
+			expectedOut: models.Wallet{WalletId: walletId, Version: 4, Name: "Savings", Tags: models.Tags{"home"}},
+		},
+		{
+			name:   "Make default",
+			update: models.WalletUpdate{IsDefault: &yes},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`SELECT id FROM users WHERE id = \$1 FOR NO KEY UPDATE`).
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`SELECT wallet_id FROM wallets WHERE user_id = \$1 AND is_default`).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"wallet_id"}).AddRow(previousId))
+				// Locked in the order of their ids.
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(previousId).
+					WillReturnRows(walletRows(previousId, models.WalletActive, true))
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, models.WalletActive, false))
+				mock.ExpectExec(`UPDATE wallets SET is_default = false, updated_at = \$1, version = version \+ 1 WHERE wallet_id = \$2 AND is_default`).
+					WithArgs(sqlmock.AnyArg(), previousId).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`UPDATE wallets SET name = \$1`).
+					WithArgs("", "", "", "", "[]", true, sqlmock.AnyArg(), walletId).
+					WillReturnRows(sqlmock.NewRows([]string{"wallet_id", "version", "is_default"}).AddRow(walletId, 4, true))
+				mock.ExpectCommit()
+			},
+			expectedOut: models.Wallet{WalletId: walletId, Version: 4, IsDefault: true},
+		},
+		{
+			name:   "Version mismatch, rollback",
+			update: models.WalletUpdate{Name: &name, ExpectedVersion: &stale},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, models.WalletActive, false))
+				mock.ExpectRollback()
+			},
+			expectedErr: models.ErrVersionMismatch,
+		},
+		{
+			name:   "Closed wallet, rollback",
+			update: models.WalletUpdate{Name: &name},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM wallets WHERE wallet_id = \$1 FOR UPDATE`).
+					WithArgs(walletId).
+					WillReturnRows(walletRows(walletId, models.WalletClosed, false))
+				mock.ExpectRollback()
+			},
+			expectedErr: models.ErrWalletClosed,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.Update(context.Background(), 1, walletId, testCase.update)
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, err, testCase.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedOut, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type WalletSQLite struct {
+	db *sqlx.DB
+}
+
+func NewWalletSQLite(db *sqlx.DB) *WalletSQLite {
+	return &WalletSQLite{db: db}
+}
+
+func (r *WalletSQLite) Create(ctx context.Context, userId int) (uuid.UUID, error) {
+	var id uuid.UUID
+	query := fmt.Sprintf("INSERT INTO %s (wallet_id, user_id, amount, created_at, updated_at) values ($1, $2, $3, $4, $5) RETURNING wallet_id", walletTable)
+
+	now := sqliteNow()
+	row := r.db.QueryRowContext(ctx, query, uuid.New(), userId, 0, now, now)
+	if err := row.Scan(&id); err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+func (r *WalletSQLite) GetAllFromUser(ctx context.Context, userId int, filter models.WalletFilter, page models.Page) ([]models.Wallet, error) {
+	var wallets []models.Wallet
+	where, args := filterWallets(userId, filter, sqliteTagMatch)
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s ORDER BY %s LIMIT $%d OFFSET $%d",
+		walletTable, where, orderWallets(filter, sqliteNameOrder), len(args)+1, len(args)+2)
+	err := r.db.SelectContext(ctx, &wallets, query, append(args, sqliteLimitArg(page), page.Offset)...)
+
+	return wallets, err
+}
+
+func (r *WalletSQLite) GetByIdFromUser(ctx context.Context, userId int, walletId uuid.UUID) (models.Wallet, error) {
+	var wallet models.Wallet
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id=$1 AND wallet_id=$2", walletTable)
+	err := r.db.GetContext(ctx, &wallet, query, userId, walletId)
+
+	return wallet, err
+}
+
+func (r *WalletSQLite) GetById(ctx context.Context, walletId uuid.UUID) (models.Wallet, error) {
+	var wallet models.Wallet
+	query := fmt.Sprintf("SELECT * FROM %s WHERE wallet_id=$1", walletTable)
+	err := r.db.GetContext(ctx, &wallet, query, walletId)
+
+	return wallet, err
+}
+
+func (r *WalletSQLite) Close(ctx context.Context, userId int, walletId uuid.UUID, input models.CloseWalletInput) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	source, target, err := getForCloseSQLite(ctx, tx, walletId, input.SweepTo)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if source.UserId != userId {
+		tx.Rollback()
+		return sql.ErrNoRows
+	}
+
+	if err := source.CheckVersion(input.ExpectedVersion); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	swept, err := checkSweep(userId, source, target)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if swept > 0 {
+		_, err = applySQLiteTransaction(ctx, tx, models.TransactionInput{
+			WalletId:      source.WalletId,
+			OperationType: models.Withdraw,
+			Amount:        swept,
+		})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		_, err = applySQLiteTransaction(ctx, tx, models.TransactionInput{
+			WalletId:      target.WalletId,
+			OperationType: models.Deposit,
+			Amount:        swept,
+		})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET status = $1, closed_at = $2, close_reason = $3, is_default = false, updated_at = $2, version = version + 1 WHERE wallet_id = $4", walletTable)
+	reason := sql.NullString{String: input.Reason, Valid: input.Reason != ""}
+	_, err = tx.ExecContext(ctx, query, models.WalletClosed, sqliteNow(), reason, walletId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if swept > 0 {
+		metrics.ObserveTransaction(models.Withdraw, swept)
+		metrics.ObserveTransaction(models.Deposit, swept)
+	}
+
+	return nil
+}
+
+func (r *WalletSQLite) SetFrozen(ctx context.Context, walletId uuid.UUID, frozen bool) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	wallet, err := getWalletSQLite(ctx, tx, walletId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if wallet.Status == models.WalletClosed {
+		tx.Rollback()
+		return models.ErrWalletClosed
+	}
+
+	status := models.WalletActive
+	if frozen {
+		status = models.WalletFrozen
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET status = $1, updated_at = $2, version = version + 1 WHERE wallet_id = $3", walletTable)
+	_, err = tx.ExecContext(ctx, query, status, sqliteNow(), walletId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *WalletSQLite) Update(ctx context.Context, userId int, walletId uuid.UUID, update models.WalletUpdate) (models.Wallet, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Wallet{}, err
+	}
+
+	ids := []uuid.UUID{walletId}
+	if update.IsDefault != nil && *update.IsDefault {
+		previous, err := defaultWallet(ctx, tx, userId)
+		if err != nil {
+			tx.Rollback()
+			return models.Wallet{}, err
+		}
+		if previous != nil && *previous != walletId {
+			ids = append(ids, *previous)
+		}
+	}
+
+	found := make(map[uuid.UUID]models.Wallet, len(ids))
+	for _, id := range ids {
+		wallet, err := getWalletSQLite(ctx, tx, id)
+		if err != nil {
+			tx.Rollback()
+			return models.Wallet{}, err
+		}
+		found[id] = wallet
+	}
+
+	wallet, err := applyUpdate(ctx, tx, userId, found, walletId, update, sqliteNow())
+	if err != nil {
+		tx.Rollback()
+		return models.Wallet{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Wallet{}, err
+	}
+
+	return wallet, nil
+}
+
+// getWalletSQLite is lockWallet for SQLite: tx already holds the database
+// write lock, so a plain read is enough.
+func getWalletSQLite(ctx context.Context, tx *sqlx.Tx, walletId uuid.UUID) (models.Wallet, error) {
+	var wallet models.Wallet
+	query := fmt.Sprintf("SELECT * FROM %s WHERE wallet_id = $1", walletTable)
+	err := tx.GetContext(ctx, &wallet, query, walletId)
+
+	return wallet, err
+}
+
+// getForCloseSQLite is lockForClose for SQLite. The wallets are read in the
+// same order, so a missing wallet is reported the same way.
+func getForCloseSQLite(ctx context.Context, tx *sqlx.Tx, walletId uuid.UUID, sweepTo *uuid.UUID) (models.Wallet, *models.Wallet, error) {
+	if sweepTo == nil {
+		source, err := getWalletSQLite(ctx, tx, walletId)
+		return source, nil, err
+	}
+
+	if *sweepTo == walletId {
+		return models.Wallet{}, nil, models.ErrInvalidSweepTarget
+	}
+
+	ids := []uuid.UUID{walletId, *sweepTo}
+	if bytes.Compare(ids[1][:], ids[0][:]) < 0 {
+		ids[0], ids[1] = ids[1], ids[0]
+	}
+
+	found := make(map[uuid.UUID]models.Wallet, len(ids))
+	for _, id := range ids {
+		wallet, err := getWalletSQLite(ctx, tx, id)
+		if errors.Is(err, sql.ErrNoRows) && id == *sweepTo {
+			return models.Wallet{}, nil, models.ErrInvalidSweepTarget
+		}
+		if err != nil {
+			return models.Wallet{}, nil, err
+		}
+		found[id] = wallet
+	}
+
+	target := found[*sweepTo]
+	return found[walletId], &target, nil
+}
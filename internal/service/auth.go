@@ -1,22 +1,18 @@
 package service
 
 import (
+	"context"
 	"crypto/sha1"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/Yoshisoul/rest-wallets/internal/config"
 	"github.com/Yoshisoul/rest-wallets/internal/models"
 	"github.com/Yoshisoul/rest-wallets/internal/repository"
 	"github.com/dgrijalva/jwt-go"
 )
 
-const (
-	salt       = "sdjkf598234yhskjdfg"
-	signingKey = "jksh45j6hKDSFGHe64f"
-	tokenTTL   = 12 * time.Hour
-)
-
 type tokenClaims struct {
 	jwt.StandardClaims
 	UserId int `json:"user_id"`
@@ -24,26 +20,49 @@ type tokenClaims struct {
 
 type AuthService struct {
 	repo repository.Authorization
+	cfg  config.AuthConfig
 }
 
-func NewAuthService(repo repository.Authorization) *AuthService {
-	return &AuthService{repo: repo}
+func NewAuthService(repo repository.Authorization, cfg config.AuthConfig) *AuthService {
+	return &AuthService{repo: repo, cfg: cfg}
 }
 
-func (s *AuthService) CreateUser(user models.SignUpInput) (int, error) {
+func (s *AuthService) CreateUser(ctx context.Context, user models.SignUpInput) (_ int, err error) {
+	ctx, span := startSpan(ctx, "AuthService.CreateUser")
+	defer endSpan(span, &err)
+	defer observeFailure("sign_up", &err)
+
 	user.Password = s.generatePasswordHash(user.Password)
-	return s.repo.CreateUser(user)
+	return s.repo.CreateUser(ctx, user)
+}
+
+func (s *AuthService) GetUser(ctx context.Context, username string) (_ models.User, err error) {
+	ctx, span := startSpan(ctx, "AuthService.GetUser")
+	defer endSpan(span, &err)
+
+	return s.repo.GetUserByUsername(ctx, username)
+}
+
+func (s *AuthService) ResetPassword(ctx context.Context, username, password string) (err error) {
+	ctx, span := startSpan(ctx, "AuthService.ResetPassword")
+	defer endSpan(span, &err)
+
+	return s.repo.UpdatePassword(ctx, username, s.generatePasswordHash(password))
 }
 
 func (s *AuthService) generatePasswordHash(password string) string {
 	hash := sha1.New()
 	hash.Write([]byte(password))
 
-	return fmt.Sprintf("%x", hash.Sum([]byte(salt)))
+	return fmt.Sprintf("%x", hash.Sum([]byte(s.cfg.Salt)))
 }
 
-func (s *AuthService) GenerateToken(username, password string) (string, error) {
-	user, err := s.repo.GetUser(username, s.generatePasswordHash(password))
+func (s *AuthService) GenerateToken(ctx context.Context, username, password string) (_ string, err error) {
+	ctx, span := startSpan(ctx, "AuthService.GenerateToken")
+	defer endSpan(span, &err)
+	defer observeFailure("sign_in", &err)
+
+	user, err := s.repo.GetUser(ctx, username, s.generatePasswordHash(password))
 
 	if err != nil {
 		return "", err
@@ -51,13 +70,13 @@ func (s *AuthService) GenerateToken(username, password string) (string, error) {
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
 		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(tokenTTL).Unix(),
+			ExpiresAt: time.Now().Add(s.cfg.TokenTTL).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
 		user.Id,
 	})
 
-	return token.SignedString([]byte(signingKey))
+	return token.SignedString([]byte(s.cfg.SigningKey))
 }
 
 func (s *AuthService) ParseToken(accessToken string) (int, error) {
@@ -66,7 +85,7 @@ func (s *AuthService) ParseToken(accessToken string) (int, error) {
 			return nil, errors.New("invalid signing method")
 		}
 
-		return []byte(signingKey), nil
+		return []byte(s.cfg.SigningKey), nil
 	})
 	if err != nil {
 		return 0, err
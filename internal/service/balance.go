@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/Yoshisoul/rest-wallets/internal/repository"
+	"github.com/Yoshisoul/rest-wallets/internal/tracing"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// maxBalancePoints bounds the periods of a balance history.
+const maxBalancePoints = 1000
+
+// snapshotRetry is how soon snapshots that failed are tried again.
+const snapshotRetry = time.Minute
+
+type BalanceService struct {
+	repo       repository.Balance
+	walletRepo repository.Wallet
+}
+
+func NewBalanceService(repo repository.Balance, walletRepo repository.Wallet) *BalanceService {
+	return &BalanceService{repo: repo, walletRepo: walletRepo}
+}
+
+func (s *BalanceService) At(ctx context.Context, userId int, walletId uuid.UUID, at time.Time) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "BalanceService.At", tracing.UserID(userId), tracing.WalletID(walletId))
+	defer endSpan(span, &err)
+
+	if at.After(time.Now()) {
+		return 0, models.ErrFutureTime
+	}
+
+	if _, err := s.walletRepo.GetByIdFromUser(ctx, userId, walletId); err != nil {
+		return 0, err
+	}
+
+	balances, err := s.repo.At(ctx, walletId, []time.Time{at})
+	if err != nil {
+		return 0, err
+	}
+
+	return balances[0], nil
+}
+
+func (s *BalanceService) History(ctx context.Context, userId int, walletId uuid.UUID, input models.BalanceHistoryInput) (_ []models.BalancePoint, err error) {
+	ctx, span := startSpan(ctx, "BalanceService.History", tracing.UserID(userId), tracing.WalletID(walletId))
+	defer endSpan(span, &err)
+
+	now := time.Now()
+	if !input.From.Before(input.To) {
+		return nil, models.ErrInvalidRange
+	}
+	if input.From.After(now) {
+		return nil, models.ErrFutureTime
+	}
+	to := input.To
+	if to.After(now) {
+		to = now
+	}
+
+	// The current period closes with the balance of now.
+	var points []models.BalancePoint
+	var at []time.Time
+	for start := input.Interval.Start(input.From); start.Before(to); start = input.Interval.Next(start) {
+		if len(points) == maxBalancePoints {
+			return nil, models.ErrTooManyPoints
+		}
+		end := input.Interval.Next(start)
+		if end.After(now) {
+			end = now
+		}
+		points = append(points, models.BalancePoint{Date: start})
+		at = append(at, end)
+	}
+
+	if _, err := s.walletRepo.GetByIdFromUser(ctx, userId, walletId); err != nil {
+		return nil, err
+	}
+
+	balances, err := s.repo.At(ctx, walletId, at)
+	if err != nil {
+		return nil, err
+	}
+	for i := range points {
+		points[i].Balance = balances[i]
+	}
+	span.SetAttributes(tracing.Rows(len(points)))
+
+	return points, nil
+}
+
+func (s *BalanceService) TakeSnapshots(ctx context.Context, at time.Time) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "BalanceService.TakeSnapshots")
+	defer endSpan(span, &err)
+	defer observeFailure("take_snapshots", &err)
+
+	return s.repo.TakeSnapshots(ctx, at)
+}
+
+// Snapshotter takes balance snapshots at every multiple of interval since the
+// zero time in UTC, so with a 24h interval at midnight UTC. Each is taken
+// delay after its moment, once the transactions dated before it have
+// committed. Snapshots are only taken once, so every instance of the service
+// can run its own Snapshotter.
+type Snapshotter struct {
+	balance  Balance
+	interval time.Duration
+	delay    time.Duration
+}
+
+func NewSnapshotter(balance Balance, interval, delay time.Duration) *Snapshotter {
+	return &Snapshotter{balance: balance, interval: interval, delay: delay}
+}
+
+// Run takes the latest snapshots due, then each as it comes due, until ctx
+// is done.
+func (s *Snapshotter) Run(ctx context.Context) error {
+	for {
+		at := time.Now().Add(-s.delay).Truncate(s.interval)
+		wait := time.Until(at.Add(s.interval + s.delay))
+		start := time.Now()
+		taken, err := s.balance.TakeSnapshots(ctx, at)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logrus.Warnf("error taking balance snapshots at %s: %s", at.UTC().Format(time.RFC3339), err.Error())
+			wait = min(wait, snapshotRetry)
+		} else if taken > 0 {
+			logrus.WithFields(logrus.Fields{
+				"at":       at.UTC().Format(time.RFC3339),
+				"wallets":  taken,
+				"duration": time.Since(start).String(),
+			}).Info("took balance snapshots")
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
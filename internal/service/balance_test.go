@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// snapshotRecorder is a Balance that records the snapshots asked for.
+type snapshotRecorder struct {
+	taken chan time.Time
+	err   error
+}
+
+func (r *snapshotRecorder) At(context.Context, int, uuid.UUID, time.Time) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (r *snapshotRecorder) History(context.Context, int, uuid.UUID, models.BalanceHistoryInput) ([]models.BalancePoint, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *snapshotRecorder) TakeSnapshots(_ context.Context, at time.Time) (int64, error) {
+	r.taken <- at
+	return 1, r.err
+}
+
+func TestSnapshotter_Run(t *testing.T) {
+	testTable := []struct {
+		name string
+		err  error
+	}{
+		{name: "Ok"},
+		{name: "Failed", err: errors.New("db is down")},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			balance := &snapshotRecorder{taken: make(chan time.Time, 1), err: testCase.err}
+			s := NewSnapshotter(balance, time.Hour, 5*time.Minute)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() { done <- s.Run(ctx) }()
+
+			// The latest snapshot due is taken right away, at the last full
+			// hour at least delay ago.
+			at := <-balance.taken
+			assert.Equal(t, time.Now().Add(-5*time.Minute).Truncate(time.Hour), at)
+
+			cancel()
+			assert.NoError(t, <-done)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/Yoshisoul/rest-wallets/internal/repository"
+	"github.com/google/uuid"
+)
+
+type CategoryService struct {
+	repo repository.Category
+}
+
+func NewCategoryService(repo repository.Category) *CategoryService {
+	return &CategoryService{repo: repo}
+}
+
+func (s *CategoryService) Create(ctx context.Context, userId int, input models.CategoryInput) (_ uuid.UUID, err error) {
+	ctx, span := startSpan(ctx, "CategoryService.Create")
+	defer endSpan(span, &err)
+	defer observeFailure("create_category", &err)
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return uuid.Nil, models.ErrNameRequired
+	}
+
+	if input.ParentId != nil {
+		_, err := s.repo.GetById(ctx, userId, *input.ParentId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, models.ErrUnknownCategory
+		}
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	return s.repo.Create(ctx, userId, input)
+}
+
+func (s *CategoryService) GetAll(ctx context.Context, userId int, page models.Page) (_ []models.Category, err error) {
+	ctx, span := startSpan(ctx, "CategoryService.GetAll")
+	defer endSpan(span, &err)
+
+	return s.repo.GetAll(ctx, userId, page)
+}
+
+func (s *CategoryService) Delete(ctx context.Context, userId int, categoryId uuid.UUID) (err error) {
+	ctx, span := startSpan(ctx, "CategoryService.Delete")
+	defer endSpan(span, &err)
+	defer observeFailure("delete_category", &err)
+
+	return s.repo.Delete(ctx, userId, categoryId)
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/Yoshisoul/rest-wallets/internal/repository"
+	"github.com/Yoshisoul/rest-wallets/internal/tracing"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// checkpointRetry is how soon a checkpoint that failed is tried again.
+const checkpointRetry = time.Minute
+
+type ChainService struct {
+	repo       repository.Chain
+	walletRepo repository.Wallet
+	key        ed25519.PrivateKey
+}
+
+// NewChainService returns a ChainService that signs checkpoints with key.
+// Without a key, checkpoints can be verified but not published.
+func NewChainService(repo repository.Chain, walletRepo repository.Wallet, key ed25519.PrivateKey) *ChainService {
+	return &ChainService{repo: repo, walletRepo: walletRepo, key: key}
+}
+
+func (s *ChainService) Verify(ctx context.Context, walletId uuid.UUID) (verification models.ChainVerification, err error) {
+	ctx, span := startSpan(ctx, "ChainService.Verify", tracing.WalletID(walletId))
+	defer endSpan(span, &err)
+	defer observeFailure("verify_chain", &err)
+
+	if _, err := s.walletRepo.GetById(ctx, walletId); err != nil {
+		return models.ChainVerification{}, err
+	}
+
+	links, err := s.repo.Links(ctx, walletId)
+	if err != nil {
+		return models.ChainVerification{}, err
+	}
+
+	verification = models.ChainVerification{WalletId: walletId, Transactions: int64(len(links)), Valid: true}
+	prev := ""
+	for i, link := range links {
+		var reason models.BreakReason
+		switch {
+		case link.Seq != int64(i+1):
+			reason = models.BreakSeqGap
+		case link.Hash == "":
+			reason = models.BreakUnsealed
+		case link.PrevHash != prev:
+			reason = models.BreakPrevHash
+		case link.ComputeHash() != link.Hash:
+			reason = models.BreakHash
+		}
+
+		if reason != "" {
+			verification.Valid = false
+			verification.Break = &models.ChainBreak{Seq: link.Seq, TransactionId: link.TransactionId, Reason: reason}
+			metrics.ObserveChainBreak(string(reason))
+			logrus.WithFields(logrus.Fields{
+				"wallet_id":      walletId,
+				"seq":            link.Seq,
+				"transaction_id": link.TransactionId,
+				"reason":         reason,
+			}).Warn("wallet hash chain is broken")
+			return verification, nil
+		}
+		prev = link.Hash
+	}
+	verification.Head = prev
+
+	return verification, nil
+}
+
+func (s *ChainService) Checkpoint(ctx context.Context, at time.Time) (checkpoint models.Checkpoint, err error) {
+	ctx, span := startSpan(ctx, "ChainService.Checkpoint")
+	defer endSpan(span, &err)
+	defer observeFailure("checkpoint", &err)
+
+	if s.key == nil {
+		return models.Checkpoint{}, models.ErrNoSigningKey
+	}
+	if at.After(time.Now()) {
+		return models.Checkpoint{}, models.ErrFutureTime
+	}
+
+	// Transactions from before the chain have no hash until sealed.
+	if _, err := s.repo.Seal(ctx); err != nil {
+		return models.Checkpoint{}, err
+	}
+
+	heads, err := s.repo.Heads(ctx, at)
+	if err != nil {
+		return models.Checkpoint{}, err
+	}
+
+	checkpoint = models.Checkpoint{
+		CheckpointId: uuid.New(),
+		At:           at.Truncate(time.Microsecond).UTC(),
+		Wallets:      int64(len(heads)),
+		Root:         checkpointRoot(heads),
+		PublicKey:    base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey)),
+		CreatedAt:    time.Now(),
+	}
+	for _, head := range heads {
+		checkpoint.Transactions += head.Seq
+	}
+	checkpoint.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, checkpoint.SignedContent()))
+
+	checkpoint, err = s.repo.CreateCheckpoint(ctx, checkpoint)
+	if err != nil {
+		return models.Checkpoint{}, err
+	}
+	metrics.ObserveCheckpoint(checkpoint.At)
+
+	return checkpoint, nil
+}
+
+func (s *ChainService) GetCheckpoints(ctx context.Context, page models.Page) (_ []models.Checkpoint, err error) {
+	ctx, span := startSpan(ctx, "ChainService.GetCheckpoints")
+	defer endSpan(span, &err)
+	defer observeFailure("get_checkpoints", &err)
+
+	return s.repo.GetCheckpoints(ctx, page)
+}
+
+func (s *ChainService) VerifyCheckpoint(ctx context.Context, checkpointId uuid.UUID) (verification models.CheckpointVerification, err error) {
+	ctx, span := startSpan(ctx, "ChainService.VerifyCheckpoint")
+	defer endSpan(span, &err)
+	defer observeFailure("verify_checkpoint", &err)
+
+	checkpoint, err := s.repo.GetCheckpoint(ctx, checkpointId)
+	if err != nil {
+		return models.CheckpointVerification{}, err
+	}
+
+	heads, err := s.repo.Heads(ctx, checkpoint.At)
+	if err != nil {
+		return models.CheckpointVerification{}, err
+	}
+
+	verification = models.CheckpointVerification{Checkpoint: checkpoint, ComputedRoot: checkpointRoot(heads)}
+	verification.RootMatches = verification.ComputedRoot == checkpoint.Root
+	publicKey, keyErr := base64.StdEncoding.DecodeString(checkpoint.PublicKey)
+	signature, signatureErr := base64.StdEncoding.DecodeString(checkpoint.Signature)
+	verification.SignatureValid = keyErr == nil && signatureErr == nil && len(publicKey) == ed25519.PublicKeySize &&
+		ed25519.Verify(publicKey, checkpoint.SignedContent(), signature)
+	// A signature by another key proves nothing: whoever edited the
+	// transactions could have signed a new root.
+	verification.KeyMatches = s.key != nil && s.key.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(publicKey))
+	verification.Valid = verification.RootMatches && verification.SignatureValid && verification.KeyMatches
+
+	return verification, nil
+}
+
+// checkpointRoot is the RFC 6962 Merkle tree hash, in hex, of the wallet
+// chain heads in wallet order. A leaf is "walletId|seq|hash".
+func checkpointRoot(heads []models.ChainHead) string {
+	leaves := make([][]byte, len(heads))
+	for i, head := range heads {
+		leaves[i] = []byte(fmt.Sprintf("%s|%d|%s", head.WalletId, head.Seq, head.Hash))
+	}
+
+	return hex.EncodeToString(merkleRoot(leaves))
+}
+
+func merkleRoot(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	case 1:
+		sum := sha256.Sum256(append([]byte{0x00}, leaves[0]...))
+		return sum[:]
+	}
+
+	// The left subtree holds the largest power of two of leaves smaller
+	// than their number.
+	split := 1
+	for split*2 < len(leaves) {
+		split *= 2
+	}
+	node := append([]byte{0x01}, merkleRoot(leaves[:split])...)
+	sum := sha256.Sum256(append(node, merkleRoot(leaves[split:])...))
+
+	return sum[:]
+}
+
+// Checkpointer publishes a checkpoint at every multiple of interval since
+// the zero time in UTC, delay after its moment once the transactions dated
+// before it have committed. There is only one checkpoint per moment and
+// Ed25519 signatures are deterministic, so every instance of the service
+// can run its own Checkpointer.
+type Checkpointer struct {
+	chain    Chain
+	interval time.Duration
+	delay    time.Duration
+}
+
+func NewCheckpointer(chain Chain, interval, delay time.Duration) *Checkpointer {
+	return &Checkpointer{chain: chain, interval: interval, delay: delay}
+}
+
+// Run publishes the latest checkpoint due, then each as it comes due, until
+// ctx is done.
+func (c *Checkpointer) Run(ctx context.Context) error {
+	for {
+		at := time.Now().Add(-c.delay).Truncate(c.interval)
+		wait := time.Until(at.Add(c.interval + c.delay))
+		checkpoint, err := c.chain.Checkpoint(ctx, at)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logrus.Warnf("error publishing checkpoint at %s: %s", at.UTC().Format(time.RFC3339), err.Error())
+			wait = min(wait, checkpointRetry)
+		} else {
+			logrus.WithFields(logrus.Fields{
+				"checkpoint_id": checkpoint.CheckpointId,
+				"at":            checkpoint.At.UTC().Format(time.RFC3339),
+				"wallets":       checkpoint.Wallets,
+				"transactions":  checkpoint.Transactions,
+				"root":          checkpoint.Root,
+			}).Info("published checkpoint")
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
@@ -10,7 +10,9 @@
 package mock_service
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/Yoshisoul/rest-wallets/internal/models"
 	uuid "github.com/google/uuid"
@@ -42,33 +44,48 @@ func (m *MockAuthorization) EXPECT() *MockAuthorizationMockRecorder {
 }
 
 // CreateUser mocks base method.
-func (m *MockAuthorization) CreateUser(user models.SignUpInput) (int, error) {
+func (m *MockAuthorization) CreateUser(ctx context.Context, user models.SignUpInput) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", user)
+	ret := m.ctrl.Call(m, "CreateUser", ctx, user)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockAuthorizationMockRecorder) CreateUser(user any) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) CreateUser(ctx, user any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthorization)(nil).CreateUser), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthorization)(nil).CreateUser), ctx, user)
 }
 
 // GenerateToken mocks base method.
-func (m *MockAuthorization) GenerateToken(username, password string) (string, error) {
+func (m *MockAuthorization) GenerateToken(ctx context.Context, username, password string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateToken", username, password)
+	ret := m.ctrl.Call(m, "GenerateToken", ctx, username, password)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateToken indicates an expected call of GenerateToken.
-func (mr *MockAuthorizationMockRecorder) GenerateToken(username, password any) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) GenerateToken(ctx, username, password any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockAuthorization)(nil).GenerateToken), username, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockAuthorization)(nil).GenerateToken), ctx, username, password)
+}
+
+// GetUser mocks base method.
+func (m *MockAuthorization) GetUser(ctx context.Context, username string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockAuthorizationMockRecorder) GetUser(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockAuthorization)(nil).GetUser), ctx, username)
 }
 
 // ParseToken mocks base method.
@@ -86,6 +103,20 @@ func (mr *MockAuthorizationMockRecorder) ParseToken(token any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockAuthorization)(nil).ParseToken), token)
 }
 
+// ResetPassword mocks base method.
+func (m *MockAuthorization) ResetPassword(ctx context.Context, username, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, username, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAuthorizationMockRecorder) ResetPassword(ctx, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAuthorization)(nil).ResetPassword), ctx, username, password)
+}
+
 // MockWallet is a mock of Wallet interface.
 type MockWallet struct {
 	ctrl     *gomock.Controller
@@ -110,63 +141,107 @@ func (m *MockWallet) EXPECT() *MockWalletMockRecorder {
 	return m.recorder
 }
 
+// Close mocks base method.
+func (m *MockWallet) Close(ctx context.Context, userId int, walletId uuid.UUID, input models.CloseWalletInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close", ctx, userId, walletId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockWalletMockRecorder) Close(ctx, userId, walletId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockWallet)(nil).Close), ctx, userId, walletId, input)
+}
+
 // Create mocks base method.
-func (m *MockWallet) Create(userId int) (uuid.UUID, error) {
+func (m *MockWallet) Create(ctx context.Context, userId int) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", userId)
+	ret := m.ctrl.Call(m, "Create", ctx, userId)
 	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockWalletMockRecorder) Create(userId any) *gomock.Call {
+func (mr *MockWalletMockRecorder) Create(ctx, userId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWallet)(nil).Create), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWallet)(nil).Create), ctx, userId)
 }
 
-// Delete mocks base method.
-func (m *MockWallet) Delete(userId int, walletId uuid.UUID) error {
+// GetAllFromUser mocks base method.
+func (m *MockWallet) GetAllFromUser(ctx context.Context, userId int, filter models.WalletFilter, page models.Page) ([]models.Wallet, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", userId, walletId)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetAllFromUser", ctx, userId, filter, page)
+	ret0, _ := ret[0].([]models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Delete indicates an expected call of Delete.
-func (mr *MockWalletMockRecorder) Delete(userId, walletId any) *gomock.Call {
+// GetAllFromUser indicates an expected call of GetAllFromUser.
+func (mr *MockWalletMockRecorder) GetAllFromUser(ctx, userId, filter, page any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockWallet)(nil).Delete), userId, walletId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllFromUser", reflect.TypeOf((*MockWallet)(nil).GetAllFromUser), ctx, userId, filter, page)
 }
 
-// GetAllFromUser mocks base method.
-func (m *MockWallet) GetAllFromUser(userId int) ([]models.Wallet, error) {
+// GetById mocks base method.
+func (m *MockWallet) GetById(ctx context.Context, walletId uuid.UUID) (models.Wallet, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllFromUser", userId)
-	ret0, _ := ret[0].([]models.Wallet)
+	ret := m.ctrl.Call(m, "GetById", ctx, walletId)
+	ret0, _ := ret[0].(models.Wallet)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllFromUser indicates an expected call of GetAllFromUser.
-func (mr *MockWalletMockRecorder) GetAllFromUser(userId any) *gomock.Call {
+// GetById indicates an expected call of GetById.
+func (mr *MockWalletMockRecorder) GetById(ctx, walletId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllFromUser", reflect.TypeOf((*MockWallet)(nil).GetAllFromUser), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockWallet)(nil).GetById), ctx, walletId)
 }
 
 // GetByIdFromUser mocks base method.
-func (m *MockWallet) GetByIdFromUser(userId int, walletId uuid.UUID) (models.Wallet, error) {
+func (m *MockWallet) GetByIdFromUser(ctx context.Context, userId int, walletId uuid.UUID) (models.Wallet, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByIdFromUser", userId, walletId)
+	ret := m.ctrl.Call(m, "GetByIdFromUser", ctx, userId, walletId)
 	ret0, _ := ret[0].(models.Wallet)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByIdFromUser indicates an expected call of GetByIdFromUser.
-func (mr *MockWalletMockRecorder) GetByIdFromUser(userId, walletId any) *gomock.Call {
+func (mr *MockWalletMockRecorder) GetByIdFromUser(ctx, userId, walletId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIdFromUser", reflect.TypeOf((*MockWallet)(nil).GetByIdFromUser), ctx, userId, walletId)
+}
+
+// SetFrozen mocks base method.
+func (m *MockWallet) SetFrozen(ctx context.Context, walletId uuid.UUID, frozen bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFrozen", ctx, walletId, frozen)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetFrozen indicates an expected call of SetFrozen.
+func (mr *MockWalletMockRecorder) SetFrozen(ctx, walletId, frozen any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFrozen", reflect.TypeOf((*MockWallet)(nil).SetFrozen), ctx, walletId, frozen)
+}
+
+// Update mocks base method.
+func (m *MockWallet) Update(ctx context.Context, userId int, walletId uuid.UUID, update models.WalletUpdate) (models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, userId, walletId, update)
+	ret0, _ := ret[0].(models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockWalletMockRecorder) Update(ctx, userId, walletId, update any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIdFromUser", reflect.TypeOf((*MockWallet)(nil).GetByIdFromUser), userId, walletId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockWallet)(nil).Update), ctx, userId, walletId, update)
 }
 
 // MockTransaction is a mock of Transaction interface.
@@ -193,47 +268,396 @@ func (m *MockTransaction) EXPECT() *MockTransactionMockRecorder {
 	return m.recorder
 }
 
+// Adjust mocks base method.
+func (m *MockTransaction) Adjust(ctx context.Context, adjustment models.AdjustmentInput) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Adjust", ctx, adjustment)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Adjust indicates an expected call of Adjust.
+func (mr *MockTransactionMockRecorder) Adjust(ctx, adjustment any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Adjust", reflect.TypeOf((*MockTransaction)(nil).Adjust), ctx, adjustment)
+}
+
+// Annotate mocks base method.
+func (m *MockTransaction) Annotate(ctx context.Context, userId int, transactionId uuid.UUID, input models.AnnotationInput) (models.Annotation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Annotate", ctx, userId, transactionId, input)
+	ret0, _ := ret[0].(models.Annotation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Annotate indicates an expected call of Annotate.
+func (mr *MockTransactionMockRecorder) Annotate(ctx, userId, transactionId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Annotate", reflect.TypeOf((*MockTransaction)(nil).Annotate), ctx, userId, transactionId, input)
+}
+
 // Create mocks base method.
-func (m *MockTransaction) Create(transaction models.TransactionInput) (uuid.UUID, error) {
+func (m *MockTransaction) Create(ctx context.Context, transaction models.TransactionInput) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", transaction)
+	ret := m.ctrl.Call(m, "Create", ctx, transaction)
 	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockTransactionMockRecorder) Create(transaction any) *gomock.Call {
+func (mr *MockTransactionMockRecorder) Create(ctx, transaction any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTransaction)(nil).Create), transaction)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTransaction)(nil).Create), ctx, transaction)
 }
 
 // GetAll mocks base method.
-func (m *MockTransaction) GetAll() ([]models.Transaction, error) {
+func (m *MockTransaction) GetAll(ctx context.Context, filter models.TransactionFilter, page models.Page) ([]models.Transaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll")
+	ret := m.ctrl.Call(m, "GetAll", ctx, filter, page)
 	ret0, _ := ret[0].([]models.Transaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockTransactionMockRecorder) GetAll() *gomock.Call {
+func (mr *MockTransactionMockRecorder) GetAll(ctx, filter, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTransaction)(nil).GetAll), ctx, filter, page)
+}
+
+// GetAllFromWallet mocks base method.
+func (m *MockTransaction) GetAllFromWallet(ctx context.Context, walletId uuid.UUID) ([]models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllFromWallet", ctx, walletId)
+	ret0, _ := ret[0].([]models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllFromWallet indicates an expected call of GetAllFromWallet.
+func (mr *MockTransactionMockRecorder) GetAllFromWallet(ctx, walletId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllFromWallet", reflect.TypeOf((*MockTransaction)(nil).GetAllFromWallet), ctx, walletId)
+}
+
+// GetAnnotations mocks base method.
+func (m *MockTransaction) GetAnnotations(ctx context.Context, userId int, transactionId uuid.UUID, page models.Page) ([]models.Annotation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAnnotations", ctx, userId, transactionId, page)
+	ret0, _ := ret[0].([]models.Annotation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAnnotations indicates an expected call of GetAnnotations.
+func (mr *MockTransactionMockRecorder) GetAnnotations(ctx, userId, transactionId, page any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTransaction)(nil).GetAll))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAnnotations", reflect.TypeOf((*MockTransaction)(nil).GetAnnotations), ctx, userId, transactionId, page)
 }
 
 // GetById mocks base method.
-func (m *MockTransaction) GetById(transactionId uuid.UUID) (models.Transaction, error) {
+func (m *MockTransaction) GetById(ctx context.Context, transactionId uuid.UUID) (models.Transaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetById", transactionId)
+	ret := m.ctrl.Call(m, "GetById", ctx, transactionId)
 	ret0, _ := ret[0].(models.Transaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetById indicates an expected call of GetById.
-func (mr *MockTransactionMockRecorder) GetById(transactionId any) *gomock.Call {
+func (mr *MockTransactionMockRecorder) GetById(ctx, transactionId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockTransaction)(nil).GetById), ctx, transactionId)
+}
+
+// MockCategory is a mock of Category interface.
+type MockCategory struct {
+	ctrl     *gomock.Controller
+	recorder *MockCategoryMockRecorder
+	isgomock struct{}
+}
+
+// MockCategoryMockRecorder is the mock recorder for MockCategory.
+type MockCategoryMockRecorder struct {
+	mock *MockCategory
+}
+
+// NewMockCategory creates a new mock instance.
+func NewMockCategory(ctrl *gomock.Controller) *MockCategory {
+	mock := &MockCategory{ctrl: ctrl}
+	mock.recorder = &MockCategoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCategory) EXPECT() *MockCategoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockCategory) Create(ctx context.Context, userId int, input models.CategoryInput) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, userId, input)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockCategoryMockRecorder) Create(ctx, userId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCategory)(nil).Create), ctx, userId, input)
+}
+
+// Delete mocks base method.
+func (m *MockCategory) Delete(ctx context.Context, userId int, categoryId uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userId, categoryId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCategoryMockRecorder) Delete(ctx, userId, categoryId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCategory)(nil).Delete), ctx, userId, categoryId)
+}
+
+// GetAll mocks base method.
+func (m *MockCategory) GetAll(ctx context.Context, userId int, page models.Page) ([]models.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", ctx, userId, page)
+	ret0, _ := ret[0].([]models.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockCategoryMockRecorder) GetAll(ctx, userId, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockCategory)(nil).GetAll), ctx, userId, page)
+}
+
+// MockBalance is a mock of Balance interface.
+type MockBalance struct {
+	ctrl     *gomock.Controller
+	recorder *MockBalanceMockRecorder
+	isgomock struct{}
+}
+
+// MockBalanceMockRecorder is the mock recorder for MockBalance.
+type MockBalanceMockRecorder struct {
+	mock *MockBalance
+}
+
+// NewMockBalance creates a new mock instance.
+func NewMockBalance(ctrl *gomock.Controller) *MockBalance {
+	mock := &MockBalance{ctrl: ctrl}
+	mock.recorder = &MockBalanceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBalance) EXPECT() *MockBalanceMockRecorder {
+	return m.recorder
+}
+
+// At mocks base method.
+func (m *MockBalance) At(ctx context.Context, userId int, walletId uuid.UUID, at time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "At", ctx, userId, walletId, at)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// At indicates an expected call of At.
+func (mr *MockBalanceMockRecorder) At(ctx, userId, walletId, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "At", reflect.TypeOf((*MockBalance)(nil).At), ctx, userId, walletId, at)
+}
+
+// History mocks base method.
+func (m *MockBalance) History(ctx context.Context, userId int, walletId uuid.UUID, input models.BalanceHistoryInput) ([]models.BalancePoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", ctx, userId, walletId, input)
+	ret0, _ := ret[0].([]models.BalancePoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History.
+func (mr *MockBalanceMockRecorder) History(ctx, userId, walletId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockBalance)(nil).History), ctx, userId, walletId, input)
+}
+
+// TakeSnapshots mocks base method.
+func (m *MockBalance) TakeSnapshots(ctx context.Context, at time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeSnapshots", ctx, at)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeSnapshots indicates an expected call of TakeSnapshots.
+func (mr *MockBalanceMockRecorder) TakeSnapshots(ctx, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeSnapshots", reflect.TypeOf((*MockBalance)(nil).TakeSnapshots), ctx, at)
+}
+
+// MockReconciliation is a mock of Reconciliation interface.
+type MockReconciliation struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconciliationMockRecorder
+	isgomock struct{}
+}
+
+// MockReconciliationMockRecorder is the mock recorder for MockReconciliation.
+type MockReconciliationMockRecorder struct {
+	mock *MockReconciliation
+}
+
+// NewMockReconciliation creates a new mock instance.
+func NewMockReconciliation(ctrl *gomock.Controller) *MockReconciliation {
+	mock := &MockReconciliation{ctrl: ctrl}
+	mock.recorder = &MockReconciliationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciliation) EXPECT() *MockReconciliationMockRecorder {
+	return m.recorder
+}
+
+// GetCases mocks base method.
+func (m *MockReconciliation) GetCases(ctx context.Context, status models.CaseStatus, page models.Page) ([]models.CorrectionCase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCases", ctx, status, page)
+	ret0, _ := ret[0].([]models.CorrectionCase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCases indicates an expected call of GetCases.
+func (mr *MockReconciliationMockRecorder) GetCases(ctx, status, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCases", reflect.TypeOf((*MockReconciliation)(nil).GetCases), ctx, status, page)
+}
+
+// ResolveCase mocks base method.
+func (m *MockReconciliation) ResolveCase(ctx context.Context, caseId uuid.UUID, resolution string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveCase", ctx, caseId, resolution)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolveCase indicates an expected call of ResolveCase.
+func (mr *MockReconciliationMockRecorder) ResolveCase(ctx, caseId, resolution any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveCase", reflect.TypeOf((*MockReconciliation)(nil).ResolveCase), ctx, caseId, resolution)
+}
+
+// Run mocks base method.
+func (m *MockReconciliation) Run(ctx context.Context, openCases bool) (models.ReconciliationReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Run", ctx, openCases)
+	ret0, _ := ret[0].(models.ReconciliationReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockReconciliationMockRecorder) Run(ctx, openCases any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockReconciliation)(nil).Run), ctx, openCases)
+}
+
+// MockChain is a mock of Chain interface.
+type MockChain struct {
+	ctrl     *gomock.Controller
+	recorder *MockChainMockRecorder
+	isgomock struct{}
+}
+
+// MockChainMockRecorder is the mock recorder for MockChain.
+type MockChainMockRecorder struct {
+	mock *MockChain
+}
+
+// NewMockChain creates a new mock instance.
+func NewMockChain(ctrl *gomock.Controller) *MockChain {
+	mock := &MockChain{ctrl: ctrl}
+	mock.recorder = &MockChainMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChain) EXPECT() *MockChainMockRecorder {
+	return m.recorder
+}
+
+// Checkpoint mocks base method.
+func (m *MockChain) Checkpoint(ctx context.Context, at time.Time) (models.Checkpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Checkpoint", ctx, at)
+	ret0, _ := ret[0].(models.Checkpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Checkpoint indicates an expected call of Checkpoint.
+func (mr *MockChainMockRecorder) Checkpoint(ctx, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Checkpoint", reflect.TypeOf((*MockChain)(nil).Checkpoint), ctx, at)
+}
+
+// GetCheckpoints mocks base method.
+func (m *MockChain) GetCheckpoints(ctx context.Context, page models.Page) ([]models.Checkpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCheckpoints", ctx, page)
+	ret0, _ := ret[0].([]models.Checkpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCheckpoints indicates an expected call of GetCheckpoints.
+func (mr *MockChainMockRecorder) GetCheckpoints(ctx, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCheckpoints", reflect.TypeOf((*MockChain)(nil).GetCheckpoints), ctx, page)
+}
+
+// Verify mocks base method.
+func (m *MockChain) Verify(ctx context.Context, walletId uuid.UUID) (models.ChainVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, walletId)
+	ret0, _ := ret[0].(models.ChainVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockChainMockRecorder) Verify(ctx, walletId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockChain)(nil).Verify), ctx, walletId)
+}
+
+// VerifyCheckpoint mocks base method.
+func (m *MockChain) VerifyCheckpoint(ctx context.Context, checkpointId uuid.UUID) (models.CheckpointVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyCheckpoint", ctx, checkpointId)
+	ret0, _ := ret[0].(models.CheckpointVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyCheckpoint indicates an expected call of VerifyCheckpoint.
+func (mr *MockChainMockRecorder) VerifyCheckpoint(ctx, checkpointId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockTransaction)(nil).GetById), transactionId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyCheckpoint", reflect.TypeOf((*MockChain)(nil).VerifyCheckpoint), ctx, checkpointId)
 }
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
+	"github.com/Yoshisoul/rest-wallets/internal/models"
+	"github.com/Yoshisoul/rest-wallets/internal/repository"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// reconcileRetry is how soon a reconciliation that failed is tried again.
+const reconcileRetry = time.Minute
+
+type ReconciliationService struct {
+	repo repository.Reconciliation
+}
+
+func NewReconciliationService(repo repository.Reconciliation) *ReconciliationService {
+	return &ReconciliationService{repo: repo}
+}
+
+func (s *ReconciliationService) Run(ctx context.Context, openCases bool) (report models.ReconciliationReport, err error) {
+	ctx, span := startSpan(ctx, "ReconciliationService.Run")
+	defer endSpan(span, &err)
+	defer observeFailure("reconcile", &err)
+
+	report.StartedAt = time.Now()
+	report.Wallets, report.Mismatches, err = s.repo.Mismatches(ctx)
+	if err != nil {
+		return models.ReconciliationReport{}, err
+	}
+
+	for i, mismatch := range report.Mismatches {
+		if openCases {
+			caseId, err := s.repo.OpenCase(ctx, mismatch)
+			if err != nil {
+				return models.ReconciliationReport{}, err
+			}
+			report.Mismatches[i].CaseId = &caseId
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"wallet_id":    mismatch.WalletId,
+			"user_id":      mismatch.UserId,
+			"stored":       mismatch.Stored,
+			"computed":     mismatch.Computed,
+			"difference":   mismatch.Difference,
+			"transactions": mismatch.Transactions,
+		}).Warn("wallet balance differs from its transactions")
+	}
+	if report.Mismatches == nil {
+		report.Mismatches = []models.BalanceMismatch{}
+	}
+	report.FinishedAt = time.Now()
+	metrics.ObserveReconciliation(len(report.Mismatches), report.FinishedAt)
+
+	return report, nil
+}
+
+func (s *ReconciliationService) GetCases(ctx context.Context, status models.CaseStatus, page models.Page) (_ []models.CorrectionCase, err error) {
+	ctx, span := startSpan(ctx, "ReconciliationService.GetCases")
+	defer endSpan(span, &err)
+	defer observeFailure("get_cases", &err)
+
+	return s.repo.GetCases(ctx, status, page)
+}
+
+func (s *ReconciliationService) ResolveCase(ctx context.Context, caseId uuid.UUID, resolution string) (err error) {
+	ctx, span := startSpan(ctx, "ReconciliationService.ResolveCase")
+	defer endSpan(span, &err)
+	defer observeFailure("resolve_case", &err)
+
+	resolution = strings.TrimSpace(resolution)
+	if resolution == "" {
+		return models.ErrResolutionRequired
+	}
+	if utf8.RuneCountInString(resolution) > models.MaxResolutionLength {
+		return models.ErrResolutionTooLong
+	}
+
+	return s.repo.ResolveCase(ctx, caseId, resolution)
+}
+
+// Reconciler runs the reconciliation at every multiple of interval since the
+// zero time in UTC, so with a 24h interval at midnight UTC. Opening a case
+// for a wallet that already has one open is a no-op, so every instance of the
+// service can run its own Reconciler.
+type Reconciler struct {
+	reconciliation Reconciliation
+	interval       time.Duration
+	openCases      bool
+}
+
+func NewReconciler(reconciliation Reconciliation, interval time.Duration, openCases bool) *Reconciler {
+	return &Reconciler{reconciliation: reconciliation, interval: interval, openCases: openCases}
+}
+
+// Run reconciles at each multiple of the interval until ctx is done.
+func (r *Reconciler) Run(ctx context.Context) error {
+	wait := time.Until(time.Now().Truncate(r.interval).Add(r.interval))
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		wait = time.Until(time.Now().Truncate(r.interval).Add(r.interval))
+		report, err := r.reconciliation.Run(ctx, r.openCases)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logrus.Warnf("error reconciling balances: %s", err.Error())
+			wait = min(wait, reconcileRetry)
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"wallets":    report.Wallets,
+			"mismatches": len(report.Mismatches),
+			"duration":   report.FinishedAt.Sub(report.StartedAt).String(),
+		}).Info("reconciled balances")
+	}
+}
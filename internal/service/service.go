@@ -1,42 +1,141 @@
 package service
 
 import (
+	"context"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/config"
+	"github.com/Yoshisoul/rest-wallets/internal/metrics"
 	"github.com/Yoshisoul/rest-wallets/internal/models"
 	"github.com/Yoshisoul/rest-wallets/internal/repository"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 //go:generate mockgen -source=service.go -destination=mocks/mock.go
 
 type Authorization interface {
-	CreateUser(user models.SignUpInput) (int, error)
-	GenerateToken(username, password string) (string, error)
+	CreateUser(ctx context.Context, user models.SignUpInput) (int, error)
+	GenerateToken(ctx context.Context, username, password string) (string, error)
 	ParseToken(token string) (int, error)
+	GetUser(ctx context.Context, username string) (models.User, error)
+	ResetPassword(ctx context.Context, username, password string) error
 }
 
 type Wallet interface {
-	Create(userId int) (uuid.UUID, error)
-	GetAllFromUser(userId int) ([]models.Wallet, error)
-	GetByIdFromUser(userId int, walletId uuid.UUID) (models.Wallet, error)
-	Delete(userId int, walletId uuid.UUID) error
+	Create(ctx context.Context, userId int) (uuid.UUID, error)
+	GetAllFromUser(ctx context.Context, userId int, filter models.WalletFilter, page models.Page) ([]models.Wallet, error)
+	GetByIdFromUser(ctx context.Context, userId int, walletId uuid.UUID) (models.Wallet, error)
+	GetById(ctx context.Context, walletId uuid.UUID) (models.Wallet, error)
+	Close(ctx context.Context, userId int, walletId uuid.UUID, input models.CloseWalletInput) error
+	SetFrozen(ctx context.Context, walletId uuid.UUID, frozen bool) error
+	Update(ctx context.Context, userId int, walletId uuid.UUID, update models.WalletUpdate) (models.Wallet, error)
 }
 
 type Transaction interface {
-	Create(transaction models.TransactionInput) (uuid.UUID, error)
-	GetAll() ([]models.Transaction, error)
-	GetById(transactionId uuid.UUID) (models.Transaction, error)
+	Create(ctx context.Context, transaction models.TransactionInput) (uuid.UUID, error)
+	Adjust(ctx context.Context, adjustment models.AdjustmentInput) (uuid.UUID, error)
+	GetAll(ctx context.Context, filter models.TransactionFilter, page models.Page) ([]models.Transaction, error)
+	GetAllFromWallet(ctx context.Context, walletId uuid.UUID) ([]models.Transaction, error)
+	GetById(ctx context.Context, transactionId uuid.UUID) (models.Transaction, error)
+	Annotate(ctx context.Context, userId int, transactionId uuid.UUID, input models.AnnotationInput) (models.Annotation, error)
+	GetAnnotations(ctx context.Context, userId int, transactionId uuid.UUID, page models.Page) ([]models.Annotation, error)
+}
+
+// Category manages the categories users classify their transactions with.
+type Category interface {
+	Create(ctx context.Context, userId int, input models.CategoryInput) (uuid.UUID, error)
+	GetAll(ctx context.Context, userId int, page models.Page) ([]models.Category, error)
+	Delete(ctx context.Context, userId int, categoryId uuid.UUID) error
+}
+
+// Balance answers what the balance of a wallet was in the past.
+type Balance interface {
+	At(ctx context.Context, userId int, walletId uuid.UUID, at time.Time) (int64, error)
+	History(ctx context.Context, userId int, walletId uuid.UUID, input models.BalanceHistoryInput) ([]models.BalancePoint, error)
+	// TakeSnapshots records the balance before at of every wallet open at
+	// that moment, unless it is already recorded, and returns the number of
+	// snapshots taken.
+	TakeSnapshots(ctx context.Context, at time.Time) (int64, error)
+}
+
+// Reconciliation checks the wallet balances against their transactions and
+// keeps the correction cases opened for the mismatches.
+type Reconciliation interface {
+	Run(ctx context.Context, openCases bool) (models.ReconciliationReport, error)
+	GetCases(ctx context.Context, status models.CaseStatus, page models.Page) ([]models.CorrectionCase, error)
+	ResolveCase(ctx context.Context, caseId uuid.UUID, resolution string) error
+}
+
+// Chain verifies the hash chains of the wallet transactions and publishes
+// signed checkpoints over them.
+type Chain interface {
+	Verify(ctx context.Context, walletId uuid.UUID) (models.ChainVerification, error)
+	Checkpoint(ctx context.Context, at time.Time) (models.Checkpoint, error)
+	GetCheckpoints(ctx context.Context, page models.Page) ([]models.Checkpoint, error)
+	VerifyCheckpoint(ctx context.Context, checkpointId uuid.UUID) (models.CheckpointVerification, error)
 }
 
 type Service struct {
 	Authorization
 	Wallet
 	Transaction
+	Category
+	Balance
+	Reconciliation
+	Chain
 }
 
-func NewService(repos *repository.Repository) *Service {
+func NewService(repos *repository.Repository, auth config.AuthConfig, checkpoints config.CheckpointsConfig) *Service {
+	// Validated by config.
+	key, _ := checkpoints.PrivateKey()
+
 	return &Service{
-		Authorization: NewAuthService(repos.Authorization),
-		Wallet:        NewWalletService(repos.Wallet),
-		Transaction:   NewTransactionService(repos.Transaction, repos.Wallet),
+		Authorization:  NewAuthService(repos.Authorization, auth),
+		Wallet:         NewWalletService(repos.Wallet),
+		Transaction:    NewTransactionService(repos.Transaction, repos.Wallet, repos.Category),
+		Category:       NewCategoryService(repos.Category),
+		Balance:        NewBalanceService(repos.Balance, repos.Wallet),
+		Reconciliation: NewReconciliationService(repos.Reconciliation),
+		Chain:          NewChainService(repos.Chain, repos.Wallet, key),
+	}
+}
+
+// WithSession identifies the client a request comes from by keys such as its
+// user or the wallet it writes to. Once the client has written, its reads
+// see the write even when reads are served by replicas.
+func WithSession(ctx context.Context, keys ...string) context.Context {
+	return repository.WithSession(ctx, keys...)
+}
+
+// SessionKeys returns the keys WithSession added to ctx.
+func SessionKeys(ctx context.Context) []string {
+	return repository.SessionKeys(ctx)
+}
+
+// observeFailure counts the operation as failed when it returns an error.
+// It is meant to be deferred with a pointer to a named error result.
+func observeFailure(operation string, err *error) {
+	if *err != nil {
+		metrics.ObserveFailure(operation, *err)
+	}
+}
+
+var tracer = otel.Tracer("github.com/Yoshisoul/rest-wallets/internal/service")
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records the error of the operation, if any, and ends the span. Like
+// observeFailure it is meant to be deferred with a named error result.
+func endSpan(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
 	}
+	span.End()
 }
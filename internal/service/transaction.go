@@ -1,33 +1,172 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
 	"github.com/Yoshisoul/rest-wallets/internal/models"
 	"github.com/Yoshisoul/rest-wallets/internal/repository"
+	"github.com/Yoshisoul/rest-wallets/internal/tracing"
 	"github.com/google/uuid"
 )
 
 type TransactionService struct {
-	repo       repository.Transaction
-	walletRepo repository.Wallet
+	repo         repository.Transaction
+	walletRepo   repository.Wallet
+	categoryRepo repository.Category
 }
 
-func NewTransactionService(repo repository.Transaction, walletRepo repository.Wallet) *TransactionService {
-	return &TransactionService{repo: repo, walletRepo: walletRepo}
+func NewTransactionService(repo repository.Transaction, walletRepo repository.Wallet, categoryRepo repository.Category) *TransactionService {
+	return &TransactionService{repo: repo, walletRepo: walletRepo, categoryRepo: categoryRepo}
 }
 
-func (s *TransactionService) Create(transaction models.TransactionInput) (uuid.UUID, error) {
-	_, err := s.walletRepo.GetById(transaction.WalletId)
+func (s *TransactionService) Create(ctx context.Context, transaction models.TransactionInput) (_ uuid.UUID, err error) {
+	ctx, span := startSpan(ctx, "TransactionService.Create",
+		tracing.WalletID(transaction.WalletId), tracing.OperationType(transaction.OperationType))
+	defer endSpan(span, &err)
+	defer observeFailure("create_transaction", &err)
+
+	wallet, err := s.walletRepo.GetById(ctx, transaction.WalletId)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	transaction.Description = trimDetail(transaction.Description)
+	transaction.ExternalRef = trimDetail(transaction.ExternalRef)
+
+	// Stored and hashed in canonical form.
+	transaction.Metadata, err = transaction.Metadata.Canonical()
 	if err != nil {
 		return uuid.Nil, err
 	}
+	if len(transaction.Metadata) > models.MaxMetadataBytes {
+		return uuid.Nil, models.ErrMetadataTooLarge
+	}
 
-	return s.repo.Create(transaction)
+	if err := s.checkCategory(ctx, wallet.UserId, transaction.CategoryId); err != nil {
+		return uuid.Nil, err
+	}
+
+	return s.repo.Create(ctx, transaction)
 }
 
-func (s *TransactionService) GetAll() ([]models.Transaction, error) {
-	return s.repo.GetAll()
+// trimDetail trims a text detail of a transaction, dropping it if blank.
+func trimDetail(detail *string) *string {
+	if detail == nil {
+		return nil
+	}
+
+	trimmed := strings.TrimSpace(*detail)
+	if trimmed == "" {
+		return nil
+	}
+
+	return &trimmed
 }
 
-func (s *TransactionService) GetById(transactionId uuid.UUID) (models.Transaction, error) {
-	return s.repo.GetById(transactionId)
+// checkCategory reports whether the category, if any, is one of the user.
+func (s *TransactionService) checkCategory(ctx context.Context, userId int, categoryId *uuid.UUID) error {
+	if categoryId == nil {
+		return nil
+	}
+
+	_, err := s.categoryRepo.GetById(ctx, userId, *categoryId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrUnknownCategory
+	}
+
+	return err
+}
+
+func (s *TransactionService) Adjust(ctx context.Context, adjustment models.AdjustmentInput) (_ uuid.UUID, err error) {
+	ctx, span := startSpan(ctx, "TransactionService.Adjust",
+		tracing.WalletID(adjustment.WalletId), tracing.OperationType(adjustment.OperationType))
+	defer endSpan(span, &err)
+	defer observeFailure("adjust", &err)
+
+	if strings.TrimSpace(adjustment.Reason) == "" {
+		return uuid.Nil, models.ErrReasonRequired
+	}
+
+	return s.repo.CreateAdjustment(ctx, adjustment)
+}
+
+func (s *TransactionService) GetAll(ctx context.Context, filter models.TransactionFilter, page models.Page) (_ []models.Transaction, err error) {
+	ctx, span := startSpan(ctx, "TransactionService.GetAll")
+	defer endSpan(span, &err)
+
+	transactions, err := s.repo.GetAll(ctx, filter, page)
+	span.SetAttributes(tracing.Rows(len(transactions)))
+
+	return transactions, err
+}
+
+func (s *TransactionService) GetAllFromWallet(ctx context.Context, walletId uuid.UUID) (_ []models.Transaction, err error) {
+	ctx, span := startSpan(ctx, "TransactionService.GetAllFromWallet", tracing.WalletID(walletId))
+	defer endSpan(span, &err)
+
+	transactions, err := s.repo.GetAllFromWallet(ctx, walletId)
+	span.SetAttributes(tracing.Rows(len(transactions)))
+
+	return transactions, err
+}
+
+func (s *TransactionService) GetById(ctx context.Context, transactionId uuid.UUID) (_ models.Transaction, err error) {
+	ctx, span := startSpan(ctx, "TransactionService.GetById")
+	defer endSpan(span, &err)
+
+	transaction, err := s.repo.GetById(ctx, transactionId)
+	if err == nil {
+		span.SetAttributes(tracing.WalletID(transaction.WalletId), tracing.OperationType(transaction.OperationType))
+	}
+
+	return transaction, err
+}
+
+func (s *TransactionService) Annotate(ctx context.Context, userId int, transactionId uuid.UUID, input models.AnnotationInput) (_ models.Annotation, err error) {
+	ctx, span := startSpan(ctx, "TransactionService.Annotate")
+	defer endSpan(span, &err)
+	defer observeFailure("annotate", &err)
+
+	if _, err := s.getFromUser(ctx, userId, transactionId); err != nil {
+		return models.Annotation{}, err
+	}
+
+	if err := s.checkCategory(ctx, userId, input.CategoryId); err != nil {
+		return models.Annotation{}, err
+	}
+	input.Notes = strings.TrimSpace(input.Notes)
+
+	return s.repo.Annotate(ctx, transactionId, input)
+}
+
+func (s *TransactionService) GetAnnotations(ctx context.Context, userId int, transactionId uuid.UUID, page models.Page) (_ []models.Annotation, err error) {
+	ctx, span := startSpan(ctx, "TransactionService.GetAnnotations")
+	defer endSpan(span, &err)
+
+	if _, err := s.getFromUser(ctx, userId, transactionId); err != nil {
+		return nil, err
+	}
+
+	annotations, err := s.repo.GetAnnotations(ctx, transactionId, page)
+	span.SetAttributes(tracing.Rows(len(annotations)))
+
+	return annotations, err
+}
+
+// getFromUser returns the transaction if it is of a wallet of the user, or
+// else fails with sql.ErrNoRows.
+func (s *TransactionService) getFromUser(ctx context.Context, userId int, transactionId uuid.UUID) (models.Transaction, error) {
+	transaction, err := s.repo.GetById(ctx, transactionId)
+	if err != nil {
+		return models.Transaction{}, err
+	}
+
+	if _, err := s.walletRepo.GetByIdFromUser(ctx, userId, transaction.WalletId); err != nil {
+		return models.Transaction{}, err
+	}
+
+	return transaction, nil
 }
@@ -1,8 +1,15 @@
 package service
 
 import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/Yoshisoul/rest-wallets/internal/models"
 	"github.com/Yoshisoul/rest-wallets/internal/repository"
+	"github.com/Yoshisoul/rest-wallets/internal/tracing"
 	"github.com/google/uuid"
 )
 
@@ -14,18 +21,122 @@ func NewWalletService(repo repository.Wallet) *WalletService {
 	return &WalletService{repo: repo}
 }
 
-func (s *WalletService) Create(userId int) (uuid.UUID, error) {
-	return s.repo.Create(userId)
+func (s *WalletService) Create(ctx context.Context, userId int) (_ uuid.UUID, err error) {
+	ctx, span := startSpan(ctx, "WalletService.Create", tracing.UserID(userId))
+	defer endSpan(span, &err)
+	defer observeFailure("create_wallet", &err)
+
+	id, err := s.repo.Create(ctx, userId)
+	span.SetAttributes(tracing.WalletID(id))
+
+	return id, err
 }
 
-func (s *WalletService) GetAllFromUser(userId int) ([]models.Wallet, error) {
-	return s.repo.GetAllFromUser(userId)
+func (s *WalletService) GetAllFromUser(ctx context.Context, userId int, filter models.WalletFilter, page models.Page) (_ []models.Wallet, err error) {
+	ctx, span := startSpan(ctx, "WalletService.GetAllFromUser", tracing.UserID(userId))
+	defer endSpan(span, &err)
+
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return nil, models.ErrInvalidRange
+	}
+
+	wallets, err := s.repo.GetAllFromUser(ctx, userId, filter, page)
+	span.SetAttributes(tracing.Rows(len(wallets)))
+
+	return wallets, err
 }
 
-func (s *WalletService) GetByIdFromUser(userId int, walletId uuid.UUID) (models.Wallet, error) {
-	return s.repo.GetByIdFromUser(userId, walletId)
+func (s *WalletService) GetByIdFromUser(ctx context.Context, userId int, walletId uuid.UUID) (_ models.Wallet, err error) {
+	ctx, span := startSpan(ctx, "WalletService.GetByIdFromUser", tracing.UserID(userId), tracing.WalletID(walletId))
+	defer endSpan(span, &err)
+
+	return s.repo.GetByIdFromUser(ctx, userId, walletId)
 }
 
-func (s *WalletService) Delete(userId int, walletId uuid.UUID) error {
-	return s.repo.Delete(userId, walletId)
+func (s *WalletService) GetById(ctx context.Context, walletId uuid.UUID) (_ models.Wallet, err error) {
+	ctx, span := startSpan(ctx, "WalletService.GetById", tracing.WalletID(walletId))
+	defer endSpan(span, &err)
+
+	return s.repo.GetById(ctx, walletId)
+}
+
+func (s *WalletService) Close(ctx context.Context, userId int, walletId uuid.UUID, input models.CloseWalletInput) (err error) {
+	ctx, span := startSpan(ctx, "WalletService.Close", tracing.UserID(userId), tracing.WalletID(walletId))
+	defer endSpan(span, &err)
+	defer observeFailure("close_wallet", &err)
+
+	return s.repo.Close(ctx, userId, walletId, input)
+}
+
+func (s *WalletService) SetFrozen(ctx context.Context, walletId uuid.UUID, frozen bool) (err error) {
+	ctx, span := startSpan(ctx, "WalletService.SetFrozen", tracing.WalletID(walletId))
+	defer endSpan(span, &err)
+	defer observeFailure("set_frozen", &err)
+
+	return s.repo.SetFrozen(ctx, walletId, frozen)
+}
+
+func (s *WalletService) Update(ctx context.Context, userId int, walletId uuid.UUID, update models.WalletUpdate) (_ models.Wallet, err error) {
+	ctx, span := startSpan(ctx, "WalletService.Update", tracing.UserID(userId), tracing.WalletID(walletId))
+	defer endSpan(span, &err)
+	defer observeFailure("update_wallet", &err)
+
+	if update.Empty() {
+		return models.Wallet{}, models.ErrNothingToUpdate
+	}
+
+	update.Name = trimLabel(update.Name)
+	update.Description = trimLabel(update.Description)
+	update.Icon = trimLabel(update.Icon)
+
+	if update.Color != nil && *update.Color != "" {
+		if !colorPattern.MatchString(*update.Color) {
+			return models.Wallet{}, models.ErrInvalidColor
+		}
+		color := strings.ToLower(*update.Color)
+		update.Color = &color
+	}
+
+	if update.Tags != nil {
+		tags, err := normalizeTags(*update.Tags)
+		if err != nil {
+			return models.Wallet{}, err
+		}
+		update.Tags = &tags
+	}
+
+	return s.repo.Update(ctx, userId, walletId, update)
+}
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// trimLabel trims a text label of a wallet. Unlike the details of a
+// transaction, a blank label is kept: it clears the label.
+func trimLabel(label *string) *string {
+	if label == nil {
+		return nil
+	}
+
+	trimmed := strings.TrimSpace(*label)
+	return &trimmed
+}
+
+// normalizeTags trims the tags and drops repeated ones, keeping the order.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || utf8.RuneCountInString(tag) > models.MaxTagLength {
+			return nil, models.ErrInvalidTag
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	if len(normalized) > models.MaxWalletTags {
+		return nil, models.ErrTooManyTags
+	}
+
+	return normalized, nil
 }
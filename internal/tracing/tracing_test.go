@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Yoshisoul/rest-wallets/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup_PropagatesTraceparent(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.TracingConfig{
+		Exporter:    "none",
+		SampleRatio: 1,
+		ServiceName: "test",
+	})
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+
+	ctx, span := otel.Tracer("test").Start(ctx, "child")
+	defer span.End()
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.True(t, span.SpanContext().IsSampled())
+
+	out := new(bytes.Buffer)
+	logger := logrus.New()
+	logger.SetOutput(out)
+	logger.SetFormatter(new(logrus.JSONFormatter))
+	logger.AddHook(LogHook{})
+	logger.WithContext(ctx).Info("hello")
+
+	var entry map[string]string
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry["trace_id"])
+	assert.Equal(t, span.SpanContext().SpanID().String(), entry["span_id"])
+}
+
+func TestLogHook_NoSpan(t *testing.T) {
+	entry := logrus.NewEntry(logrus.New()).WithContext(trace.ContextWithSpanContext(context.Background(), trace.SpanContext{}))
+	require.NoError(t, LogHook{}.Fire(entry))
+	assert.NotContains(t, entry.Data, "trace_id")
+
+	entry = logrus.NewEntry(logrus.New())
+	require.NoError(t, LogHook{}.Fire(entry))
+	assert.NotContains(t, entry.Data, "trace_id")
+}
@@ -0,0 +1,28 @@
+DROP INDEX transactions_wallet_id_created_at_idx;
+DROP INDEX wallets_user_id_idx;
+
+ALTER TABLE transactions
+    DROP CONSTRAINT transactions_amount_positive,
+    DROP CONSTRAINT transactions_wallet_id_fkey;
+
+ALTER TABLE wallets
+    DROP CONSTRAINT wallets_amount_non_negative,
+    DROP CONSTRAINT wallets_user_id_fkey;
+
+ALTER TABLE transactions
+    ALTER COLUMN operation_type TYPE VARCHAR(15) USING operation_type::text;
+
+DROP TYPE operation_type;
+
+ALTER TABLE transactions
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE wallets
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMP USING updated_at AT TIME ZONE 'UTC';
+
+INSERT INTO transactions (transaction_id, wallet_id, operation_type, amount, created_at)
+SELECT transaction_id, wallet_id, operation_type, amount, created_at AT TIME ZONE 'UTC'
+FROM transactions_quarantine;
+
+DROP TABLE transactions_quarantine;
@@ -0,0 +1,89 @@
+-- Rows that can't satisfy the new constraints are moved here instead of
+-- being dropped, so they can be reviewed and replayed by hand.
+CREATE TABLE transactions_quarantine
+(
+    transaction_id UUID PRIMARY KEY,
+    wallet_id UUID NOT NULL,
+    operation_type VARCHAR(15) NOT NULL,
+    amount BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    quarantined_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+DO $$
+DECLARE
+    moved BIGINT;
+BEGIN
+    -- Hard wallet deletes left transactions pointing at nothing.
+    WITH orphaned AS (
+        DELETE FROM transactions t
+        WHERE NOT EXISTS (SELECT 1 FROM wallets w WHERE w.wallet_id = t.wallet_id)
+        RETURNING t.*
+    )
+    INSERT INTO transactions_quarantine (transaction_id, wallet_id, operation_type, amount, created_at, reason)
+    SELECT transaction_id, wallet_id, operation_type, amount, created_at AT TIME ZONE 'UTC', 'wallet not found'
+    FROM orphaned;
+    GET DIAGNOSTICS moved = ROW_COUNT;
+    RAISE NOTICE 'quarantined % transaction(s) referencing deleted wallets', moved;
+
+    -- Unknown operation types were inserted but never applied to a balance.
+    WITH unknown AS (
+        DELETE FROM transactions t
+        WHERE t.operation_type NOT IN ('DEPOSIT', 'WITHDRAW')
+        RETURNING t.*
+    )
+    INSERT INTO transactions_quarantine (transaction_id, wallet_id, operation_type, amount, created_at, reason)
+    SELECT transaction_id, wallet_id, operation_type, amount, created_at AT TIME ZONE 'UTC', 'unknown operation type'
+    FROM unknown;
+    GET DIAGNOSTICS moved = ROW_COUNT;
+    RAISE NOTICE 'quarantined % transaction(s) with unknown operation types', moved;
+END $$;
+
+-- Existing values were written without a zone by a service running in UTC.
+ALTER TABLE wallets
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE transactions
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+
+CREATE TYPE operation_type AS ENUM ('DEPOSIT', 'WITHDRAW');
+
+ALTER TABLE transactions
+    ALTER COLUMN operation_type TYPE operation_type USING operation_type::operation_type;
+
+-- Constraints are added NOT VALID so new writes are checked right away, then
+-- validated separately: historical overdrafts or negative amounts must not
+-- block the migration, they are reported instead.
+ALTER TABLE wallets
+    ADD CONSTRAINT wallets_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE RESTRICT NOT VALID,
+    ADD CONSTRAINT wallets_amount_non_negative CHECK (amount >= 0) NOT VALID;
+
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_wallet_id_fkey FOREIGN KEY (wallet_id) REFERENCES wallets (wallet_id) ON DELETE RESTRICT,
+    ADD CONSTRAINT transactions_amount_positive CHECK (amount > 0) NOT VALID;
+
+DO $$
+BEGIN
+    BEGIN
+        ALTER TABLE wallets VALIDATE CONSTRAINT wallets_user_id_fkey;
+    EXCEPTION WHEN foreign_key_violation THEN
+        RAISE WARNING 'wallets_user_id_fkey left NOT VALID: some wallets reference missing users';
+    END;
+
+    BEGIN
+        ALTER TABLE wallets VALIDATE CONSTRAINT wallets_amount_non_negative;
+    EXCEPTION WHEN check_violation THEN
+        RAISE WARNING 'wallets_amount_non_negative left NOT VALID: some wallets have a negative balance';
+    END;
+
+    BEGIN
+        ALTER TABLE transactions VALIDATE CONSTRAINT transactions_amount_positive;
+    EXCEPTION WHEN check_violation THEN
+        RAISE WARNING 'transactions_amount_positive left NOT VALID: some transactions have a non-positive amount';
+    END;
+END $$;
+
+CREATE INDEX wallets_user_id_idx ON wallets (user_id);
+CREATE INDEX transactions_wallet_id_created_at_idx ON transactions (wallet_id, created_at);
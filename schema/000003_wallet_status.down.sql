@@ -0,0 +1,10 @@
+-- Closed wallets become indistinguishable from open ones after this, which
+-- is how the service behaved before lifecycle states existed.
+ALTER TABLE wallets
+    DROP CONSTRAINT wallets_closed_is_empty,
+    DROP CONSTRAINT wallets_closed_at_matches_status,
+    DROP COLUMN close_reason,
+    DROP COLUMN closed_at,
+    DROP COLUMN status;
+
+DROP TYPE wallet_status;
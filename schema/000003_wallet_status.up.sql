@@ -0,0 +1,8 @@
+CREATE TYPE wallet_status AS ENUM ('ACTIVE', 'FROZEN', 'CLOSED');
+
+ALTER TABLE wallets
+    ADD COLUMN status wallet_status NOT NULL DEFAULT 'ACTIVE',
+    ADD COLUMN closed_at TIMESTAMPTZ,
+    ADD COLUMN close_reason VARCHAR(255),
+    ADD CONSTRAINT wallets_closed_at_matches_status CHECK ((status = 'CLOSED') = (closed_at IS NOT NULL)),
+    ADD CONSTRAINT wallets_closed_is_empty CHECK (status <> 'CLOSED' OR amount = 0);
@@ -0,0 +1,8 @@
+-- Manual balance corrections posted by operators. The balance change itself
+-- is an ordinary transaction; this table records why it was made.
+CREATE TABLE adjustments
+(
+    transaction_id UUID PRIMARY KEY REFERENCES transactions (transaction_id) ON DELETE RESTRICT,
+    reason VARCHAR(255) NOT NULL CHECK (reason <> ''),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,11 @@
+-- Token buckets shared by all instances. Losing them on a crash only resets
+-- the limits, so the table is not WAL-logged.
+CREATE UNLOGGED TABLE rate_limits
+(
+    key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX rate_limits_updated_at_idx ON rate_limits (updated_at);
@@ -0,0 +1,3 @@
+-- Incremented on every change of the wallet, so clients can make requests
+-- conditional on the version they last saw.
+ALTER TABLE wallets ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
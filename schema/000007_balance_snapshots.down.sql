@@ -0,0 +1 @@
+DROP TABLE balance_snapshots;
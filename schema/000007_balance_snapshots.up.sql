@@ -0,0 +1,10 @@
+-- Balances of every open wallet at regular moments, so a past balance is a
+-- snapshot plus the transactions made since, instead of the whole history.
+-- amount is the sum of the transactions made before taken_at.
+CREATE TABLE balance_snapshots
+(
+    wallet_id UUID NOT NULL REFERENCES wallets (wallet_id) ON DELETE RESTRICT,
+    taken_at TIMESTAMPTZ NOT NULL,
+    amount BIGINT NOT NULL,
+    PRIMARY KEY (wallet_id, taken_at)
+);
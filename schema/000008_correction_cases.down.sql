@@ -0,0 +1,3 @@
+DROP TABLE correction_cases;
+
+DROP TYPE case_status;
@@ -0,0 +1,20 @@
+-- Wallets whose balance doesn't match their transactions, found by the
+-- reconciliation job and left for an operator to review. A wallet has at
+-- most one open case; it keeps the balances of when it was first found.
+CREATE TYPE case_status AS ENUM ('OPEN', 'RESOLVED');
+
+CREATE TABLE correction_cases
+(
+    case_id UUID PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets (wallet_id) ON DELETE RESTRICT,
+    stored_amount BIGINT NOT NULL,
+    computed_amount BIGINT NOT NULL,
+    status case_status NOT NULL DEFAULT 'OPEN',
+    opened_at TIMESTAMPTZ NOT NULL,
+    resolved_at TIMESTAMPTZ,
+    resolution VARCHAR(255),
+    CONSTRAINT correction_cases_resolved CHECK ((status = 'RESOLVED') = (resolved_at IS NOT NULL AND resolution IS NOT NULL))
+);
+
+CREATE UNIQUE INDEX correction_cases_open_wallet_idx ON correction_cases (wallet_id) WHERE status = 'OPEN';
+CREATE INDEX correction_cases_opened_at_idx ON correction_cases (opened_at);
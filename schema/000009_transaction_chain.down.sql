@@ -0,0 +1,8 @@
+DROP TABLE chain_checkpoints;
+
+DROP INDEX transactions_wallet_id_seq_idx;
+
+ALTER TABLE transactions
+    DROP COLUMN seq,
+    DROP COLUMN prev_hash,
+    DROP COLUMN hash;
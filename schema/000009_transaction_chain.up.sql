@@ -0,0 +1,33 @@
+-- Every transaction is chained to the previous one of its wallet: seq numbers
+-- the transactions of a wallet from 1, and hash is the SHA-256 of the
+-- transaction contents and prev_hash, the hash of the previous transaction.
+-- Transactions from before the chain only get their seq here. The service
+-- computes their hashes, which are empty until then.
+ALTER TABLE transactions
+    ADD COLUMN seq BIGINT,
+    ADD COLUMN prev_hash TEXT NOT NULL DEFAULT '',
+    ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+
+UPDATE transactions t SET seq = n.seq
+FROM (
+    SELECT transaction_id, ROW_NUMBER() OVER (PARTITION BY wallet_id ORDER BY created_at, transaction_id) AS seq
+    FROM transactions
+) n
+WHERE t.transaction_id = n.transaction_id;
+
+ALTER TABLE transactions ALTER COLUMN seq SET NOT NULL;
+
+CREATE UNIQUE INDEX transactions_wallet_id_seq_idx ON transactions (wallet_id, seq);
+
+-- Signed Merkle roots over the heads of all wallet chains at a moment.
+CREATE TABLE chain_checkpoints
+(
+    checkpoint_id UUID PRIMARY KEY,
+    at TIMESTAMPTZ NOT NULL UNIQUE,
+    wallets BIGINT NOT NULL,
+    transactions BIGINT NOT NULL,
+    root TEXT NOT NULL,
+    public_key TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
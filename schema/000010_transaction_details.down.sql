@@ -0,0 +1,9 @@
+DROP TABLE transaction_annotations;
+
+ALTER TABLE transactions
+    DROP COLUMN description,
+    DROP COLUMN category_id,
+    DROP COLUMN external_ref,
+    DROP COLUMN metadata;
+
+DROP TABLE categories;
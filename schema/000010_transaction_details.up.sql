@@ -0,0 +1,38 @@
+-- Categories are a taxonomy of each user: a category can have a parent
+-- category of the same user.
+CREATE TABLE categories
+(
+    category_id UUID PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    parent_id UUID REFERENCES categories (category_id) ON DELETE RESTRICT,
+    name VARCHAR(64) NOT NULL CHECK (name <> ''),
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE INDEX categories_parent_id_idx ON categories (parent_id);
+
+-- Set when the transaction is recorded and covered by its hash, like the
+-- rest of the transaction.
+ALTER TABLE transactions
+    ADD COLUMN description VARCHAR(500),
+    ADD COLUMN category_id UUID REFERENCES categories (category_id) ON DELETE RESTRICT,
+    ADD COLUMN external_ref VARCHAR(128),
+    ADD COLUMN metadata JSONB CHECK (jsonb_typeof(metadata) = 'object');
+
+CREATE INDEX transactions_category_id_idx ON transactions (category_id);
+CREATE INDEX transactions_external_ref_idx ON transactions (external_ref);
+
+-- The editable layer over the transactions: every edit of the category and
+-- notes of a transaction adds a version, the latest being current.
+CREATE TABLE transaction_annotations
+(
+    transaction_id UUID NOT NULL REFERENCES transactions (transaction_id) ON DELETE RESTRICT,
+    version BIGINT NOT NULL,
+    category_id UUID REFERENCES categories (category_id) ON DELETE RESTRICT,
+    notes VARCHAR(2000) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (transaction_id, version)
+);
+
+CREATE INDEX transaction_annotations_category_id_idx ON transaction_annotations (category_id);
@@ -0,0 +1,11 @@
+DROP INDEX wallets_tags_idx;
+DROP INDEX wallets_user_id_default_idx;
+
+ALTER TABLE wallets
+    DROP CONSTRAINT wallets_closed_is_not_default,
+    DROP COLUMN name,
+    DROP COLUMN description,
+    DROP COLUMN color,
+    DROP COLUMN icon,
+    DROP COLUMN tags,
+    DROP COLUMN is_default;
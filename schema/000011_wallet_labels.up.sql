@@ -0,0 +1,14 @@
+-- Labels the owner of a wallet edits to tell the wallets apart. Tags are a
+-- JSON array of strings.
+ALTER TABLE wallets
+    ADD COLUMN name VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN description VARCHAR(500) NOT NULL DEFAULT '',
+    ADD COLUMN color VARCHAR(7) NOT NULL DEFAULT '',
+    ADD COLUMN icon VARCHAR(32) NOT NULL DEFAULT '',
+    ADD COLUMN tags JSONB NOT NULL DEFAULT '[]' CHECK (jsonb_typeof(tags) = 'array'),
+    ADD COLUMN is_default BOOLEAN NOT NULL DEFAULT false,
+    ADD CONSTRAINT wallets_closed_is_not_default CHECK (status <> 'CLOSED' OR NOT is_default);
+
+-- A user has at most one default wallet.
+CREATE UNIQUE INDEX wallets_user_id_default_idx ON wallets (user_id) WHERE is_default;
+CREATE INDEX wallets_tags_idx ON wallets USING GIN (tags);
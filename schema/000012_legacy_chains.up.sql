@@ -0,0 +1,12 @@
+-- Wallets whose chains start with transactions from before the chain, with
+-- the seq of the last of them. The service computes their hashes once and
+-- deletes the row. Any other empty hash was cleared after the fact, so it is
+-- a break and is never computed again.
+CREATE TABLE legacy_chains
+(
+    wallet_id UUID PRIMARY KEY REFERENCES wallets (wallet_id) ON DELETE CASCADE,
+    seq BIGINT NOT NULL
+);
+
+INSERT INTO legacy_chains (wallet_id, seq)
+SELECT wallet_id, MAX(seq) FROM transactions WHERE hash = '' GROUP BY wallet_id;
@@ -0,0 +1,14 @@
+// Package schema embeds the SQL migrations so that the service binary can
+// apply them without the external migrate CLI.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var Migrations embed.FS
+
+// SQLiteMigrations are the migrations of the sqlite driver, in the sqlite
+// directory. Their versions follow Migrations.
+//
+//go:embed sqlite/*.sql
+var SQLiteMigrations embed.FS
@@ -0,0 +1,7 @@
+DROP TABLE adjustments;
+
+DROP TABLE transactions;
+
+DROP TABLE wallets;
+
+DROP TABLE users;
@@ -0,0 +1,47 @@
+-- SQLite support starts from version 5 of the Postgres schema, as a single
+-- migration. Later versions are kept in step with the Postgres migrations so
+-- that both databases report the same schema version.
+--
+-- UUIDs are stored as their text form and timestamps as UTC text, which sorts
+-- in time order. Rate limits are not stored: the postgres rate limit backend
+-- requires Postgres.
+CREATE TABLE users
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    username TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL
+);
+
+CREATE TABLE wallets
+(
+    wallet_id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    amount INTEGER NOT NULL DEFAULT 0 CHECK (amount >= 0),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'FROZEN', 'CLOSED')),
+    closed_at TIMESTAMP,
+    close_reason TEXT,
+    CONSTRAINT wallets_closed_at_matches_status CHECK ((status = 'CLOSED') = (closed_at IS NOT NULL)),
+    CONSTRAINT wallets_closed_is_empty CHECK (status <> 'CLOSED' OR amount = 0)
+);
+
+CREATE TABLE transactions
+(
+    transaction_id TEXT PRIMARY KEY,
+    wallet_id TEXT NOT NULL REFERENCES wallets (wallet_id) ON DELETE RESTRICT,
+    operation_type TEXT NOT NULL CHECK (operation_type IN ('DEPOSIT', 'WITHDRAW')),
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE adjustments
+(
+    transaction_id TEXT PRIMARY KEY REFERENCES transactions (transaction_id) ON DELETE RESTRICT,
+    reason TEXT NOT NULL CHECK (reason <> ''),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX wallets_user_id_idx ON wallets (user_id);
+CREATE INDEX transactions_wallet_id_created_at_idx ON transactions (wallet_id, created_at);
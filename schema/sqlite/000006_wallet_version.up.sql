@@ -0,0 +1 @@
+ALTER TABLE wallets ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,7 @@
+CREATE TABLE balance_snapshots
+(
+    wallet_id TEXT NOT NULL REFERENCES wallets (wallet_id) ON DELETE RESTRICT,
+    taken_at TIMESTAMP NOT NULL,
+    amount INTEGER NOT NULL,
+    PRIMARY KEY (wallet_id, taken_at)
+);
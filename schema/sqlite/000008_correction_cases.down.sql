@@ -0,0 +1 @@
+DROP TABLE correction_cases;
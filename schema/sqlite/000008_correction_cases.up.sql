@@ -0,0 +1,15 @@
+CREATE TABLE correction_cases
+(
+    case_id TEXT PRIMARY KEY,
+    wallet_id TEXT NOT NULL REFERENCES wallets (wallet_id) ON DELETE RESTRICT,
+    stored_amount INTEGER NOT NULL,
+    computed_amount INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'RESOLVED')),
+    opened_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP,
+    resolution TEXT,
+    CHECK ((status = 'RESOLVED') = (resolved_at IS NOT NULL AND resolution IS NOT NULL))
+);
+
+CREATE UNIQUE INDEX correction_cases_open_wallet_idx ON correction_cases (wallet_id) WHERE status = 'OPEN';
+CREATE INDEX correction_cases_opened_at_idx ON correction_cases (opened_at);
@@ -0,0 +1,7 @@
+DROP TABLE chain_checkpoints;
+
+DROP INDEX transactions_wallet_id_seq_idx;
+
+ALTER TABLE transactions DROP COLUMN seq;
+ALTER TABLE transactions DROP COLUMN prev_hash;
+ALTER TABLE transactions DROP COLUMN hash;
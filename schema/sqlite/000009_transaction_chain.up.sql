@@ -0,0 +1,24 @@
+ALTER TABLE transactions ADD COLUMN seq INTEGER;
+ALTER TABLE transactions ADD COLUMN prev_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+
+UPDATE transactions SET seq = (
+    SELECT COUNT(*) FROM transactions t
+    WHERE t.wallet_id = transactions.wallet_id
+        AND (t.created_at < transactions.created_at
+            OR (t.created_at = transactions.created_at AND t.transaction_id <= transactions.transaction_id))
+);
+
+CREATE UNIQUE INDEX transactions_wallet_id_seq_idx ON transactions (wallet_id, seq);
+
+CREATE TABLE chain_checkpoints
+(
+    checkpoint_id TEXT PRIMARY KEY,
+    at TIMESTAMP NOT NULL UNIQUE,
+    wallets INTEGER NOT NULL,
+    transactions INTEGER NOT NULL,
+    root TEXT NOT NULL,
+    public_key TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,11 @@
+DROP TABLE transaction_annotations;
+
+DROP INDEX transactions_category_id_idx;
+DROP INDEX transactions_external_ref_idx;
+
+ALTER TABLE transactions DROP COLUMN description;
+ALTER TABLE transactions DROP COLUMN category_id;
+ALTER TABLE transactions DROP COLUMN external_ref;
+ALTER TABLE transactions DROP COLUMN metadata;
+
+DROP TABLE categories;
@@ -0,0 +1,34 @@
+CREATE TABLE categories
+(
+    category_id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    parent_id TEXT REFERENCES categories (category_id) ON DELETE RESTRICT,
+    name TEXT NOT NULL CHECK (name <> '' AND length(name) <= 64),
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE INDEX categories_parent_id_idx ON categories (parent_id);
+
+-- Metadata is stored as JSON text. category_id has no foreign key, which
+-- SQLite couldn't drop again: categories in use are not deleted by the
+-- service.
+ALTER TABLE transactions ADD COLUMN description TEXT CHECK (length(description) <= 500);
+ALTER TABLE transactions ADD COLUMN category_id TEXT;
+ALTER TABLE transactions ADD COLUMN external_ref TEXT CHECK (length(external_ref) <= 128);
+ALTER TABLE transactions ADD COLUMN metadata TEXT CHECK (json_type(metadata) = 'object');
+
+CREATE INDEX transactions_category_id_idx ON transactions (category_id);
+CREATE INDEX transactions_external_ref_idx ON transactions (external_ref);
+
+CREATE TABLE transaction_annotations
+(
+    transaction_id TEXT NOT NULL REFERENCES transactions (transaction_id) ON DELETE RESTRICT,
+    version INTEGER NOT NULL,
+    category_id TEXT REFERENCES categories (category_id) ON DELETE RESTRICT,
+    notes TEXT NOT NULL DEFAULT '' CHECK (length(notes) <= 2000),
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (transaction_id, version)
+);
+
+CREATE INDEX transaction_annotations_category_id_idx ON transaction_annotations (category_id);
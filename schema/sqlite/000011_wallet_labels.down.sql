@@ -0,0 +1,8 @@
+DROP INDEX wallets_user_id_default_idx;
+
+ALTER TABLE wallets DROP COLUMN name;
+ALTER TABLE wallets DROP COLUMN description;
+ALTER TABLE wallets DROP COLUMN color;
+ALTER TABLE wallets DROP COLUMN icon;
+ALTER TABLE wallets DROP COLUMN tags;
+ALTER TABLE wallets DROP COLUMN is_default;
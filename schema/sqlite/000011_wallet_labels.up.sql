@@ -0,0 +1,9 @@
+-- Tags are stored as JSON text.
+ALTER TABLE wallets ADD COLUMN name TEXT NOT NULL DEFAULT '' CHECK (length(name) <= 64);
+ALTER TABLE wallets ADD COLUMN description TEXT NOT NULL DEFAULT '' CHECK (length(description) <= 500);
+ALTER TABLE wallets ADD COLUMN color TEXT NOT NULL DEFAULT '' CHECK (length(color) <= 7);
+ALTER TABLE wallets ADD COLUMN icon TEXT NOT NULL DEFAULT '' CHECK (length(icon) <= 32);
+ALTER TABLE wallets ADD COLUMN tags TEXT NOT NULL DEFAULT '[]' CHECK (json_type(tags) = 'array');
+ALTER TABLE wallets ADD COLUMN is_default BOOLEAN NOT NULL DEFAULT false CHECK (status <> 'CLOSED' OR NOT is_default);
+
+CREATE UNIQUE INDEX wallets_user_id_default_idx ON wallets (user_id) WHERE is_default;
@@ -0,0 +1,8 @@
+CREATE TABLE legacy_chains
+(
+    wallet_id TEXT PRIMARY KEY REFERENCES wallets (wallet_id) ON DELETE CASCADE,
+    seq INTEGER NOT NULL
+);
+
+INSERT INTO legacy_chains (wallet_id, seq)
+SELECT wallet_id, MAX(seq) FROM transactions WHERE hash = '' GROUP BY wallet_id;
@@ -2,26 +2,160 @@ package models
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
-	"time"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/Yoshisoul/rest-wallets/internal/config"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 type Server struct {
 	httpServer *http.Server
+	// cancelRequests cancels the context of every in-flight request.
+	cancelRequests context.CancelFunc
+
+	tls         config.TLSConfig
+	certificate atomic.Pointer[tls.Certificate]
+}
+
+func NewServer(cfg config.HTTPConfig, handler http.Handler) (*Server, error) {
+	requests, cancel := context.WithCancel(context.Background())
+
+	if cfg.H2C && !cfg.TLS.Enabled {
+		handler = h2c.NewHandler(handler, new(http2.Server))
+	}
+
+	s := &Server{
+		httpServer: &http.Server{
+			Addr:              ":" + cfg.Port,
+			Handler:           handler,
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			BaseContext:       func(net.Listener) context.Context { return requests },
+		},
+		cancelRequests: cancel,
+		tls:            cfg.TLS,
+	}
+
+	if cfg.TLS.Enabled {
+		tlsConfig, err := s.tlsConfig()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		s.httpServer.TLSConfig = tlsConfig
+	}
+
+	return s, nil
+}
+
+// tlsConfig loads the certificate and the client CA. The certificate is
+// looked up on every handshake, so ReloadCertificate takes effect for new
+// connections while the established ones keep going.
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	if err := s.ReloadCertificate(); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return s.certificate.Load(), nil
+		},
+	}
+	if s.tls.MinVersion == "1.3" {
+		tlsConfig.MinVersion = tls.VersionTLS13
+	}
+
+	switch s.tls.ClientAuth {
+	case "optional":
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(s.tls.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading client CA: %w", err)
+	}
+
+	tlsConfig.ClientCAs = x509.NewCertPool()
+	if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", s.tls.ClientCAFile)
+	}
+
+	return tlsConfig, nil
+}
+
+// ReloadCertificate reads the certificate and key files again. On error the
+// previous certificate stays in use.
+func (s *Server) ReloadCertificate() error {
+	certificate, err := tls.LoadX509KeyPair(s.tls.CertFile, s.tls.KeyFile)
+	if err != nil {
+		return fmt.Errorf("error loading TLS certificate: %w", err)
+	}
+
+	s.certificate.Store(&certificate)
+	return nil
 }
 
-func (s *Server) Run(port string, handler http.Handler) error {
-	s.httpServer = &http.Server{
-		Addr:           ":" + port,
-		Handler:        handler,
-		MaxHeaderBytes: 1 << 20, // 1 MB
-		ReadTimeout:    10 * time.Second,
-		WriteTimeout:   10 * time.Second,
+// ReloadOnSignal reloads the certificate on every SIGHUP until ctx is done.
+// It is a no-op for servers without TLS.
+func (s *Server) ReloadOnSignal(ctx context.Context) error {
+	if !s.tls.Enabled {
+		return nil
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			if err := s.ReloadCertificate(); err != nil {
+				logrus.Errorf("keeping the previous TLS certificate: %s", err.Error())
+				continue
+			}
+			logrus.Info("TLS certificate reloaded")
+		}
+	}
+}
+
+func (s *Server) Run() error {
+	if s.httpServer.TLSConfig != nil {
+		return s.httpServer.ListenAndServeTLS("", "")
 	}
 
 	return s.httpServer.ListenAndServe()
 }
 
+// Shutdown stops accepting connections and waits for in-flight requests. If
+// ctx expires first, their contexts are cancelled so that open database
+// transactions roll back, and the connections are closed.
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		s.cancelRequests()
+		err = errors.Join(err, s.httpServer.Close())
+	}
+
+	return err
 }
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Yoshisoul/rest-wallets/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate writes a self-signed certificate for commonName and its key.
+func writeCertificate(t *testing.T, certFile, keyFile, commonName string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+}
+
+func servedCommonName(t *testing.T, s *Server) string {
+	t.Helper()
+
+	certificate, err := s.httpServer.TLSConfig.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	require.NoError(t, err)
+
+	return leaf.Subject.CommonName
+}
+
+func TestServer_ReloadCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertificate(t, certFile, keyFile, "first")
+
+	s, err := NewServer(config.HTTPConfig{
+		Port: "0",
+		TLS:  config.TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, ClientAuth: "none"},
+	}, http.NotFoundHandler())
+	require.NoError(t, err)
+	assert.Equal(t, "first", servedCommonName(t, s))
+
+	writeCertificate(t, certFile, keyFile, "second")
+	require.NoError(t, s.ReloadCertificate())
+	assert.Equal(t, "second", servedCommonName(t, s))
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0o600))
+	assert.Error(t, s.ReloadCertificate())
+	assert.Equal(t, "second", servedCommonName(t, s))
+}
+
+func TestNewServer_ClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	caFile, caKeyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	writeCertificate(t, certFile, keyFile, "server")
+	writeCertificate(t, caFile, caKeyFile, "ca")
+
+	cfg := config.HTTPConfig{
+		Port: "0",
+		TLS: config.TLSConfig{
+			Enabled:      true,
+			CertFile:     certFile,
+			KeyFile:      keyFile,
+			MinVersion:   "1.3",
+			ClientAuth:   "require",
+			ClientCAFile: caFile,
+		},
+	}
+	s, err := NewServer(cfg, http.NotFoundHandler())
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, s.httpServer.TLSConfig.ClientAuth)
+	assert.Equal(t, uint16(tls.VersionTLS13), s.httpServer.TLSConfig.MinVersion)
+	assert.NotNil(t, s.httpServer.TLSConfig.ClientCAs)
+
+	cfg.TLS.ClientCAFile = filepath.Join(dir, "missing.crt")
+	_, err = NewServer(cfg, http.NotFoundHandler())
+	assert.ErrorContains(t, err, "client CA")
+}